@@ -0,0 +1,61 @@
+package arm
+
+import "ndsemu/emu/debugger"
+
+// Maximum depth of the shadow call stack; older frames are discarded.
+const cMaxCallStack = 128
+
+// callStack is a shadow call stack, maintained while a debugger is attached
+// by tracking the branches executed by the CPU. Calls (BL/BLX) and
+// exceptions push a frame with the return address; any branch whose target
+// is the return address of a frame (BX LR, MOV PC,LR, POP {PC}, exception
+// return, etc.) unwinds the stack up to that frame. Matching on the return
+// address rather than on the opcode makes it robust against tail calls,
+// longjmp() and interworking veneers.
+type callStack struct {
+	frames []debugger.CallFrame
+}
+
+func (cs *callStack) push(f debugger.CallFrame) {
+	if len(cs.frames) == cMaxCallStack {
+		copy(cs.frames, cs.frames[1:])
+		cs.frames = cs.frames[:cMaxCallStack-1]
+	}
+	cs.frames = append(cs.frames, f)
+}
+
+// update is called for each branch, before changing the PC
+func (cs *callStack) update(cpu *Cpu, newpc reg, reason BranchType) {
+	switch reason {
+	case BranchCall:
+		cs.push(debugger.CallFrame{
+			Target: uint32(newpc),
+			Ret:    uint32(cpu.Regs[14]) &^ 1,
+		})
+	case BranchInterrupt:
+		// Exceptions return to the instruction following the one
+		// being executed (which is where cpu.pc points to).
+		cs.push(debugger.CallFrame{
+			Target:    uint32(newpc),
+			Ret:       uint32(cpu.pc),
+			Exception: true,
+		})
+	default:
+		for i := len(cs.frames) - 1; i >= 0; i-- {
+			if cs.frames[i].Ret == uint32(newpc) {
+				cs.frames = cs.frames[:i]
+				return
+			}
+		}
+	}
+}
+
+// CallStack returns the current shadow call stack (the last frame is the
+// innermost one). The call stack is only tracked while a debugger is
+// attached.
+func (cpu *Cpu) CallStack() []debugger.CallFrame {
+	if cpu.calls == nil {
+		return nil
+	}
+	return append([]debugger.CallFrame(nil), cpu.calls.frames...)
+}
@@ -72,6 +72,11 @@ func (c *Cp15) CheckDTcm(addr uint32) []uint8 {
 	return nil
 }
 
+// DtcmBase returns the address at which the DTCM is currently mapped.
+func (c *Cp15) DtcmBase() uint32 {
+	return uint32(c.regDtcmVsize) & 0xFFFFF000
+}
+
 func (c *Cp15) ExceptionVector() uint32 {
 	if c.regControl.Bit(13) {
 		return 0xFFFF0000
@@ -58,9 +58,13 @@ type Cpu struct {
 	targetCycles int64
 	tightExit    bool
 
+	// Set by Jump(), to know if the debugger moved the PC within Trace()
+	jumped bool
+
 	// manual tracing support
 	DebugTrace int
 	dbg        debugger.CpuDebugger
+	calls      *callStack
 }
 
 func NewCpu(arch Arch, bus emu.Bus, dojit bool) *Cpu {
@@ -231,6 +235,15 @@ func (cpu *Cpu) SetSwiHle(swi uint8, hle func(cpu *Cpu) int64) {
 	cpu.swiHle[swi] = hle
 }
 
+// Jump moves the program counter to the specified address. This is meant to
+// be used by HLE functions that need to alter the control flow (eg: to
+// execute the same SWI again after the CPU has been halted), and by debuggers.
+// When called by a debugger within Trace(), the traced opcode is not executed.
+func (cpu *Cpu) Jump(addr uint32) {
+	cpu.branch(reg(addr), BranchJump)
+	cpu.jumped = true
+}
+
 // Set the status of the external (virtual) lines. This is modeled
 // to resemble the physical lines of the CPU core, but without the
 // need of full fidelity to high/low signals or clocking.
@@ -125,6 +125,50 @@ func (cpu *Cpu) GetPc() uint32 {
 
 func (cpu *Cpu) SetDebugger(dbg debugger.CpuDebugger) {
 	cpu.dbg = dbg
+	if dbg != nil && cpu.calls == nil {
+		cpu.calls = new(callStack)
+	}
+}
+
+func (cpu *Cpu) GetCpsr() uint32 {
+	return cpu.Cpsr.Uint32()
+}
+
+func (cpu *Cpu) SetCpsr(val uint32) {
+	cpu.Cpsr.Set(val, cpu)
+}
+
+// ReadMem reads memory through the CPU bus on behalf of a debugger: the
+// access doesn't trigger watchpoints and doesn't consume cycles.
+func (cpu *Cpu) ReadMem(addr uint32, buf []byte) {
+	dbg, clock := cpu.dbg, cpu.Clock
+	cpu.dbg = nil
+	for i := range buf {
+		buf[i] = cpu.Read8(addr + uint32(i))
+	}
+	cpu.dbg, cpu.Clock = dbg, clock
+}
+
+// WriteMem writes memory through the CPU bus on behalf of a debugger. It
+// uses the widest aligned accesses possible, as some areas (eg: VRAM) don't
+// support 8-bit writes.
+func (cpu *Cpu) WriteMem(addr uint32, buf []byte) {
+	dbg, clock := cpu.dbg, cpu.Clock
+	cpu.dbg = nil
+	for len(buf) > 0 {
+		switch {
+		case addr&3 == 0 && len(buf) >= 4:
+			cpu.Write32(addr, binary.LittleEndian.Uint32(buf))
+			addr, buf = addr+4, buf[4:]
+		case addr&1 == 0 && len(buf) >= 2:
+			cpu.Write16(addr, binary.LittleEndian.Uint16(buf))
+			addr, buf = addr+2, buf[2:]
+		default:
+			cpu.Write8(addr, buf[0])
+			addr, buf = addr+1, buf[1:]
+		}
+	}
+	cpu.dbg, cpu.Clock = dbg, clock
 }
 
 func (cpu *Cpu) breakpoint(msg string, args ...interface{}) {
@@ -201,7 +245,7 @@ func (cpu *Cpu) Disasm(pc uint32) (string, []byte) {
 			return "<unmapped memory>", []byte{0, 0, 0, 0}
 		}
 		op := uint32(mem[0]) | uint32(mem[1])<<8 | uint32(mem[2])<<16 | uint32(mem[3])<<24
-		n := disasmArmTable[((op>>16)&0xFF0)|((op>>4)&0xF)](cpu, op, pc)
+		n := cpu.DisasmOp(op, pc, false)
 		binary.LittleEndian.PutUint32(buf[:], op)
 		return n, buf[:]
 	} else {
@@ -211,12 +255,57 @@ func (cpu *Cpu) Disasm(pc uint32) (string, []byte) {
 			return "<unmapped memory>", []byte{0, 0}
 		}
 		op := uint16(mem[0]) | uint16(mem[1])<<8
-		n := disasmThumbTable[(op>>8)&0xFF](cpu, op, pc)
+		n := cpu.DisasmOp(uint32(op), pc, true)
 		binary.LittleEndian.PutUint16(buf[:], op)
 		return n, buf[:]
 	}
 }
 
+// DisasmOp disassembles the specified opcode, as if it was at address pc.
+func (cpu *Cpu) DisasmOp(op uint32, pc uint32, thumb bool) string {
+	if !thumb {
+		return disasmArmTable[((op>>16)&0xFF0)|((op>>4)&0xF)](cpu, op, pc)
+	}
+	return disasmThumbTable[(op>>8)&0xFF](cpu, uint16(op), pc)
+}
+
+// FetchOpcode returns the opcode at the specified address, in the current
+// CPU state (ARM or Thumb). It doesn't have side effects, and returns 0 if
+// the memory is not mapped.
+func (cpu *Cpu) FetchOpcode(pc uint32) uint32 {
+	mem := cpu.opFetchPointer(pc)
+	if mem == nil {
+		return 0
+	}
+	if cpu.Cpsr.T() {
+		return uint32(binary.LittleEndian.Uint16(mem))
+	}
+	return binary.LittleEndian.Uint32(mem)
+}
+
+// CopyRegs copies the current values of the registers into regs. It is
+// a faster version of GetRegs() for code that is called very often.
+func (cpu *Cpu) CopyRegs(regs *[16]uint32) {
+	for i := range regs {
+		regs[i] = uint32(cpu.Regs[i])
+	}
+}
+
+// GetDebugger returns the debugger installed into the CPU (if any)
+func (cpu *Cpu) GetDebugger() debugger.CpuDebugger {
+	return cpu.dbg
+}
+
+// GetClock returns the current value of the CPU clock (in cycles)
+func (cpu *Cpu) GetClock() int64 {
+	return cpu.Clock
+}
+
+// Halted returns true if the CPU is halted, waiting for an interrupt
+func (cpu *Cpu) Halted() bool {
+	return cpu.lines&LineHalt != 0
+}
+
 func (cpu *Cpu) DumpStatus() {
 
 	fmt.Printf("--------- Status at %v ----------\n", cpu.GetPC())
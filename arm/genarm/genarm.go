@@ -593,7 +593,11 @@ func (g *Generator) writeOpBranch(op uint32) {
 		g.WriteDisasm("b", "o:int32(op<<8)>>6")
 	}
 	g.writeOpBranchInner(link)
-	g.writeBranch("  cpu.Regs[15]", "BranchCall")
+	if link {
+		g.writeBranch("  cpu.Regs[15]", "BranchCall")
+	} else {
+		g.writeBranch("  cpu.Regs[15]", "BranchJump")
+	}
 }
 
 func (g *Generator) writeOpSwi(op uint32) {
@@ -1033,7 +1037,7 @@ func (g *Generator) writeOpBlock(op uint32) {
 		g.WriteExitIfOpInvalid("cpu.Regs[15]&1!=0 && cpu.arch < ARMv5", "changing T bit in LDM PC on ARMv4")
 		fmt.Fprintf(g, "  newpc := cpu.Regs[15]\n")
 		fmt.Fprintf(g, "  if newpc&1 != 0 {cpu.Cpsr.SetT(true,cpu); newpc &^= 1} else {newpc &^= 3}\n")
-		g.writeBranch("   newpc", "BranchJump")
+		g.writeBranch("   newpc", "BranchReturn")
 		fmt.Fprintf(g, "}\n")
 	} else {
 		fmt.Fprintf(g, "var val uint32\n")
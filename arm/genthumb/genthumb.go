@@ -219,7 +219,11 @@ func (g *Generator) writeOpF5HiReg(op uint16) {
 		fmt.Fprintf(g, "if op&0x80 != 0 { cpu.Regs[14] = (cpu.Regs[15]-2)|1 }\n")
 		fmt.Fprintf(g, "newpc := reg(rs)&^1\n")
 		fmt.Fprintf(g, "if rs&1==0 { cpu.Cpsr.SetT(false,cpu); newpc &^= 3 }\n")
+		fmt.Fprintf(g, "if op&0x80 != 0 {\n")
 		g.writeBranch("newpc", "BranchCall")
+		fmt.Fprintf(g, "} else {\n")
+		g.writeBranch("newpc", "BranchJump")
+		fmt.Fprintf(g, "}\n")
 		fmt.Fprintf(g, "_=rdx\n")
 
 		fmt.Fprintf(&g.Disasm, "if op&0x80 != 0 {\n")
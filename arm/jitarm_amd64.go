@@ -11,7 +11,8 @@ import (
 )
 
 // ABI for JIT functions:
-//    R15 = *Cpu
+//
+//	R15 = *Cpu
 var (
 	jitRegCpu = a.R15
 )
@@ -1246,7 +1247,11 @@ func (j *jitArm) emitOpBranch(op uint32) {
 
 	j.Add(a.Imm{off}, j.oArmReg(15))
 	j.Movl(j.oArmReg(15), a.Eax)
-	j.emitBranch(a.Eax, BranchCall, 0)
+	if link || op>>28 == 0xF {
+		j.emitBranch(a.Eax, BranchCall, 0)
+	} else {
+		j.emitBranch(a.Eax, BranchJump, 0)
+	}
 }
 
 func (j *jitArm) emitCallSpsr() {
@@ -4,6 +4,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/rand"
+	"ndsemu/emu/debugger"
+	"reflect"
 	"runtime/debug"
 	"testing"
 
@@ -427,3 +429,63 @@ func TestAlu(t *testing.T) {
 		testf(0x010073d5, "ldrble    r0, [r3, #0x-1]!")
 	}
 }
+
+// Run branches both with the interpreter and the JIT, and check that the
+// call stacks tracked for the debugger are the same.
+func TestJitCallStack(t *testing.T) {
+	debug.SetGCPercent(-1) // Disable GC for now
+
+	jita, err := a.NewGoABI(1024 * 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const PC = 0x2000000
+	const ret = PC + 0x108
+
+	for _, tc := range []struct {
+		op   uint32 // big-endian, like in TestAlu
+		desc string
+	}{
+		{0x400000eb, "bl        #0x108"},
+		{0x400000ea, "b         #0x108"}, // not a call: returns to the caller
+		{0x200000ea, "b         #0x88"},  // not a call: unrelated jump
+		{0x1eff2fe1, "bx        lr"},
+		{0x400000fa, "blx       #0x108"},
+	} {
+		var linearmem [4]byte
+		binary.BigEndian.PutUint32(linearmem[:], tc.op)
+		op := binary.LittleEndian.Uint32(linearmem[:])
+
+		var cpu1, cpu2 Cpu
+		bus1 := &debugBus{LinearMem: linearmem[:], RandData: make([]uint32, 16)}
+		bus2 := &debugBus{LinearMem: linearmem[:], RandData: make([]uint32, 16)}
+		for i, cpu := range []*Cpu{&cpu1, &cpu2} {
+			cpu.arch = ARMv5
+			cpu.bus = []*debugBus{bus1, bus2}[i]
+			cpu.Cpsr._mode = uint8(CpuModeUser)
+			cpu.Cpsr.Set(uint32(CpuModeUser), cpu)
+			cpu.Regs[14] = ret
+			cpu.pc = PC
+			cpu.calls = &callStack{frames: []debugger.CallFrame{
+				{Target: 0x2001000, Ret: ret},
+			}}
+		}
+
+		jit := &jitArm{Assembler: jita, Cpu: &cpu2}
+		jit.Off = 0
+		jit.StartPc = PC
+		f, err := jit.EmitBlock([]uint32{op})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cpu1.Run(1)
+		f(&cpu2)
+
+		cs1, cs2 := cpu1.CallStack(), cpu2.CallStack()
+		if !reflect.DeepEqual(cs1, cs2) {
+			t.Errorf("%s: different call stacks: exp:%v jit:%v", tc.desc, cs1, cs2)
+		}
+	}
+}
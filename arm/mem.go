@@ -26,7 +26,7 @@ func (cpu *Cpu) opFetchPointer(addr uint32) []uint8 {
 
 func (cpu *Cpu) Read32(addr uint32) uint32 {
 	if cpu.dbg != nil {
-		cpu.dbg.WatchRead(addr)
+		cpu.dbg.WatchRead(addr, 4)
 	}
 
 	// Unaligned memory reads are forcibly aligned.
@@ -54,7 +54,7 @@ nodtcm:
 
 func (cpu *Cpu) Write32(addr uint32, val uint32) {
 	if cpu.dbg != nil {
-		cpu.dbg.WatchWrite(addr, uint32(val))
+		cpu.dbg.WatchWrite(addr, uint32(val), 4)
 	}
 
 	// Unaligned memory writes are forcibly aligned
@@ -84,7 +84,7 @@ nodtcm:
 
 func (cpu *Cpu) Read16(addr uint32) uint16 {
 	if cpu.dbg != nil {
-		cpu.dbg.WatchRead(addr)
+		cpu.dbg.WatchRead(addr, 2)
 	}
 
 	// Unaligned memory reads are forcibly aligned.
@@ -111,7 +111,7 @@ nodtcm:
 
 func (cpu *Cpu) Write16(addr uint32, val uint16) {
 	if cpu.dbg != nil {
-		cpu.dbg.WatchWrite(addr, uint32(val))
+		cpu.dbg.WatchWrite(addr, uint32(val), 2)
 	}
 	cpu.Clock += 1
 	// Unaligned memory writes are forcibly aligned
@@ -139,7 +139,7 @@ nodtcm:
 
 func (cpu *Cpu) Read8(addr uint32) uint8 {
 	if cpu.dbg != nil {
-		cpu.dbg.WatchRead(addr)
+		cpu.dbg.WatchRead(addr, 1)
 	}
 	cpu.Clock += 1
 	if cpu.cp15 != nil {
@@ -160,7 +160,7 @@ nodtcm:
 
 func (cpu *Cpu) Write8(addr uint32, val uint8) {
 	if cpu.dbg != nil {
-		cpu.dbg.WatchWrite(addr, uint32(val))
+		cpu.dbg.WatchWrite(addr, uint32(val), 1)
 	}
 	cpu.Clock += 1
 	if cpu.cp15 != nil {
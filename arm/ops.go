@@ -108,9 +108,12 @@ const (
 )
 
 // Execute a branch to a target. The specified reason is only used for logs and
-// heuristics.
+// heuristics (like the shadow call stack).
 // NOTE: this function is called by JIT, so don't change signature
 func (cpu *Cpu) branch(newpc reg, reason BranchType) {
+	if cpu.calls != nil {
+		cpu.calls.update(cpu, newpc, reason)
+	}
 	cpu.Clock += 2
 	cpu.tightExit = true
 	cpu.prevpc = cpu.pc
@@ -146,10 +149,12 @@ func (cpu *Cpu) Run(until int64) {
 	for cpu.Clock < cpu.targetCycles {
 		lines := cpu.lines
 		if lines&LineHalt != 0 {
-			cpu.Clock = cpu.targetCycles
+			// Trace before skipping the cycles, so that the debugger
+			// can tell the time spent halted.
 			if trace != nil {
 				trace(uint32(cpu.pc))
 			}
+			cpu.Clock = cpu.targetCycles
 			return
 		}
 		// Check for interrupts outside of the tight loop. This theoretically
@@ -222,7 +227,11 @@ func (cpu *Cpu) Run(until int64) {
 			if cpu.jit != nil {
 				if fcode := cpu.jit.Lookup(uint32(cpu.pc)); fcode != nil {
 					if trace != nil {
+						cpu.jumped = false
 						trace(uint32(cpu.pc - 4))
+						if cpu.jumped {
+							continue
+						}
 					}
 					fcode()
 					continue
@@ -234,7 +243,13 @@ func (cpu *Cpu) Run(until int64) {
 				cpu.pc += 4
 
 				if trace != nil {
+					cpu.jumped = false
 					trace(uint32(cpu.pc - 4))
+					if cpu.jumped {
+						// The debugger moved the PC: the opcode must not be
+						// executed, fetch again from the new address.
+						break
+					}
 				}
 
 				op := binary.LittleEndian.Uint32(mem[i:])
@@ -259,7 +274,11 @@ func (cpu *Cpu) Run(until int64) {
 				cpu.pc += 2
 
 				if trace != nil {
+					cpu.jumped = false
 					trace(uint32(cpu.pc - 2))
+					if cpu.jumped {
+						break
+					}
 				}
 
 				op := binary.LittleEndian.Uint16(mem[i:])
@@ -1,4 +1,4 @@
-// Generated on 2017-06-18 01:20:56.24527106 +0200 CEST
+// Generated on 2026-10-17 01:57:18.310096646 +0000 UTC
 package arm
 
 import "bytes"
@@ -18662,7 +18662,7 @@ func (cpu *Cpu) opArm810(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -18817,7 +18817,7 @@ func (cpu *Cpu) opArm830(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -18953,7 +18953,7 @@ func (cpu *Cpu) opArm850(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -19128,7 +19128,7 @@ func (cpu *Cpu) opArm870(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -19249,7 +19249,7 @@ func (cpu *Cpu) opArm890(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -19400,7 +19400,7 @@ func (cpu *Cpu) opArm8B0(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -19534,7 +19534,7 @@ func (cpu *Cpu) opArm8D0(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -19705,7 +19705,7 @@ func (cpu *Cpu) opArm8F0(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -19829,7 +19829,7 @@ func (cpu *Cpu) opArm910(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -19984,7 +19984,7 @@ func (cpu *Cpu) opArm930(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -20120,7 +20120,7 @@ func (cpu *Cpu) opArm950(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -20295,7 +20295,7 @@ func (cpu *Cpu) opArm970(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 			rn += 4
 		}
@@ -20418,7 +20418,7 @@ func (cpu *Cpu) opArm990(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -20569,7 +20569,7 @@ func (cpu *Cpu) opArm9B0(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -20703,7 +20703,7 @@ func (cpu *Cpu) opArm9D0(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -20874,7 +20874,7 @@ func (cpu *Cpu) opArm9F0(op uint32) {
 				} else {
 					newpc &^= 3
 				}
-				cpu.branch(newpc, BranchJump)
+				cpu.branch(newpc, BranchReturn)
 			}
 		}
 		mask >>= 1
@@ -20929,7 +20929,7 @@ func (cpu *Cpu) opArmA00(op uint32) {
 	// B
 	off := int32(op<<8) >> 6
 	cpu.Regs[15] += reg(off)
-	cpu.branch(cpu.Regs[15], BranchCall)
+	cpu.branch(cpu.Regs[15], BranchJump)
 }
 
 func (cpu *Cpu) disasmArmA00(op uint32, pc uint32) string {
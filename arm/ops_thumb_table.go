@@ -1,4 +1,4 @@
-// Generated on 2017-06-18 01:20:56.902016754 +0200 CEST
+// Generated on 2026-10-17 01:57:18.862146165 +0000 UTC
 package arm
 
 import "bytes"
@@ -699,7 +699,11 @@ func (cpu *Cpu) opThumb47(op uint16) {
 		cpu.Cpsr.SetT(false, cpu)
 		newpc &^= 3
 	}
-	cpu.branch(newpc, BranchCall)
+	if op&0x80 != 0 {
+		cpu.branch(newpc, BranchCall)
+	} else {
+		cpu.branch(newpc, BranchJump)
+	}
 	_ = rdx
 }
 
@@ -0,0 +1,75 @@
+package arm
+
+import (
+	"ndsemu/emu/savestate"
+)
+
+func serializeRegs(st *savestate.Stream, regs []reg) {
+	for i := range regs {
+		st.Uint32((*uint32)(&regs[i]))
+	}
+}
+
+// Serialize saves or loads the full state of the CPU, including all the
+// banked registers and the CP15 state (if enabled). Other coprocessors are
+// not part of the CPU state and must be serialized separately.
+func (cpu *Cpu) Serialize(st *savestate.Stream) {
+	st.Section("cpu")
+	serializeRegs(st, cpu.Regs[:])
+	st.Bool(&cpu.Cpsr.N)
+	st.Bool(&cpu.Cpsr.Z)
+	st.Bool(&cpu.Cpsr.C)
+	st.Bool(&cpu.Cpsr.V)
+	st.Bool(&cpu.Cpsr.Q)
+	st.Bool(&cpu.Cpsr._i)
+	st.Bool(&cpu.Cpsr._f)
+	st.Bool(&cpu.Cpsr._t)
+	st.Uint8(&cpu.Cpsr._mode)
+	st.Int64(&cpu.Clock)
+
+	serializeRegs(st, cpu.UsrBank[:])
+	serializeRegs(st, cpu.FiqBank[:])
+	serializeRegs(st, cpu.SvcBank[:])
+	serializeRegs(st, cpu.AbtBank[:])
+	serializeRegs(st, cpu.IrqBank[:])
+	serializeRegs(st, cpu.UndBank[:])
+	serializeRegs(st, cpu.SpsrBank[:])
+	serializeRegs(st, cpu.UsrBank2[:])
+	serializeRegs(st, cpu.FiqBank2[:])
+
+	st.Uint32((*uint32)(&cpu.pc))
+	st.Uint32((*uint32)(&cpu.prevpc))
+	lines := int(cpu.lines)
+	st.Int(&lines)
+	cpu.lines = Line(lines)
+	st.Int64(&cpu.targetCycles)
+
+	if cpu.cp15 != nil {
+		cpu.cp15.serialize(st)
+	}
+
+	if st.Loading() {
+		// Force the CPU to go through the main loop, to reevaluate
+		// the new IRQ lines.
+		cpu.tightExit = true
+
+		// The memory contents have been replaced, so all the compiled
+		// blocks are now stale.
+		if cpu.jit != nil {
+			cpu.jit.InvalidateAll()
+		}
+	}
+}
+
+func (c *Cp15) serialize(st *savestate.Stream) {
+	st.Section("cp15")
+	st.Uint32((*uint32)(&c.regControl))
+	st.Uint32((*uint32)(&c.regDtcmVsize))
+	st.Uint32((*uint32)(&c.regItcmVsize))
+	st.Uint32(&c.accessPerm)
+	st.Bytes(c.itcm)
+	st.Bytes(c.dtcm)
+	if st.Loading() {
+		c.updateTcmConfig()
+	}
+}
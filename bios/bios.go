@@ -0,0 +1,186 @@
+// Package bios implements a high-level emulation (HLE) of the NDS BIOS, so
+// that the emulator can run games without the original BIOS dumps.
+//
+// All the SWI calls are intercepted and emulated in Go. The BIOS memory area
+// is filled with a minimal stub that only contains the exception vectors and
+// the IRQ dispatcher (which must run as ARM code, as it calls the handler
+// installed by the game). The boot process is not emulated, so games must
+// be started with direct boot.
+package bios
+
+import (
+	"encoding/binary"
+
+	"ndsemu/arm"
+	log "ndsemu/emu/logger"
+)
+
+var modBios = log.NewModule("bios")
+
+// memory is the subset of arm.Cpu used to access the memory bus
+type memory interface {
+	Read8(addr uint32) uint8
+	Read16(addr uint32) uint16
+	Read32(addr uint32) uint32
+	Write8(addr uint32, val uint8)
+	Write16(addr uint32, val uint16)
+	Write32(addr uint32, val uint32)
+}
+
+const (
+	cStubSize9 = 4 * 1024
+	cStubSize7 = 16 * 1024
+
+	// Offset of the IRQ dispatcher within the stub
+	cIrqHandlerOff = 0x100
+)
+
+// ARM opcodes of the IRQ dispatcher. It saves the registers that the ARM
+// ABI considers volatile, calls the handler installed by the game, and
+// returns from the exception. The address of the handler is stored at
+// DTCM+0x3FFC on ARM9, and at 0x3FFFFFC (mirror of 0x380FFFC) on ARM7.
+var irqHandler9 = []uint32{
+	0xE92D500F, // stmfd sp!, {r0-r3,r12,lr}
+	0xEE190F11, // mrc p15, 0, r0, c9, c1, 0
+	0xE1A00620, // mov r0, r0, lsr #12
+	0xE1A00600, // mov r0, r0, lsl #12
+	0xE2800901, // add r0, r0, #0x4000
+	0xE28FE000, // add lr, pc, #0
+	0xE510F004, // ldr pc, [r0, #-4]
+	0xE8BD500F, // ldmfd sp!, {r0-r3,r12,lr}
+	0xE25EF004, // subs pc, lr, #4
+}
+
+var irqHandler7 = []uint32{
+	0xE92D500F, // stmfd sp!, {r0-r3,r12,lr}
+	0xE3A00301, // mov r0, #0x04000000
+	0xE28FE000, // add lr, pc, #0
+	0xE510F004, // ldr pc, [r0, #-4]
+	0xE8BD500F, // ldmfd sp!, {r0-r3,r12,lr}
+	0xE25EF004, // subs pc, lr, #4
+}
+
+func makeStub(size int, irq []uint32) []byte {
+	stub := make([]byte, size)
+
+	// All vectors but IRQ are endless loops ("b ."), as there is nothing
+	// sensible to do. SWIs never reach the vector, as they are all
+	// intercepted by HLE.
+	for i := uint32(0); i < 8; i++ {
+		binary.LittleEndian.PutUint32(stub[i*4:], 0xEAFFFFFE)
+	}
+
+	// IRQ vector: branch to the dispatcher
+	const irqVector = 0x18
+	binary.LittleEndian.PutUint32(stub[irqVector:],
+		0xEA000000|((cIrqHandlerOff-irqVector-8)>>2))
+
+	for i, op := range irq {
+		binary.LittleEndian.PutUint32(stub[cIrqHandlerOff+i*4:], op)
+	}
+	return stub
+}
+
+// Stub9 returns a BIOS image to be mapped in place of the ARM9 BIOS.
+func Stub9() []byte {
+	return makeStub(cStubSize9, irqHandler9)
+}
+
+// Stub7 returns a BIOS image to be mapped in place of the ARM7 BIOS.
+func Stub7() []byte {
+	return makeStub(cStubSize7, irqHandler7)
+}
+
+type bios struct {
+	cpu  *arm.Cpu
+	cp15 *arm.Cp15
+	name string
+
+	// Set while the CPU is halted within IntrWait, with the address of the
+	// SWI opcode that will be executed again (see wait).
+	waiting bool
+	waitPc  uint32
+}
+
+// Address of the IRQ check flags, used by IntrWait to know which interrupts
+// have been serviced. The IRQ handler of the game is expected to set them.
+func (b *bios) irqFlagsAddr() uint32 {
+	if b.cp15 != nil {
+		return b.cp15.DtcmBase() + 0x3FF8
+	}
+	return 0x380FFF8
+}
+
+func (b *bios) install(swis map[uint8]func(*bios) int64) {
+	for i := 0; i < 256; i++ {
+		num := uint8(i)
+		f := swis[num]
+		if f == nil {
+			f = func(b *bios) int64 { return b.unimplemented(num) }
+		}
+		b.cpu.SetSwiHle(num, func(*arm.Cpu) int64 { return f(b) })
+	}
+}
+
+func (b *bios) unimplemented(num uint8) int64 {
+	modBios.ErrorZ("unimplemented SWI").
+		String("cpu", b.name).
+		Hex8("num", num).
+		Hex32("pc", b.cpu.GetPc()).
+		End()
+	return 0
+}
+
+// Activate9 installs the HLE BIOS on the ARM9 CPU
+func Activate9(cpu *arm.Cpu, cp15 *arm.Cp15) {
+	b := &bios{cpu: cpu, cp15: cp15, name: "arm9"}
+	b.install(map[uint8]func(*bios) int64{
+		0x03: (*bios).waitByLoop,
+		0x04: (*bios).intrWait,
+		0x05: (*bios).vblankIntrWait,
+		0x06: (*bios).halt,
+		0x09: (*bios).div,
+		0x0B: (*bios).cpuSet,
+		0x0C: (*bios).cpuFastSet,
+		0x0D: (*bios).sqrt,
+		0x0E: (*bios).getCRC16,
+		0x0F: (*bios).isDebugger,
+		0x10: (*bios).bitUnPack,
+		0x11: (*bios).lz77UnCompWram,
+		0x12: (*bios).lz77UnCompVram,
+		0x13: (*bios).huffUnComp,
+		0x14: (*bios).rlUnCompWram,
+		0x15: (*bios).rlUnCompVram,
+		0x16: (*bios).diff8bitUnFilter,
+		0x18: (*bios).diff16bitUnFilter,
+		0x1F: (*bios).customPost,
+	})
+}
+
+// Activate7 installs the HLE BIOS on the ARM7 CPU
+func Activate7(cpu *arm.Cpu) {
+	b := &bios{cpu: cpu, name: "arm7"}
+	b.install(map[uint8]func(*bios) int64{
+		0x03: (*bios).waitByLoop,
+		0x04: (*bios).intrWait,
+		0x05: (*bios).vblankIntrWait,
+		0x06: (*bios).halt,
+		0x07: (*bios).halt, // Sleep: no difference for us
+		0x08: (*bios).soundBias,
+		0x09: (*bios).div,
+		0x0B: (*bios).cpuSet,
+		0x0C: (*bios).cpuFastSet,
+		0x0D: (*bios).sqrt,
+		0x0E: (*bios).getCRC16,
+		0x0F: (*bios).isDebugger,
+		0x10: (*bios).bitUnPack,
+		0x11: (*bios).lz77UnCompWram,
+		0x12: (*bios).lz77UnCompVram,
+		0x13: (*bios).huffUnComp,
+		0x14: (*bios).rlUnCompWram,
+		0x15: (*bios).rlUnCompVram,
+		0x1A: (*bios).getSineTable,
+		0x1B: (*bios).getPitchTable,
+		0x1F: (*bios).customPost,
+	})
+}
@@ -0,0 +1,244 @@
+package bios
+
+// Decompression functions. The "ReadByCallback" variants of the BIOS
+// functions (used for VRAM destinations) allow the game to provide callbacks
+// to read the source stream; since we cannot easily call back into emulated
+// code, we ignore the callbacks and read the source directly from memory,
+// which is what all known callbacks do anyway.
+
+// writeOut writes the decompressed data into the destination, using
+// either 8-bit or 16-bit accesses (VRAM doesn't support 8-bit writes).
+func writeOut(mem memory, dst uint32, data []byte, wide bool) {
+	if !wide {
+		for i, v := range data {
+			mem.Write8(dst+uint32(i), v)
+		}
+		return
+	}
+	for i := 0; i < len(data); i += 2 {
+		val := uint16(data[i])
+		if i+1 < len(data) {
+			val |= uint16(data[i+1]) << 8
+		}
+		mem.Write16(dst+uint32(i), val)
+	}
+}
+
+func lz77Decompress(mem memory, src uint32) []byte {
+	size := mem.Read32(src) >> 8
+	src += 4
+
+	out := make([]byte, 0, size)
+	for uint32(len(out)) < size {
+		flags := mem.Read8(src)
+		src++
+		for i := 0; i < 8 && uint32(len(out)) < size; i++ {
+			if flags&0x80 == 0 {
+				out = append(out, mem.Read8(src))
+				src++
+			} else {
+				b0, b1 := mem.Read8(src), mem.Read8(src+1)
+				src += 2
+				disp := (int(b0&0xF)<<8 | int(b1)) + 1
+				n := int(b0>>4) + 3
+				for j := 0; j < n && uint32(len(out)) < size; j++ {
+					if disp > len(out) {
+						// Invalid stream, fill with zeros
+						out = append(out, 0)
+					} else {
+						out = append(out, out[len(out)-disp])
+					}
+				}
+			}
+			flags <<= 1
+		}
+	}
+	return out
+}
+
+func huffDecompress(mem memory, src uint32) []byte {
+	hdr := mem.Read32(src)
+	bits := uint(hdr & 0xF)
+	size := hdr >> 8
+	if bits != 4 && bits != 8 {
+		modBios.ErrorZ("unsupported huffman data size").Uint("bits", bits).End()
+		return make([]byte, size)
+	}
+
+	treeSize := uint32(mem.Read8(src + 4))
+	root := src + 5
+	stream := src + 4 + (treeSize+1)*2
+
+	out := make([]byte, 0, size)
+	var acc uint32
+	var accbits uint
+	node := root
+	for uint32(len(out)) < size {
+		word := mem.Read32(stream)
+		stream += 4
+		for i := 0; i < 32 && uint32(len(out)) < size; i++ {
+			val := mem.Read8(node)
+			next := (node &^ 1) + uint32(val&0x3F)*2 + 2
+			var isData bool
+			if word&0x80000000 == 0 {
+				isData = val&0x80 != 0
+			} else {
+				next++
+				isData = val&0x40 != 0
+			}
+			word <<= 1
+
+			if !isData {
+				node = next
+				continue
+			}
+
+			acc |= uint32(mem.Read8(next)) << accbits
+			accbits += bits
+			node = root
+			if accbits == 32 {
+				out = append(out, byte(acc), byte(acc>>8), byte(acc>>16), byte(acc>>24))
+				acc, accbits = 0, 0
+			}
+		}
+	}
+	return out[:size]
+}
+
+func rlDecompress(mem memory, src uint32) []byte {
+	size := mem.Read32(src) >> 8
+	src += 4
+
+	out := make([]byte, 0, size)
+	for uint32(len(out)) < size {
+		flag := mem.Read8(src)
+		src++
+		if flag&0x80 != 0 {
+			n := int(flag&0x7F) + 3
+			val := mem.Read8(src)
+			src++
+			for j := 0; j < n; j++ {
+				out = append(out, val)
+			}
+		} else {
+			n := int(flag&0x7F) + 1
+			for j := 0; j < n; j++ {
+				out = append(out, mem.Read8(src))
+				src++
+			}
+		}
+	}
+	return out[:size]
+}
+
+func diffUnfilter(mem memory, src uint32, wide bool) []byte {
+	size := mem.Read32(src) >> 8
+	src += 4
+
+	out := make([]byte, size)
+	if !wide {
+		var acc uint8
+		for i := range out {
+			acc += mem.Read8(src + uint32(i))
+			out[i] = acc
+		}
+	} else {
+		var acc uint16
+		for i := 0; i+1 < len(out); i += 2 {
+			acc += mem.Read16(src + uint32(i))
+			out[i], out[i+1] = byte(acc), byte(acc>>8)
+		}
+	}
+	return out
+}
+
+func bitUnPack(mem memory, src, dst, info uint32) {
+	srclen := uint32(mem.Read16(info))
+	srcw := uint(mem.Read8(info + 2))
+	dstw := uint(mem.Read8(info + 3))
+	offset := mem.Read32(info + 4)
+	zeroData := offset&0x80000000 != 0
+	offset &= 0x7FFFFFFF
+	if srcw == 0 || dstw == 0 {
+		modBios.ErrorZ("invalid BitUnPack parameters").End()
+		return
+	}
+
+	var acc uint32
+	var accbits uint
+	for i := uint32(0); i < srclen; i++ {
+		b := mem.Read8(src + i)
+		for bit := uint(0); bit < 8; bit += srcw {
+			val := (uint32(b) >> bit) & (1<<srcw - 1)
+			if val != 0 || zeroData {
+				val += offset
+			}
+			acc |= (val & (1<<dstw - 1)) << accbits
+			accbits += dstw
+			if accbits >= 32 {
+				mem.Write32(dst, acc)
+				dst += 4
+				acc, accbits = 0, 0
+			}
+		}
+	}
+}
+
+// SWI 10h - BitUnPack
+func (b *bios) bitUnPack() int64 {
+	bitUnPack(b.cpu, b.reg(0), b.reg(1), b.reg(2))
+	return 64
+}
+
+// SWI 11h - LZ77UnCompReadNormalWrite8bit
+func (b *bios) lz77UnCompWram() int64 {
+	data := lz77Decompress(b.cpu, b.reg(0))
+	writeOut(b.cpu, b.reg(1), data, false)
+	return int64(len(data)) * 4
+}
+
+// SWI 12h - LZ77UnCompReadByCallbackWrite16bit
+func (b *bios) lz77UnCompVram() int64 {
+	data := lz77Decompress(b.cpu, b.reg(0))
+	writeOut(b.cpu, b.reg(1), data, true)
+	return int64(len(data)) * 4
+}
+
+// SWI 13h - HuffUnCompReadByCallback
+func (b *bios) huffUnComp() int64 {
+	data := huffDecompress(b.cpu, b.reg(0))
+	dst := b.reg(1)
+	for i := 0; i+3 < len(data); i += 4 {
+		b.cpu.Write32(dst+uint32(i),
+			uint32(data[i])|uint32(data[i+1])<<8|uint32(data[i+2])<<16|uint32(data[i+3])<<24)
+	}
+	return int64(len(data)) * 8
+}
+
+// SWI 14h - RLUnCompReadNormalWrite8bit
+func (b *bios) rlUnCompWram() int64 {
+	data := rlDecompress(b.cpu, b.reg(0))
+	writeOut(b.cpu, b.reg(1), data, false)
+	return int64(len(data)) * 4
+}
+
+// SWI 15h - RLUnCompReadByCallbackWrite16bit
+func (b *bios) rlUnCompVram() int64 {
+	data := rlDecompress(b.cpu, b.reg(0))
+	writeOut(b.cpu, b.reg(1), data, true)
+	return int64(len(data)) * 4
+}
+
+// SWI 16h - Diff8bitUnFilterWrite8bit (ARM9 only)
+func (b *bios) diff8bitUnFilter() int64 {
+	data := diffUnfilter(b.cpu, b.reg(0), false)
+	writeOut(b.cpu, b.reg(1), data, false)
+	return int64(len(data)) * 4
+}
+
+// SWI 18h - Diff16bitUnFilter (ARM9 only)
+func (b *bios) diff16bitUnFilter() int64 {
+	data := diffUnfilter(b.cpu, b.reg(0), true)
+	writeOut(b.cpu, b.reg(1), data, true)
+	return int64(len(data)) * 4
+}
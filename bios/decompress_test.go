@@ -0,0 +1,68 @@
+package bios
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+type fakeMem []byte
+
+func (m fakeMem) Read8(addr uint32) uint8   { return m[addr] }
+func (m fakeMem) Read16(addr uint32) uint16 { return binary.LittleEndian.Uint16(m[addr:]) }
+func (m fakeMem) Read32(addr uint32) uint32 { return binary.LittleEndian.Uint32(m[addr:]) }
+func (m fakeMem) Write8(addr uint32, val uint8) {
+	m[addr] = val
+}
+func (m fakeMem) Write16(addr uint32, val uint16) {
+	binary.LittleEndian.PutUint16(m[addr:], val)
+}
+func (m fakeMem) Write32(addr uint32, val uint32) {
+	binary.LittleEndian.PutUint32(m[addr:], val)
+}
+
+func TestDecompress(t *testing.T) {
+	var tests = []struct {
+		name string
+		fn   func(mem memory, src uint32) []byte
+		src  []byte
+		exp  string
+	}{
+		{"lz77", lz77Decompress,
+			[]byte{0x10, 9, 0, 0, 0x10, 'A', 'B', 'C', 0x30, 0x02},
+			"ABCABCABC"},
+		{"rle", rlDecompress,
+			[]byte{0x30, 5, 0, 0, 0x81, 'A', 0x00, 'B'},
+			"AAAAB"},
+		{"huffman", huffDecompress,
+			[]byte{0x28, 4, 0, 0, 1, 0xC0, 'A', 'B', 0, 0, 0, 0x50},
+			"ABAB"},
+		{"diff8", func(mem memory, src uint32) []byte { return diffUnfilter(mem, src, false) },
+			[]byte{0x81, 4, 0, 0, 'A', 1, 1, 0xFF},
+			"ABCB"},
+	}
+
+	for _, test := range tests {
+		mem := make(fakeMem, 256)
+		copy(mem, test.src)
+		if got := string(test.fn(mem, 0)); got != test.exp {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.exp)
+		}
+	}
+}
+
+func TestBitUnPack(t *testing.T) {
+	mem := make(fakeMem, 256)
+	// source: 1bpp, two bytes
+	mem[0x00] = 0x81
+	mem[0x01] = 0x00
+	// info: srclen=2, srcw=1, dstw=4, offset=2 (no zero data)
+	copy(mem[0x10:], []byte{2, 0, 1, 4, 2, 0, 0, 0})
+
+	bitUnPack(mem, 0x00, 0x20, 0x10)
+	if got := mem.Read32(0x20); got != 0x30000003 {
+		t.Errorf("first word: got %08x", got)
+	}
+	if got := mem.Read32(0x24); got != 0 {
+		t.Errorf("second word: got %08x", got)
+	}
+}
@@ -0,0 +1,249 @@
+package bios
+
+import (
+	"math"
+
+	"ndsemu/arm"
+
+	"github.com/howeyc/crc16"
+)
+
+// I/O registers accessed by the BIOS
+const (
+	regIme       = 0x4000208
+	regIe        = 0x4000210
+	regIf        = 0x4000214
+	regPostFlg   = 0x4000300
+	regSoundBias = 0x4000504
+)
+
+func (b *bios) reg(n int) uint32 {
+	return uint32(b.cpu.Regs[n])
+}
+
+func (b *bios) setReg(n int, val uint32) {
+	b.cpu.SetReg(n, val)
+}
+
+// SWI 03h - WaitByLoop
+func (b *bios) waitByLoop() int64 {
+	return int64(int32(b.reg(0))) * 4
+}
+
+// wait implements the BIOS loop used by IntrWait: it waits until one of the
+// interrupts in mask has been serviced by the IRQ handler of the game (which
+// is expected to set the IRQ check flags).
+//
+// To emulate the loop, we halt the CPU and rewind the PC to the SWI opcode,
+// so that it gets executed again when the IRQ handler returns. That execution
+// is the same wait going on, so the flags must not be discarded again.
+func (b *bios) wait(discard bool, mask uint32) int64 {
+	cpu := b.cpu
+	pc := cpu.GetPc()
+	if b.waiting && b.waitPc == pc {
+		discard = false
+	}
+	b.waiting = false
+	cpu.Write32(regIme, 1)
+
+	flagsAddr := b.irqFlagsAddr()
+	flags := cpu.Read32(flagsAddr)
+	if discard {
+		cpu.Write32(flagsAddr, flags&^mask)
+	} else if flags&mask != 0 {
+		cpu.Write32(flagsAddr, flags&^mask)
+		return 0
+	}
+
+	b.setReg(0, 0)
+	b.setReg(1, mask)
+	b.waiting, b.waitPc = true, pc
+	cpu.Jump(pc)
+
+	// Halt until the next interrupt, unless one is already pending (in
+	// which case it will be serviced immediately).
+	if cpu.Read32(regIe)&cpu.Read32(regIf) == 0 {
+		cpu.SetLine(arm.LineHalt, true)
+	}
+	return 0
+}
+
+// SWI 04h - IntrWait
+func (b *bios) intrWait() int64 {
+	return b.wait(b.reg(0) != 0, b.reg(1))
+}
+
+// SWI 05h - VBlankIntrWait
+func (b *bios) vblankIntrWait() int64 {
+	// Like the real BIOS, this is IntrWait with r0=1 and r1=1
+	b.setReg(0, 1)
+	b.setReg(1, 1)
+	return b.intrWait()
+}
+
+// SWI 06h - Halt
+func (b *bios) halt() int64 {
+	b.cpu.SetLine(arm.LineHalt, true)
+	return 0
+}
+
+// SWI 08h - SoundBias (ARM7 only)
+func (b *bios) soundBias() int64 {
+	// The BIOS slowly moves the level, with a delay between each step;
+	// we just set the final value.
+	var bias uint16
+	if b.reg(0) != 0 {
+		bias = 0x200
+	}
+	b.cpu.Write16(regSoundBias, bias)
+	return 0
+}
+
+// SWI 09h - Div
+func (b *bios) div() int64 {
+	num, den := int32(b.reg(0)), int32(b.reg(1))
+	if den == 0 {
+		modBios.WarnZ("division by zero").String("cpu", b.name).End()
+		res := int32(1)
+		if num < 0 {
+			res = -1
+		}
+		b.setReg(0, uint32(res))
+		b.setReg(1, uint32(num))
+		b.setReg(3, 1)
+		return 32
+	}
+
+	q, r := num/den, num%den
+	b.setReg(0, uint32(q))
+	b.setReg(1, uint32(r))
+	if q < 0 {
+		q = -q
+	}
+	b.setReg(3, uint32(q))
+	return 32
+}
+
+// SWI 0Bh - CpuSet
+func (b *bios) cpuSet() int64 {
+	cpu := b.cpu
+	src, dst, cnt := b.reg(0), b.reg(1), b.reg(2)
+	count := cnt & 0x1FFFFF
+	fill := cnt&(1<<24) != 0
+
+	if cnt&(1<<26) != 0 {
+		src &^= 3
+		dst &^= 3
+		val := cpu.Read32(src)
+		for i := uint32(0); i < count; i++ {
+			if !fill {
+				val = cpu.Read32(src + i*4)
+			}
+			cpu.Write32(dst+i*4, val)
+		}
+	} else {
+		src &^= 1
+		dst &^= 1
+		val := cpu.Read16(src)
+		for i := uint32(0); i < count; i++ {
+			if !fill {
+				val = cpu.Read16(src + i*2)
+			}
+			cpu.Write16(dst+i*2, val)
+		}
+	}
+	return int64(count) * 4
+}
+
+// SWI 0Ch - CpuFastSet
+func (b *bios) cpuFastSet() int64 {
+	cpu := b.cpu
+	src, dst, cnt := b.reg(0)&^3, b.reg(1)&^3, b.reg(2)
+	count := ((cnt & 0x1FFFFF) + 7) &^ 7
+	fill := cnt&(1<<24) != 0
+
+	val := cpu.Read32(src)
+	for i := uint32(0); i < count; i++ {
+		if !fill {
+			val = cpu.Read32(src + i*4)
+		}
+		cpu.Write32(dst+i*4, val)
+	}
+	return int64(count) * 2
+}
+
+// SWI 0Dh - Sqrt
+func (b *bios) sqrt() int64 {
+	val := b.reg(0)
+
+	// Integer square root (bit by bit)
+	var res, bit uint32 = 0, 1 << 30
+	for bit > val {
+		bit >>= 2
+	}
+	for bit != 0 {
+		if val >= res+bit {
+			val -= res + bit
+			res = (res >> 1) + bit
+		} else {
+			res >>= 1
+		}
+		bit >>= 2
+	}
+
+	b.setReg(0, res)
+	return 64
+}
+
+// SWI 0Eh - GetCRC16
+func (b *bios) getCRC16() int64 {
+	crc, addr, size := uint16(b.reg(0)), b.reg(1), b.reg(2)
+
+	// The size is not validated by the BIOS, so read the memory in chunks
+	// rather than allocating a buffer for the whole range.
+	var buf [256]byte
+	for left := size; left > 0; {
+		n := uint32(len(buf))
+		if left < n {
+			n = left
+		}
+		for i := uint32(0); i < n; i++ {
+			buf[i] = b.cpu.Read8(addr + i)
+		}
+		// crc16.Update inverts the CRC before and after the computation,
+		// while the BIOS doesn't.
+		crc = ^crc16.Update(^crc, crc16.IBMTable, buf[:n])
+		addr += n
+		left -= n
+	}
+	b.setReg(0, uint32(crc))
+	return int64(size) * 8
+}
+
+// SWI 0Fh - IsDebugger
+func (b *bios) isDebugger() int64 {
+	b.setReg(0, 0)
+	return 0
+}
+
+// SWI 1Ah - GetSineTable (ARM7 only)
+func (b *bios) getSineTable() int64 {
+	idx := b.reg(0) & 0x3F
+	val := math.Sin(float64(idx) * math.Pi / 128)
+	b.setReg(0, uint32(math.Floor(val*0x8000+0.5)))
+	return 0
+}
+
+// SWI 1Bh - GetPitchTable (ARM7 only)
+func (b *bios) getPitchTable() int64 {
+	idx := b.reg(0) % 0x300
+	val := math.Exp2(float64(idx)/0x300) - 1
+	b.setReg(0, uint32(math.Floor(val*0x10000+0.5)))
+	return 0
+}
+
+// SWI 1Fh - CustomPost
+func (b *bios) customPost() int64 {
+	b.cpu.Write8(regPostFlg, uint8(b.reg(0)))
+	return 0
+}
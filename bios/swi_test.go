@@ -0,0 +1,315 @@
+package bios
+
+import (
+	"testing"
+
+	"ndsemu/arm"
+)
+
+// testBus is a minimal ARM7 memory map, to run code on a real CPU: the BIOS
+// stub, 64KB of main RAM, 64KB of WRAM (mirrored up to 0x3FFFFFF, like the
+// real one) and the interrupt registers.
+type testBus struct {
+	cpu          *arm.Cpu
+	bios         fakeMem
+	ram, wram    fakeMem
+	ime, ie, irf uint32
+}
+
+const (
+	testRam  = 0x2000000
+	testWram = 0x3800000
+)
+
+func (bus *testBus) mem(addr uint32) fakeMem {
+	switch addr >> 24 {
+	case 0:
+		return bus.bios[addr:]
+	case 2:
+		return bus.ram[addr&0xFFFF:]
+	case 3:
+		return bus.wram[addr&0xFFFF:]
+	}
+	return nil
+}
+
+func (bus *testBus) io(addr uint32) *uint32 {
+	switch addr &^ 3 {
+	case regIme:
+		return &bus.ime
+	case regIe:
+		return &bus.ie
+	case regIf:
+		return &bus.irf
+	}
+	return nil
+}
+
+func (bus *testBus) WaitStates() int { return 0 }
+
+func (bus *testBus) Read32(addr uint32) uint32 {
+	if r := bus.io(addr); r != nil {
+		return *r
+	}
+	return bus.mem(addr).Read32(0)
+}
+
+func (bus *testBus) Write32(addr uint32, val uint32) {
+	switch addr {
+	case regIf:
+		// Writing 1 acknowledges the interrupts
+		bus.irf &^= val
+		bus.cpu.SetLine(arm.LineIrq, bus.ie&bus.irf != 0)
+	case regIme, regIe:
+		*bus.io(addr) = val
+	default:
+		bus.mem(addr).Write32(0, val)
+	}
+}
+
+func (bus *testBus) Read16(addr uint32) uint16       { return bus.mem(addr).Read16(0) }
+func (bus *testBus) Write16(addr uint32, val uint16) { bus.mem(addr).Write16(0, val) }
+func (bus *testBus) Read8(addr uint32) uint8         { return bus.mem(addr).Read8(0) }
+func (bus *testBus) Write8(addr uint32, val uint8)   { bus.mem(addr).Write8(0, val) }
+func (bus *testBus) FetchPointer(addr uint32) []uint8 {
+	return bus.mem(addr)
+}
+
+// raise triggers the specified interrupts
+func (bus *testBus) raise(irqs uint32) {
+	bus.irf |= irqs
+	bus.cpu.SetLine(arm.LineIrq, bus.ie&bus.irf != 0)
+}
+
+// IRQ handler of the "game", in ARM code. Like real games, it acknowledges
+// the interrupts and sets them in the IRQ check flags.
+var testIrqHandler = []uint32{
+	0xE59F0018, // ldr r0, [pc, #0x18]
+	0xE5901000, // ldr r1, [r0]
+	0xE5801000, // str r1, [r0]
+	0xE59F0010, // ldr r0, [pc, #0x10]
+	0xE5902000, // ldr r2, [r0]
+	0xE1822001, // orr r2, r2, r1
+	0xE5802000, // str r2, [r0]
+	0xE12FFF1E, // bx lr
+	regIf,
+	testWram + 0xFFF8,
+}
+
+// Addresses of the test code and of the IRQ handler
+const (
+	testCodeAddr = testRam
+	testIrqAddr  = testRam + 0x100
+	testDataAddr = testRam + 0x1000
+)
+
+// newTestCpu returns an ARM7 running with the HLE BIOS, with the IRQ
+// handler installed and the interrupts in ie enabled.
+func newTestCpu(ie uint32) (*arm.Cpu, *testBus) {
+	bus := &testBus{
+		bios: fakeMem(Stub7()),
+		ram:  make(fakeMem, 0x10000),
+		wram: make(fakeMem, 0x10000),
+		ie:   ie,
+	}
+	cpu := arm.NewCpu(arm.ARMv4, bus, false)
+	bus.cpu = cpu
+	Activate7(cpu)
+
+	for i, op := range testIrqHandler {
+		bus.Write32(testIrqAddr+uint32(i)*4, op)
+	}
+	bus.Write32(testWram+0xFFFC, testIrqAddr)
+
+	// Stack for the IRQ dispatcher
+	cpu.Cpsr.SetMode(arm.CpuModeIrq, cpu)
+	cpu.SetReg(13, testWram+0x8000)
+	cpu.Cpsr.SetMode(arm.CpuModeSupervisor, cpu)
+
+	return cpu, bus
+}
+
+// run lets the CPU run for a while
+func run(cpu *arm.Cpu) {
+	cpu.Run(cpu.Clock + 1000)
+}
+
+func TestIntrWait(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		swi    uint32
+		r0, r1 uint32
+		flags  uint32   // IRQ check flags when the SWI is called
+		irqs   []uint32 // interrupts raised, one after the other
+		wake   int      // number of interrupts after which the SWI returns
+		exp    uint32   // IRQ check flags at the end
+	}{
+		{"IntrWait, discard", 4, 1, 1, 1, []uint32{1}, 1, 0},
+		{"IntrWait, flag already set", 4, 0, 1, 1, nil, 0, 0},
+		{"IntrWait, no discard", 4, 0, 1, 0, []uint32{1}, 1, 0},
+		{"IntrWait, other interrupts", 4, 1, 2, 0, []uint32{1, 1, 2}, 3, 1},
+		{"IntrWait, multiple interrupts", 4, 1, 6, 0, []uint32{1, 4}, 2, 1},
+		{"VBlankIntrWait", 5, 0, 0, 1, []uint32{1}, 1, 0},
+		{"VBlankIntrWait, other interrupts", 5, 0, 0, 1, []uint32{2, 1}, 2, 2},
+	} {
+		cpu, bus := newTestCpu(0xFFFF)
+		for i, op := range []uint32{
+			0xEF000000 | tc.swi<<16, // swi #n
+			0xE3A04001,              // mov r4, #1
+			0xEAFFFFFE,              // b .
+		} {
+			bus.Write32(testCodeAddr+uint32(i)*4, op)
+		}
+		bus.Write32(testWram+0xFFF8, tc.flags)
+		cpu.SetReg(0, tc.r0)
+		cpu.SetReg(1, tc.r1)
+		cpu.SetPC(testCodeAddr)
+
+		run(cpu)
+		for i := 0; i <= len(tc.irqs); i++ {
+			if i > 0 {
+				bus.raise(tc.irqs[i-1])
+				run(cpu)
+			}
+			if got, exp := cpu.Regs[4] == 1, i >= tc.wake; got != exp {
+				t.Errorf("%s: after %d interrupts: returned = %v, want %v", tc.desc, i, got, exp)
+			}
+		}
+		if got := bus.Read32(testWram + 0xFFF8); got != tc.exp {
+			t.Errorf("%s: flags = %x, want %x", tc.desc, got, tc.exp)
+		}
+	}
+}
+
+func TestDiv(t *testing.T) {
+	cpu, _ := newTestCpu(0)
+	b := &bios{cpu: cpu, name: "arm7"}
+
+	for _, tc := range []struct {
+		desc       string
+		num, den   int32
+		r0, r1, r3 int32
+	}{
+		{"positive", 7, 2, 3, 1, 3},
+		{"negative numerator", -7, 2, -3, -1, 3},
+		{"negative denominator", 7, -2, -3, 1, 3},
+		{"both negative", -7, -2, 3, -1, 3},
+		{"overflow", -0x80000000, -1, -0x80000000, 0, -0x80000000},
+		{"zero by zero", 0, 0, 1, 0, 1},
+		{"positive by zero", 5, 0, 1, 5, 1},
+		{"negative by zero", -5, 0, -1, -5, 1},
+	} {
+		cpu.SetReg(0, uint32(tc.num))
+		cpu.SetReg(1, uint32(tc.den))
+		b.div()
+		if r0, r1, r3 := int32(cpu.Regs[0]), int32(cpu.Regs[1]), int32(cpu.Regs[3]); r0 != tc.r0 || r1 != tc.r1 || r3 != tc.r3 {
+			t.Errorf("%s: got r0=%d r1=%d r3=%d, want r0=%d r1=%d r3=%d",
+				tc.desc, r0, r1, r3, tc.r0, tc.r1, tc.r3)
+		}
+	}
+}
+
+func TestSqrt(t *testing.T) {
+	cpu, _ := newTestCpu(0)
+	b := &bios{cpu: cpu, name: "arm7"}
+
+	for _, tc := range []struct {
+		val, exp uint32
+	}{
+		{0, 0}, {1, 1}, {2, 1}, {4, 2}, {99, 9}, {100, 10},
+		{0x40000000, 0x8000}, {0xFFFFFFFF, 0xFFFF},
+	} {
+		cpu.SetReg(0, tc.val)
+		b.sqrt()
+		if got := uint32(cpu.Regs[0]); got != tc.exp {
+			t.Errorf("sqrt(%d) = %d, want %d", tc.val, got, tc.exp)
+		}
+	}
+}
+
+func TestCpuSet(t *testing.T) {
+	const fill, word = 1 << 24, 1 << 26
+
+	for _, tc := range []struct {
+		desc string
+		fn   func(*bios) int64
+		cnt  uint32
+		size int  // size of the units
+		n    int  // number of units written
+		fill bool // whether the first unit is repeated
+	}{
+		{"CpuSet, copy 16", (*bios).cpuSet, 3, 2, 3, false},
+		{"CpuSet, fill 16", (*bios).cpuSet, 3 | fill, 2, 3, true},
+		{"CpuSet, copy 32", (*bios).cpuSet, 3 | word, 4, 3, false},
+		{"CpuSet, fill 32", (*bios).cpuSet, 3 | word | fill, 4, 3, true},
+		{"CpuSet, empty", (*bios).cpuSet, word, 4, 0, false},
+		{"CpuFastSet, copy", (*bios).cpuFastSet, 3, 4, 8, false},
+		{"CpuFastSet, fill", (*bios).cpuFastSet, 9 | fill, 4, 16, true},
+	} {
+		cpu, bus := newTestCpu(0)
+		b := &bios{cpu: cpu, name: "arm7"}
+		const src, dst = testDataAddr, testDataAddr + 0x100
+		for i := uint32(0); i < 0x100; i++ {
+			bus.Write8(src+i, uint8(i+1))
+		}
+
+		cpu.SetReg(0, src)
+		cpu.SetReg(1, dst)
+		cpu.SetReg(2, tc.cnt)
+		tc.fn(b)
+
+		for i := 0; i < (tc.n+1)*tc.size; i++ {
+			var exp uint8
+			switch {
+			case i >= tc.n*tc.size:
+				exp = 0
+			case tc.fill:
+				exp = uint8(i%tc.size + 1)
+			default:
+				exp = uint8(i + 1)
+			}
+			if got := bus.Read8(dst + uint32(i)); got != exp {
+				t.Errorf("%s: byte %d = %d, want %d", tc.desc, i, got, exp)
+			}
+		}
+	}
+}
+
+func TestGetCRC16(t *testing.T) {
+	cpu, bus := newTestCpu(0)
+	b := &bios{cpu: cpu, name: "arm7"}
+
+	crc := func(init uint32, data []byte) uint32 {
+		copy(bus.ram[testDataAddr&0xFFFF:], data)
+		cpu.SetReg(0, init)
+		cpu.SetReg(1, testDataAddr)
+		cpu.SetReg(2, uint32(len(data)))
+		b.getCRC16()
+		return uint32(cpu.Regs[0])
+	}
+
+	for _, tc := range []struct {
+		desc string
+		init uint32
+		data string
+		exp  uint32
+	}{
+		{"empty", 0x1234, "", 0x1234},
+		{"init 0 (CRC-16/ARC)", 0, "123456789", 0xBB3D},
+		{"init FFFF (CRC-16/MODBUS)", 0xFFFF, "123456789", 0x4B37},
+	} {
+		if got := crc(tc.init, []byte(tc.data)); got != tc.exp {
+			t.Errorf("%s: crc = %04x, want %04x", tc.desc, got, tc.exp)
+		}
+	}
+
+	// Data longer than the internal buffer: the result must be the same as
+	// computing the CRC in two steps.
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = uint8(i * 7)
+	}
+	if got, exp := crc(0xFFFF, data), crc(crc(0xFFFF, data[:100]), data[100:]); got != exp {
+		t.Errorf("long data: crc = %04x, want %04x", got, exp)
+	}
+}
@@ -0,0 +1,227 @@
+package cheat
+
+import "fmt"
+
+// Action Replay DS code types (see GBATEK, "DS Cart Cheat Action Replay DS").
+// Codes are pairs of words (XXXXXXXX YYYYYYYY); the type is in the top 4 bits
+// of the first word, or in the top 8 bits for the C and D types:
+//
+//	0XXXXXXX YYYYYYYY  word[XXXXXXX+offset] = YYYYYYYY
+//	1XXXXXXX 0000YYYY  half[XXXXXXX+offset] = YYYY
+//	2XXXXXXX 000000YY  byte[XXXXXXX+offset] = YY
+//	3XXXXXXX YYYYYYYY  IF YYYYYYYY > word[XXXXXXX]
+//	4XXXXXXX YYYYYYYY  IF YYYYYYYY < word[XXXXXXX]
+//	5XXXXXXX YYYYYYYY  IF YYYYYYYY = word[XXXXXXX]
+//	6XXXXXXX YYYYYYYY  IF YYYYYYYY <> word[XXXXXXX]
+//	7XXXXXXX ZZZZYYYY  IF YYYY > (half[XXXXXXX] AND NOT ZZZZ)
+//	8XXXXXXX ZZZZYYYY  IF YYYY < (half[XXXXXXX] AND NOT ZZZZ)
+//	9XXXXXXX ZZZZYYYY  IF YYYY = (half[XXXXXXX] AND NOT ZZZZ)
+//	AXXXXXXX ZZZZYYYY  IF YYYY <> (half[XXXXXXX] AND NOT ZZZZ)
+//	BXXXXXXX 00000000  offset = word[XXXXXXX+offset]
+//	C0000000 YYYYYYYY  FOR loop = 0 TO YYYYYYYY
+//	C5000000 XXXXYYYY  counter += 1; IF (counter AND YYYY) = XXXX
+//	C6000000 XXXXXXXX  word[XXXXXXXX] = offset
+//	D0000000 00000000  ENDIF
+//	D1000000 00000000  NEXT
+//	D2000000 00000000  NEXT, then clear conditions, offset and datareg
+//	D3000000 XXXXXXXX  offset = XXXXXXXX
+//	D4000000 XXXXXXXX  datareg += XXXXXXXX
+//	D5000000 XXXXXXXX  datareg = XXXXXXXX
+//	D6000000 XXXXXXXX  word[XXXXXXXX+offset] = datareg; offset += 4
+//	D7000000 XXXXXXXX  half[XXXXXXXX+offset] = datareg; offset += 2
+//	D8000000 XXXXXXXX  byte[XXXXXXXX+offset] = datareg; offset += 1
+//	D9000000 XXXXXXXX  datareg = word[XXXXXXXX+offset]
+//	DA000000 XXXXXXXX  datareg = half[XXXXXXXX+offset]
+//	DB000000 XXXXXXXX  datareg = byte[XXXXXXXX+offset]
+//	DC000000 XXXXXXXX  offset += XXXXXXXX
+//	EXXXXXXX YYYYYYYY  copy YYYYYYYY bytes from the following codes to [XXXXXXX+offset]
+//	FXXXXXXX YYYYYYYY  copy YYYYYYYY bytes from [offset] to [XXXXXXX]
+//
+// In conditional codes, if XXXXXXX is zero, the offset is used as address.
+// C4 codes (that refer to the code list stored in memory by the AR) are not
+// supported.
+
+// Maximum number of codes executed per frame, to avoid locking the emulator
+// with runaway loops.
+const cMaxSteps = 1 << 20
+
+func validateCodes(codes [][2]uint32) error {
+	for i := 0; i < len(codes); i++ {
+		hi, lo := codes[i][0], codes[i][1]
+		switch hi >> 28 {
+		case 0xC:
+			switch hi >> 24 {
+			case 0xC0, 0xC5, 0xC6:
+			case 0xC4:
+				return fmt.Errorf("code %08X: type C4 is not supported", hi)
+			default:
+				return fmt.Errorf("code %08X: invalid type", hi)
+			}
+		case 0xD:
+			if hi>>24 > 0xDC {
+				return fmt.Errorf("code %08X: invalid type", hi)
+			}
+		case 0xE:
+			// Computed in 64 bits, as lo+7 might overflow
+			n := (uint64(lo) + 7) / 8
+			if n > uint64(len(codes)-i-1) {
+				return fmt.Errorf("code %08X: missing data", hi)
+			}
+			i += int(n)
+		}
+	}
+	return nil
+}
+
+// arState is the state of the virtual machine that runs the codes
+type arState struct {
+	offset uint32
+	data   uint32
+
+	// Condition stack: bit 0 is the current condition, and it is set if the
+	// codes must be skipped.
+	skip uint32
+
+	loop struct {
+		active bool
+		count  uint32
+		start  int    // Index of the first code of the loop
+		skip   uint32 // Condition stack at the beginning of the loop
+	}
+}
+
+func (st *arState) cond(ok bool) {
+	st.skip <<= 1
+	if !ok {
+		st.skip |= 1
+	}
+}
+
+// next implements the end of a loop (D1/D2 codes), returning the index of
+// the next code to execute.
+func (st *arState) next(i int, flush bool) int {
+	if st.loop.active {
+		if st.loop.count > 0 {
+			st.loop.count--
+			st.skip = st.loop.skip
+			return st.loop.start
+		}
+		st.loop.active = false
+	}
+	if flush {
+		st.offset, st.data, st.skip = 0, 0, 0
+	}
+	return i + 1
+}
+
+func (c *Cheat) runAR(bus Bus) {
+	var st arState
+	codes := c.codes
+
+	for i, steps := 0, 0; i < len(codes) && steps < cMaxSteps; steps++ {
+		hi, lo := codes[i][0], codes[i][1]
+		typ, addr := hi>>28, hi&0x0FFFFFFF
+		if typ == 0xC || typ == 0xD {
+			typ = hi >> 24
+		}
+
+		// While skipping, only track nested conditions, and the end of
+		// conditions and loops
+		if st.skip&1 != 0 {
+			switch {
+			case typ >= 0x3 && typ <= 0xA, typ == 0xC5:
+				st.cond(false)
+			case typ == 0xD0:
+				st.skip >>= 1
+			case typ == 0xD1, typ == 0xD2:
+				i = st.next(i, typ == 0xD2)
+				continue
+			case typ == 0xE:
+				i += int((uint64(lo) + 7) / 8)
+			}
+			i++
+			continue
+		}
+
+		caddr := addr
+		if caddr == 0 {
+			caddr = st.offset
+		}
+		switch typ {
+		case 0x0:
+			bus.Write32(addr+st.offset, lo)
+		case 0x1:
+			bus.Write16(addr+st.offset, uint16(lo))
+		case 0x2:
+			bus.Write8(addr+st.offset, uint8(lo))
+		case 0x3:
+			st.cond(lo > bus.Read32(caddr))
+		case 0x4:
+			st.cond(lo < bus.Read32(caddr))
+		case 0x5:
+			st.cond(lo == bus.Read32(caddr))
+		case 0x6:
+			st.cond(lo != bus.Read32(caddr))
+		case 0x7, 0x8, 0x9, 0xA:
+			val := bus.Read16(caddr) &^ uint16(lo>>16)
+			y := uint16(lo)
+			st.cond(typ == 0x7 && y > val || typ == 0x8 && y < val ||
+				typ == 0x9 && y == val || typ == 0xA && y != val)
+		case 0xB:
+			st.offset = bus.Read32(addr + st.offset)
+		case 0xC0:
+			st.loop.active = true
+			st.loop.count = lo
+			st.loop.start = i + 1
+			st.loop.skip = st.skip
+		case 0xC5:
+			c.counter++
+			st.cond(c.counter&lo&0xFFFF == lo>>16)
+		case 0xC6:
+			bus.Write32(lo, st.offset)
+		case 0xD0:
+			st.skip >>= 1
+		case 0xD1, 0xD2:
+			i = st.next(i, typ == 0xD2)
+			continue
+		case 0xD3:
+			st.offset = lo
+		case 0xD4:
+			st.data += lo
+		case 0xD5:
+			st.data = lo
+		case 0xD6:
+			bus.Write32(lo+st.offset, st.data)
+			st.offset += 4
+		case 0xD7:
+			bus.Write16(lo+st.offset, uint16(st.data))
+			st.offset += 2
+		case 0xD8:
+			bus.Write8(lo+st.offset, uint8(st.data))
+			st.offset++
+		case 0xD9:
+			st.data = bus.Read32(lo + st.offset)
+		case 0xDA:
+			st.data = uint32(bus.Read16(lo + st.offset))
+		case 0xDB:
+			st.data = uint32(bus.Read8(lo + st.offset))
+		case 0xDC:
+			st.offset += lo
+		case 0xE:
+			dst := addr + st.offset
+			for n := uint32(0); n < lo; n++ {
+				w := codes[i+1+int(n/8)][(n/4)&1]
+				bus.Write8(dst+n, uint8(w>>(8*(n&3))))
+			}
+			i += int((uint64(lo) + 7) / 8)
+		case 0xF:
+			// The length is not limited by the code list, so each byte
+			// counts as a step.
+			for n := uint32(0); n < lo && steps < cMaxSteps; n++ {
+				bus.Write8(addr+n, bus.Read8(st.offset+n))
+				steps++
+			}
+		}
+		i++
+	}
+}
@@ -0,0 +1,189 @@
+// Package cheat implements cheat codes: Action Replay DS code lists, and raw
+// memory patches. Cheats are applied once per frame through the ARM9 bus.
+package cheat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Bus is the memory bus the cheats are applied to
+type Bus interface {
+	Read8(addr uint32) uint8
+	Read16(addr uint32) uint16
+	Read32(addr uint32) uint32
+	Write8(addr uint32, val uint8)
+	Write16(addr uint32, val uint16)
+	Write32(addr uint32, val uint32)
+}
+
+// Cheat is a named list of codes. Each line of a cheat is either an Action
+// Replay DS code ("XXXXXXXX YYYYYYYY"), or a raw memory patch in the form
+// "ADDR = VALUE", that writes a 32-bit value; the size can be specified with
+// a suffix on the address (eg: "0x02001234:8 = 99", or ":16").
+type Cheat struct {
+	Name    string
+	Enabled bool
+
+	patches []patch
+	codes   [][2]uint32
+	counter uint32 // Counter used by C5 codes
+}
+
+type patch struct {
+	addr uint32
+	val  uint32
+	size int
+}
+
+// Parse parses the code of a cheat
+func Parse(name, code string) (*Cheat, error) {
+	c := &Cheat{Name: name, Enabled: true}
+	for nline, line := range strings.Split(code, "\n") {
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var err error
+		if strings.Contains(line, "=") {
+			err = c.parsePatch(line)
+		} else {
+			err = c.parseCode(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cheat %q, line %d: %v", name, nline+1, err)
+		}
+	}
+	if err := validateCodes(c.codes); err != nil {
+		return nil, fmt.Errorf("cheat %q: %v", name, err)
+	}
+	return c, nil
+}
+
+func (c *Cheat) parsePatch(line string) error {
+	parts := strings.SplitN(line, "=", 2)
+	addr, val := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+	p := patch{size: 4}
+	if idx := strings.IndexByte(addr, ':'); idx >= 0 {
+		switch addr[idx+1:] {
+		case "8":
+			p.size = 1
+		case "16":
+			p.size = 2
+		case "32":
+			p.size = 4
+		default:
+			return fmt.Errorf("invalid size %q", addr[idx+1:])
+		}
+		addr = addr[:idx]
+	}
+
+	a, err := strconv.ParseUint(addr, 0, 32)
+	if err != nil {
+		return fmt.Errorf("invalid address %q", addr)
+	}
+	v, err := strconv.ParseUint(val, 0, p.size*8)
+	if err != nil {
+		return fmt.Errorf("invalid value %q", val)
+	}
+	p.addr, p.val = uint32(a), uint32(v)
+	c.patches = append(c.patches, p)
+	return nil
+}
+
+func (c *Cheat) parseCode(line string) error {
+	f := strings.Fields(line)
+	if len(f) != 2 || len(f[0]) != 8 || len(f[1]) != 8 {
+		return fmt.Errorf("invalid code %q", line)
+	}
+	hi, err1 := strconv.ParseUint(f[0], 16, 32)
+	lo, err2 := strconv.ParseUint(f[1], 16, 32)
+	if err1 != nil || err2 != nil {
+		return fmt.Errorf("invalid code %q", line)
+	}
+	c.codes = append(c.codes, [2]uint32{uint32(hi), uint32(lo)})
+	return nil
+}
+
+// Run applies the cheat
+func (c *Cheat) Run(bus Bus) {
+	for _, p := range c.patches {
+		switch p.size {
+		case 1:
+			bus.Write8(p.addr, uint8(p.val))
+		case 2:
+			bus.Write16(p.addr, uint16(p.val))
+		case 4:
+			bus.Write32(p.addr, p.val)
+		}
+	}
+	if len(c.codes) > 0 {
+		c.runAR(bus)
+	}
+}
+
+// List is the list of cheats for a game
+type List []*Cheat
+
+// Run applies all the enabled cheats; it must be called once per frame.
+func (l List) Run(bus Bus) {
+	for _, c := range l {
+		if c.Enabled {
+			c.Run(bus)
+		}
+	}
+}
+
+// Load loads the cheats for the game with the specified gamecode from a TOML
+// file like the following:
+//
+//	[[Cheat]]
+//	Name = "Infinite lives"
+//	Game = "ASME"          # optional: gamecode of the game
+//	Disabled = false       # optional: if true, the cheat is not applied
+//	Code = """
+//	94000130 FCFF0000
+//	12045678 00000009
+//	D2000000 00000000
+//	"""
+//
+// Cheats whose Game doesn't match the gamecode are ignored, so that a single
+// file can hold the cheats for multiple games.
+func Load(fn string, gamecode string) (List, error) {
+	var cfg struct {
+		Cheat []struct {
+			Name     string
+			Game     string
+			Disabled bool
+			Code     string
+		}
+	}
+	if _, err := toml.DecodeFile(fn, &cfg); err != nil {
+		return nil, err
+	}
+
+	var list List
+	for _, fc := range cfg.Cheat {
+		if fc.Game != "" && fc.Game != gamecode {
+			continue
+		}
+		if fc.Code == "" {
+			return nil, fmt.Errorf("cheat %q: missing code", fc.Name)
+		}
+		c, err := Parse(fc.Name, fc.Code)
+		if err != nil {
+			return nil, err
+		}
+		c.Enabled = !fc.Disabled
+		list = append(list, c)
+	}
+	return list, nil
+}
@@ -0,0 +1,160 @@
+package cheat
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testBus [0x100]byte
+
+func (b *testBus) Read8(addr uint32) uint8   { return b[addr] }
+func (b *testBus) Read16(addr uint32) uint16 { return binary.LittleEndian.Uint16(b[addr:]) }
+func (b *testBus) Read32(addr uint32) uint32 { return binary.LittleEndian.Uint32(b[addr:]) }
+func (b *testBus) Write8(addr uint32, val uint8) {
+	b[addr] = val
+}
+func (b *testBus) Write16(addr uint32, val uint16) {
+	binary.LittleEndian.PutUint16(b[addr:], val)
+}
+func (b *testBus) Write32(addr uint32, val uint32) {
+	binary.LittleEndian.PutUint32(b[addr:], val)
+}
+
+// countBus accepts accesses to any address, and counts them
+type countBus struct {
+	reads, writes int
+}
+
+func (b *countBus) Read8(addr uint32) uint8         { b.reads++; return 0 }
+func (b *countBus) Read16(addr uint32) uint16       { b.reads++; return 0 }
+func (b *countBus) Read32(addr uint32) uint32       { b.reads++; return 0 }
+func (b *countBus) Write8(addr uint32, val uint8)   { b.writes++ }
+func (b *countBus) Write16(addr uint32, val uint16) { b.writes++ }
+func (b *countBus) Write32(addr uint32, val uint32) { b.writes++ }
+
+func TestPatches(t *testing.T) {
+	c, err := Parse("test", `
+		0x10 = 0x12345678
+		0x20:16 = 0xABCD   # comment
+		0x30:8 = 99
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bus testBus
+	c.Run(&bus)
+	if v := bus.Read32(0x10); v != 0x12345678 {
+		t.Errorf("invalid word: %08x", v)
+	}
+	if v := bus.Read32(0x20); v != 0xABCD {
+		t.Errorf("invalid half: %08x", v)
+	}
+	if v := bus.Read32(0x30); v != 99 {
+		t.Errorf("invalid byte: %08x", v)
+	}
+
+	for _, code := range []string{"0x10:12 = 1", "0x10:8 = 0x100", "foo = 1", "1234 5678", "C4000000 00000000", "E0000010 00000010",
+		"E2000000 FFFFFFFF\n00000000 00000000"} {
+		if _, err := Parse("test", code); err == nil {
+			t.Errorf("%q: expected error", code)
+		}
+	}
+}
+
+func TestActionReplay(t *testing.T) {
+	tests := []struct {
+		name  string
+		code  string
+		check func(b *testBus) bool
+	}{
+		{"write", "00000010 11223344\n10000020 0000AABB\n20000022 000000CC",
+			func(b *testBus) bool { return b.Read32(0x10) == 0x11223344 && b.Read32(0x20) == 0xCCAABB }},
+		{"cond-true", "50000000 00000000\n00000010 00000001\nD0000000 00000000\n00000014 00000002",
+			func(b *testBus) bool { return b.Read32(0x10) == 1 && b.Read32(0x14) == 2 }},
+		{"cond-false", "60000004 00000000\n00000010 00000001\nD0000000 00000000\n00000014 00000002",
+			func(b *testBus) bool { return b.Read32(0x10) == 0 && b.Read32(0x14) == 2 }},
+		{"nested", "60000004 00000000\n50000004 00000000\nD0000000 00000000\n00000010 00000001\nD0000000 00000000\n00000014 00000002",
+			func(b *testBus) bool { return b.Read32(0x10) == 0 && b.Read32(0x14) == 2 }},
+		{"cond16", "90000008 FF000034\n00000010 00000001\nD2000000 00000000",
+			func(b *testBus) bool { return b.Read32(0x10) == 1 }},
+		{"loop", "D3000000 00000040\nD5000000 00000007\nC0000000 00000003\nD8000000 00000000\nD4000000 00000001\nD2000000 00000000",
+			func(b *testBus) bool { return b.Read32(0x40) == 0x0A090807 && b.Read32(0x44) == 0 }},
+		{"pointer", "B000000C 00000000\n00000004 000000FF",
+			func(b *testBus) bool { return b.Read32(0x54) == 0xFF }},
+		{"patch", "E0000060 00000006\n44332211 CCBB6655",
+			func(b *testBus) bool { return b.Read32(0x60) == 0x44332211 && b.Read32(0x64) == 0x6655 }},
+		{"copy", "D3000000 00000008\nF0000070 00000004",
+			func(b *testBus) bool { return b.Read32(0x70) == 0x5634 }},
+		{"datareg", "D9000000 00000008\nD4000000 00000001\nD6000000 00000080",
+			func(b *testBus) bool { return b.Read32(0x80) == 0x5635 }},
+	}
+
+	for _, tt := range tests {
+		c, err := Parse(tt.name, tt.code)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var bus testBus
+		bus.Write32(0x8, 0x5634)
+		bus.Write32(0xC, 0x50)
+		c.Run(&bus)
+		if !tt.check(&bus) {
+			t.Errorf("%s: invalid result", tt.name)
+		}
+	}
+}
+
+func TestActionReplayHugeCopy(t *testing.T) {
+	// The copy must be bounded like the other codes
+	c, err := Parse("test", "F2000000 FFFFFFFF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bus countBus
+	c.Run(&bus)
+	if bus.writes == 0 || bus.writes > cMaxSteps {
+		t.Errorf("invalid number of bytes copied: %d", bus.writes)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.cht")
+	err := os.WriteFile(fn, []byte(`
+[[Cheat]]
+Name = "all games"
+Code = "0x10 = 1"
+
+[[Cheat]]
+Name = "this game"
+Game = "ABCE"
+Disabled = true
+Code = """
+00000010 00000002
+"""
+
+[[Cheat]]
+Name = "other game"
+Game = "XYZE"
+Code = "0x10 = 3"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := Load(fn, "ABCE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Name != "all games" || !list[0].Enabled ||
+		list[1].Name != "this game" || list[1].Enabled {
+		t.Fatalf("invalid cheats: %+v", list)
+	}
+	var bus testBus
+	list.Run(&bus)
+	if v := bus.Read32(0x10); v != 1 {
+		t.Errorf("invalid value: %d", v)
+	}
+}
@@ -0,0 +1,77 @@
+// Command ndsemu-headless runs the emulator without opening any window or
+// audio device, for automated testing. It accepts the same options of ndsemu
+// to boot the emulator (see nds.Config), plus the ones to select the frames
+// to run and the output to write.
+//
+// Usage:
+//
+//	ndsemu-headless [flags] rom.nds [rom.gba]
+//	ndsemu-headless -regress manifest.toml
+//
+// Examples:
+//
+//	ndsemu-headless -s -frames 600 -shot-frames 300,600 game.nds
+//	ndsemu-headless -s -play run.nmv -audio-out audio.raw game.nds
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+
+	log "ndsemu/emu/logger"
+	"ndsemu/nds"
+)
+
+var (
+	flagFrames   = flag.Int("frames", 0, "number of frames to run (0 = no limit)")
+	flagAudioOut = flag.String("audio-out", "", "dump audio into the specified file (raw, 16-bit stereo)")
+	flagShotDir  = flag.String("shot-dir", ".", "directory where screenshots are saved")
+	flagShotList = flag.String("shot-frames", "", "comma-separated list of frames to take screenshots at")
+	flagGfxView  = flag.String("gfx-view", "", "write VRAM/palette/OAM views as PNG into the specified directory at each frame")
+	flagRegress  = flag.String("regress", "", "run the regression test suite described by the specified manifest")
+	flagRegOut   = flag.String("regress-out", "regress-failed", "directory where screenshots of failed regression tests are saved")
+	flagRegUpd   = flag.Bool("regress-update", false, "regenerate golden images/hashes of the regression test suite")
+
+	cfg nds.Config
+)
+
+func init() {
+	cfg.AddFlags(flag.CommandLine)
+}
+
+func main() {
+	flag.Parse()
+
+	if *flagRegress != "" {
+		os.Exit(nds.RunRegress(*flagRegress, *flagRegOut, *flagRegUpd))
+	}
+
+	hcfg := nds.HeadlessConfig{
+		Frames:     *flagFrames,
+		AudioOut:   *flagAudioOut,
+		ShotDir:    *flagShotDir,
+		ShotFrames: make(map[int]bool),
+		GfxView:    *flagGfxView,
+	}
+	if *flagShotList != "" {
+		for _, f := range strings.Split(*flagShotList, ",") {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				log.ModEmu.FatalZ("invalid frame number").String("frame", f).End()
+			}
+			hcfg.ShotFrames[n] = true
+		}
+	}
+
+	cfg.Roms = flag.Args()
+	movie, err := nds.Boot(&cfg)
+	if err != nil {
+		log.ModEmu.FatalZ(err.Error()).End()
+	}
+
+	code := nds.RunHeadless(hcfg, movie)
+	nds.Shutdown()
+	os.Exit(code)
+}
@@ -0,0 +1,535 @@
+package e2d
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"ndsemu/emu"
+)
+
+// This file contains the decoders used by the graphic viewer: they render
+// the contents of VRAM (tilemaps, tiles, palettes and sprites) into images,
+// using the same memory mapping and registers seen by the engine. They are
+// not meant to be fast, and are never called during normal emulation.
+
+// TileMode selects how tiles are decoded in a tile sheet
+type TileMode int
+
+const (
+	Tile16        TileMode = iota // 4bpp: 16 palettes of 16 colors
+	Tile256                       // 8bpp: one palette of 256 colors
+	Tile256ExtPal                 // 8bpp: 16 extended palettes of 256 colors
+)
+
+func (m TileMode) String() string {
+	switch m {
+	case Tile16:
+		return "16"
+	case Tile256:
+		return "256"
+	case Tile256ExtPal:
+		return "extpal"
+	}
+	return fmt.Sprintf("TileMode(%d)", int(m))
+}
+
+const (
+	cTileSheetColumns = 32
+	cSpriteCellSize   = 64
+)
+
+// The linear bank covers 64 banks of 8K
+const cVramLinearMask = 64*VramSmallestBankSize - 1
+
+// rgb555 converts a NDS color into RGBA. Bit 15 (used as alpha by direct
+// color bitmaps) is ignored.
+func rgb555(c uint16) color.RGBA {
+	r := uint8(c & 0x1F)
+	g := uint8((c >> 5) & 0x1F)
+	b := uint8((c >> 10) & 0x1F)
+	return color.RGBA{r<<3 | r>>2, g<<3 | g>>2, b<<3 | b>>2, 0xFF}
+}
+
+func palColor(pal []byte, idx int) color.RGBA {
+	if idx*2+1 >= len(pal) {
+		return color.RGBA{}
+	}
+	return rgb555(emu.Read16LE(pal[idx*2:]))
+}
+
+func vramGet8(v *VramLinearBank, off int) uint8 {
+	return v.Get8(off & cVramLinearMask)
+}
+
+func vramGet16(v *VramLinearBank, off int) uint16 {
+	return uint16(vramGet8(v, off)) | uint16(vramGet8(v, off+1))<<8
+}
+
+// tilePixel returns the color index of the pixel (x,y) within a tile; off
+// is the offset of the tile in VRAM.
+func tilePixel(v *VramLinearBank, off int, x, y int, depth256 bool) int {
+	if depth256 {
+		return int(vramGet8(v, off+y*8+x))
+	}
+	pix := vramGet8(v, off+y*4+x/2)
+	return int(pix>>(4*uint(x&1))) & 0xF
+}
+
+// palettes returns the standard palettes (BG and OBJ) of the engine
+func (e2d *HwEngine2d) palettes() (bg, obj []byte) {
+	pram := e2d.mc.VramPalette(e2d.Idx)
+	return pram[:512], pram[512:]
+}
+
+// bgExtPalette returns the extended palette slot used by the specified BG
+// layer; BG0 and BG1 can optionally use slots 2 and 3 (bit 13 of BGxCNT).
+func (e2d *HwEngine2d) bgExtPalette(lidx int) []byte {
+	slot := lidx
+	if lidx < 2 && *e2d.bgregs[lidx].Cnt&(1<<13) != 0 {
+		slot += 2
+	}
+	ext := e2d.mc.VramLinearBank(e2d.Idx, VramLinearBGExtPal, 0)
+	return ext.FetchPointer(8 * 1024 * slot)[:8*1024]
+}
+
+func (e2d *HwEngine2d) objExtPalette() []byte {
+	ext := e2d.mc.VramLinearBank(e2d.Idx, VramLinearOBJExtPal, 0)
+	return ext.FetchPointer(0)[:8*1024]
+}
+
+func (e2d *HwEngine2d) bgExtPalEnabled() bool {
+	return e2d.DispCnt.Value&(1<<30) != 0 && e2d.hwtype == HwNds
+}
+
+func (e2d *HwEngine2d) objExtPalEnabled() bool {
+	return e2d.DispCnt.Value&(1<<31) != 0 && e2d.hwtype == HwNds
+}
+
+// drawPalettes draws a list of palettes of n colors each, one palette per
+// row, each color being a sz*sz square.
+func drawPalettes(img *image.RGBA, y0 int, pal []byte, n int, sz int) int {
+	for i := 0; i < len(pal)/2; i++ {
+		c := palColor(pal, i)
+		px, py := (i%n)*sz, y0+(i/n)*sz
+		for y := 0; y < sz; y++ {
+			for x := 0; x < sz; x++ {
+				img.SetRGBA(px+x, py+y, c)
+			}
+		}
+	}
+	return y0 + (len(pal)/2/n)*sz
+}
+
+// ViewPalettes draws the standard palettes of the engine: the BG palette on
+// the top half, and the OBJ palette on the bottom half. Each row is a 16-color
+// palette.
+func (e2d *HwEngine2d) ViewPalettes() *image.RGBA {
+	const sz = 8
+	bg, obj := e2d.palettes()
+	img := image.NewRGBA(image.Rect(0, 0, 16*sz, 32*sz))
+	y := drawPalettes(img, 0, bg, 16, sz)
+	drawPalettes(img, y, obj, 16, sz)
+	return img
+}
+
+// ViewExtPalettes draws the extended palettes of the engine: the four BG
+// slots first, and then the OBJ slot. Each row is a 256-color palette.
+// Extended palettes are drawn even if they are disabled in DISPCNT, as long
+// as the VRAM banks are mapped.
+func (e2d *HwEngine2d) ViewExtPalettes() *image.RGBA {
+	const sz = 2
+	img := image.NewRGBA(image.Rect(0, 0, 256*sz, 5*16*sz))
+	ext := e2d.mc.VramLinearBank(e2d.Idx, VramLinearBGExtPal, 0)
+	y := 0
+	for slot := 0; slot < 4; slot++ {
+		y = drawPalettes(img, y, ext.FetchPointer(8 * 1024 * slot)[:8*1024], 256, sz)
+	}
+	drawPalettes(img, y, e2d.objExtPalette(), 256, sz)
+	return img
+}
+
+// TileSheet draws ntiles consecutive tiles starting at offset off within the
+// specified VRAM bank, 32 tiles per row. pal is the palette RAM (or extended
+// palette slot) used to decode colors, and palnum is the palette number (in
+// 16-color or extended palette mode). Transparent pixels (color 0) are left
+// transparent.
+func TileSheet(src *VramLinearBank, off int, ntiles int, mode TileMode, pal []byte, palnum int) *image.RGBA {
+	rows := (ntiles + cTileSheetColumns - 1) / cTileSheetColumns
+	img := image.NewRGBA(image.Rect(0, 0, cTileSheetColumns*8, rows*8))
+
+	depth256 := mode != Tile16
+	tsize := 32
+	if depth256 {
+		tsize = 64
+	}
+	palbase := 0
+	switch mode {
+	case Tile16:
+		palbase = (palnum & 15) * 16
+	case Tile256ExtPal:
+		palbase = (palnum & 15) * 256
+	}
+
+	for t := 0; t < ntiles; t++ {
+		tx, ty := (t%cTileSheetColumns)*8, (t/cTileSheetColumns)*8
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				if pix := tilePixel(src, off+t*tsize, x, y, depth256); pix != 0 {
+					img.SetRGBA(tx+x, ty+y, palColor(pal, palbase+pix))
+				}
+			}
+		}
+	}
+	return img
+}
+
+// bgBases returns the map and char bases of the specified BG layer, exactly
+// as computed by the layer drawing functions.
+func (e2d *HwEngine2d) bgBases(lidx int) (mapBase, charBase int) {
+	cnt := *e2d.bgregs[lidx].Cnt
+	switch e2d.bgmodes[lidx] {
+	case BgModeLargeBitmap:
+		return 0, 0
+	case BgModeAffineBitmap, BgModeAffineBitmapDirect:
+		return int((cnt>>8)&0x1F) * 16 * 1024, 0
+	}
+	mapBase = int((cnt>>8)&0x1F) * 2 * 1024
+	charBase = int((cnt>>2)&0xF) * 16 * 1024
+	if e2d.A() && e2d.hwtype == HwNds {
+		mapBase += int((e2d.DispCnt.Value>>27)&7) * 64 * 1024
+		charBase += int((e2d.DispCnt.Value>>24)&7) * 64 * 1024
+	}
+	return
+}
+
+// BgTileMode returns the mode used to decode the tiles of the specified BG
+// layer.
+func (e2d *HwEngine2d) BgTileMode(lidx int) TileMode {
+	switch e2d.bgmodes[lidx] {
+	case BgModeAffine:
+		return Tile256
+	case BgModeText:
+		if !e2d.bgregs[lidx].depth256() {
+			return Tile16
+		}
+	}
+	if e2d.bgExtPalEnabled() {
+		return Tile256ExtPal
+	}
+	return Tile256
+}
+
+// ViewBgTiles draws the tiles addressable by the specified BG layer (starting
+// from its char base), decoded with the specified mode and palette.
+func (e2d *HwEngine2d) ViewBgTiles(lidx int, mode TileMode, palnum int) *image.RGBA {
+	_, charBase := e2d.bgBases(lidx)
+	chars := e2d.mc.VramLinearBank(e2d.Idx, VramLinearBG, 0)
+	ntiles := 1024
+	if e2d.bgmodes[lidx] == BgModeAffine {
+		ntiles = 256
+	}
+	pal, _ := e2d.palettes()
+	if mode == Tile256ExtPal {
+		pal = e2d.bgExtPalette(lidx)
+	}
+	return TileSheet(&chars, charBase, ntiles, mode, pal, palnum)
+}
+
+// ViewObjTiles draws the whole OBJ VRAM as a tile sheet, decoded with the
+// specified mode and palette.
+func (e2d *HwEngine2d) ViewObjTiles(mode TileMode, palnum int) *image.RGBA {
+	tiles := e2d.mc.VramLinearBank(e2d.Idx, VramLinearOAM, 0)
+	size := 256 * 1024
+	if e2d.B() {
+		size = 128 * 1024
+	}
+	tsize := 64
+	_, pal := e2d.palettes()
+	switch mode {
+	case Tile16:
+		tsize = 32
+	case Tile256ExtPal:
+		pal = e2d.objExtPalette()
+	}
+	return TileSheet(&tiles, 0, size/tsize, mode, pal, palnum)
+}
+
+// BgLayerSize returns the full size in pixels of the specified BG layer (the
+// whole tilemap or bitmap, not just the visible area). It returns (0,0) for
+// the 3D layer.
+func (e2d *HwEngine2d) BgLayerSize(lidx int) (w, h int) {
+	cnt := *e2d.bgregs[lidx].Cnt
+	szidx := int(cnt>>14) & 3
+	switch e2d.bgmodes[lidx] {
+	case BgModeText:
+		return 256 << uint(szidx&1), 256 << uint(szidx>>1)
+	case BgModeAffine, BgModeAffineMap16:
+		return 128 << uint(szidx), 128 << uint(szidx)
+	case BgModeAffineBitmap, BgModeAffineBitmapDirect:
+		return bmpSize[szidx].w, bmpSize[szidx].h
+	case BgModeLargeBitmap:
+		return bmpSize[szidx+4].w, bmpSize[szidx+4].h
+	}
+	return 0, 0
+}
+
+// ViewBgLayer draws the whole tilemap (or bitmap) of the specified BG layer,
+// ignoring scrolling, affine transformations and special effects. It returns
+// nil if the layer is used to display 3D graphics.
+func (e2d *HwEngine2d) ViewBgLayer(lidx int) *image.RGBA {
+	w, h := e2d.BgLayerSize(lidx)
+	if w == 0 {
+		return nil
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	mapBase, charBase := e2d.bgBases(lidx)
+	vram := e2d.mc.VramLinearBank(e2d.Idx, VramLinearBG, 0)
+	bgPal, _ := e2d.palettes()
+	extPal := e2d.bgExtPalEnabled()
+	var xpal []byte
+	if extPal {
+		xpal = e2d.bgExtPalette(lidx)
+	}
+
+	// tile draws a tile described by a 16-bit map entry
+	tile := func(px, py int, entry uint16, depth256 bool) {
+		tnum := int(entry & 1023)
+		hflip := (entry>>10)&1 != 0
+		vflip := (entry>>11)&1 != 0
+		palnum := int(entry>>12) & 0xF
+
+		pal, palbase, off := bgPal, palnum*16, charBase+tnum*32
+		if depth256 {
+			palbase, off = 0, charBase+tnum*64
+			if extPal {
+				pal, palbase = xpal, palnum*256
+			}
+		}
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				sx, sy := x, y
+				if hflip {
+					sx = 7 - x
+				}
+				if vflip {
+					sy = 7 - y
+				}
+				if pix := tilePixel(&vram, off, sx, sy, depth256); pix != 0 {
+					img.SetRGBA(px+x, py+y, palColor(pal, palbase+pix))
+				}
+			}
+		}
+	}
+
+	switch e2d.bgmodes[lidx] {
+	case BgModeText:
+		// The map is made of 32x32 screen blocks of 2K each, arranged
+		// left-to-right and top-to-bottom
+		depth256 := e2d.bgregs[lidx].depth256()
+		for ty := 0; ty < h/8; ty++ {
+			for tx := 0; tx < w/8; tx++ {
+				block := (ty/32)*(w/256) + tx/32
+				entry := vramGet16(&vram, mapBase+block*2048+((ty&31)*32+(tx&31))*2)
+				tile(tx*8, ty*8, entry, depth256)
+			}
+		}
+
+	case BgModeAffineMap16:
+		for ty := 0; ty < h/8; ty++ {
+			for tx := 0; tx < w/8; tx++ {
+				entry := vramGet16(&vram, mapBase+(ty*w/8+tx)*2)
+				tile(tx*8, ty*8, entry, true)
+			}
+		}
+
+	case BgModeAffine:
+		for ty := 0; ty < h/8; ty++ {
+			for tx := 0; tx < w/8; tx++ {
+				tnum := int(vramGet8(&vram, mapBase+ty*w/8+tx))
+				for y := 0; y < 8; y++ {
+					for x := 0; x < 8; x++ {
+						if pix := tilePixel(&vram, charBase+tnum*64, x, y, true); pix != 0 {
+							img.SetRGBA(tx*8+x, ty*8+y, palColor(bgPal, pix))
+						}
+					}
+				}
+			}
+		}
+
+	case BgModeAffineBitmap, BgModeLargeBitmap:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if pix := vramGet8(&vram, mapBase+y*w+x); pix != 0 {
+					img.SetRGBA(x, y, palColor(bgPal, int(pix)))
+				}
+			}
+		}
+
+	case BgModeAffineBitmapDirect:
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				if pix := vramGet16(&vram, mapBase+(y*w+x)*2); pix&0x8000 != 0 {
+					img.SetRGBA(x, y, rgb555(pix))
+				}
+			}
+		}
+	}
+	return img
+}
+
+// OamEntry is a decoded OAM entry (sprite attributes)
+type OamEntry struct {
+	Index    int
+	X, Y     int
+	W, H     int // Size in pixels
+	Mode     int // 0=normal, 1=affine, 2=hidden, 3=affine double size
+	PixMode  int // 0=normal, 1=semi-transparent, 2=window, 3=bitmap
+	Tile     int
+	Palette  int
+	Priority int
+	Depth256 bool
+	HFlip    bool
+	VFlip    bool
+	AffineId int // Index of the affine parameters (affine modes only)
+}
+
+var objModeNames = [4]string{"normal", "affine", "hidden", "affine2x"}
+var objPixModeNames = [4]string{"normal", "alpha", "window", "bitmap"}
+
+func (o *OamEntry) String() string {
+	s := fmt.Sprintf("%3d: pos=(%4d,%4d) size=%2dx%-2d %-8s %-6s tile=%4d pal=%2d pri=%d",
+		o.Index, o.X, o.Y, o.W, o.H, objModeNames[o.Mode], objPixModeNames[o.PixMode],
+		o.Tile, o.Palette, o.Priority)
+	if o.Depth256 {
+		s += " 256"
+	}
+	if o.HFlip {
+		s += " hflip"
+	}
+	if o.VFlip {
+		s += " vflip"
+	}
+	if o.Mode == objModeAffine || o.Mode == objModeAffineDouble {
+		s += fmt.Sprintf(" affine=%d", o.AffineId)
+	}
+	return s
+}
+
+// OamEntries decodes all the 128 entries of the engine OAM
+func (e2d *HwEngine2d) OamEntries() []OamEntry {
+	oam := e2d.mc.VramOAM(e2d.Idx)
+	objs := make([]OamEntry, 128)
+	for i := range objs {
+		a0, a1, a2 := emu.Read16LE(oam[i*8:]), emu.Read16LE(oam[i*8+2:]), emu.Read16LE(oam[i*8+4:])
+		sz := objWidth[((a0>>14)<<2)|(a1>>14)]
+		o := OamEntry{
+			Index:    i,
+			X:        int(a1 & 0x1FF),
+			Y:        int(a0 & 0xFF),
+			W:        sz.w * 8,
+			H:        sz.h * 8,
+			Mode:     int(a0>>8) & 3,
+			PixMode:  int(a0>>10) & 3,
+			Tile:     int(a2 & 1023),
+			Palette:  int(a2>>12) & 0xF,
+			Priority: int(a2>>10) & 3,
+			Depth256: (a0>>13)&1 != 0,
+			AffineId: int(a1>>9) & 0x1F,
+		}
+		if o.X >= 256 {
+			o.X -= 0x200
+		}
+		if o.Mode == objModeNormal {
+			o.HFlip = (a1>>12)&1 != 0
+			o.VFlip = (a1>>13)&1 != 0
+		}
+		objs[i] = o
+	}
+	return objs
+}
+
+// ViewSprites draws all the sprites defined in OAM (including hidden ones),
+// each in a 64x64 cell of a 16x8 grid, in OAM order. Sprites are drawn
+// unscaled and without affine transformation.
+func (e2d *HwEngine2d) ViewSprites() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16*cSpriteCellSize, 8*cSpriteCellSize))
+	tiles := e2d.mc.VramLinearBank(e2d.Idx, VramLinearOAM, 0)
+	_, objPal := e2d.palettes()
+	var xpal []byte
+	extPal := e2d.objExtPalEnabled()
+	if extPal {
+		xpal = e2d.objExtPalette()
+	}
+
+	dispcnt := e2d.DispCnt.Value
+	mapping1d := (dispcnt>>4)&1 != 0
+	boundary := 32
+	if mapping1d {
+		boundary <<= (dispcnt >> 20) & 3
+	}
+	bitmap1d := (dispcnt>>6)&1 != 0
+
+	for _, o := range e2d.OamEntries() {
+		cx, cy := (o.Index%16)*cSpriteCellSize, (o.Index/16)*cSpriteCellSize
+		tw := o.W / 8
+
+		if o.PixMode == objPixModeBitmap {
+			var off, pitch int
+			switch {
+			case bitmap1d && (dispcnt>>22)&1 == 0:
+				off, pitch = objBitmap_CalcAddress_1D128(o.Tile), o.W
+			case bitmap1d:
+				off, pitch = objBitmap_CalcAddress_1D256(o.Tile), o.W
+			case (dispcnt>>5)&1 == 0:
+				off, pitch = objBitmap_CalcAddress_2D128(o.Tile), 128
+			default:
+				off, pitch = objBitmap_CalcAddress_2D256(o.Tile), 256
+			}
+			for y := 0; y < o.H; y++ {
+				for x := 0; x < o.W; x++ {
+					if pix := vramGet16(&tiles, off+(y*pitch+x)*2); pix&0x8000 != 0 {
+						img.SetRGBA(cx+x, cy+y, rgb555(pix))
+					}
+				}
+			}
+			continue
+		}
+
+		// Pitch of the sprite, expressed in number of chars (see drawOBJ)
+		pitch := tw
+		if !mapping1d {
+			pitch = 32
+			if o.Depth256 {
+				pitch = 16
+			}
+		}
+		charSize := 32
+		pal, palbase := objPal, o.Palette*16
+		if o.Depth256 {
+			charSize = 64
+			palbase = 0
+			if extPal {
+				pal, palbase = xpal, o.Palette*256
+			}
+		}
+
+		off := o.Tile * boundary
+		for y := 0; y < o.H; y++ {
+			for x := 0; x < o.W; x++ {
+				sx, sy := x, y
+				if o.HFlip {
+					sx = o.W - x - 1
+				}
+				if o.VFlip {
+					sy = o.H - y - 1
+				}
+				toff := off + ((sy/8)*pitch+sx/8)*charSize
+				if pix := tilePixel(&tiles, toff, sx&7, sy&7, o.Depth256); pix != 0 {
+					img.SetRGBA(cx+x, cy+y, palColor(pal, palbase+pix))
+				}
+			}
+		}
+	}
+	return img
+}
@@ -18,11 +18,11 @@ func (r *bgRegs) depth256() bool   { return (*r.Cnt>>7)&1 != 0 }
 
 type HwEngine2d struct {
 	Idx      int
-	DispCnt  hwio.Reg32 `hwio:"offset=0x00,wcb"`
-	Bg0Cnt   hwio.Reg16 `hwio:"offset=0x08"`
-	Bg1Cnt   hwio.Reg16 `hwio:"offset=0x0A"`
-	Bg2Cnt   hwio.Reg16 `hwio:"offset=0x0C"`
-	Bg3Cnt   hwio.Reg16 `hwio:"offset=0x0E"`
+	DispCnt  hwio.Reg32 `hwio:"offset=0x00,wcb,bits=Mode:0-2|Bg3d:3|ObjTile1d:4|ObjBmp2dDim:5|ObjBmp1d:6|Blank:7|Bg0:8|Bg1:9|Bg2:10|Bg3:11|Obj:12|Win0:13|Win1:14|ObjWin:15|DispMode:16-17|VramBlock:18-19|ObjTileBound:20-21|ObjBmpBound:22|HBlankObj:23|CharBase:24-26|ScrBase:27-29|BgExtPal:30|ObjExtPal:31"`
+	Bg0Cnt   hwio.Reg16 `hwio:"offset=0x08,bits=Prio:0-1|CharBase:2-5|Mosaic:6|Colors256:7|ScrBase:8-12|Wrap:13|Size:14-15"`
+	Bg1Cnt   hwio.Reg16 `hwio:"offset=0x0A,bits=Prio:0-1|CharBase:2-5|Mosaic:6|Colors256:7|ScrBase:8-12|Wrap:13|Size:14-15"`
+	Bg2Cnt   hwio.Reg16 `hwio:"offset=0x0C,bits=Prio:0-1|CharBase:2-5|Mosaic:6|Colors256:7|ScrBase:8-12|Wrap:13|Size:14-15"`
+	Bg3Cnt   hwio.Reg16 `hwio:"offset=0x0E,bits=Prio:0-1|CharBase:2-5|Mosaic:6|Colors256:7|ScrBase:8-12|Wrap:13|Size:14-15"`
 	Bg0XOfs  hwio.Reg16 `hwio:"offset=0x10,writeonly"`
 	Bg0YOfs  hwio.Reg16 `hwio:"offset=0x12,writeonly"`
 	Bg1XOfs  hwio.Reg16 `hwio:"offset=0x14,writeonly"`
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"ndsemu/emu"
 	"ndsemu/emu/gfx"
-	"ndsemu/emu/hw"
+	"ndsemu/emu/host"
 )
 
 const (
@@ -95,7 +95,7 @@ func (e2d *HwEngine2d) drawOBJ(lidx int, drawWindow bool) func(gfx.Line) {
 			sy++
 			return
 		}
-		if gKeyState[hw.SCANCODE_6] != 0 {
+		if gKeyState[host.SCANCODE_6] != 0 {
 			sy++
 			return
 		}
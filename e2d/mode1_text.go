@@ -2,7 +2,7 @@ package e2d
 
 import (
 	"ndsemu/emu/gfx"
-	"ndsemu/emu/hw"
+	"ndsemu/emu/host"
 )
 
 func (e2d *HwEngine2d) drawChar16(y int, src []byte, dst gfx.Line, hflip bool, attrs uint32, pal uint16, extpal bool) {
@@ -75,11 +75,11 @@ func (e2d *HwEngine2d) DrawBG(lidx int) func(gfx.Line) {
 
 	y := 0
 	return func(line gfx.Line) {
-		if e2d.DispCnt.Value&onmask == 0 || gKeyState[hw.SCANCODE_1+lidx] != 0 {
+		if e2d.DispCnt.Value&onmask == 0 || gKeyState[host.SCANCODE_1+lidx] != 0 {
 			y++
 			return
 		}
-		if (e2d.A() && gKeyState[hw.SCANCODE_9] != 0) || (e2d.B() && gKeyState[hw.SCANCODE_8] != 0) {
+		if (e2d.A() && gKeyState[host.SCANCODE_9] != 0) || (e2d.B() && gKeyState[host.SCANCODE_8] != 0) {
 			y++
 			return
 		}
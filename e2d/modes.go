@@ -3,7 +3,7 @@ package e2d
 import (
 	"ndsemu/emu"
 	"ndsemu/emu/gfx"
-	"ndsemu/emu/hw"
+	"ndsemu/emu/host"
 )
 
 /************************************************
@@ -11,9 +11,7 @@ import (
  ************************************************/
 
 func (e2d *HwEngine2d) BeginFrame() {
-	if gKeyState == nil {
-		gKeyState = hw.GetKeyboardState()
-	}
+	gKeyState = host.KeyboardState()
 
 	// Read current display mode once per frame (do not switch between
 	// display modes within a frame)
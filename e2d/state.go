@@ -0,0 +1,20 @@
+package e2d
+
+import (
+	"ndsemu/emu/hwio"
+	"ndsemu/emu/savestate"
+)
+
+// Serialize saves or loads the registers of the 2D engine. All other internal
+// state is either recomputed at the beginning of each frame, or derived from
+// registers, so it is regenerated after loading.
+func (e2d *HwEngine2d) Serialize(st *savestate.Stream) {
+	st.Section("e2d")
+	hwio.SerializeRegs(st, e2d)
+
+	if st.Loading() {
+		e2d.WriteBLDALPHA(0, e2d.BldAlpha.Value)
+		e2d.specialEffectsChanged = true
+		e2d.masterBrightChanged = true
+	}
+}
@@ -3,6 +3,9 @@ package debugger
 import (
 	"fmt"
 	"ndsemu/emu"
+	"ndsemu/emu/hwio"
+	log "ndsemu/emu/logger"
+	"ndsemu/emu/symbols"
 
 	ui "github.com/gizak/termui"
 )
@@ -16,11 +19,11 @@ type CpuDebugger interface {
 	// finishes.
 	Trace(pc uint32)
 
-	// WatchRead/WatchWrite must be called before each memory access. They can
-	// be used by the debugger to implement watchpoints and thus intercept
-	// memory accesses
-	WatchRead(addr uint32)
-	WatchWrite(addr uint32, val uint32)
+	// WatchRead/WatchWrite must be called before each memory access, with
+	// the size of the access in bytes (1, 2 or 4). They can be used by the
+	// debugger to implement watchpoints and thus intercept memory accesses
+	WatchRead(addr uint32, size int)
+	WatchWrite(addr uint32, val uint32, size int)
 
 	// Break() can be called by the CPU core to force breaking into the debugger.
 	// It can be used in situations such as invalid opcodes
@@ -41,16 +44,36 @@ type Cpu interface {
 
 	GetPc() uint32
 	Disasm(pc uint32) (string, []byte)
+
+	// ReadMem/WriteMem access the memory through the CPU bus, without
+	// triggering watchpoints.
+	ReadMem(addr uint32, buf []byte)
+	WriteMem(addr uint32, buf []byte)
+
+	// CallStack returns the current call stack, the innermost frame being
+	// the last one.
+	CallStack() []CallFrame
+}
+
+// CallFrame is a frame of the call stack of a CPU
+type CallFrame struct {
+	Target    uint32 // Address of the called function (or exception vector)
+	Ret       uint32 // Return address
+	Exception bool   // True if the frame was created by an exception (eg: IRQ)
 }
 
+var modDbg = log.NewModule("debugger")
+
 type Debugger struct {
 	sync   *emu.Sync
 	cpus   []Cpu
+	syms   []*symbols.Table
+	iotabs []*hwio.Table
 	curcpu int
 
 	userBkps []uint32
 	ourBkps  []uint32
-	watches  []uint32
+	watches  []Watchpoint
 
 	running   []bool
 	focusline int
@@ -61,7 +84,10 @@ type Debugger struct {
 	uiRegs    *ui.List
 	uiLog     *ui.List
 	uiCalls   *ui.List
-	pcchain   [][]uint32
+	uiMem     *ui.List
+
+	mem    memView
+	prompt *prompt
 
 	breakch chan string
 
@@ -80,68 +106,55 @@ func New(cpus []Cpu, sync *emu.Sync) *Debugger {
 		focusline: -1,
 		// runch:     make(chan bool, 1),
 		running: make([]bool, len(cpus)),
-		pcchain: make([][]uint32, len(cpus)),
+		syms:    make([]*symbols.Table, len(cpus)),
+		iotabs:  make([]*hwio.Table, len(cpus)),
 		breakch: make(chan string),
 	}
 
 	for idx, cpu := range cpus {
-		dbg.pcchain[idx] = make([]uint32, 1)
 		cpu.SetDebugger(dbgForCpu{dbg, idx})
 	}
 
 	return dbg
 }
 
-func (dbg dbgForCpu) WatchRead(addr uint32) {
-	for _, wa := range dbg.watches {
-		if wa == addr {
+func (dbg dbgForCpu) WatchRead(addr uint32, size int) {
+	for i := range dbg.watches {
+		wp := &dbg.watches[i]
+		if wp.Match(dbg.cpuidx, addr, size, false, 0) {
+			modDbg.WarnZ("watchpoint hit").
+				String("wp", wp.String()).
+				Hex32("addr", addr).
+				Int("size", size).
+				String("op", "read").
+				End()
 			dbg.curcpu = dbg.cpuidx
 			dbg.Break("watchpoint")
+			return
 		}
 	}
 }
 
-func (dbg dbgForCpu) WatchWrite(addr uint32, val uint32) {
-	for _, wa := range dbg.watches {
-		if wa == addr {
+func (dbg dbgForCpu) WatchWrite(addr uint32, val uint32, size int) {
+	for i := range dbg.watches {
+		wp := &dbg.watches[i]
+		if wp.Match(dbg.cpuidx, addr, size, true, val) {
+			modDbg.WarnZ("watchpoint hit").
+				String("wp", wp.String()).
+				Hex32("addr", addr).
+				Int("size", size).
+				String("op", "write").
+				Hex32("val", val).
+				End()
 			dbg.curcpu = dbg.cpuidx
 			dbg.Break("watchpoint")
-		}
-	}
-}
-
-func (dbg dbgForCpu) updateChain(pc uint32) {
-	idx := dbg.cpuidx
-	lpc := dbg.pcchain[idx][len(dbg.pcchain[idx])-1]
-
-	// Semi-contiuguous
-	if pc >= lpc-16 && pc <= lpc+16 {
-		dbg.pcchain[idx][len(dbg.pcchain[idx])-1] = pc
-		return
-	}
-
-	// Looks like a jump. Let's see if we can find a previous call frame
-	pcidx := 0
-	for pcidx = len(dbg.pcchain[idx]) - 1; pcidx >= 0; pcidx-- {
-		oldpc := dbg.pcchain[idx][pcidx]
-		if pc > oldpc && pc <= oldpc+4 {
-			dbg.pcchain[idx] = dbg.pcchain[idx][:pcidx+1]
-			dbg.pcchain[idx][pcidx] = pc
 			return
 		}
 	}
-
-	// Add it to the top of the stack, (and remove old entries)
-	dbg.pcchain[idx] = append(dbg.pcchain[idx], pc)
-	if len(dbg.pcchain[idx]) > 16 {
-		dbg.pcchain[idx] = dbg.pcchain[idx][len(dbg.pcchain[idx])-16:]
-	}
 }
 
 func (dbg dbgForCpu) Trace(pc uint32) {
 	idx := dbg.cpuidx
-	dbg.updateChain(pc)
-
 	if msg, found := dbg.checkBreapoint(idx, pc); found {
 		dbg.curcpu = dbg.cpuidx
 		dbg.Break(msg)
@@ -160,7 +173,7 @@ func (dbg *Debugger) checkBreapoint(cpuidx int, pc uint32) (string, bool) {
 
 	for _, b := range dbg.userBkps {
 		if b == pc {
-			return fmt.Sprintf("user breakpoint at %08x", pc), true
+			return fmt.Sprintf("user breakpoint at %08x%s", pc, dbg.symbolize(cpuidx, pc)), true
 		}
 	}
 	for idx, b := range dbg.ourBkps {
@@ -224,6 +237,7 @@ func (dbg *Debugger) Run() {
 	dbg.log = newLogReader()
 
 	dbg.initUi()
+	dbg.initMemKeys()
 	defer ui.Close()
 
 	run := func() {
@@ -259,7 +273,7 @@ func (dbg *Debugger) Run() {
 		dbg.refreshUi()
 	}
 
-	ui.Handle("/sys/kbd/<space>", func(ui.Event) {
+	dbg.handleKey("<space>", func() {
 		if !dbg.running[dbg.curcpu] {
 			run()
 		} else {
@@ -267,27 +281,27 @@ func (dbg *Debugger) Run() {
 		}
 	})
 
-	ui.Handle("/sys/kbd/<enter>", func(ui.Event) {
+	dbg.handleKey("<enter>", func() {
 		if !dbg.running[dbg.curcpu] && dbg.focusline >= 0 {
 			pc := dbg.linepc[dbg.focusline]
 			runto(pc)
 		}
 	})
 
-	ui.Handle("/sys/kbd/<up>", func(ui.Event) {
+	dbg.handleKey("<up>", func() {
 		if !dbg.running[dbg.curcpu] {
 			dbg.focusline--
 			dbg.refreshUi()
 		}
 	})
-	ui.Handle("/sys/kbd/<down>", func(ui.Event) {
+	dbg.handleKey("<down>", func() {
 		if !dbg.running[dbg.curcpu] {
 			dbg.focusline++
 			dbg.refreshUi()
 		}
 	})
 
-	ui.Handle("/sys/kbd/r", func(ui.Event) {
+	dbg.handleKey("r", func() {
 		if !dbg.running[dbg.curcpu] {
 			// force refresh of disasm screen
 			dbg.linepc = nil
@@ -296,7 +310,7 @@ func (dbg *Debugger) Run() {
 		}
 	})
 
-	ui.Handle("/sys/kbd/s", func(ui.Event) {
+	dbg.handleKey("s", func() {
 		if !dbg.running[dbg.curcpu] {
 			dbg.resumeEmulation(false, func() {
 				dbg.refreshUi()
@@ -304,7 +318,7 @@ func (dbg *Debugger) Run() {
 		}
 	})
 
-	ui.Handle("/sys/kbd/n", func(ui.Event) {
+	dbg.handleKey("n", func() {
 		if !dbg.running[dbg.curcpu] {
 			pc := dbg.cpus[dbg.curcpu].GetPc()
 			if pc != dbg.linepc[dbg.pcline] {
@@ -322,19 +336,19 @@ func (dbg *Debugger) Run() {
 		}
 	})
 
-	ui.Handle("/sys/kbd/1", func(ui.Event) {
+	dbg.handleKey("1", func() {
 		if !dbg.running[dbg.curcpu] {
 			switchcpu(0)
 		}
 	})
 
-	ui.Handle("/sys/kbd/2", func(ui.Event) {
+	dbg.handleKey("2", func() {
 		if !dbg.running[dbg.curcpu] {
 			switchcpu(1)
 		}
 	})
 
-	ui.Handle("/sys/kbd/q", func(ui.Event) {
+	dbg.handleKey("q", func() {
 		dbg.stopMonitored()
 		ui.StopLoop()
 	})
@@ -348,10 +362,37 @@ func (dbg *Debugger) Run() {
 	ui.Loop()
 }
 
+// SetSymbols sets the symbol table of a CPU, used to show addresses as
+// function+offset.
+func (dbg *Debugger) SetSymbols(cpuidx int, syms *symbols.Table) {
+	dbg.syms[cpuidx] = syms
+}
+
+// SetIoTable sets the bus of a CPU, used to inspect the I/O registers
+func (dbg *Debugger) SetIoTable(cpuidx int, t *hwio.Table) {
+	dbg.iotabs[cpuidx] = t
+}
+
+// symbolize returns the address formatted as " (function+offset)", or an
+// empty string if there is no symbol for it.
+func (dbg *Debugger) symbolize(cpuidx int, addr uint32) string {
+	if fn := dbg.syms[cpuidx].Format(addr); fn != "" {
+		return " (" + fn + ")"
+	}
+	return ""
+}
+
 func (dbg *Debugger) AddBreakpoint(pc uint32) {
 	dbg.userBkps = append(dbg.userBkps, pc)
 }
 
+// AddWatchpoint adds a watchpoint that breaks on any access to the specified
+// address.
 func (dbg *Debugger) AddWatchpoint(addr uint32) {
-	dbg.watches = append(dbg.watches, addr)
+	dbg.AddWatch(Watchpoint{Start: addr, End: addr, Read: true, Write: true, Cpu: -1})
+}
+
+// AddWatch adds a generic watchpoint
+func (dbg *Debugger) AddWatch(wp Watchpoint) {
+	dbg.watches = append(dbg.watches, wp)
 }
@@ -0,0 +1,745 @@
+package debugger
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	log "ndsemu/emu/logger"
+)
+
+var modGdb = log.NewModule("gdb")
+
+// GdbCpu is the interface required by the GDB stub to access a CPU core.
+type GdbCpu interface {
+	Cpu
+
+	GetCpsr() uint32
+	SetCpsr(val uint32)
+
+	// Jump moves the PC to the specified address. If called while the CPU
+	// is stopped within Trace(), the traced instruction must not be executed,
+	// and Trace() must be called again for the new address.
+	Jump(addr uint32)
+}
+
+// Unix signals used in stop replies
+const (
+	gdbSigInt  = 2
+	gdbSigTrap = 5
+)
+
+// Maximum size of a packet; it is reported to the client, that will never
+// send larger packets.
+const gdbPacketSize = 0x4000
+
+// Target description sent to the client: ARM core registers, plus CPSR.
+const gdbTargetXml = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+<architecture>arm</architecture>
+<feature name="org.gnu.gdb.arm.core">
+<reg name="r0" bitsize="32"/>
+<reg name="r1" bitsize="32"/>
+<reg name="r2" bitsize="32"/>
+<reg name="r3" bitsize="32"/>
+<reg name="r4" bitsize="32"/>
+<reg name="r5" bitsize="32"/>
+<reg name="r6" bitsize="32"/>
+<reg name="r7" bitsize="32"/>
+<reg name="r8" bitsize="32"/>
+<reg name="r9" bitsize="32"/>
+<reg name="r10" bitsize="32"/>
+<reg name="r11" bitsize="32"/>
+<reg name="r12" bitsize="32"/>
+<reg name="sp" bitsize="32" type="data_ptr"/>
+<reg name="lr" bitsize="32"/>
+<reg name="pc" bitsize="32" type="code_ptr"/>
+<reg name="cpsr" bitsize="32"/>
+</feature>
+</target>
+`
+
+type gdbWatch struct {
+	start       uint32
+	end         uint64 // exclusive, so it can be 1<<32
+	read, write bool
+}
+
+type gdbStop struct {
+	cpu    int
+	signal int
+	watch  string // watchpoint description in the stop reply (eg: "watch:2000000;")
+	msg    string // message to be shown to the user
+}
+
+// GdbServer implements the GDB remote serial protocol, so that GDB (eg:
+// arm-none-eabi-gdb) can be used to debug the emulated code. Each CPU is
+// exposed as a thread (ARM9 is thread 1, ARM7 is thread 2) of the same
+// process.
+//
+// The server works in all-stop mode: when a CPU stops (because of a
+// breakpoint, a watchpoint or a single step), the whole emulation is blocked
+// until GDB resumes it. Breakpoints and watchpoints apply to all CPUs, as GDB
+// sees a single address space.
+//
+// Killing the process (k, vKill) is handled like a detach: the emulation is
+// not terminated, but keeps running without breakpoints and watchpoints, and
+// a new client can connect later.
+type GdbServer struct {
+	cpus  []GdbCpu
+	names []string
+
+	// State accessed by the emulation; it is modified by the server only
+	// while the emulation is stopped.
+	bkps    map[uint32]bool
+	watches []gdbWatch
+	step    int  // index of the CPU being single-stepped, or -1
+	active  bool // true if Trace() must check for breakpoints/single-step
+	curcpu  int  // CPU that called Trace() last
+	curpc   []uint32
+	jumped  []bool // PC changed by the client, see writeReg
+
+	// Set by the server (also while the emulation is running) to request
+	// the emulation to stop as soon as possible.
+	breakReq int32
+
+	stopch   chan gdbStop
+	resumech chan struct{}
+
+	// Connection state, accessed only by the server goroutine
+	conn    net.Conn
+	noAck   int32
+	gthread int // thread selected for register/memory access (Hg)
+	cthread int // thread selected for execution (Hc)
+	last    gdbStop
+}
+
+type gdbForCpu struct {
+	*GdbServer
+	cpuidx int
+}
+
+// NewGdbServer creates a GDB server for the specified CPUs. The emulation
+// is stopped at the first instruction, until a client connects and resumes
+// it.
+func NewGdbServer(cpus []GdbCpu, names []string) *GdbServer {
+	s := &GdbServer{
+		cpus:     cpus,
+		names:    names,
+		bkps:     make(map[uint32]bool),
+		step:     -1,
+		curpc:    make([]uint32, len(cpus)),
+		jumped:   make([]bool, len(cpus)),
+		breakReq: 1,
+		stopch:   make(chan gdbStop),
+		resumech: make(chan struct{}),
+	}
+	for idx, cpu := range cpus {
+		cpu.SetDebugger(gdbForCpu{s, idx})
+	}
+	return s
+}
+
+// ListenAndServe listens on the specified TCP address and serves GDB
+// clients, one at a time.
+func (s *GdbServer) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	modGdb.WarnZ("waiting for GDB connection").String("addr", ln.Addr().String()).End()
+	go s.Serve(ln)
+	return nil
+}
+
+// Serve accepts GDB clients on the specified listener, and serves them one
+// at a time.
+func (s *GdbServer) Serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			modGdb.ErrorZ("accept failed").Error("err", err).End()
+			return
+		}
+		modGdb.WarnZ("GDB connected").String("addr", conn.RemoteAddr().String()).End()
+		s.serve(conn)
+		modGdb.WarnZ("GDB disconnected").End()
+	}
+}
+
+func (dbg gdbForCpu) Trace(pc uint32) {
+	dbg.curcpu = dbg.cpuidx
+	dbg.curpc[dbg.cpuidx] = pc
+
+	if dbg.jumped[dbg.cpuidx] {
+		// The client moved the PC while the CPU was stopped: this is the
+		// first Trace() for the new address, where the CPU was considered
+		// to be already stopped.
+		dbg.jumped[dbg.cpuidx] = false
+		return
+	}
+	if atomic.LoadInt32(&dbg.breakReq) != 0 {
+		if atomic.CompareAndSwapInt32(&dbg.breakReq, 1, 0) {
+			dbg.stop(gdbStop{cpu: dbg.cpuidx, signal: gdbSigInt})
+		}
+		return
+	}
+	if !dbg.active {
+		return
+	}
+	if dbg.step == dbg.cpuidx || dbg.bkps[pc] {
+		dbg.step = -1
+		dbg.stop(gdbStop{cpu: dbg.cpuidx, signal: gdbSigTrap})
+	}
+}
+
+func (dbg gdbForCpu) checkWatch(addr uint32, size int, write bool) {
+	if !dbg.active {
+		return
+	}
+	for _, w := range dbg.watches {
+		if uint64(addr) < w.end && uint64(addr)+uint64(size) > uint64(w.start) && ((write && w.write) || (!write && w.read)) {
+			kind := "watch"
+			if !write {
+				kind = "rwatch"
+				if w.write {
+					kind = "awatch"
+				}
+			}
+			dbg.stop(gdbStop{
+				cpu:    dbg.cpuidx,
+				signal: gdbSigTrap,
+				watch:  fmt.Sprintf("%s:%x;", kind, addr),
+			})
+			return
+		}
+	}
+}
+
+func (dbg gdbForCpu) WatchRead(addr uint32, size int) {
+	dbg.checkWatch(addr, size, false)
+}
+
+func (dbg gdbForCpu) WatchWrite(addr uint32, val uint32, size int) {
+	dbg.checkWatch(addr, size, true)
+}
+
+func (dbg gdbForCpu) Break(msg string) {
+	dbg.stop(gdbStop{cpu: dbg.cpuidx, signal: gdbSigTrap, msg: msg})
+}
+
+// Break stops the emulation, showing a message into GDB. It can be called
+// by hardware devices that detect a condition worth debugging.
+func (s *GdbServer) Break(msg string) {
+	s.stop(gdbStop{cpu: s.curcpu, signal: gdbSigTrap, msg: msg})
+}
+
+// stop is called by the emulation goroutine, and blocks until the server
+// resumes the emulation.
+func (s *GdbServer) stop(st gdbStop) {
+	s.stopch <- st
+	<-s.resumech
+}
+
+// detach removes all breakpoints and watchpoints, and lets the emulation
+// run freely.
+func (s *GdbServer) detach() {
+	s.bkps = make(map[uint32]bool)
+	s.watches = nil
+	s.resume(-1)
+}
+
+// resume restarts the emulation after a stop, optionally single-stepping
+// the specified CPU.
+func (s *GdbServer) resume(step int) {
+	s.step = step
+	s.active = step >= 0 || len(s.bkps) != 0 || len(s.watches) != 0
+	s.resumech <- struct{}{}
+}
+
+func (s *GdbServer) send(data string) error {
+	var sum uint8
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	_, err := fmt.Fprintf(s.conn, "$%s#%02x", data, sum)
+	return err
+}
+
+// readPackets reads packets from the connection and sends them to the
+// channel, which is closed when the connection is closed. Interrupt requests
+// (Ctrl-C) are handled immediately.
+func (s *GdbServer) readPackets(ch chan<- string) {
+	defer close(ch)
+	r := bufio.NewReader(s.conn)
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		switch c {
+		case 0x03:
+			atomic.StoreInt32(&s.breakReq, 1)
+		case '$':
+			data, err := r.ReadString('#')
+			if err != nil {
+				return
+			}
+			var sum [2]byte
+			if _, err := io.ReadFull(r, sum[:]); err != nil {
+				return
+			}
+			if atomic.LoadInt32(&s.noAck) == 0 {
+				s.conn.Write([]byte{'+'})
+			}
+			ch <- gdbUnescape(data[:len(data)-1])
+		}
+	}
+}
+
+func gdbUnescape(data string) string {
+	if strings.IndexByte(data, '}') < 0 {
+		return data
+	}
+	var out []byte
+	for i := 0; i < len(data); i++ {
+		if data[i] == '}' && i+1 < len(data) {
+			i++
+			out = append(out, data[i]^0x20)
+		} else {
+			out = append(out, data[i])
+		}
+	}
+	return string(out)
+}
+
+func (s *GdbServer) stopReply(st gdbStop) string {
+	return fmt.Sprintf("T%02x%sthread:%x;", st.signal, st.watch, st.cpu+1)
+}
+
+func (s *GdbServer) serve(conn net.Conn) {
+	defer conn.Close()
+	s.conn = conn
+	atomic.StoreInt32(&s.noAck, 0)
+
+	// Stop the emulation (if it's not already stopped) before processing
+	// any packet; the client will ask for the stop reason.
+	atomic.StoreInt32(&s.breakReq, 1)
+	s.last = <-s.stopch
+	atomic.StoreInt32(&s.breakReq, 0)
+	s.gthread, s.cthread = s.last.cpu, s.last.cpu
+	running := false
+
+	pkts := make(chan string)
+	go s.readPackets(pkts)
+
+	for {
+		select {
+		case st := <-s.stopch:
+			running = false
+			s.last = st
+			s.gthread = st.cpu
+			s.cthread = st.cpu
+			if st.msg != "" {
+				s.send("O" + hex.EncodeToString([]byte(st.msg+"\n")))
+			}
+			s.send(s.stopReply(st))
+
+		case pkt, ok := <-pkts:
+			if !ok {
+				// Connection closed: stop the emulation to safely
+				// remove all breakpoints, and let it run freely.
+				if running {
+					atomic.StoreInt32(&s.breakReq, 1)
+					<-s.stopch
+					atomic.StoreInt32(&s.breakReq, 0)
+				}
+				s.detach()
+				return
+			}
+			if running {
+				modGdb.WarnZ("packet ignored while running").String("pkt", pkt).End()
+				continue
+			}
+			reply, resume := s.handle(pkt)
+			if reply != "" || !resume {
+				s.send(reply)
+			}
+			switch {
+			case pkt == "QStartNoAckMode":
+				atomic.StoreInt32(&s.noAck, 1)
+			case len(pkt) > 0 && (pkt[0] == 'D' || pkt[0] == 'k' || strings.HasPrefix(pkt, "vKill")):
+				// Detached: discard any packet still in flight
+				go func() {
+					for range pkts {
+					}
+				}()
+				return
+			}
+			running = running || resume
+		}
+	}
+}
+
+// handle processes a packet, returning the reply. resume is true if the
+// emulation was resumed: in this case, an empty reply is not sent, as the
+// client will wait for the stop reply.
+func (s *GdbServer) handle(pkt string) (reply string, resume bool) {
+	// An empty packet ("$#00") is not valid: reply with an empty packet,
+	// like for unsupported ones.
+	if len(pkt) == 0 {
+		return "", false
+	}
+	args := pkt[1:]
+
+	switch pkt[0] {
+	case '?':
+		return s.stopReply(s.last), false
+	case 'g':
+		var out strings.Builder
+		for i := 0; i < 17; i++ {
+			out.WriteString(gdbHex32(s.readReg(s.gthread, i)))
+		}
+		return out.String(), false
+	case 'G':
+		if len(args) < 17*8 {
+			return "E01", false
+		}
+		for i := 0; i < 17; i++ {
+			val, err := gdbParseHex32(args[i*8 : i*8+8])
+			if err != nil {
+				return "E01", false
+			}
+			s.writeReg(s.gthread, i, val)
+		}
+		return "OK", false
+	case 'p':
+		n, err := strconv.ParseUint(args, 16, 32)
+		if err != nil || n > 16 {
+			return "E01", false
+		}
+		return gdbHex32(s.readReg(s.gthread, int(n))), false
+	case 'P':
+		idx := strings.IndexByte(args, '=')
+		if idx < 0 {
+			return "E01", false
+		}
+		n, err1 := strconv.ParseUint(args[:idx], 16, 32)
+		val, err2 := gdbParseHex32(args[idx+1:])
+		if err1 != nil || err2 != nil || n > 16 {
+			return "E01", false
+		}
+		s.writeReg(s.gthread, int(n), val)
+		return "OK", false
+	case 'm':
+		addr, size, err := gdbParseAddrLen(args)
+		if err != nil {
+			return "E01", false
+		}
+		if size > gdbPacketSize/2-16 {
+			size = gdbPacketSize/2 - 16
+		}
+		// Reading I/O registers might have side effects (eg: popping
+		// FIFOs), so it is not allowed.
+		if addr < 0x05000000 && uint64(addr)+uint64(size) > 0x04000000 {
+			return "E03", false
+		}
+		buf := make([]byte, size)
+		s.cpus[s.gthread].ReadMem(addr, buf)
+		return hex.EncodeToString(buf), false
+	case 'M':
+		idx := strings.IndexByte(args, ':')
+		if idx < 0 {
+			return "E01", false
+		}
+		addr, size, err := gdbParseAddrLen(args[:idx])
+		if err != nil {
+			return "E01", false
+		}
+		buf, err := hex.DecodeString(args[idx+1:])
+		if err != nil || uint32(len(buf)) != size {
+			return "E01", false
+		}
+		s.cpus[s.gthread].WriteMem(addr, buf)
+		return "OK", false
+	case 'c', 's':
+		// The optional argument is the address to resume at
+		if args != "" {
+			addr, err := strconv.ParseUint(args, 16, 32)
+			if err != nil {
+				return "E01", false
+			}
+			s.writeReg(s.cthread, 15, uint32(addr))
+		}
+		if pkt[0] == 's' {
+			s.resume(s.cthread)
+		} else {
+			s.resume(-1)
+		}
+		return "", true
+	case 'H':
+		if len(args) < 1 {
+			return "E01", false
+		}
+		th, err := s.parseThread(args[1:], true)
+		if err != nil {
+			return "E01", false
+		}
+		if th < 0 {
+			th = s.last.cpu
+		}
+		switch args[0] {
+		case 'g':
+			s.gthread = th
+		case 'c':
+			s.cthread = th
+		}
+		return "OK", false
+	case 'T':
+		if _, err := s.parseThread(args, false); err != nil {
+			return "E01", false
+		}
+		return "OK", false
+	case 'Z', 'z':
+		return s.handleBreakpoint(pkt[0] == 'Z', args), false
+	case 'v':
+		return s.handleV(args)
+	case 'q':
+		return s.handleQuery(args), false
+	case 'Q':
+		if args == "StartNoAckMode" {
+			return "OK", false
+		}
+		return "", false
+	case 'D', 'k':
+		// Kill does not terminate the emulator, see GdbServer
+		s.detach()
+		return "OK", true
+	}
+
+	// Unsupported packet
+	return "", false
+}
+
+func (s *GdbServer) handleV(args string) (string, bool) {
+	switch {
+	case args == "Cont?":
+		return "vCont;c;C;s;S", false
+	case strings.HasPrefix(args, "Cont;"):
+		// Since we work in all-stop mode, we just need to know whether
+		// a thread must be stepped; all the others will run anyway.
+		step := -1
+		for _, action := range strings.Split(args[5:], ";") {
+			if action == "" || (action[0] != 's' && action[0] != 'S') {
+				continue
+			}
+			step = s.cthread
+			if idx := strings.IndexByte(action, ':'); idx >= 0 {
+				th, err := s.parseThread(action[idx+1:], true)
+				if err != nil {
+					return "E01", false
+				}
+				if th >= 0 {
+					step = th
+				}
+			}
+		}
+		s.resume(step)
+		return "", true
+	case strings.HasPrefix(args, "Kill"):
+		s.detach()
+		return "OK", true
+	}
+	return "", false
+}
+
+func (s *GdbServer) handleQuery(args string) string {
+	switch {
+	case strings.HasPrefix(args, "Supported"):
+		return fmt.Sprintf("PacketSize=%x;qXfer:features:read+;QStartNoAckMode+;vContSupported+", gdbPacketSize)
+	case args == "Attached":
+		return "1"
+	case args == "C":
+		return fmt.Sprintf("QC%x", s.last.cpu+1)
+	case args == "fThreadInfo":
+		var ids []string
+		for i := range s.cpus {
+			ids = append(ids, strconv.FormatInt(int64(i+1), 16))
+		}
+		return "m" + strings.Join(ids, ",")
+	case args == "sThreadInfo":
+		return "l"
+	case strings.HasPrefix(args, "ThreadExtraInfo,"):
+		th, err := s.parseThread(args[16:], false)
+		if err != nil {
+			return "E01"
+		}
+		return hex.EncodeToString([]byte(s.names[th]))
+	case strings.HasPrefix(args, "Rcmd,"):
+		cmd, err := hex.DecodeString(args[5:])
+		if err != nil {
+			return "E01"
+		}
+		return hex.EncodeToString([]byte(s.monitor(string(cmd))))
+	case strings.HasPrefix(args, "Xfer:features:read:target.xml:"):
+		off, size, err := gdbParseAddrLen(args[30:])
+		if err != nil {
+			return "E01"
+		}
+		if off >= uint32(len(gdbTargetXml)) {
+			return "l"
+		}
+		data := gdbTargetXml[off:]
+		if uint32(len(data)) > size {
+			return "m" + data[:size]
+		}
+		return "l" + data
+	}
+	return ""
+}
+
+// monitor executes a "monitor" command, returning its output
+func (s *GdbServer) monitor(cmd string) string {
+	switch strings.TrimSpace(cmd) {
+	case "callstack":
+		var out strings.Builder
+		frames := s.cpus[s.gthread].CallStack()
+		for i := len(frames) - 1; i >= 0; i-- {
+			f := frames[i]
+			kind := ""
+			if f.Exception {
+				kind = "exception, "
+			}
+			fmt.Fprintf(&out, "#%d %08x (%sreturn to %08x)\n", len(frames)-1-i, f.Target, kind, f.Ret)
+		}
+		return out.String()
+	case "help":
+		return "callstack -- show the call stack of the current thread\n"
+	}
+	return "unknown command, try \"monitor help\"\n"
+}
+
+func (s *GdbServer) handleBreakpoint(insert bool, args string) string {
+	parts := strings.Split(args, ",")
+	if len(parts) < 3 {
+		return "E01"
+	}
+	addr, size, err := gdbParseAddrLen(parts[1] + "," + parts[2])
+	if err != nil {
+		return "E01"
+	}
+
+	var w gdbWatch
+	switch parts[0] {
+	case "0", "1": // software and hardware breakpoints are the same for us
+		if insert {
+			s.bkps[addr] = true
+		} else {
+			delete(s.bkps, addr)
+		}
+		return "OK"
+	case "2":
+		w = gdbWatch{start: addr, end: uint64(addr) + uint64(size), write: true}
+	case "3":
+		w = gdbWatch{start: addr, end: uint64(addr) + uint64(size), read: true}
+	case "4":
+		w = gdbWatch{start: addr, end: uint64(addr) + uint64(size), read: true, write: true}
+	default:
+		return ""
+	}
+
+	if insert {
+		s.watches = append(s.watches, w)
+		return "OK"
+	}
+	for i := range s.watches {
+		if s.watches[i] == w {
+			s.watches = append(s.watches[:i], s.watches[i+1:]...)
+			break
+		}
+	}
+	return "OK"
+}
+
+// parseThread parses a thread ID, returning the CPU index. If all is true,
+// "-1" and "0" (all threads / any thread) are accepted, and -1 is returned.
+func (s *GdbServer) parseThread(arg string, all bool) (int, error) {
+	if all && (arg == "-1" || arg == "0") {
+		return -1, nil
+	}
+	th, err := strconv.ParseUint(arg, 16, 32)
+	if err != nil || th < 1 || int(th) > len(s.cpus) {
+		return 0, errors.New("invalid thread")
+	}
+	return int(th) - 1, nil
+}
+
+func (s *GdbServer) readReg(cpuidx int, n int) uint32 {
+	cpu := s.cpus[cpuidx]
+	switch {
+	case n == 16:
+		return cpu.GetCpsr()
+	case n == 15 && cpuidx == s.last.cpu:
+		// The stopped CPU is within the execution of the instruction,
+		// so its registers reflect the pipeline
+		return s.curpc[cpuidx]
+	default:
+		return cpu.GetRegs()[n]
+	}
+}
+
+func (s *GdbServer) writeReg(cpuidx int, n int, val uint32) {
+	cpu := s.cpus[cpuidx]
+	switch {
+	case n == 16:
+		cpu.SetCpsr(val)
+	case n == 15:
+		// The PC must be moved through Jump, as the CPU does not fetch from
+		// its register. Writing the same value (eg: G packets) must not
+		// jump, as the CPU would discard the instruction it has fetched.
+		if val == s.readReg(cpuidx, 15) {
+			return
+		}
+		cpu.SetReg(n, val)
+		cpu.Jump(val)
+		if cpuidx == s.last.cpu {
+			s.curpc[cpuidx] = val
+			s.jumped[cpuidx] = true
+		}
+	default:
+		cpu.SetReg(n, val)
+	}
+}
+
+// gdbHex32 encodes a 32-bit value in target byte order (little endian)
+func gdbHex32(val uint32) string {
+	return fmt.Sprintf("%02x%02x%02x%02x", uint8(val), uint8(val>>8), uint8(val>>16), uint8(val>>24))
+}
+
+func gdbParseHex32(s string) (uint32, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil || len(buf) != 4 {
+		return 0, errors.New("invalid register value")
+	}
+	return uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16 | uint32(buf[3])<<24, nil
+}
+
+func gdbParseAddrLen(s string) (uint32, uint32, error) {
+	idx := strings.IndexByte(s, ',')
+	if idx < 0 {
+		return 0, 0, errors.New("invalid address")
+	}
+	addr, err1 := strconv.ParseUint(s[:idx], 16, 32)
+	size, err2 := strconv.ParseUint(s[idx+1:], 16, 32)
+	if err1 != nil || err2 != nil {
+		return 0, 0, errors.New("invalid address")
+	}
+	return uint32(addr), uint32(size), nil
+}
@@ -0,0 +1,187 @@
+package debugger
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var fakeRegNames = [16]string{
+	"r0", "r1", "r2", "r3", "r4", "r5", "r6", "r7",
+	"r8", "r9", "r10", "r11", "r12", "sp", "lr", "pc",
+}
+
+type fakeCpu struct {
+	dbg    CpuDebugger
+	regs   [16]uint32
+	cpsr   uint32
+	mem    [0x2000]byte
+	calls  []CallFrame
+	jumped bool
+	target uint32
+}
+
+func (c *fakeCpu) SetDebugger(dbg CpuDebugger)    { c.dbg = dbg }
+func (c *fakeCpu) GetRegNames() []string          { return fakeRegNames[:] }
+func (c *fakeCpu) GetRegs() []uint32              { return c.regs[:] }
+func (c *fakeCpu) SetReg(idx int, val uint32)     { c.regs[idx] = val }
+func (c *fakeCpu) GetSpecialRegNames() []string   { return nil }
+func (c *fakeCpu) GetSpecialRegs() []string       { return nil }
+func (c *fakeCpu) GetPc() uint32                  { return c.regs[15] }
+func (c *fakeCpu) Disasm(uint32) (string, []byte) { return "", nil }
+func (c *fakeCpu) CallStack() []CallFrame         { return c.calls }
+func (c *fakeCpu) GetCpsr() uint32                { return c.cpsr }
+func (c *fakeCpu) SetCpsr(val uint32)             { c.cpsr = val }
+func (c *fakeCpu) Jump(addr uint32)               { c.jumped, c.target = true, addr }
+func (c *fakeCpu) ReadMem(addr uint32, buf []byte) {
+	copy(buf, c.mem[addr:])
+}
+func (c *fakeCpu) WriteMem(addr uint32, buf []byte) {
+	copy(c.mem[addr:], buf)
+}
+
+type gdbClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *gdbClient) cmd(pkt string) string {
+	var sum uint8
+	for i := 0; i < len(pkt); i++ {
+		sum += pkt[i]
+	}
+	fmt.Fprintf(c.conn, "$%s#%02x", pkt, sum)
+	if ack, err := c.r.ReadByte(); err != nil || ack != '+' {
+		c.t.Fatalf("%s: no ack (%q, %v)", pkt, ack, err)
+	}
+	return c.reply()
+}
+
+func (c *gdbClient) reply() string {
+	if _, err := c.r.ReadString('$'); err != nil {
+		c.t.Fatal(err)
+	}
+	data, err := c.r.ReadString('#')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.r.Discard(2)
+	return data[:len(data)-1]
+}
+
+func (c *gdbClient) expect(pkt, exp string) {
+	if got := c.cmd(pkt); got != exp {
+		c.t.Errorf("%s: got %q, expected %q", pkt, got, exp)
+	}
+}
+
+func TestGdbServer(t *testing.T) {
+	cpu := &fakeCpu{cpsr: 0x1F}
+	s := NewGdbServer([]GdbCpu{cpu}, []string{"arm9"})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go s.Serve(ln)
+
+	// Fake emulation: execute linear code, writing memory at 0x40 and 0x44
+	var done int32
+	go func() {
+		for pc := uint32(0); atomic.LoadInt32(&done) == 0; pc = (pc + 4) & 0xFFF {
+			cpu.jumped = false
+			cpu.dbg.Trace(pc)
+			if cpu.jumped {
+				// Like the ARM core, don't execute the instruction
+				pc = cpu.target - 4
+				continue
+			}
+			switch pc {
+			case 0x40:
+				cpu.dbg.WatchWrite(0x1000, 0, 4)
+			case 0x44:
+				cpu.dbg.WatchWrite(0xFFFFFFFE, 0, 2)
+			}
+		}
+	}()
+	defer atomic.StoreInt32(&done, 1)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := &gdbClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+
+	c.expect("?", "T02thread:1;")
+	c.expect("", "")
+	c.expect("qfThreadInfo", "m1")
+	c.expect("qThreadExtraInfo,1", "61726d39")
+	if xml := c.cmd("qXfer:features:read:target.xml:0,ffff"); !strings.HasPrefix(xml, "l<?xml") {
+		t.Errorf("invalid target description: %q", xml)
+	}
+
+	c.expect("P1=78563412", "OK")
+	c.expect("p1", "78563412")
+	c.expect("p10", "1f000000")
+	if regs := c.cmd("g"); len(regs) != 17*8 || regs[8:16] != "78563412" {
+		t.Errorf("invalid registers: %q", regs)
+	}
+
+	c.expect("M1000,4:01020304", "OK")
+	c.expect("m1000,4", "01020304")
+	c.expect("m4000000,4", "E03")
+
+	// Breakpoint, single step
+	c.expect("Z0,20,4", "OK")
+	c.expect("c", "T05thread:1;")
+	c.expect("pf", "20000000")
+	c.expect("s", "T05thread:1;")
+	c.expect("pf", "24000000")
+	c.expect("z0,20,4", "OK")
+
+	// Watchpoint
+	c.expect("Z2,1000,4", "OK")
+	c.expect("vCont;c", "T05watch:1000;thread:1;")
+	c.expect("pf", "40000000")
+	c.expect("z2,1000,4", "OK")
+
+	// Watchpoint at the end of the address space
+	c.expect("Z2,fffffffe,2", "OK")
+	c.expect("c", "T05watch:fffffffe;thread:1;")
+	c.expect("pf", "44000000")
+	c.expect("z2,fffffffe,2", "OK")
+
+	// Changing the PC: the instruction at the new PC is the next to be
+	// executed.
+	c.expect("Z0,20,4", "OK")
+	c.expect("c", "T05thread:1;")
+	c.expect("Pf=00010000", "OK")
+	c.expect("pf", "00010000")
+	c.expect("s", "T05thread:1;")
+	c.expect("pf", "04010000")
+	regs := c.cmd("g")
+	c.expect("G"+regs[:15*8]+"00020000"+regs[16*8:], "OK")
+	c.expect("pf", "00020000")
+	c.expect("s", "T05thread:1;")
+	c.expect("pf", "04020000")
+	c.expect("s300", "T05thread:1;")
+	c.expect("pf", "04030000")
+	c.expect("c10", "T05thread:1;")
+	c.expect("pf", "20000000")
+	c.expect("z0,20,4", "OK")
+
+	// Call stack, through monitor command ("callstack")
+	cpu.calls = []CallFrame{{Target: 0x2000000, Ret: 0x1004}, {Target: 0x18, Ret: 0x2000010, Exception: true}}
+	if out, _ := hex.DecodeString(c.cmd("qRcmd,63616c6c737461636b")); string(out) != "#0 00000018 (exception, return to 02000010)\n#1 02000000 (return to 00001004)\n" {
+		t.Errorf("invalid call stack: %q", out)
+	}
+
+	c.expect("D", "OK")
+}
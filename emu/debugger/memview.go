@@ -0,0 +1,318 @@
+package debugger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ndsemu/emu/hwio"
+
+	ui "github.com/gizak/termui"
+)
+
+const cMemViewLines = 16
+
+// memView is the state of the memory pane. Memory is read through the bus of
+// the current CPU, so that it shows the memory as seen by that CPU (TCM, WRAM
+// mapping, VRAM banking, etc.).
+type memView struct {
+	addr   uint32
+	width  int // 1, 2 or 4 bytes
+	follow int // index of the followed register, or -1
+
+	// I/O registers view: registers are listed starting from index iopos
+	io    bool
+	iopos int
+}
+
+// prompt is a line of text being typed by the user; while a prompt is
+// active, all keys are routed to it.
+type prompt struct {
+	label string
+	text  string
+	done  func(text string)
+}
+
+// isIoAddr returns true for addresses in the I/O area: reading them might
+// have side effects, so the memory pane doesn't show them.
+func isIoAddr(addr uint32) bool {
+	return addr>>24 == 0x04
+}
+
+func (dbg *Debugger) initMemUi() {
+	dbg.mem = memView{width: 1, follow: -1}
+	dbg.uiMem = ui.NewList()
+	dbg.uiMem.BorderLabel = "Memory"
+	dbg.uiMem.BorderFg = ui.ColorGreen
+	dbg.uiMem.Height = cMemViewLines + 2
+}
+
+// parseMemAddr parses an address typed by the user: a hex number, a register
+// name or a symbol.
+func (dbg *Debugger) parseMemAddr(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if idx := dbg.regIndex(s); idx >= 0 {
+		return dbg.cpus[dbg.curcpu].GetRegs()[idx], nil
+	}
+	if v, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 32); err == nil {
+		return uint32(v), nil
+	}
+	if v, found := dbg.syms[dbg.curcpu].Resolve(s); found {
+		return v, nil
+	}
+	return 0, fmt.Errorf("invalid address: %q", s)
+}
+
+func (dbg *Debugger) regIndex(name string) int {
+	for idx, n := range dbg.cpus[dbg.curcpu].GetRegNames() {
+		if n == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// ioRegs returns the I/O registers mapped on the bus of the current CPU
+func (dbg *Debugger) ioRegs() []hwio.RegInfo {
+	if t := dbg.iotabs[dbg.curcpu]; t != nil {
+		return t.Regs(0x04000000, 0x04FFFFFF)
+	}
+	return nil
+}
+
+// gotoIoReg moves the I/O view to the first register at the specified
+// address (or later), or whose name contains the specified text.
+func (dbg *Debugger) gotoIoReg(text string) {
+	text = strings.TrimSpace(text)
+	addr, err := strconv.ParseUint(strings.TrimPrefix(text, "0x"), 16, 32)
+	for i, r := range dbg.ioRegs() {
+		if err == nil && r.Addr >= uint32(addr) ||
+			err != nil && strings.Contains(strings.ToLower(r.Name), strings.ToLower(text)) {
+			dbg.mem.iopos = i
+			return
+		}
+	}
+	modDbg.ErrorZ("register not found").String("reg", text).End()
+}
+
+func (dbg *Debugger) refreshIo() {
+	regs := dbg.ioRegs()
+	if dbg.mem.iopos > len(regs)-cMemViewLines {
+		dbg.mem.iopos = len(regs) - cMemViewLines
+	}
+	if dbg.mem.iopos < 0 {
+		dbg.mem.iopos = 0
+	}
+
+	dbg.uiMem.BorderLabel = "I/O registers"
+	if p := dbg.prompt; p != nil {
+		dbg.uiMem.BorderLabel = p.label + p.text + "_"
+	}
+
+	lines := make([]string, 0, cMemViewLines)
+	for i := dbg.mem.iopos; i < len(regs) && len(lines) < cMemViewLines; i++ {
+		lines = append(lines, regs[i].String())
+	}
+	dbg.uiMem.Items = lines
+}
+
+func (dbg *Debugger) refreshMem() {
+	mv := &dbg.mem
+	cpu := dbg.cpus[dbg.curcpu]
+	if mv.io {
+		dbg.refreshIo()
+		return
+	}
+	if mv.follow >= 0 {
+		mv.addr = cpu.GetRegs()[mv.follow]
+	}
+	mv.addr &^= uint32(mv.width - 1)
+
+	label := fmt.Sprintf("Memory (%d-bit)", mv.width*8)
+	if mv.follow >= 0 {
+		label += " - follow " + cpu.GetRegNames()[mv.follow]
+	}
+	if p := dbg.prompt; p != nil {
+		label = p.label + p.text + "_"
+	}
+	dbg.uiMem.BorderLabel = label
+
+	lines := make([]string, cMemViewLines)
+	var buf [16]byte
+	for i := range lines {
+		addr := mv.addr + uint32(i*16)
+		if isIoAddr(addr) {
+			lines[i] = fmt.Sprintf("%08x  (I/O)", addr)
+			continue
+		}
+		cpu.ReadMem(addr, buf[:])
+
+		var hex, ascii strings.Builder
+		for j := 0; j < 16; j += mv.width {
+			switch mv.width {
+			case 1:
+				fmt.Fprintf(&hex, "%02x ", buf[j])
+			case 2:
+				fmt.Fprintf(&hex, "%04x ", binary.LittleEndian.Uint16(buf[j:]))
+			case 4:
+				fmt.Fprintf(&hex, "%08x ", binary.LittleEndian.Uint32(buf[j:]))
+			}
+		}
+		for _, c := range buf {
+			if c < 0x20 || c >= 0x7F {
+				c = '.'
+			}
+			ascii.WriteByte(c)
+		}
+		lines[i] = fmt.Sprintf("%08x  %s %s", addr, hex.String(), ascii.String())
+	}
+	dbg.uiMem.Items = lines
+}
+
+// editMem parses a list of values and writes them starting at the specified
+// address, using the current width of the view (eg: "2000000 12 34 56").
+func (dbg *Debugger) editMem(text string) error {
+	f := strings.Fields(text)
+	if len(f) < 2 {
+		return fmt.Errorf("syntax: <addr> <value> [<value>...]")
+	}
+	addr, err := dbg.parseMemAddr(f[0])
+	if err != nil {
+		return err
+	}
+	w := dbg.mem.width
+	buf := make([]byte, 0, w*(len(f)-1))
+	for _, s := range f[1:] {
+		v, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, w*8)
+		if err != nil {
+			return fmt.Errorf("invalid value: %q", s)
+		}
+		var tmp [4]byte
+		binary.LittleEndian.PutUint32(tmp[:], uint32(v))
+		buf = append(buf, tmp[:w]...)
+	}
+	if isIoAddr(addr) || isIoAddr(addr+uint32(len(buf))-1) {
+		return fmt.Errorf("cannot write to I/O registers")
+	}
+	dbg.cpus[dbg.curcpu].WriteMem(addr, buf)
+	return nil
+}
+
+func (dbg *Debugger) startPrompt(label string, done func(string)) {
+	dbg.prompt = &prompt{label: label, done: done}
+	dbg.refreshUi()
+}
+
+// promptKey handles a key pressed while a prompt is active
+func (dbg *Debugger) promptKey(key string) {
+	p := dbg.prompt
+	switch key {
+	case "<enter>":
+		dbg.prompt = nil
+		p.done(p.text)
+	case "<escape>":
+		dbg.prompt = nil
+	case "<backspace>", "C-8":
+		if len(p.text) > 0 {
+			p.text = p.text[:len(p.text)-1]
+		}
+	case "<space>":
+		p.text += " "
+	default:
+		if len(key) == 1 {
+			p.text += key
+		}
+	}
+	dbg.refreshUi()
+}
+
+// handleKey registers a keyboard handler, which is bypassed while the user
+// is typing into a prompt.
+func (dbg *Debugger) handleKey(key string, fn func()) {
+	ui.Handle("/sys/kbd/"+key, func(ui.Event) {
+		if dbg.prompt != nil {
+			dbg.promptKey(key)
+			return
+		}
+		fn()
+	})
+}
+
+func (dbg *Debugger) initMemKeys() {
+	// Keys without an handler are only used by prompts
+	ui.Handle("/sys/kbd", func(e ui.Event) {
+		if dbg.prompt != nil {
+			dbg.promptKey(e.Data.(ui.EvtKbd).KeyStr)
+		}
+	})
+
+	stopped := func(fn func()) func() {
+		return func() {
+			if !dbg.running[dbg.curcpu] {
+				fn()
+				dbg.refreshUi()
+			}
+		}
+	}
+
+	// Switch between memory and I/O registers
+	dbg.handleKey("i", stopped(func() {
+		dbg.mem.io = !dbg.mem.io
+	}))
+
+	// Goto address (hex number, register or symbol)
+	dbg.handleKey("g", stopped(func() {
+		if dbg.mem.io {
+			dbg.startPrompt("Goto I/O register (address or name): ", dbg.gotoIoReg)
+			return
+		}
+		dbg.startPrompt("Goto: ", func(text string) {
+			if addr, err := dbg.parseMemAddr(text); err != nil {
+				modDbg.ErrorZ("memory view").Error("err", err).End()
+			} else {
+				dbg.mem.addr = addr
+				dbg.mem.follow = -1
+			}
+		})
+	}))
+
+	// Follow register (empty to stop following)
+	dbg.handleKey("f", stopped(func() {
+		dbg.startPrompt("Follow register: ", func(text string) {
+			dbg.mem.follow = dbg.regIndex(strings.TrimSpace(text))
+		})
+	}))
+
+	// Edit memory: <addr> <value> [<value>...]
+	dbg.handleKey("e", stopped(func() {
+		dbg.startPrompt(fmt.Sprintf("Write %d-bit: ", dbg.mem.width*8), func(text string) {
+			if err := dbg.editMem(text); err != nil {
+				modDbg.ErrorZ("memory view").Error("err", err).End()
+			}
+		})
+	}))
+
+	// Switch between 8/16/32-bit views
+	dbg.handleKey("w", stopped(func() {
+		dbg.mem.width *= 2
+		if dbg.mem.width > 4 {
+			dbg.mem.width = 1
+		}
+	}))
+
+	scroll := func(delta int32) func() {
+		return stopped(func() {
+			if dbg.mem.io {
+				dbg.mem.iopos += int(delta)
+				return
+			}
+			dbg.mem.addr += uint32(delta * 16)
+			dbg.mem.follow = -1
+		})
+	}
+	dbg.handleKey("[", scroll(-1))
+	dbg.handleKey("]", scroll(1))
+	dbg.handleKey("{", scroll(-cMemViewLines))
+	dbg.handleKey("}", scroll(cMemViewLines))
+}
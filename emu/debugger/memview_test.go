@@ -0,0 +1,42 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemView(t *testing.T) {
+	cpu := &fakeCpu{}
+	dbg := New([]Cpu{cpu}, nil)
+	dbg.initMemUi()
+
+	dbg.mem.width = 2
+	if err := dbg.editMem("100 1234 0x5678"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cpu.mem[0x100:0x104]; string(got) != "\x34\x12\x78\x56" {
+		t.Errorf("invalid memory after edit: %x", got)
+	}
+	if err := dbg.editMem("100 12345"); err == nil {
+		t.Errorf("value too large for 16-bit view was accepted")
+	}
+	if err := dbg.editMem("4000000 1"); err == nil {
+		t.Errorf("write to I/O area was accepted")
+	}
+
+	copy(cpu.mem[0x200:], "Hello")
+	dbg.mem.addr = 0x201
+	dbg.mem.width = 4
+	dbg.refreshMem()
+	if exp := "00000200  6c6c6548 0000006f 00000000 00000000  Hello..........."; dbg.uiMem.Items[0] != exp {
+		t.Errorf("invalid line:\n%q\n%q", dbg.uiMem.Items[0], exp)
+	}
+
+	// Following a register
+	cpu.regs[13] = 0x1F0
+	dbg.mem.follow = dbg.regIndex("sp")
+	dbg.refreshMem()
+	if !strings.HasPrefix(dbg.uiMem.Items[1], "00000200  6c6c6548") {
+		t.Errorf("invalid line when following register: %q", dbg.uiMem.Items[1])
+	}
+}
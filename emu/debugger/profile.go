@@ -0,0 +1,299 @@
+package debugger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"ndsemu/emu/symbols"
+)
+
+// ProfileCpu is the interface required by the guest profiler
+type ProfileCpu interface {
+	SetDebugger(dbg CpuDebugger)
+	GetDebugger() CpuDebugger
+
+	GetClock() int64
+	Halted() bool
+}
+
+type profCount struct {
+	insns  int64
+	cycles int64
+}
+
+type cpuProfile struct {
+	name string
+	cpu  ProfileCpu
+	next CpuDebugger
+	syms *symbols.Table
+
+	// PC and clock at the last call to Trace
+	pc     uint32
+	clock  int64
+	halted bool
+	valid  bool
+
+	counts map[uint32]*profCount
+	halt   map[uint32]int64 // Cycles spent halted, by PC where the CPU halted
+}
+
+// Profiler is an exact profiler of the guest code: it attributes the cycles
+// elapsed between two consecutive instructions to the first one, and the
+// cycles spent while the CPU is halted to a special "[halt]" function (whose
+// caller is the location where the CPU halted). Profiles are written in the
+// pprof format, so that they can be analyzed with "go tool pprof".
+//
+// Like the Tracer, the profiler hooks into the CPU as a debugger, and
+// forwards all calls to the debugger previously installed (if any).
+type Profiler struct {
+	cpus  []*cpuProfile
+	start time.Time
+}
+
+func NewProfiler(cpus []ProfileCpu, names []string) *Profiler {
+	p := &Profiler{start: time.Now()}
+	for i, cpu := range cpus {
+		cp := &cpuProfile{
+			name:   names[i],
+			cpu:    cpu,
+			next:   cpu.GetDebugger(),
+			counts: make(map[uint32]*profCount),
+			halt:   make(map[uint32]int64),
+		}
+		p.cpus = append(p.cpus, cp)
+		cpu.SetDebugger(cp)
+	}
+	return p
+}
+
+// SetSymbols sets the symbol table used to name the functions of a CPU
+func (p *Profiler) SetSymbols(cpuidx int, syms *symbols.Table) {
+	p.cpus[cpuidx].syms = syms
+}
+
+// Reset discards the samples collected so far. It must be called from the
+// emulation goroutine.
+func (p *Profiler) Reset() {
+	for _, cp := range p.cpus {
+		cp.counts = make(map[uint32]*profCount)
+		cp.halt = make(map[uint32]int64)
+		cp.valid = false
+	}
+	p.start = time.Now()
+}
+
+// Dump writes the profile of each CPU into <prefix>-<cpu>.pb.gz. It must be
+// called from the emulation goroutine (eg: between frames).
+func (p *Profiler) Dump(prefix string) error {
+	for _, cp := range p.cpus {
+		fn := prefix + "-" + cp.name + ".pb.gz"
+		f, err := os.Create(fn)
+		if err != nil {
+			return err
+		}
+		err = cp.write(f, p.start)
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			return err
+		}
+		modDbg.WarnZ("guest profile dumped").String("file", fn).Int("locations", len(cp.counts)).End()
+	}
+	return nil
+}
+
+func (cp *cpuProfile) Trace(pc uint32) {
+	clock := cp.cpu.GetClock()
+	if cp.valid {
+		if cp.halted {
+			cp.halt[cp.pc] += clock - cp.clock
+		} else if c := cp.counts[cp.pc]; c != nil {
+			c.cycles += clock - cp.clock
+		}
+	}
+	cp.pc, cp.clock, cp.valid = pc, clock, true
+
+	// While halted, Trace is called once per execution slice, and not for
+	// an actual instruction
+	cp.halted = cp.cpu.Halted()
+	if !cp.halted {
+		c := cp.counts[pc]
+		if c == nil {
+			c = new(profCount)
+			cp.counts[pc] = c
+		}
+		c.insns++
+	}
+
+	if cp.next != nil {
+		cp.next.Trace(pc)
+	}
+}
+
+func (cp *cpuProfile) WatchRead(addr uint32, size int) {
+	if cp.next != nil {
+		cp.next.WatchRead(addr, size)
+	}
+}
+
+func (cp *cpuProfile) WatchWrite(addr uint32, val uint32, size int) {
+	if cp.next != nil {
+		cp.next.WatchWrite(addr, val, size)
+	}
+}
+
+func (cp *cpuProfile) Break(msg string) {
+	if cp.next != nil {
+		cp.next.Break(msg)
+	} else {
+		modDbg.FatalZ("debug breakpoint, exiting").String("msg", msg).End()
+	}
+}
+
+// write writes the profile in pprof format (gzipped protobuf, see
+// github.com/google/pprof/proto/profile.proto).
+func (cp *cpuProfile) write(w io.Writer, start time.Time) error {
+	var pb protobuf
+	strs := map[string]int64{}
+	str := func(s string) int64 {
+		if idx, ok := strs[s]; ok {
+			return idx
+		}
+		idx := int64(len(strs))
+		strs[s] = idx
+		return idx
+	}
+	str("")
+
+	// Sample types: instructions and cycles
+	for _, st := range [][2]string{{"instructions", "count"}, {"cycles", "count"}} {
+		var vt protobuf
+		vt.int(1, str(st[0]))
+		vt.int(2, str(st[1]))
+		pb.bytes(1, vt)
+	}
+
+	// A single mapping for the whole address space, already symbolized
+	var m protobuf
+	m.int(1, 1)
+	m.int(3, 1<<32)
+	m.int(5, str(cp.name))
+	m.int(7, 1)
+	pb.bytes(3, m)
+
+	// Functions are symbols, or single addresses when the address is not
+	// covered by a symbol
+	funcs := map[string]int64{}
+	function := func(name string) int64 {
+		if id, ok := funcs[name]; ok {
+			return id
+		}
+		id := int64(len(funcs) + 1)
+		funcs[name] = id
+		var f protobuf
+		f.int(1, id)
+		f.int(2, str(name))
+		f.int(3, str(name))
+		pb.bytes(5, f)
+		return id
+	}
+	location := func(id int64, addr uint32, fn int64) {
+		var l, line protobuf
+		l.int(1, id)
+		l.int(2, 1)
+		l.int(3, int64(addr))
+		line.int(1, fn)
+		l.bytes(4, line)
+		pb.bytes(4, l)
+	}
+	name := func(pc uint32) string {
+		if s, _, ok := cp.syms.Lookup(pc); ok {
+			return s.Name
+		}
+		return fmt.Sprintf("0x%08x", pc)
+	}
+
+	pcs := make([]uint32, 0, len(cp.counts))
+	for pc := range cp.counts {
+		pcs = append(pcs, pc)
+	}
+	for pc := range cp.halt {
+		if cp.counts[pc] == nil {
+			pcs = append(pcs, pc)
+		}
+	}
+	sort.Slice(pcs, func(i, j int) bool { return pcs[i] < pcs[j] })
+
+	// Location IDs: 1 is [halt], then one per PC
+	haltfn := function("[halt]")
+	location(1, 0, haltfn)
+	for i, pc := range pcs {
+		id := int64(i + 2)
+		location(id, pc, function(name(pc)))
+
+		if c := cp.counts[pc]; c != nil {
+			var s protobuf
+			s.packed(1, []int64{id})
+			s.packed(2, []int64{c.insns, c.cycles})
+			pb.bytes(2, s)
+		}
+		if h := cp.halt[pc]; h != 0 {
+			var s protobuf
+			s.packed(1, []int64{1, id})
+			s.packed(2, []int64{0, h})
+			pb.bytes(2, s)
+		}
+	}
+
+	table := make([]string, len(strs))
+	for s, idx := range strs {
+		table[idx] = s
+	}
+	for _, s := range table {
+		pb.bytes(6, []byte(s))
+	}
+	pb.int(9, start.UnixNano())
+	pb.int(10, int64(time.Since(start)))
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(pb); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// protobuf is a minimal protobuf encoder, supporting only the wire types
+// required by the pprof format.
+type protobuf []byte
+
+func (pb *protobuf) varint(v uint64) {
+	for v >= 0x80 {
+		*pb = append(*pb, byte(v)|0x80)
+		v >>= 7
+	}
+	*pb = append(*pb, byte(v))
+}
+
+func (pb *protobuf) int(field int, v int64) {
+	pb.varint(uint64(field) << 3)
+	pb.varint(uint64(v))
+}
+
+func (pb *protobuf) bytes(field int, b []byte) {
+	pb.varint(uint64(field)<<3 | 2)
+	pb.varint(uint64(len(b)))
+	*pb = append(*pb, b...)
+}
+
+func (pb *protobuf) packed(field int, vals []int64) {
+	var b protobuf
+	for _, v := range vals {
+		b.varint(uint64(v))
+	}
+	pb.bytes(field, b)
+}
@@ -0,0 +1,83 @@
+package debugger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ndsemu/emu/symbols"
+)
+
+type profCpu struct {
+	dbg    CpuDebugger
+	clock  int64
+	halted bool
+}
+
+func (c *profCpu) SetDebugger(dbg CpuDebugger) { c.dbg = dbg }
+func (c *profCpu) GetDebugger() CpuDebugger    { return c.dbg }
+func (c *profCpu) GetClock() int64             { return c.clock }
+func (c *profCpu) Halted() bool                { return c.halted }
+
+func TestProfiler(t *testing.T) {
+	cpu := &profCpu{}
+	p := NewProfiler([]ProfileCpu{cpu}, []string{"arm9"})
+	p.SetSymbols(0, symbols.New([]symbols.Symbol{{Addr: 0x100, Size: 8, Name: "loop"}}))
+
+	step := func(pc uint32, cycles int64) {
+		cpu.dbg.Trace(pc)
+		cpu.clock += cycles
+	}
+	step(0x100, 1)
+	step(0x104, 3)
+	step(0x100, 1)
+	step(0x104, 3)
+
+	// Halt for 100 cycles over two slices
+	cpu.halted = true
+	step(0x108, 60)
+	step(0x108, 40)
+	cpu.halted = false
+	step(0x18, 2)
+	step(0x1C, 0)
+
+	cp := p.cpus[0]
+	if c := cp.counts[0x100]; c == nil || c.insns != 2 || c.cycles != 2 {
+		t.Errorf("invalid count for 0x100: %+v", c)
+	}
+	if c := cp.counts[0x104]; c == nil || c.insns != 2 || c.cycles != 6 {
+		t.Errorf("invalid count for 0x104: %+v", c)
+	}
+	if c := cp.counts[0x108]; c != nil {
+		t.Errorf("halt counted as instruction: %+v", c)
+	}
+	if h := cp.halt[0x108]; h != 100 {
+		t.Errorf("invalid halt cycles: %d", h)
+	}
+	if c := cp.counts[0x18]; c == nil || c.insns != 1 || c.cycles != 2 {
+		t.Errorf("invalid count for 0x18: %+v", c)
+	}
+
+	prefix := filepath.Join(t.TempDir(), "prof")
+	if err := p.Dump(prefix); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(prefix + "-arm9.pb.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		t.Error("empty profile")
+	}
+}
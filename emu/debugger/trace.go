@@ -0,0 +1,275 @@
+package debugger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+
+	log "ndsemu/emu/logger"
+)
+
+// TraceCpu is the interface required by the instruction trace recorder
+type TraceCpu interface {
+	SetDebugger(dbg CpuDebugger)
+	GetDebugger() CpuDebugger
+
+	GetCpsr() uint32
+	CopyRegs(regs *[16]uint32)
+	FetchOpcode(pc uint32) uint32
+	DisasmOp(op uint32, pc uint32, thumb bool) string
+}
+
+// TraceConfig configures the instruction trace recorder
+type TraceConfig struct {
+	Size   int    // Number of instructions recorded for each CPU
+	Out    string // Prefix of the dump files (<Out>-<cpu>.txt or .trace)
+	Binary bool   // Dump in binary format instead of text
+}
+
+type traceEntry struct {
+	pc, op, cpsr uint32
+
+	// Registers modified by the instruction (r0-r14). Their values are
+	// stored in the values ring buffer, starting at absolute index vals.
+	mask uint16
+	vals uint64
+}
+
+// Binary trace format: a header followed by a list of records. The values of
+// the registers might have been discarded from the ring buffer (while the
+// instruction is still there): in this case, the record has the bit 15 of
+// mask set, and no values.
+//
+//	Header:
+//	  [8]byte  magic "NDSTRACE"
+//	  uint32   version (1)
+//	  [8]byte  cpu name
+//	  uint64   number of records
+//	Record:
+//	  uint32   pc
+//	  uint32   opcode (16-bit for Thumb)
+//	  uint32   cpsr before execution
+//	  uint16   mask of registers modified by the instruction
+//	  []uint32 new values of modified registers (one per bit in mask)
+const (
+	cTraceMagic      = "NDSTRACE"
+	cTraceVersion    = 1
+	cTraceValuesLost = 1 << 15
+)
+
+type traceBuffer struct {
+	name string
+	cpu  TraceCpu
+	next CpuDebugger
+	t    *Tracer
+
+	entries []traceEntry
+	values  []uint32
+	n       uint64 // number of entries recorded so far
+	nv      uint64 // number of values recorded so far
+
+	// Registers at the last recorded instruction
+	regs [16]uint32
+}
+
+// Tracer records the last executed instructions of each CPU into a ring
+// buffer, together with the registers modified by each instruction. The
+// trace is dumped into a file on demand (Dump), or when a CPU breaks because
+// of a crash (eg: jump to an invalid address).
+//
+// The tracer hooks into the CPU as a debugger; if another debugger was
+// installed, all calls are forwarded to it.
+type Tracer struct {
+	cfg  TraceConfig
+	bufs []*traceBuffer
+}
+
+func NewTracer(cpus []TraceCpu, names []string, cfg TraceConfig) *Tracer {
+	t := &Tracer{cfg: cfg}
+	for i, cpu := range cpus {
+		b := &traceBuffer{
+			name:    names[i],
+			cpu:     cpu,
+			next:    cpu.GetDebugger(),
+			t:       t,
+			entries: make([]traceEntry, cfg.Size),
+			// On average, instructions modify about one register
+			values: make([]uint32, cfg.Size*2),
+		}
+		cpu.CopyRegs(&b.regs)
+		t.bufs = append(t.bufs, b)
+		cpu.SetDebugger(b)
+	}
+	return t
+}
+
+func (b *traceBuffer) Trace(pc uint32) {
+	var regs [16]uint32
+	b.cpu.CopyRegs(&regs)
+
+	// Store the registers modified by the previous instruction
+	if b.n > 0 {
+		last := &b.entries[(b.n-1)%uint64(len(b.entries))]
+		last.vals = b.nv
+		for i := 0; i < 15; i++ {
+			if regs[i] != b.regs[i] {
+				last.mask |= 1 << uint(i)
+				b.values[b.nv%uint64(len(b.values))] = regs[i]
+				b.nv++
+			}
+		}
+	}
+	b.regs = regs
+
+	b.entries[b.n%uint64(len(b.entries))] = traceEntry{
+		pc:   pc,
+		op:   b.cpu.FetchOpcode(pc),
+		cpsr: b.cpu.GetCpsr(),
+	}
+	b.n++
+
+	if b.next != nil {
+		b.next.Trace(pc)
+	}
+}
+
+func (b *traceBuffer) WatchRead(addr uint32, size int) {
+	if b.next != nil {
+		b.next.WatchRead(addr, size)
+	}
+}
+
+func (b *traceBuffer) WatchWrite(addr uint32, val uint32, size int) {
+	if b.next != nil {
+		b.next.WatchWrite(addr, val, size)
+	}
+}
+
+func (b *traceBuffer) Break(msg string) {
+	if err := b.t.Dump(); err != nil {
+		modDbg.ErrorZ("cannot dump trace").Error("err", err).End()
+	}
+	if b.next != nil {
+		b.next.Break(msg)
+	} else {
+		log.ModCpu.FatalZ("debug breakpoint, exiting").String("msg", msg).End()
+	}
+}
+
+// record returns the i-th recorded entry (0 is the oldest one still in the
+// buffer), and the values of the modified registers (nil if they were
+// discarded).
+func (b *traceBuffer) record(i uint64) (*traceEntry, []uint32) {
+	first := uint64(0)
+	if b.n > uint64(len(b.entries)) {
+		first = b.n - uint64(len(b.entries))
+	}
+	e := &b.entries[(first+i)%uint64(len(b.entries))]
+
+	nvals := uint64(bits.OnesCount16(e.mask))
+	if nvals == 0 {
+		return e, []uint32{}
+	}
+	if e.vals+uint64(len(b.values)) < b.nv {
+		return e, nil
+	}
+	vals := make([]uint32, nvals)
+	for j := range vals {
+		vals[j] = b.values[(e.vals+uint64(j))%uint64(len(b.values))]
+	}
+	return e, vals
+}
+
+func (b *traceBuffer) count() uint64 {
+	if b.n > uint64(len(b.entries)) {
+		return uint64(len(b.entries))
+	}
+	return b.n
+}
+
+func (b *traceBuffer) dumpText(w io.Writer) {
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	for i := uint64(0); i < b.count(); i++ {
+		e, vals := b.record(i)
+		thumb := e.cpsr&(1<<5) != 0
+		if thumb {
+			fmt.Fprintf(bw, "%08x: %04x     ", e.pc, e.op)
+		} else {
+			fmt.Fprintf(bw, "%08x: %08x ", e.pc, e.op)
+		}
+		fmt.Fprintf(bw, "%-32s cpsr=%08x", b.cpu.DisasmOp(e.op, e.pc, thumb), e.cpsr)
+		if vals == nil {
+			fmt.Fprintf(bw, " <lost>")
+		}
+		j := 0
+		for r := 0; r < 15 && vals != nil; r++ {
+			if e.mask&(1<<uint(r)) != 0 {
+				fmt.Fprintf(bw, " r%d=%08x", r, vals[j])
+				j++
+			}
+		}
+		fmt.Fprintln(bw)
+	}
+}
+
+func (b *traceBuffer) dumpBinary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	var name [8]byte
+	copy(name[:], b.name)
+	bw.WriteString(cTraceMagic)
+	binary.Write(bw, binary.LittleEndian, uint32(cTraceVersion))
+	bw.Write(name[:])
+	binary.Write(bw, binary.LittleEndian, b.count())
+
+	var buf [14]byte
+	for i := uint64(0); i < b.count(); i++ {
+		e, vals := b.record(i)
+		mask := e.mask
+		if vals == nil {
+			mask = cTraceValuesLost
+		}
+		binary.LittleEndian.PutUint32(buf[0:], e.pc)
+		binary.LittleEndian.PutUint32(buf[4:], e.op)
+		binary.LittleEndian.PutUint32(buf[8:], e.cpsr)
+		binary.LittleEndian.PutUint16(buf[12:], mask)
+		bw.Write(buf[:])
+		binary.Write(bw, binary.LittleEndian, vals)
+	}
+	return bw.Flush()
+}
+
+// Dump writes the trace of each CPU into a file, named after the configured
+// prefix and the name of the CPU. As the trace is recorded without locking,
+// it must be called while the CPUs are not running (eg: between frames, or
+// from a breakpoint).
+func (t *Tracer) Dump() error {
+	for _, b := range t.bufs {
+		fn := t.cfg.Out + "-" + b.name + ".txt"
+		if t.cfg.Binary {
+			fn = t.cfg.Out + "-" + b.name + ".trace"
+		}
+		f, err := os.Create(fn)
+		if err != nil {
+			return err
+		}
+		if t.cfg.Binary {
+			err = b.dumpBinary(f)
+		} else {
+			b.dumpText(f)
+		}
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			return err
+		}
+		modDbg.WarnZ("trace dumped").String("file", fn).Uint64("count", b.count()).End()
+	}
+	return nil
+}
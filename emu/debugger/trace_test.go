@@ -0,0 +1,74 @@
+package debugger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func (c *fakeCpu) GetDebugger() CpuDebugger  { return c.dbg }
+func (c *fakeCpu) CopyRegs(regs *[16]uint32) { *regs = c.regs }
+func (c *fakeCpu) FetchOpcode(pc uint32) uint32 {
+	return 0xE0000000 | pc
+}
+func (c *fakeCpu) DisasmOp(op uint32, pc uint32, thumb bool) string {
+	return "op"
+}
+
+func TestTracer(t *testing.T) {
+	dir := t.TempDir()
+	cpu := &fakeCpu{}
+	tr := NewTracer([]TraceCpu{cpu}, []string{"arm9"}, TraceConfig{
+		Size: 4,
+		Out:  filepath.Join(dir, "trace"),
+	})
+
+	// Each instruction modifies r1, and every other instruction also r2
+	for pc := uint32(0); pc < 0x40; pc += 4 {
+		cpu.dbg.Trace(pc)
+		cpu.regs[1] = pc
+		if pc&4 != 0 {
+			cpu.regs[2] = pc
+		}
+	}
+	cpu.dbg.Trace(0x40)
+
+	if err := tr.Dump(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "trace-arm9.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	exp := []string{
+		"00000034: e0000034 op                               cpsr=00000000 r1=00000034 r2=00000034",
+		"00000038: e0000038 op                               cpsr=00000000 r1=00000038",
+		"0000003c: e000003c op                               cpsr=00000000 r1=0000003c r2=0000003c",
+		"00000040: e0000040 op                               cpsr=00000000",
+	}
+	if len(lines) != len(exp) {
+		t.Fatalf("invalid trace:\n%s", data)
+	}
+	for i := range exp {
+		if lines[i] != exp[i] {
+			t.Errorf("line %d: got %q, expected %q", i, lines[i], exp[i])
+		}
+	}
+
+	// Check that discarded values are detected
+	tr.bufs[0].values = tr.bufs[0].values[:2]
+	if _, vals := tr.bufs[0].record(0); vals != nil {
+		t.Errorf("lost values not detected")
+	}
+
+	var buf bytes.Buffer
+	if err := tr.bufs[0].dumpBinary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte(cTraceMagic)) {
+		t.Errorf("invalid binary trace")
+	}
+}
@@ -3,6 +3,7 @@ package debugger
 import (
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 
 	ui "github.com/gizak/termui"
@@ -27,12 +28,15 @@ func (dbg *Debugger) initUi() {
 	dbg.uiLog.Height = 20
 	dbg.log.SetNumLines(20)
 
+	dbg.initMemUi()
+
 	ui.Body.AddRows(
 		ui.NewRow(
 			ui.NewCol(6, 0, dbg.uiCode),
 			ui.NewCol(1, 0, dbg.uiCalls),
 			ui.NewCol(6, 0,
 				dbg.uiRegs,
+				dbg.uiMem,
 				dbg.uiLog,
 			),
 		),
@@ -58,11 +62,33 @@ func (dbg *Debugger) disasmBlock(pc uint32, sz int, area uint32) {
 		}
 		datahex := hex.EncodeToString(buf)
 		dbg.linepc[i] = pc
-		dbg.lines[i] = fmt.Sprintf("   %08x  %-16s%s", pc, datahex, text)
+		dbg.lines[i] = fmt.Sprintf("   %08x  %-16s%-24s%s", pc, datahex, text, dbg.disasmComment(pc, text))
 		pc += uint32(len(buf))
 	}
 }
 
+// disasmComment returns a comment for a disassembled line, showing the name
+// of the function starting at the line (if any) and the symbol pointed by
+// branch targets.
+func (dbg *Debugger) disasmComment(pc uint32, text string) string {
+	syms := dbg.syms[dbg.curcpu]
+	var comment []string
+	if s, off, ok := syms.Lookup(pc); ok && off == 0 {
+		comment = append(comment, s.Name+":")
+	}
+	if f := strings.Fields(text); len(f) == 2 && strings.HasPrefix(f[0], "b") {
+		if target, err := strconv.ParseUint(f[1], 16, 32); err == nil {
+			if fn := syms.Format(uint32(target)); fn != "" {
+				comment = append(comment, "-> "+fn)
+			}
+		}
+	}
+	if len(comment) == 0 {
+		return ""
+	}
+	return "; " + strings.Join(comment, " ")
+}
+
 func (dbg *Debugger) refreshCode() {
 	curpc := dbg.cpus[dbg.curcpu].GetPc()
 
@@ -111,6 +137,10 @@ func (dbg *Debugger) refreshCode() {
 
 	dbg.uiCode.Items = final
 	dbg.uiCode.Height = len(final) + 2
+	dbg.uiCode.BorderLabel = "Code"
+	if fn := dbg.syms[dbg.curcpu].Format(curpc); fn != "" {
+		dbg.uiCode.BorderLabel = "Code - " + fn
+	}
 }
 
 func (dbg *Debugger) refreshRegs() {
@@ -142,11 +172,20 @@ func (dbg *Debugger) refreshRegs() {
 	dbg.uiRegs.Height = len(lines) + 2
 }
 
+// refreshCall shows the functions in the call stack (by name if symbols are
+// available); frames created by exceptions are marked with "*".
 func (dbg *Debugger) refreshCall() {
-	chain := dbg.pcchain[dbg.curcpu]
-	calls := make([]string, 0, len(chain))
-	for _, pc := range chain {
-		calls = append(calls, fmt.Sprintf("%08x", pc))
+	frames := dbg.cpus[dbg.curcpu].CallStack()
+	calls := make([]string, 0, len(frames))
+	for _, f := range frames {
+		name := dbg.syms[dbg.curcpu].Format(f.Target)
+		if name == "" {
+			name = fmt.Sprintf("%08x", f.Target)
+		}
+		if f.Exception {
+			name += "*"
+		}
+		calls = append(calls, name)
 	}
 
 	dbg.uiCalls.Items = calls
@@ -162,6 +201,7 @@ func (dbg *Debugger) refreshUi() {
 	dbg.refreshRegs()
 	dbg.refreshLog()
 	dbg.refreshCall()
+	dbg.refreshMem()
 
 	ui.Body.Align()
 	ui.Render(ui.Body)
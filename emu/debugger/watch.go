@@ -0,0 +1,124 @@
+package debugger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Watchpoint describes a memory area to be watched by the debugger.
+type Watchpoint struct {
+	Start, End uint32    // Watched address range (inclusive)
+	Read       bool      // Break on reads
+	Write      bool      // Break on writes
+	Size       int       // If not zero, break only on accesses of this size (1, 2 or 4)
+	Cpu        int       // If not -1, break only on accesses by this CPU
+	Cond       WatchCond // Condition on the written value
+}
+
+// Match returns true if the specified memory access triggers the watchpoint.
+// For reads, the value is not known and the condition is ignored.
+func (wp *Watchpoint) Match(cpuidx int, addr uint32, size int, write bool, val uint32) bool {
+	if write && !wp.Write || !write && !wp.Read {
+		return false
+	}
+	if addr > wp.End || addr+uint32(size)-1 < wp.Start {
+		return false
+	}
+	if wp.Size != 0 && wp.Size != size {
+		return false
+	}
+	if wp.Cpu != -1 && wp.Cpu != cpuidx {
+		return false
+	}
+	return !write || wp.Cond.Match(val)
+}
+
+func (wp *Watchpoint) String() string {
+	var s string
+	if wp.Start == wp.End {
+		s = fmt.Sprintf("%08x", wp.Start)
+	} else {
+		s = fmt.Sprintf("%08x-%08x", wp.Start, wp.End)
+	}
+	if wp.Read {
+		s += " r"
+	}
+	if wp.Write {
+		s += " w"
+	}
+	if wp.Size != 0 {
+		s += fmt.Sprintf(" size=%d", wp.Size)
+	}
+	if wp.Cond.Op != "" {
+		s += fmt.Sprintf(" val%s%#x", wp.Cond.Op, wp.Cond.Value)
+	}
+	return s
+}
+
+// WatchCond is a condition on the value written to memory. The value is
+// compared with the specified operator; "&" matches if any of the bits in
+// Value is set.
+type WatchCond struct {
+	Op    string // "", "==", "!=", "<", "<=", ">", ">=", "&"
+	Value uint32
+}
+
+var watchOps = []string{"==", "!=", "<=", ">=", "<", ">", "&"}
+
+// ParseWatchCond parses a condition like "> 0x100". An empty string means
+// no condition.
+func ParseWatchCond(s string) (WatchCond, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return WatchCond{}, nil
+	}
+	for _, op := range watchOps {
+		if strings.HasPrefix(s, op) {
+			val, err := strconv.ParseUint(strings.TrimSpace(s[len(op):]), 0, 32)
+			if err != nil {
+				return WatchCond{}, fmt.Errorf("invalid value in condition %q", s)
+			}
+			return WatchCond{Op: op, Value: uint32(val)}, nil
+		}
+	}
+	return WatchCond{}, fmt.Errorf("invalid operator in condition %q", s)
+}
+
+func (c WatchCond) Match(val uint32) bool {
+	switch c.Op {
+	case "==":
+		return val == c.Value
+	case "!=":
+		return val != c.Value
+	case "<":
+		return val < c.Value
+	case "<=":
+		return val <= c.Value
+	case ">":
+		return val > c.Value
+	case ">=":
+		return val >= c.Value
+	case "&":
+		return val&c.Value != 0
+	}
+	return true
+}
+
+// ParseWatchRange parses an address range like "0x2000000-0x20000FF"
+// (inclusive), or a single address.
+func ParseWatchRange(s string) (start, end uint32, err error) {
+	parts := strings.SplitN(s, "-", 2)
+	v1, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 0, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid address %q", s)
+	}
+	if len(parts) == 1 {
+		return uint32(v1), uint32(v1), nil
+	}
+	v2, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 0, 32)
+	if err != nil || v2 < v1 {
+		return 0, 0, fmt.Errorf("invalid address range %q", s)
+	}
+	return uint32(v1), uint32(v2), nil
+}
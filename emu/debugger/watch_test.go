@@ -0,0 +1,48 @@
+package debugger
+
+import "testing"
+
+func TestWatchpoint(t *testing.T) {
+	cond, err := ParseWatchCond("> 0x100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end, err := ParseWatchRange("0x27FFC3C-0x27FFC3F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wp := Watchpoint{Start: start, End: end, Write: true, Cpu: 1, Cond: cond}
+
+	tests := []struct {
+		cpu   int
+		addr  uint32
+		size  int
+		write bool
+		val   uint32
+		exp   bool
+	}{
+		{1, 0x27FFC3C, 4, true, 0x101, true},
+		{1, 0x27FFC3C, 4, true, 0x100, false},  // condition
+		{0, 0x27FFC3C, 4, true, 0x101, false},  // other cpu
+		{1, 0x27FFC3C, 4, false, 0x101, false}, // read
+		{1, 0x27FFC3A, 4, true, 0x101, true},   // partial overlap
+		{1, 0x27FFC38, 4, true, 0x101, false},
+		{1, 0x27FFC40, 1, true, 0x101, false},
+	}
+	for _, tt := range tests {
+		if got := wp.Match(tt.cpu, tt.addr, tt.size, tt.write, tt.val); got != tt.exp {
+			t.Errorf("%+v: got %v", tt, got)
+		}
+	}
+
+	wp.Size = 2
+	if wp.Match(1, 0x27FFC3C, 4, true, 0x101) {
+		t.Errorf("size filter not applied")
+	}
+
+	for _, s := range []string{"0x100", "=> 4", "> x"} {
+		if _, err := ParseWatchCond(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
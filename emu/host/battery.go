@@ -1,4 +1,4 @@
-package hw
+package host
 
 // Read the battery charge status (0-100)
 var ReadBatteryStatus func() int
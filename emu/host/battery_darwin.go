@@ -1,4 +1,4 @@
-package hw
+package host
 
 import (
 	"os/exec"
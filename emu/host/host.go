@@ -0,0 +1,20 @@
+// Package host exposes the state of the host machine (keyboard and battery)
+// to the emulator core. The state is provided by the frontend (eg: emu/hw
+// for the SDL frontend), so that the core doesn't depend on it: without a
+// frontend, no key is ever pressed.
+package host
+
+var keyState = make([]uint8, 512)
+
+// KeyboardState returns the current state of the keyboard, indexed by
+// scancode (see SCANCODE_*); pressed keys have a non-zero value.
+func KeyboardState() []uint8 {
+	return keyState
+}
+
+// SetKeyboardState is called by the frontend to publish the keyboard state.
+// The slice can be updated by the frontend afterwards, without calling
+// SetKeyboardState again.
+func SetKeyboardState(state []uint8) {
+	keyState = state
+}
@@ -7,6 +7,7 @@ import (
 	"unsafe"
 
 	"ndsemu/emu/gfx"
+	"ndsemu/emu/host"
 
 	"github.com/veandco/go-sdl2/sdl"
 )
@@ -275,6 +276,7 @@ func GetKeyboardState() []uint8 {
 		sdl.Do(func() {
 			kstate = sdl.GetKeyboardState()
 		})
+		host.SetKeyboardState(kstate)
 	})
 	return kstate
 }
@@ -0,0 +1,163 @@
+package hwio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BitField describes a field within a register (bits Lo to Hi, inclusive).
+// Bitfields are declared with the "bits" option in the hwio struct tag, as a
+// |-separated list of fields:
+//
+//	bits=Mode:0-2|Bg0:8|Bg1:9
+type BitField struct {
+	Name   string
+	Lo, Hi uint
+}
+
+// Extract returns the value of the field within the register value
+func (f BitField) Extract(val uint64) uint64 {
+	return (val >> f.Lo) & (1<<(f.Hi-f.Lo+1) - 1)
+}
+
+func parseBits(s string) ([]BitField, error) {
+	var fields []BitField
+	for _, f := range strings.Split(s, "|") {
+		parts := strings.SplitN(f, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid bitfield: %q", f)
+		}
+		rng := strings.SplitN(parts[1], "-", 2)
+		lo, err := strconv.ParseUint(rng[0], 10, 6)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bitfield: %q", f)
+		}
+		hi := lo
+		if len(rng) == 2 {
+			if hi, err = strconv.ParseUint(rng[1], 10, 6); err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid bitfield: %q", f)
+			}
+		}
+		fields = append(fields, BitField{Name: parts[0], Lo: uint(lo), Hi: uint(hi)})
+	}
+	return fields, nil
+}
+
+// Bitfields are only needed for introspection, so they are not stored within
+// the registers, but in the table where they are mapped (Table.bits). They
+// are boxed as the radix tree needs comparable values.
+type regBits struct {
+	fields []BitField
+}
+
+// parseRegBits parses the bitfields declared in the tag of a register of the
+// specified size. It returns nil if there are none.
+func parseRegBits(tag hwiotag, nbits int) (*regBits, error) {
+	sbits := tag.Get("bits")
+	if sbits == "" {
+		return nil, nil
+	}
+	fields, err := parseBits(sbits)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if f.Hi >= uint(nbits) {
+			return nil, fmt.Errorf("bitfield out of register: %q", f.Name)
+		}
+	}
+	return &regBits{fields}, nil
+}
+
+// mapBits records the bitfields of a register mapped at addr
+func (t *Table) mapBits(addr uint32, size uint32, tag hwiotag) error {
+	bits, err := parseRegBits(tag, int(size)*8)
+	if bits == nil || err != nil {
+		return err
+	}
+	return t.bits.InsertRange(addr, addr+size-1, bits)
+}
+
+// RegInfo describes a register mapped in a table
+type RegInfo struct {
+	Name    string
+	Addr    uint32
+	Size    int    // Size in bytes
+	Value   uint64 // Current value, as stored in the register
+	Flags   RegFlags
+	ReadCb  bool // The value read by the CPU is computed by a callback
+	WriteCb bool // Writes have side effects
+	Bits    []BitField
+}
+
+// String returns a description of the register with its decoded bitfields
+// (eg: "04000000 DispCnt 00010100 w! Mode=0 Bg0=1").
+func (r *RegInfo) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%08x %-12s %0*x", r.Addr, r.Name, r.Size*2, r.Value)
+	switch {
+	case r.Flags&RegFlagReadOnly != 0:
+		sb.WriteString(" ro")
+	case r.Flags&RegFlagWriteOnly != 0:
+		sb.WriteString(" wo")
+	}
+	if r.ReadCb {
+		sb.WriteString(" r!")
+	}
+	if r.WriteCb {
+		sb.WriteString(" w!")
+	}
+	for _, b := range r.Bits {
+		fmt.Fprintf(&sb, " %s=%x", b.Name, b.Extract(r.Value))
+	}
+	return sb.String()
+}
+
+func (t *Table) regInfo(addr uint32, io interface{}) (RegInfo, bool) {
+	var ri RegInfo
+	switch r := io.(type) {
+	case *Reg8:
+		ri = RegInfo{Name: r.Name, Size: 1, Value: uint64(r.Value), Flags: r.Flags,
+			ReadCb: r.ReadCb != nil, WriteCb: r.WriteCb != nil}
+	case *Reg16:
+		ri = RegInfo{Name: r.Name, Size: 2, Value: uint64(r.Value), Flags: r.Flags,
+			ReadCb: r.ReadCb != nil, WriteCb: r.WriteCb != nil}
+	case *Reg32:
+		ri = RegInfo{Name: r.Name, Size: 4, Value: uint64(r.Value), Flags: r.Flags,
+			ReadCb: r.ReadCb != nil, WriteCb: r.WriteCb != nil}
+	case *Reg64:
+		ri = RegInfo{Name: r.Name, Size: 8, Value: r.Value, Flags: r.Flags,
+			ReadCb: r.ReadCb != nil, WriteCb: r.WriteCb != nil}
+	default:
+		return ri, false
+	}
+	ri.Addr = addr
+	if bits, ok := t.bits.Search(addr).(*regBits); ok {
+		ri.Bits = bits.fields
+	}
+	return ri, true
+}
+
+// Regs returns the registers mapped in the specified address range, sorted
+// by address. Memory areas are not included. Registers are inspected without
+// going through the bus, so no callback is invoked (and thus, the returned
+// value might differ from the one that the CPU would read).
+func (t *Table) Regs(begin, end uint32) []RegInfo {
+	var regs []RegInfo
+	var last interface{}
+	var lastEnd uint32
+	t.table8.Walk(begin, end, func(addr uint32, io interface{}) {
+		// Each register is mapped at all its byte addresses; skip all but
+		// the first one (mirrors are reported separately).
+		if io == last && addr == lastEnd+1 {
+			lastEnd = addr
+			return
+		}
+		last, lastEnd = io, addr
+		if ri, ok := t.regInfo(addr, io); ok {
+			regs = append(regs, ri)
+		}
+	})
+	return regs
+}
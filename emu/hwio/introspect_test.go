@@ -0,0 +1,82 @@
+package hwio
+
+import "testing"
+
+type introspectBank struct {
+	Cnt  Reg16 `hwio:"offset=0x0,rwmask=0xFF,wcb,bits=Mode:0-2|Enable:7"`
+	Stat Reg8  `hwio:"offset=0x2,readonly,rcb"`
+	Data Reg32 `hwio:"offset=0x4"`
+	Ram  Mem   `hwio:"offset=0x100,size=0x100"`
+}
+
+func (b *introspectBank) WriteCNT(old, val uint16) {}
+func (b *introspectBank) ReadSTAT(val uint8) uint8 { return val }
+
+func TestTableRegs(t *testing.T) {
+	bank := new(introspectBank)
+	MustInitRegs(bank)
+	bank.Cnt.Value = 0x85
+	bank.Data.Value = 0x12345678
+
+	table := NewTable("t1")
+	table.MapBank(0x4000000, bank, 0)
+	table.MapBank(0x4001000, bank, 0) // mirror
+
+	regs := table.Regs(0x4000000, 0x4000FFF)
+	if len(regs) != 3 {
+		t.Fatalf("invalid number of registers: %v", regs)
+	}
+	exp := []string{
+		"04000000 Cnt          0085 w! Mode=5 Enable=1",
+		"04000002 Stat         00 ro r!",
+		"04000004 Data         12345678",
+	}
+	for i := range exp {
+		if got := regs[i].String(); got != exp[i] {
+			t.Errorf("reg %d: got %q, want %q", i, got, exp[i])
+		}
+	}
+
+	regs = table.Regs(0x4000000, 0x4FFFFFF)
+	if len(regs) != 6 || regs[3].Addr != 0x4001000 {
+		t.Errorf("invalid mirror registers: %v", regs)
+	}
+
+	// Start in the middle of a register
+	regs = table.Regs(0x4000005, 0x4000007)
+	if len(regs) != 1 || regs[0].Addr != 0x4000005 || regs[0].Name != "Data" {
+		t.Errorf("invalid partial range: %v", regs)
+	}
+}
+
+func TestTableRegsUnmap(t *testing.T) {
+	bank := new(introspectBank)
+	MustInitRegs(bank)
+
+	// Bitfields belong to the mapping, so they go away with it
+	table := NewTable("t1")
+	table.MapBank(0x4000000, bank, 0)
+	table.UnmapBank(0x4000000, bank, 0)
+	if bits := table.bits.Search(0x4000000); bits != nil {
+		t.Errorf("bitfields not removed by UnmapBank: %v", bits)
+	}
+	table.MapBank(0x4000000, bank, 0)
+	table.Reset()
+	if bits := table.bits.Search(0x4000000); bits != nil {
+		t.Errorf("bitfields not removed by Reset: %v", bits)
+	}
+
+	// The same register mapped directly has no bitfields
+	table.MapReg16(0x4000000, &bank.Cnt)
+	if regs := table.Regs(0x4000000, 0x4000001); len(regs) != 1 || regs[0].Bits != nil {
+		t.Errorf("unexpected bitfields: %v", regs)
+	}
+}
+
+func TestParseBits(t *testing.T) {
+	for _, s := range []string{"A", "A:", ":1", "A:3-1", "A:x", "A:1-64"} {
+		if _, err := parseBits(s); err == nil {
+			t.Errorf("invalid bitfield %q accepted", s)
+		}
+	}
+}
@@ -113,6 +113,34 @@ func (node *radixNode) remove(shift uint, begin, end uint32) {
 	}
 }
 
+// walk calls fn for each leaf overlapping the range [begin, end], with the
+// first address of the leaf within the range.
+func (node *radixNode) walk(shift uint, base, begin, end uint32, fn func(addr uint32, v interface{})) {
+	for i := uint32(0); i < cRadixNumNodes; i++ {
+		lo := base | i<<shift
+		hi := lo | (uint32(1)<<shift - 1)
+		if hi < begin || lo > end {
+			continue
+		}
+		switch c := node.children[i].(type) {
+		case nil:
+		case *radixNode:
+			c.walk(shift-cRadixWidth, lo, begin, end, fn)
+		default:
+			if lo < begin {
+				lo = begin
+			}
+			fn(lo, c)
+		}
+	}
+}
+
+// Walk calls fn for each address (or range of addresses) mapped within
+// [begin, end], in ascending order.
+func (t *radixTree) Walk(begin, end uint32, fn func(addr uint32, v interface{})) {
+	t.root.walk(cRadixStartShift, 0, begin, end, fn)
+}
+
 func (t *radixTree) InsertRange(begin, end uint32, v interface{}) error {
 	return t.root.insert(cRadixStartShift, begin, end, v)
 }
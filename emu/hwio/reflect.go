@@ -103,6 +103,10 @@ func MustInitRegs(data interface{}) {
 //    writeonly       the register is write-only; any attempt to read from it
 //                    will be ignored and logged as errors.
 //
+//    bits=A:0-2|B:3  description of the bitfields of the register, used only
+//                    for introspection (see Table.Regs). It only applies to
+//                    registers mapped with Table.MapBank.
+//
 func InitRegs(data interface{}) error {
 	val := reflect.ValueOf(data).Elem()
 
@@ -260,6 +264,10 @@ func InitRegs(data interface{}) error {
 		if flags != 0 {
 			valueField.FieldByName("Flags").SetUint(uint64(flags))
 		}
+
+		if _, err := parseRegBits(tag, nbits); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -268,6 +276,7 @@ func InitRegs(data interface{}) error {
 type bankRegInfo struct {
 	regPtr interface{}
 	offset uint32
+	tag    hwiotag
 }
 
 // Given a structure, parse the hwid to extract the description of a bank
@@ -308,6 +317,7 @@ func bankGetRegs(data interface{}, bankNum int) ([]bankRegInfo, error) {
 				regs = append(regs, bankRegInfo{
 					regPtr: valueField.Addr().Interface(),
 					offset: uint32(offset),
+					tag:    tag,
 				})
 			}
 		}
@@ -0,0 +1,47 @@
+package hwio
+
+import (
+	"reflect"
+
+	"ndsemu/emu/savestate"
+)
+
+// SerializeRegs saves or loads the contents of all the registers and
+// memory areas stored as fields in a data-structure (the same kind of
+// structure that is passed to InitRegs).
+//
+// For registers, only the current value is serialized; names, masks and
+// callbacks are considered configuration, and are expected to be already
+// initialized by InitRegs. For memory areas, only areas that own their
+// buffer (that is, the ones declared with the "size" option) are serialized,
+// as the others are just views on memory owned by other components.
+//
+// Notice that writing the value back does not invoke any write callback: the
+// caller is responsible for recomputing any derived state after loading.
+func SerializeRegs(st *savestate.Stream, data interface{}) {
+	val := reflect.ValueOf(data).Elem()
+
+	for i := 0; i < val.NumField(); i++ {
+		valueField := val.Field(i)
+		varField := val.Type().Field(i)
+		if varField.PkgPath != "" {
+			// unexported field
+			continue
+		}
+
+		switch reg := valueField.Addr().Interface().(type) {
+		case *Reg8:
+			st.Uint8(&reg.Value)
+		case *Reg16:
+			st.Uint16(&reg.Value)
+		case *Reg32:
+			st.Uint32(&reg.Value)
+		case *Reg64:
+			st.Uint64(&reg.Value)
+		case *Mem:
+			if parseTag(varField.Tag).Get("size") != "" {
+				st.Bytes(reg.Data)
+			}
+		}
+	}
+}
@@ -33,6 +33,9 @@ type Table struct {
 	table8  radixTree
 	table16 radixTree
 	table32 radixTree
+
+	// Bitfields of the registers mapped by MapBank (see mapBits)
+	bits radixTree
 }
 
 type io32to16 Table
@@ -76,6 +79,7 @@ func (t *Table) Reset() {
 	t.table8 = radixTree{}
 	t.table16 = radixTree{}
 	t.table32 = radixTree{}
+	t.bits = radixTree{}
 }
 
 // Map a register bank (that is, a structure containing mulitple IoReg* fields).
@@ -99,20 +103,29 @@ func (t *Table) MapBank(addr uint32, bank interface{}, bankNum int) {
 	}
 
 	for _, reg := range regs {
+		var size uint32
 		switch r := reg.regPtr.(type) {
 		case *Mem:
 			t.MapMem(addr+reg.offset, r)
+			continue
 		case *Reg64:
 			t.MapReg64(addr+reg.offset, r)
+			size = 8
 		case *Reg32:
 			t.MapReg32(addr+reg.offset, r)
+			size = 4
 		case *Reg16:
 			t.MapReg16(addr+reg.offset, r)
+			size = 2
 		case *Reg8:
 			t.MapReg8(addr+reg.offset, r)
+			size = 1
 		default:
 			panic(fmt.Errorf("invalid reg type: %T", r))
 		}
+		if err := t.mapBits(addr+reg.offset, size, reg.tag); err != nil {
+			panic(err)
+		}
 	}
 }
 
@@ -233,6 +246,7 @@ func (t *Table) Unmap(begin uint32, end uint32) {
 	t.table8.RemoveRange(begin, end)
 	t.table16.RemoveRange(begin, end)
 	t.table32.RemoveRange(begin, end)
+	t.bits.RemoveRange(begin, end)
 }
 
 func (t *Table) Read8(addr uint32) uint8 {
@@ -0,0 +1,397 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"ndsemu/emu/fixed"
+
+	logrus "gopkg.in/Sirupsen/logrus.v0"
+)
+
+// Binary log format. The file starts with an 8-byte header (binMagic),
+// followed by a sequence of records, each one starting with a kind byte:
+//
+//	0: string definition: uvarint length, bytes. Defined strings are
+//	   numbered sequentially starting from 1.
+//	1: log entry:
+//	     byte     level (logrus.Level)
+//	     str      module name
+//	     str      message
+//	     uvarint  frame+1 (0 if unknown)
+//	     uvarint  number of fields
+//	     fields:  str key, byte type (FieldType), value
+//
+// A "str" is a uvarint that is either (id<<1) for a defined string, or
+// (len<<1)|1 followed by the string bytes for strings that are not worth
+// interning. Field values are encoded depending on their type: a byte for
+// bools, a uvarint for unsigned and hex integers, a zigzag varint for
+// signed integers, durations and fixed-point numbers (4 of them for
+// vectors), a str for strings, and a uvarint length plus bytes for blobs.
+// Stringers are stored as strings.
+const (
+	binMagic = "NDSLOG\x00\x01"
+
+	binKindString = 0
+	binKindEntry  = 1
+
+	// Strings longer than this are stored inline, as they are unlikely to
+	// be repeated (eg: formatted error messages).
+	binMaxInternLen = 64
+	binMaxStrings   = 1 << 16
+)
+
+type binWriter struct {
+	mu   sync.Mutex
+	w    *bufio.Writer
+	strs map[string]uint64
+	buf  []byte
+}
+
+var binOut *binWriter
+
+// SetBinaryOutput makes the logger write all entries (created through the
+// xxxZ functions) into w, in a compact binary format that can be queried with
+// the ndslog tool (emu/logger/ndslog). Warnings and errors are still printed
+// to the text output as well. Call FlushBinaryOutput before closing w.
+func SetBinaryOutput(w io.Writer) error {
+	if binOut != nil {
+		if err := FlushBinaryOutput(); err != nil {
+			return err
+		}
+	}
+	if w == nil {
+		binOut = nil
+		return nil
+	}
+	bw := &binWriter{
+		w:    bufio.NewWriterSize(w, 1<<20),
+		strs: make(map[string]uint64),
+	}
+	if _, err := bw.w.WriteString(binMagic); err != nil {
+		return err
+	}
+	binOut = bw
+	return nil
+}
+
+// FlushBinaryOutput flushes the entries buffered by the binary output
+func FlushBinaryOutput() error {
+	bw := binOut
+	if bw == nil {
+		return nil
+	}
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return bw.w.Flush()
+}
+
+func (bw *binWriter) uvarint(v uint64) {
+	bw.buf = binary.AppendUvarint(bw.buf, v)
+}
+
+func (bw *binWriter) varint(v int64) {
+	bw.buf = binary.AppendVarint(bw.buf, v)
+}
+
+func (bw *binWriter) str(s string) {
+	if id, ok := bw.strs[s]; ok {
+		bw.uvarint(id << 1)
+		return
+	}
+	if len(s) > binMaxInternLen || len(bw.strs) >= binMaxStrings {
+		bw.uvarint(uint64(len(s))<<1 | 1)
+		bw.buf = append(bw.buf, s...)
+		return
+	}
+
+	// Define the string before the record being encoded
+	id := uint64(len(bw.strs) + 1)
+	bw.strs[s] = id
+	var def [binary.MaxVarintLen64 + 1]byte
+	def[0] = binKindString
+	n := binary.PutUvarint(def[1:], uint64(len(s)))
+	bw.w.Write(def[:n+1])
+	bw.w.WriteString(s)
+	bw.uvarint(id << 1)
+}
+
+func (bw *binWriter) write(z *EntryZ) {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+
+	var frame uint64
+	nfields := 0
+	for i := 0; i < z.zfidx; i++ {
+		f := &z.zfbuf[i]
+		if f.Key == "_frame" {
+			frame = f.Integer + 1
+		} else {
+			nfields++
+		}
+	}
+
+	bw.buf = append(bw.buf[:0], binKindEntry, byte(z.lvl))
+	bw.str(modNames[z.mod])
+	bw.str(z.msg)
+	bw.uvarint(frame)
+	bw.uvarint(uint64(nfields))
+	for i := 0; i < z.zfidx; i++ {
+		f := &z.zfbuf[i]
+		if f.Key == "_frame" {
+			continue
+		}
+		bw.str(f.Key)
+		switch f.Type {
+		case FieldTypeBool:
+			b := byte(0)
+			if f.Boolean {
+				b = 1
+			}
+			bw.buf = append(bw.buf, byte(f.Type), b)
+		case FieldTypeString:
+			bw.buf = append(bw.buf, byte(f.Type))
+			bw.str(f.String)
+		case FieldTypeStringer:
+			bw.buf = append(bw.buf, byte(FieldTypeString))
+			bw.str(f.Value())
+		case FieldTypeError:
+			bw.buf = append(bw.buf, byte(f.Type))
+			bw.str(f.Value())
+		case FieldTypeHex8, FieldTypeHex16, FieldTypeHex32, FieldTypeHex64, FieldTypeUint:
+			bw.buf = append(bw.buf, byte(f.Type))
+			bw.uvarint(f.Integer)
+		case FieldTypeInt:
+			bw.buf = append(bw.buf, byte(f.Type))
+			bw.varint(int64(f.Integer))
+		case FieldTypeDuration:
+			bw.buf = append(bw.buf, byte(f.Type))
+			bw.varint(int64(f.Duration))
+		case FieldTypeFixed12:
+			bw.buf = append(bw.buf, byte(f.Type))
+			bw.varint(int64(f.Fixed12.V))
+		case FieldTypeVector12:
+			bw.buf = append(bw.buf, byte(f.Type))
+			for _, v := range f.Vector12 {
+				bw.varint(int64(v.V))
+			}
+		case FieldTypeBlob:
+			bw.buf = append(bw.buf, byte(f.Type))
+			bw.uvarint(uint64(len(f.Blob)))
+			bw.buf = append(bw.buf, f.Blob...)
+		default:
+			bw.buf = append(bw.buf, byte(FieldTypeUnknown))
+		}
+	}
+	bw.w.Write(bw.buf)
+}
+
+// Record is a log entry read from a binary log
+type Record struct {
+	Level  logrus.Level
+	Module string
+	Msg    string
+	Frame  int64 // -1 if the entry was logged outside of the emulation
+	Fields []ZField
+
+	// CPU whose program counter was logged (through the "pc-<cpu>" field
+	// added by the emulator context), and its value. Cpu is empty if the
+	// entry has no PC.
+	Cpu string
+	PC  uint32
+}
+
+// Field returns the field with the specified key
+func (r *Record) Field(key string) (*ZField, bool) {
+	for i := range r.Fields {
+		if r.Fields[i].Key == key {
+			return &r.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// Text formats the record like the text output of the logger
+func (r *Record) Text() string {
+	frame := "xxx"
+	if r.Frame >= 0 {
+		frame = fmt.Sprint(r.Frame)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s[%05s] [%s] %-*s ",
+		strings.ToUpper(r.Level.String())[0:4], frame, r.Module, 40-len(r.Module), r.Msg)
+	for i := range r.Fields {
+		fmt.Fprintf(&sb, " %s=%s", r.Fields[i].Key, r.Fields[i].Value())
+	}
+	return sb.String()
+}
+
+// BinaryReader reads records from a binary log
+type BinaryReader struct {
+	r    *bufio.Reader
+	strs []string
+}
+
+func NewBinaryReader(r io.Reader) (*BinaryReader, error) {
+	br := &BinaryReader{r: bufio.NewReaderSize(r, 1<<20), strs: []string{""}}
+	var magic [len(binMagic)]byte
+	if _, err := io.ReadFull(br.r, magic[:]); err != nil || string(magic[:]) != binMagic {
+		return nil, errors.New("not a binary log file")
+	}
+	return br, nil
+}
+
+var errBinCorrupted = errors.New("corrupted binary log")
+
+func (br *BinaryReader) bytes(n uint64) ([]byte, error) {
+	if n > 1<<24 {
+		return nil, errBinCorrupted
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(br.r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (br *BinaryReader) str() (string, error) {
+	v, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		return "", err
+	}
+	if v&1 != 0 {
+		buf, err := br.bytes(v >> 1)
+		return string(buf), err
+	}
+	if v>>1 >= uint64(len(br.strs)) {
+		return "", errBinCorrupted
+	}
+	return br.strs[v>>1], nil
+}
+
+// Next returns the next record in the log, or io.EOF at the end of the log.
+// A log truncated by a crash of the emulator is valid up to its last
+// complete record.
+func (br *BinaryReader) Next() (*Record, error) {
+	for {
+		kind, err := br.r.ReadByte()
+		if err != nil {
+			return nil, io.EOF
+		}
+		var rec *Record
+		switch kind {
+		case binKindString:
+			var s []byte
+			n, err2 := binary.ReadUvarint(br.r)
+			if err = err2; err == nil {
+				s, err = br.bytes(n)
+				br.strs = append(br.strs, string(s))
+			}
+		case binKindEntry:
+			rec, err = br.entry()
+		default:
+			err = errBinCorrupted
+		}
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if err != nil || rec != nil {
+			return rec, err
+		}
+	}
+}
+
+func (br *BinaryReader) entry() (*Record, error) {
+	// Decoding errors are sticky: the first one is returned at the end
+	var err error
+	u8 := func() byte {
+		b, e := br.r.ReadByte()
+		if err == nil {
+			err = e
+		}
+		return b
+	}
+	uvarint := func() uint64 {
+		v, e := binary.ReadUvarint(br.r)
+		if err == nil {
+			err = e
+		}
+		return v
+	}
+	varint := func() int64 {
+		v, e := binary.ReadVarint(br.r)
+		if err == nil {
+			err = e
+		}
+		return v
+	}
+	str := func() string {
+		s, e := br.str()
+		if err == nil {
+			err = e
+		}
+		return s
+	}
+
+	rec := &Record{Level: logrus.Level(u8())}
+	rec.Module = str()
+	rec.Msg = str()
+	rec.Frame = int64(uvarint()) - 1
+	nfields := uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if nfields > uint64(len(EntryZ{}.zfbuf)) {
+		return nil, errBinCorrupted
+	}
+
+	rec.Fields = make([]ZField, nfields)
+	for i := range rec.Fields {
+		f := &rec.Fields[i]
+		f.Key = str()
+		f.Type = FieldType(u8())
+		switch f.Type {
+		case FieldTypeBool:
+			f.Boolean = u8() != 0
+		case FieldTypeString:
+			f.String = str()
+		case FieldTypeError:
+			f.Error = errors.New(str())
+		case FieldTypeHex8, FieldTypeHex16, FieldTypeHex32, FieldTypeHex64, FieldTypeUint:
+			f.Integer = uvarint()
+		case FieldTypeInt:
+			f.Integer = uint64(varint())
+		case FieldTypeDuration:
+			f.Duration = time.Duration(varint())
+		case FieldTypeFixed12:
+			f.Fixed12 = fixed.F12{V: int32(varint())}
+		case FieldTypeVector12:
+			for j := range f.Vector12 {
+				f.Vector12[j] = fixed.F12{V: int32(varint())}
+			}
+		case FieldTypeBlob:
+			if n := uvarint(); err == nil {
+				f.Blob, err = br.bytes(n)
+			}
+		case FieldTypeUnknown:
+		default:
+			if err == nil {
+				err = errBinCorrupted
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if rec.Cpu == "" && f.Type == FieldTypeHex32 && strings.HasPrefix(f.Key, "pc-") {
+			rec.Cpu, rec.PC = f.Key[3:], uint32(f.Integer)
+		}
+	}
+	return rec, nil
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ndsemu/emu/fixed"
+)
+
+type testContext struct{ frame int64 }
+
+func (c *testContext) AddLogContext(z *EntryZ) {
+	z.Int64("_frame", c.frame)
+	z.Hex32("pc-arm9", 0x02000100+uint32(c.frame))
+}
+
+func TestBinaryOutput(t *testing.T) {
+	var buf, text bytes.Buffer
+	SetOutput(&text)
+	defer SetOutput(os.Stdout)
+	if err := SetBinaryOutput(&buf); err != nil {
+		t.Fatal(err)
+	}
+	defer SetBinaryOutput(nil)
+
+	EnableDebugModules(ModEmu.Mask())
+	defer DisableDebugModules(ModEmu.Mask())
+
+	ModEmu.DebugZ("no context").End()
+
+	ctx := &testContext{}
+	contexts = append(contexts, ctx)
+	defer func() { contexts = contexts[:len(contexts)-1] }()
+	for i := 0; i < 3; i++ {
+		ctx.frame = int64(i)
+		ModEmu.DebugZ("write").Hex16("addr", 0x208).Int("val", -i).Bool("ok", i == 1).End()
+	}
+	ctx.frame = 3
+	ModEmu.WarnZ("all types").String("s", strings.Repeat("x", 100)).Error("err", errors.New("fail")).
+		Duration("d", time.Second).Fixed12("f", fixed.F12{V: 0x1800}).Blob("b", []byte{1, 2}).End()
+	if err := FlushBinaryOutput(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the warning is printed as text
+	if n := strings.Count(text.String(), "\n"); n != 1 {
+		t.Errorf("invalid text output (%d lines): %q", n, text.String())
+	}
+
+	data := buf.Bytes()
+	br, err := NewBinaryReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recs []*Record
+	for {
+		r, err := br.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r)
+	}
+	if len(recs) != 5 {
+		t.Fatalf("invalid number of records: %d", len(recs))
+	}
+
+	if r := recs[0]; r.Msg != "no context" || r.Module != "emu" || r.Frame != -1 || r.Cpu != "" {
+		t.Errorf("invalid record: %+v", r)
+	}
+	r := recs[2]
+	if r.Frame != 1 || r.Cpu != "arm9" || r.PC != 0x02000101 {
+		t.Errorf("invalid record: %+v", r)
+	}
+	if f, ok := r.Field("val"); !ok || f.Value() != "-1" {
+		t.Errorf("invalid val field: %+v", f)
+	}
+	if s := r.Text(); !strings.Contains(s, "addr=0208 val=-1 ok=true pc-arm9=02000101") {
+		t.Errorf("invalid text: %q", s)
+	}
+
+	r = recs[4]
+	var vals []string
+	for _, f := range r.Fields {
+		vals = append(vals, f.Key+"="+f.Value())
+	}
+	exp := "s=" + strings.Repeat("x", 100) + " err=fail d=1s f=1.5000 b=0102 pc-arm9=02000103"
+	if got := strings.Join(vals, " "); got != exp {
+		t.Errorf("invalid fields: %q", got)
+	}
+
+	// A truncated log is read up to the last complete record
+	br, _ = NewBinaryReader(bytes.NewReader(data[:len(data)-1]))
+	n := 0
+	for {
+		if _, err := br.Next(); err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break
+		}
+		n++
+	}
+	if n != 4 {
+		t.Errorf("invalid number of records in truncated log: %d", n)
+	}
+}
@@ -216,6 +216,31 @@ func (z *EntryZ) end() {
 		c.AddLogContext(z)
 	}
 
+	// With a binary output, only warnings and errors are also printed as
+	// text, so that they are still visible on the console
+	if binOut != nil {
+		binOut.write(z)
+	}
+	if binOut == nil || z.lvl <= logrus.WarnLevel {
+		z.writeText()
+	}
+
+	if z.lvl == logrus.FatalLevel {
+		FlushBinaryOutput()
+		os.Exit(1)
+	} else if z.lvl == logrus.PanicLevel {
+		FlushBinaryOutput()
+		panic("raising panic in logger")
+	}
+
+	// Recycle entry
+	buf := z.buf
+	*z = EntryZ{}
+	z.buf = buf
+	ezpool.Put(z)
+}
+
+func (z *EntryZ) writeText() {
 	modname := modNames[z.mod]
 	frame := "xxx"
 	levelText := strings.ToUpper(z.lvl.String())[0:4]
@@ -262,18 +287,6 @@ func (z *EntryZ) end() {
 	z.buf.WriteByte('\n')
 	output.Write(z.buf.Bytes())
 	z.buf.Reset()
-
-	if z.lvl == logrus.FatalLevel {
-		os.Exit(1)
-	} else if z.lvl == logrus.PanicLevel {
-		panic("raising panic in logger")
-	}
-
-	// Recycle entry
-	buf := z.buf
-	*z = EntryZ{}
-	z.buf = buf
-	ezpool.Put(z)
 }
 
 func (z *EntryZ) End() {
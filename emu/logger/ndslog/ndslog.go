@@ -0,0 +1,281 @@
+// Command ndslog queries the binary logs written by ndsemu -log-bin, and
+// exports the matching entries as text (in the same format of the text log)
+// or JSON (one object per line).
+//
+// Usage:
+//
+//	ndslog [flags] file.nlog...
+//
+// Examples:
+//
+//	ndslog -mod dma,gx -frames 100-120 log.nlog
+//	ndslog -pc 02004000-02004800 -field val=0 -format json log.nlog
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	log "ndsemu/emu/logger"
+)
+
+type fieldFilters []string
+
+func (f *fieldFilters) String() string     { return strings.Join(*f, ",") }
+func (f *fieldFilters) Set(s string) error { *f = append(*f, s); return nil }
+
+var (
+	flagMod    = flag.String("mod", "", "comma-separated list of modules")
+	flagLevel  = flag.String("level", "", "minimum level (debug, info, warning, error)")
+	flagFrames = flag.String("frames", "", "frame range (eg: 100-200, 100-, -200, 150)")
+	flagCpu    = flag.String("cpu", "", "only entries with the PC of the specified CPU (eg: arm9)")
+	flagPc     = flag.String("pc", "", "PC range, in hex (eg: 02000000-02001000)")
+	flagMsg    = flag.String("msg", "", "only entries whose message contains the specified string")
+	flagFormat = flag.String("format", "text", "output format: text or json")
+	flagLimit  = flag.Int("n", 0, "stop after the specified number of entries (0 = no limit)")
+	flagFields fieldFilters
+)
+
+type query struct {
+	mods     map[string]bool
+	level    int
+	fmin     int64
+	fmax     int64
+	pcmin    uint32
+	pcmax    uint32
+	pcfilter bool
+	fields   [][2]string
+}
+
+// parseRange parses a range in the form "A-B", "A-", "-B" or "A"; missing
+// bounds are returned as the specified defaults.
+func parseRange(s string, base int, min, max uint64) (uint64, uint64, error) {
+	lo, hi := s, s
+	if idx := strings.IndexByte(s, '-'); idx >= 0 {
+		lo, hi = s[:idx], s[idx+1:]
+	}
+	var err error
+	if lo != "" {
+		if min, err = strconv.ParseUint(lo, base, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid range %q", s)
+		}
+	}
+	if hi != "" {
+		if max, err = strconv.ParseUint(hi, base, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid range %q", s)
+		}
+	}
+	return min, max, nil
+}
+
+func newQuery() (*query, error) {
+	q := &query{level: -1, fmin: -1, fmax: 1<<63 - 1, pcmax: 0xFFFFFFFF}
+
+	if *flagMod != "" {
+		q.mods = make(map[string]bool)
+		for _, m := range strings.Split(*flagMod, ",") {
+			q.mods[m] = true
+		}
+	}
+	if *flagLevel != "" {
+		lvl, err := parseLevel(*flagLevel)
+		if err != nil {
+			return nil, err
+		}
+		q.level = lvl
+	}
+	if *flagFrames != "" {
+		min, max, err := parseRange(*flagFrames, 10, 0, 1<<63-1)
+		if err != nil {
+			return nil, err
+		}
+		q.fmin, q.fmax = int64(min), int64(max)
+	}
+	if *flagPc != "" {
+		min, max, err := parseRange(strings.Replace(*flagPc, "0x", "", -1), 16, 0, 0xFFFFFFFF)
+		if err != nil {
+			return nil, err
+		}
+		q.pcmin, q.pcmax, q.pcfilter = uint32(min), uint32(max), true
+	}
+	for _, f := range flagFields {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field filter %q (expected key=value)", f)
+		}
+		q.fields = append(q.fields, [2]string{kv[0], kv[1]})
+	}
+	return q, nil
+}
+
+func parseLevel(s string) (int, error) {
+	// Lower values are more severe (see logrus.Level)
+	switch strings.ToLower(s) {
+	case "debug":
+		return 5, nil
+	case "info":
+		return 4, nil
+	case "warn", "warning":
+		return 3, nil
+	case "error":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("invalid level %q", s)
+}
+
+// matchValue compares a field with the value specified on the command line.
+// Numeric fields are compared by value, so that hex fields can be matched
+// regardless of the leading zeros (eg: "addr=4000208" or "addr=0x04000208").
+func matchValue(f *log.ZField, val string) bool {
+	switch f.Type {
+	case log.FieldTypeHex8, log.FieldTypeHex16, log.FieldTypeHex32, log.FieldTypeHex64:
+		v, err := strconv.ParseUint(strings.TrimPrefix(val, "0x"), 16, 64)
+		return err == nil && v == f.Integer
+	case log.FieldTypeUint:
+		v, err := strconv.ParseUint(val, 0, 64)
+		return err == nil && v == f.Integer
+	case log.FieldTypeInt:
+		v, err := strconv.ParseInt(val, 0, 64)
+		return err == nil && v == int64(f.Integer)
+	}
+	return f.Value() == val
+}
+
+func (q *query) match(r *log.Record) bool {
+	if q.mods != nil && !q.mods[r.Module] {
+		return false
+	}
+	if q.level >= 0 && int(r.Level) > q.level {
+		return false
+	}
+	if r.Frame < q.fmin || r.Frame > q.fmax {
+		return false
+	}
+	if *flagCpu != "" && r.Cpu != *flagCpu {
+		return false
+	}
+	if q.pcfilter && (r.Cpu == "" || r.PC < q.pcmin || r.PC > q.pcmax) {
+		return false
+	}
+	if *flagMsg != "" && !strings.Contains(r.Msg, *flagMsg) {
+		return false
+	}
+	for _, kv := range q.fields {
+		f, ok := r.Field(kv[0])
+		if !ok || !matchValue(f, kv[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func jsonRecord(r *log.Record) map[string]interface{} {
+	fields := make(map[string]interface{}, len(r.Fields))
+	for i := range r.Fields {
+		f := &r.Fields[i]
+		switch f.Type {
+		case log.FieldTypeBool:
+			fields[f.Key] = f.Boolean
+		case log.FieldTypeInt:
+			fields[f.Key] = int64(f.Integer)
+		case log.FieldTypeUint:
+			fields[f.Key] = f.Integer
+		default:
+			fields[f.Key] = f.Value()
+		}
+	}
+	obj := map[string]interface{}{
+		"level":  r.Level.String(),
+		"module": r.Module,
+		"msg":    r.Msg,
+		"fields": fields,
+	}
+	if r.Frame >= 0 {
+		obj["frame"] = r.Frame
+	}
+	if r.Cpu != "" {
+		obj["cpu"] = r.Cpu
+		obj["pc"] = fmt.Sprintf("%08x", r.PC)
+	}
+	return obj
+}
+
+func dump(fn string, q *query, out io.Writer, count *int) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br, err := log.NewBinaryReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	enc := json.NewEncoder(out)
+	for *flagLimit == 0 || *count < *flagLimit {
+		r, err := br.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%s: %v", fn, err)
+		}
+
+		// Frames are logged in order, so stop reading as soon as the range
+		// is over
+		if r.Frame > q.fmax {
+			break
+		}
+		if !q.match(r) {
+			continue
+		}
+		*count++
+		if *flagFormat == "json" {
+			if err := enc.Encode(jsonRecord(r)); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprintln(out, r.Text())
+		}
+	}
+	return nil
+}
+
+func main() {
+	flag.Var(&flagFields, "field", "only entries with the specified field value (key=value, can be repeated)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] file...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *flagFormat != "text" && *flagFormat != "json" {
+		fmt.Fprintf(os.Stderr, "invalid format: %q\n", *flagFormat)
+		os.Exit(2)
+	}
+
+	q, err := newQuery()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	count := 0
+	for _, fn := range flag.Args() {
+		if err := dump(fn, q, out, &count); err != nil {
+			out.Flush()
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+// Package savestate implements a simple binary serializer that is used
+// to save and restore the full state of the emulator.
+//
+// The same code path is used both for saving and loading: each component
+// implements a Serialize() method that calls the Stream methods on pointers
+// to its own fields. When saving, the values are read and written to the
+// stream; when loading, the values are read from the stream and stored
+// into the fields. This guarantees that the save and load sequences can
+// never go out of sync.
+package savestate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const cMagic = "NDSEMUSS"
+
+// MaxSliceLen is the maximum length of the variable-size buffers in a
+// savestate (the biggest ones are backup memories, up to 8 MiB). A longer
+// length means that the stream is corrupted.
+const MaxSliceLen = 16 << 20
+
+// ErrVersion is returned by Load when the savestate was created by
+// an incompatible version of the emulator.
+var ErrVersion = errors.New("savestate: incompatible version")
+
+// PartialError is returned by Load when an error happens while the object is
+// being deserialized. In this case, the object is left in an undefined state
+// (part of it was loaded from the savestate); any other error returned by
+// Load means that the object was not modified.
+type PartialError struct {
+	Err error
+}
+
+func (e *PartialError) Error() string { return e.Err.Error() }
+func (e *PartialError) Unwrap() error { return e.Err }
+
+// A component that can be saved into a savestate.
+type Serializable interface {
+	// Serialize saves or loads the state of the component. The
+	// direction of the transfer can be checked with st.Loading(), which
+	// is needed to recompute derived state after loading.
+	Serialize(st *Stream)
+}
+
+// Stream is a bidirectional serializer. All the methods accept pointers
+// to values that are either written into the stream (when saving), or
+// overwritten with the contents of the stream (when loading).
+//
+// After the first error, all methods become no-ops; the error can be
+// inspected through Err().
+type Stream struct {
+	w   *bufio.Writer
+	r   *bufio.Reader
+	err error
+	buf [8]byte
+}
+
+// Loading returns true if the stream is being used to load a state.
+func (st *Stream) Loading() bool { return st.r != nil }
+
+// Err returns the first error that was encountered while serializing.
+func (st *Stream) Err() error { return st.err }
+
+// SetError aborts the serialization with the specified error. It can be
+// used by components that detect an inconsistent state. Only the first
+// error is remembered.
+func (st *Stream) SetError(err error) {
+	if st.err == nil {
+		st.err = err
+	}
+}
+
+func (st *Stream) data(n int) []byte {
+	if st.err != nil {
+		return nil
+	}
+	buf := st.buf[:n]
+	if st.r != nil {
+		if _, err := io.ReadFull(st.r, buf); err != nil {
+			st.SetError(err)
+			return nil
+		}
+	}
+	return buf
+}
+
+func (st *Stream) flush(buf []byte) {
+	if st.w != nil && st.err == nil {
+		if _, err := st.w.Write(buf); err != nil {
+			st.SetError(err)
+		}
+	}
+}
+
+func (st *Stream) Uint8(v *uint8) {
+	if buf := st.data(1); buf != nil {
+		if st.Loading() {
+			*v = buf[0]
+		} else {
+			buf[0] = *v
+			st.flush(buf)
+		}
+	}
+}
+
+func (st *Stream) Uint16(v *uint16) {
+	if buf := st.data(2); buf != nil {
+		if st.Loading() {
+			*v = binary.LittleEndian.Uint16(buf)
+		} else {
+			binary.LittleEndian.PutUint16(buf, *v)
+			st.flush(buf)
+		}
+	}
+}
+
+func (st *Stream) Uint32(v *uint32) {
+	if buf := st.data(4); buf != nil {
+		if st.Loading() {
+			*v = binary.LittleEndian.Uint32(buf)
+		} else {
+			binary.LittleEndian.PutUint32(buf, *v)
+			st.flush(buf)
+		}
+	}
+}
+
+func (st *Stream) Uint64(v *uint64) {
+	if buf := st.data(8); buf != nil {
+		if st.Loading() {
+			*v = binary.LittleEndian.Uint64(buf)
+		} else {
+			binary.LittleEndian.PutUint64(buf, *v)
+			st.flush(buf)
+		}
+	}
+}
+
+func (st *Stream) Int32(v *int32) {
+	u := uint32(*v)
+	st.Uint32(&u)
+	*v = int32(u)
+}
+
+func (st *Stream) Int64(v *int64) {
+	u := uint64(*v)
+	st.Uint64(&u)
+	*v = int64(u)
+}
+
+// Int serializes a native int, always as a 64-bit value so that savestates
+// are portable across architectures.
+func (st *Stream) Int(v *int) {
+	v64 := int64(*v)
+	st.Int64(&v64)
+	*v = int(v64)
+}
+
+func (st *Stream) Bool(v *bool) {
+	var b uint8
+	if *v {
+		b = 1
+	}
+	st.Uint8(&b)
+	*v = b != 0
+}
+
+// Len serializes the length of a variable-size object. When loading, a length
+// that is negative or greater than max aborts the serialization, so that the
+// caller can safely allocate memory for the object.
+func (st *Stream) Len(n *int, max int) {
+	st.Int(n)
+	if st.Loading() && st.err == nil && (*n < 0 || *n > max) {
+		st.SetError(fmt.Errorf("savestate: corrupted stream (length %d, max %d)", *n, max))
+	}
+}
+
+// sliceLen serializes the length of a variable-size slice, that is -1 for a
+// nil slice.
+func (st *Stream) sliceLen(n *int32) {
+	st.Int32(n)
+	if st.Loading() && st.err == nil && (*n < -1 || *n > MaxSliceLen) {
+		st.SetError(fmt.Errorf("savestate: corrupted stream (slice length %d)", *n))
+	}
+}
+
+// Bytes serializes a fixed-size buffer. When loading, the size saved in the
+// stream must match the size of the buffer.
+func (st *Stream) Bytes(buf []byte) {
+	n := uint32(len(buf))
+	st.Uint32(&n)
+	if st.err != nil {
+		return
+	}
+	if int(n) != len(buf) {
+		st.SetError(fmt.Errorf("savestate: buffer size mismatch (got %d, want %d)", n, len(buf)))
+		return
+	}
+	st.rawBytes(buf)
+}
+
+// ByteSlice serializes a variable-size buffer, up to MaxSliceLen bytes. When
+// loading, the slice is reallocated with the size found in the stream. A nil
+// slice is preserved as nil.
+func (st *Stream) ByteSlice(buf *[]byte) {
+	n := int32(len(*buf))
+	if *buf == nil {
+		n = -1
+	}
+	st.sliceLen(&n)
+	if st.err != nil {
+		return
+	}
+	if st.Loading() {
+		if n < 0 {
+			*buf = nil
+			return
+		}
+		*buf = make([]byte, n)
+	}
+	st.rawBytes(*buf)
+}
+
+// Uint32Slice serializes a variable-size slice of 32-bit words, up to
+// MaxSliceLen words.
+func (st *Stream) Uint32Slice(buf *[]uint32) {
+	n := int32(len(*buf))
+	if *buf == nil {
+		n = -1
+	}
+	st.sliceLen(&n)
+	if st.err != nil {
+		return
+	}
+	if st.Loading() {
+		if n < 0 {
+			*buf = nil
+			return
+		}
+		*buf = make([]uint32, n)
+	}
+	for i := range *buf {
+		st.Uint32(&(*buf)[i])
+	}
+}
+
+func (st *Stream) rawBytes(buf []byte) {
+	if st.err != nil {
+		return
+	}
+	if st.Loading() {
+		if _, err := io.ReadFull(st.r, buf); err != nil {
+			st.SetError(err)
+		}
+	} else {
+		st.flush(buf)
+	}
+}
+
+// Value serializes a fixed-size value (as defined by encoding/binary),
+// such as a struct made of exported fixed-size fields, or an array
+// of them. v must be a pointer.
+func (st *Stream) Value(v interface{}) {
+	if st.err != nil {
+		return
+	}
+	var err error
+	if st.Loading() {
+		err = binary.Read(st.r, binary.LittleEndian, v)
+	} else {
+		err = binary.Write(st.w, binary.LittleEndian, v)
+	}
+	st.SetError(err)
+}
+
+// Section writes a named marker into the stream. When loading, the marker
+// is checked, so that a mismatch between the saving and loading code is
+// caught as soon as possible, and reported with a meaningful error.
+func (st *Stream) Section(name string) {
+	tag := []byte(name)
+	st.ByteSlice(&tag)
+	if st.err == nil && string(tag) != name {
+		st.SetError(fmt.Errorf("savestate: corrupted stream (section %q, found %q)", name, tag))
+	}
+}
+
+// Save writes a compressed savestate of obj into w, tagged with the
+// specified version number.
+func Save(w io.Writer, version uint32, obj Serializable) error {
+	gz := gzip.NewWriter(w)
+	st := &Stream{w: bufio.NewWriter(gz)}
+
+	magic := []byte(cMagic)
+	st.rawBytes(magic)
+	st.Uint32(&version)
+	obj.Serialize(st)
+
+	if st.err == nil {
+		st.SetError(st.w.Flush())
+	}
+	if st.err == nil {
+		st.SetError(gz.Close())
+	}
+	return st.err
+}
+
+// Load reads a savestate from r into obj. If the savestate was written with
+// a different version number, ErrVersion is returned and obj is not modified.
+// If any other error is returned, the state of obj is undefined.
+func Load(r io.Reader, version uint32, obj Serializable) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	st := &Stream{r: bufio.NewReader(gz)}
+
+	var magic [len(cMagic)]byte
+	st.rawBytes(magic[:])
+	if st.err != nil {
+		return st.err
+	}
+	if string(magic[:]) != cMagic {
+		return errors.New("savestate: invalid file format")
+	}
+
+	var ver uint32
+	st.Uint32(&ver)
+	if st.err != nil {
+		return st.err
+	}
+	if ver != version {
+		return ErrVersion
+	}
+
+	obj.Serialize(st)
+	if st.err != nil {
+		return &PartialError{st.err}
+	}
+	return nil
+}
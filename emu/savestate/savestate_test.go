@@ -0,0 +1,197 @@
+package savestate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+type testState struct {
+	A8   uint8
+	A16  uint16
+	A32  uint32
+	A64  int64
+	N    int
+	B    bool
+	Fix  [4]byte
+	Var  []byte
+	Nil  []byte
+	W    []uint32
+	Arr  [3]int32
+	Pair struct{ X, Y int16 }
+}
+
+func (s *testState) Serialize(st *Stream) {
+	st.Section("test")
+	st.Uint8(&s.A8)
+	st.Uint16(&s.A16)
+	st.Uint32(&s.A32)
+	st.Int64(&s.A64)
+	st.Int(&s.N)
+	st.Bool(&s.B)
+	st.Bytes(s.Fix[:])
+	st.ByteSlice(&s.Var)
+	st.ByteSlice(&s.Nil)
+	st.Uint32Slice(&s.W)
+	st.Value(&s.Arr)
+	st.Value(&s.Pair)
+}
+
+func TestRoundTrip(t *testing.T) {
+	s1 := &testState{
+		A8: 0x12, A16: 0x1234, A32: 0x12345678, A64: -5, N: -1234567, B: true,
+		Fix: [4]byte{1, 2, 3, 4},
+		Var: []byte("hello"),
+		W:   []uint32{1, 0xFFFFFFFF},
+		Arr: [3]int32{-1, 0, 1},
+	}
+	s1.Pair.X, s1.Pair.Y = -7, 7
+
+	var buf bytes.Buffer
+	if err := Save(&buf, 3, s1); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	s2 := new(testState)
+	if err := Load(bytes.NewReader(data), 3, s2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s1, s2) {
+		t.Errorf("invalid roundtrip:\n%+v\n%+v", s1, s2)
+	}
+
+	if err := Load(bytes.NewReader(data), 4, s2); err != ErrVersion {
+		t.Errorf("invalid version check: %v", err)
+	}
+}
+
+// saveRaw saves obj and returns the uncompressed savestate
+func saveRaw(t *testing.T, obj Serializable) []byte {
+	var buf bytes.Buffer
+	if err := Save(&buf, 3, obj); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// compress compresses a raw savestate, so that it can be loaded
+func compress(raw []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(raw)
+	gw.Close()
+	return buf.Bytes()
+}
+
+func TestLoadErrors(t *testing.T) {
+	// Truncate in the middle of the state
+	raw := saveRaw(t, &testState{Var: []byte("hello")})
+	trunc := compress(raw[:len(raw)/2])
+
+	var perr *PartialError
+	if err := Load(bytes.NewReader(trunc), 3, new(testState)); !errors.As(err, &perr) {
+		t.Errorf("truncated state: expected PartialError, got %v", err)
+	}
+	if err := Load(bytes.NewReader([]byte("garbage")), 3, new(testState)); err == nil || errors.As(err, &perr) {
+		t.Errorf("invalid file: expected non-partial error, got %v", err)
+	}
+}
+
+func TestLoadCorruptedLength(t *testing.T) {
+	raw := saveRaw(t, &testState{Var: []byte("hello"), W: []uint32{1, 0xFFFFFFFF}})
+	varLen := bytes.Index(raw, []byte("\x05\x00\x00\x00hello"))
+	wLen := bytes.Index(raw, []byte("\x02\x00\x00\x00\x01\x00\x00\x00\xFF\xFF\xFF\xFF"))
+	if varLen < 0 || wLen < 0 {
+		t.Fatal("slices not found in the savestate")
+	}
+
+	for _, tc := range []struct {
+		desc string
+		off  int
+		len  uint32
+	}{
+		{"byte slice, negative", varLen, 0xFFFFFFF0},
+		{"byte slice, too big", varLen, MaxSliceLen + 1},
+		{"byte slice, max int32", varLen, 0x7FFFFFFF},
+		{"byte slice, truncated", varLen, 0x1000},
+		{"uint32 slice, negative", wLen, 0x80000000},
+		{"uint32 slice, too big", wLen, MaxSliceLen + 1},
+	} {
+		data := append([]byte{}, raw...)
+		binary.LittleEndian.PutUint32(data[tc.off:], tc.len)
+
+		var perr *PartialError
+		if err := Load(bytes.NewReader(compress(data)), 3, new(testState)); !errors.As(err, &perr) {
+			t.Errorf("%s: expected PartialError, got %v", tc.desc, err)
+		}
+	}
+}
+
+type testLen struct {
+	N int
+}
+
+func (s *testLen) Serialize(st *Stream) {
+	st.Len(&s.N, 10)
+}
+
+func TestLen(t *testing.T) {
+	for _, tc := range []struct {
+		n  int
+		ok bool
+	}{
+		{0, true},
+		{10, true},
+		{-1, false},
+		{11, false},
+		{-1 << 62, false},
+	} {
+		// Save doesn't check the length, so invalid values can be written
+		var buf bytes.Buffer
+		if err := Save(&buf, 3, &testLen{tc.n}); err != nil {
+			t.Fatal(err)
+		}
+		s := new(testLen)
+		err := Load(&buf, 3, s)
+		if tc.ok && (err != nil || s.N != tc.n) {
+			t.Errorf("len %d: got %d, err %v", tc.n, s.N, err)
+		}
+		var perr *PartialError
+		if !tc.ok && !errors.As(err, &perr) {
+			t.Errorf("len %d: expected PartialError, got %v", tc.n, err)
+		}
+	}
+}
+
+func TestLoadGarbage(t *testing.T) {
+	// Random data after a valid header must be reported as an error,
+	// without panicking or allocating huge buffers.
+	header := saveRaw(t, &testState{})[:len(cMagic)+4]
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		data := make([]byte, rnd.Intn(256))
+		rnd.Read(data)
+		// Keep the section name valid, to reach the other fields
+		if i%2 == 0 {
+			data = append([]byte("\x04\x00\x00\x00test"), data...)
+		}
+		data = append(append([]byte{}, header...), data...)
+		if err := Load(bytes.NewReader(compress(data)), 3, new(testState)); err == nil {
+			t.Errorf("garbage #%d loaded without errors", i)
+		}
+	}
+}
@@ -0,0 +1,579 @@
+package script
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"ndsemu/emu/debugger"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Script is a parsed automation script, ready to be run by an Engine.
+//
+// Scripts contain one command per line; empty lines and lines beginning with
+// '#' are ignored. Commands are executed in sequence at the end of a frame,
+// until a command that needs to wait is found; the script is then resumed at
+// the end of a later frame. When the script is finished, the emulation goes
+// on normally. These are the available commands:
+//
+//	wait N                        wait for N frames
+//	wait pc CPU ADDR [TIMEOUT]    wait until the CPU executes ADDR
+//	wait mem CPU ADDR SIZE OP VAL [TIMEOUT]
+//	                              wait until the value in memory matches
+//	press KEY[+KEY...] [N]        hold the buttons for N frames (default: 1)
+//	touch X Y [N]                 touch the screen for N frames (default: 1)
+//	write CPU ADDR SIZE VAL       write a value into memory
+//	peek CPU ADDR SIZE            print a value from memory
+//	assert CPU ADDR SIZE OP VAL   fail unless the value in memory matches
+//	print TEXT                    print a message
+//	screenshot FILE               save a screenshot of the last frame
+//	save FILE                     save a savestate
+//	load FILE                     load a savestate
+//	exit [CODE]                   exit from the emulator (default code: 0)
+//	on frame CMD                  run CMD at the end of each frame
+//	on pc CPU ADDR CMD            run CMD when the CPU executes ADDR
+//	on read CPU RANGE CMD         run CMD when the CPU reads from RANGE
+//	on write CPU RANGE CMD        run CMD when the CPU writes to RANGE
+//
+// CPU is the name of a CPU (eg: "arm9"). ADDR is a number, or any other
+// syntax supported by Host.ResolveAddr (like symbol names); RANGE is either an
+// ADDR or two numbers separated by '-' (inclusive). SIZE is the size of the
+// memory access in bytes (1, 2 or 4). OP is one of "==", "!=", "<", "<=", ">",
+// ">=", or "&" (true if any of the bits of VAL is set). TIMEOUT is a number of
+// frames after which the wait fails (default: no timeout).
+//
+// Hooks installed with "on" can only run commands that complete immediately
+// (write, peek, assert, print and exit). Within the text of print, the
+// placeholders {frame}, {cpu}, {pc}, {addr} and {val} are replaced with the
+// current frame number and with the details of the event that triggered the
+// hook.
+//
+// A failing command (eg: assert, or a wait that timed out) stops the script
+// and makes the emulator exit with code 1.
+type Script struct {
+	cmds []*command
+}
+
+type command struct {
+	pos  string // Position in the script (file:line)
+	text string
+	hook bool // Can be used within hooks
+	exec func(ctx *hookCtx) (waiter, error)
+}
+
+// hookCtx describes the event that triggered a hook
+type hookCtx struct {
+	cpu       int
+	pc        uint32
+	addr, val uint32
+}
+
+// waiter is called at the end of each frame until it returns true
+type waiter func() (bool, error)
+
+// Load parses a script file and starts running it
+func (e *Engine) Load(fn string) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s, err := e.Parse(f, fn)
+	if err != nil {
+		return err
+	}
+	e.Run(s)
+	return nil
+}
+
+// Parse parses a script; name is used in error messages. Addresses and CPU
+// names are resolved at parse time, so symbols must already be loaded.
+func (e *Engine) Parse(r io.Reader, name string) (*Script, error) {
+	s := &Script{}
+	sc := bufio.NewScanner(r)
+	for nline := 1; sc.Scan(); nline++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", name, nline)
+		cmd, err := e.compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", pos, err)
+		}
+		cmd.pos = pos
+		s.cmds = append(s.cmds, cmd)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run starts running a script, starting from the end of the current frame.
+// It replaces the script being run, if any (hooks are not removed).
+func (e *Engine) Run(s *Script) {
+	e.run = &runner{e: e, s: s}
+}
+
+type runner struct {
+	e    *Engine
+	s    *Script
+	pc   int
+	wait waiter
+	cur  *command
+}
+
+func (r *runner) step() {
+	for !r.e.exited {
+		if r.wait != nil {
+			done, err := r.wait()
+			if err != nil {
+				r.e.fail(r.cur, err)
+				return
+			}
+			if !done {
+				return
+			}
+			r.wait = nil
+		}
+		if r.pc >= len(r.s.cmds) {
+			r.e.run = nil
+			return
+		}
+		r.cur = r.s.cmds[r.pc]
+		r.pc++
+
+		w, err := r.cur.exec(&hookCtx{cpu: -1})
+		if err != nil {
+			r.e.fail(r.cur, err)
+			return
+		}
+		r.wait = w
+	}
+}
+
+func (e *Engine) fail(cmd *command, err error) {
+	modScript.ErrorZ("script failed").
+		String("at", cmd.pos).
+		String("cmd", cmd.text).
+		Error("err", err).
+		End()
+	e.Exit(1)
+}
+
+// runHook runs a command from within a hook
+func (e *Engine) runHook(cmd *command, ctx *hookCtx) {
+	if e.exited {
+		return
+	}
+	if _, err := cmd.exec(ctx); err != nil {
+		e.fail(cmd, err)
+	}
+}
+
+func (e *Engine) parseAddr(cpu int, s string) (uint32, error) {
+	return e.host.ResolveAddr(e.names[cpu], s)
+}
+
+func (e *Engine) parseRange(cpu int, s string) (uint32, uint32, error) {
+	if strings.Contains(s, "-") {
+		return debugger.ParseWatchRange(s)
+	}
+	addr, err := e.parseAddr(cpu, s)
+	return addr, addr, err
+}
+
+func parseSize(s string) (int, error) {
+	switch s {
+	case "1", "2", "4":
+		return int(s[0] - '0'), nil
+	}
+	return 0, fmt.Errorf("invalid size %q", s)
+}
+
+func parseUint(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return uint32(v), nil
+}
+
+func parseInt(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return v, nil
+}
+
+// parseOptInt parses an optional trailing number
+func parseOptInt(args []string, idx int, def int) (int, error) {
+	if len(args) <= idx {
+		return def, nil
+	}
+	if len(args) > idx+1 {
+		return 0, errors.New("too many arguments")
+	}
+	return parseInt(args[idx])
+}
+
+// memArgs are the "CPU ADDR SIZE" arguments of memory commands
+type memArgs struct {
+	cpu  int
+	addr uint32
+	size int
+}
+
+func (e *Engine) parseMem(args []string) (m memArgs, err error) {
+	if len(args) < 3 {
+		return m, errors.New("missing arguments")
+	}
+	if m.cpu, err = e.CpuIndex(args[0]); err != nil {
+		return
+	}
+	if m.addr, err = e.parseAddr(m.cpu, args[1]); err != nil {
+		return
+	}
+	m.size, err = parseSize(args[2])
+	return
+}
+
+// memCond parses "CPU ADDR SIZE OP VAL" and returns a function that
+// evaluates the condition, returning also the current value.
+func (e *Engine) memCond(args []string) (func() (bool, uint32), error) {
+	if len(args) < 5 {
+		return nil, errors.New("missing arguments")
+	}
+	m, err := e.parseMem(args[:3])
+	if err != nil {
+		return nil, err
+	}
+	cond, err := debugger.ParseWatchCond(args[3] + " " + args[4])
+	if err != nil {
+		return nil, err
+	}
+	return func() (bool, uint32) {
+		val := e.Read(m.cpu, m.addr, m.size)
+		return cond.Match(val), val
+	}, nil
+}
+
+// timeout returns a function that fails after n frames (if not zero)
+func (e *Engine) timeout(n int) func() error {
+	end := e.frame + n
+	return func() error {
+		if n != 0 && e.frame >= end {
+			return fmt.Errorf("timeout after %d frames", n)
+		}
+		return nil
+	}
+}
+
+func (e *Engine) compile(line string) (*command, error) {
+	args := strings.Fields(line)
+	name, args := args[0], args[1:]
+	cmd := &command{text: line}
+
+	switch name {
+	case "wait":
+		if len(args) == 0 {
+			return nil, errors.New("missing arguments")
+		}
+		switch args[0] {
+		case "pc":
+			if len(args) < 3 {
+				return nil, errors.New("missing arguments")
+			}
+			cpu, err := e.CpuIndex(args[1])
+			if err != nil {
+				return nil, err
+			}
+			pc, err := e.parseAddr(cpu, args[2])
+			if err != nil {
+				return nil, err
+			}
+			tmo, err := parseOptInt(args, 3, 0)
+			if err != nil {
+				return nil, err
+			}
+			cmd.exec = func(*hookCtx) (waiter, error) {
+				hit := false
+				var h *Hook
+				h = e.OnExec(cpu, pc, func(int, uint32) {
+					hit = true
+					e.Remove(h)
+				})
+				expired := e.timeout(tmo)
+				return func() (bool, error) {
+					if hit {
+						return true, nil
+					}
+					if err := expired(); err != nil {
+						e.Remove(h)
+						return false, err
+					}
+					return false, nil
+				}, nil
+			}
+
+		case "mem":
+			match, err := e.memCond(args[1:])
+			if err != nil {
+				return nil, err
+			}
+			tmo, err := parseOptInt(args, 6, 0)
+			if err != nil {
+				return nil, err
+			}
+			cmd.exec = func(*hookCtx) (waiter, error) {
+				expired := e.timeout(tmo)
+				return func() (bool, error) {
+					if ok, _ := match(); ok {
+						return true, nil
+					}
+					return false, expired()
+				}, nil
+			}
+
+		default:
+			n, err := parseOptInt(args, 0, 0)
+			if err != nil {
+				return nil, err
+			}
+			cmd.exec = func(*hookCtx) (waiter, error) {
+				return e.waitFrames(n), nil
+			}
+		}
+
+	case "press":
+		if len(args) == 0 {
+			return nil, errors.New("missing arguments")
+		}
+		var in Input
+		for _, k := range strings.Split(args[0], "+") {
+			mask, err := e.host.KeyMask(k)
+			if err != nil {
+				return nil, err
+			}
+			in.Keys |= mask
+		}
+		n, err := parseOptInt(args, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			e.SetInput(in, n)
+			return e.waitFrames(n), nil
+		}
+
+	case "touch":
+		if len(args) < 2 {
+			return nil, errors.New("missing arguments")
+		}
+		x, err := parseInt(args[0])
+		if err != nil {
+			return nil, err
+		}
+		y, err := parseInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		n, err := parseOptInt(args, 2, 1)
+		if err != nil {
+			return nil, err
+		}
+		in := Input{PenDown: true, PenX: x, PenY: y}
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			e.SetInput(in, n)
+			return e.waitFrames(n), nil
+		}
+
+	case "write":
+		if len(args) != 4 {
+			return nil, errors.New("usage: write CPU ADDR SIZE VAL")
+		}
+		m, err := e.parseMem(args[:3])
+		if err != nil {
+			return nil, err
+		}
+		val, err := parseUint(args[3])
+		if err != nil {
+			return nil, err
+		}
+		cmd.hook = true
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			e.Write(m.cpu, m.addr, m.size, val)
+			return nil, nil
+		}
+
+	case "peek":
+		if len(args) != 3 {
+			return nil, errors.New("usage: peek CPU ADDR SIZE")
+		}
+		m, err := e.parseMem(args)
+		if err != nil {
+			return nil, err
+		}
+		cmd.hook = true
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			val := e.Read(m.cpu, m.addr, m.size)
+			fmt.Fprintf(e.Out, "%s %08x: %0*x\n", e.names[m.cpu], m.addr, m.size*2, val)
+			return nil, nil
+		}
+
+	case "assert":
+		if len(args) != 5 {
+			return nil, errors.New("usage: assert CPU ADDR SIZE OP VAL")
+		}
+		match, err := e.memCond(args)
+		if err != nil {
+			return nil, err
+		}
+		cmd.hook = true
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			if ok, val := match(); !ok {
+				return nil, fmt.Errorf("assertion failed (value: %#x)", val)
+			}
+			return nil, nil
+		}
+
+	case "print":
+		text := strings.TrimSpace(strings.TrimPrefix(line, name))
+		cmd.hook = true
+		cmd.exec = func(ctx *hookCtx) (waiter, error) {
+			fmt.Fprintln(e.Out, e.expand(text, ctx))
+			return nil, nil
+		}
+
+	case "screenshot", "save", "load":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("usage: %s FILE", name)
+		}
+		fn := args[0]
+		do := map[string]func(string) error{
+			"screenshot": e.Screenshot,
+			"save":       e.SaveState,
+			"load":       e.LoadState,
+		}[name]
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			return nil, do(fn)
+		}
+
+	case "exit":
+		code, err := parseOptInt(args, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		cmd.hook = true
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			e.Exit(code)
+			return nil, nil
+		}
+
+	case "on":
+		return e.compileHook(line, args)
+
+	default:
+		return nil, fmt.Errorf("unknown command %q", name)
+	}
+	return cmd, nil
+}
+
+func (e *Engine) waitFrames(n int) waiter {
+	end := e.frame + n
+	return func() (bool, error) {
+		return e.frame >= end, nil
+	}
+}
+
+// compileHook compiles the "on" command, which installs a hook that runs
+// another command.
+func (e *Engine) compileHook(line string, args []string) (*command, error) {
+	nargs := map[string]int{"frame": 1, "pc": 3, "read": 3, "write": 3}
+	if len(args) == 0 || nargs[args[0]] == 0 {
+		return nil, errors.New("usage: on frame|pc|read|write ... CMD")
+	}
+	n := nargs[args[0]]
+	if len(args) <= n {
+		return nil, errors.New("missing command")
+	}
+	hcmd, err := e.compile(strings.Join(args[n:], " "))
+	if err != nil {
+		return nil, err
+	}
+	if !hcmd.hook {
+		return nil, fmt.Errorf("command cannot be used in a hook: %q", hcmd.text)
+	}
+	cmd := &command{text: line}
+
+	if args[0] == "frame" {
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			hcmd.pos = cmd.pos
+			e.OnFrame(func(int) { e.runHook(hcmd, &hookCtx{cpu: -1}) })
+			return nil, nil
+		}
+		return cmd, nil
+	}
+
+	cpu, err := e.CpuIndex(args[1])
+	if err != nil {
+		return nil, err
+	}
+	switch args[0] {
+	case "pc":
+		pc, err := e.parseAddr(cpu, args[2])
+		if err != nil {
+			return nil, err
+		}
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			hcmd.pos = cmd.pos
+			e.OnExec(cpu, pc, func(cpu int, pc uint32) {
+				e.runHook(hcmd, &hookCtx{cpu: cpu, pc: pc, addr: pc})
+			})
+			return nil, nil
+		}
+	case "read", "write":
+		start, end, err := e.parseRange(cpu, args[2])
+		if err != nil {
+			return nil, err
+		}
+		write := args[0] == "write"
+		cmd.exec = func(*hookCtx) (waiter, error) {
+			hcmd.pos = cmd.pos
+			if write {
+				e.OnWrite(cpu, start, end, func(cpu int, addr, val uint32, size int) {
+					e.runHook(hcmd, &hookCtx{cpu: cpu, addr: addr, val: val})
+				})
+			} else {
+				e.OnRead(cpu, start, end, func(cpu int, addr uint32, size int) {
+					e.runHook(hcmd, &hookCtx{cpu: cpu, addr: addr})
+				})
+			}
+			return nil, nil
+		}
+	}
+	return cmd, nil
+}
+
+// expand replaces the placeholders in the text of print
+func (e *Engine) expand(text string, ctx *hookCtx) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+	cpu := ""
+	if ctx.cpu >= 0 {
+		cpu = e.names[ctx.cpu]
+	}
+	return strings.NewReplacer(
+		"{frame}", strconv.Itoa(e.frame),
+		"{cpu}", cpu,
+		"{pc}", fmt.Sprintf("%08x", ctx.pc),
+		"{addr}", fmt.Sprintf("%08x", ctx.addr),
+		"{val}", fmt.Sprintf("%x", ctx.val),
+	).Replace(text)
+}
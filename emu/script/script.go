@@ -0,0 +1,318 @@
+// Package script implements an automation layer for the emulator, to be used
+// for tool-assisted testing. The Engine exposes a Go API to install hooks
+// (end of frame, instruction execution, memory and I/O accesses) and to
+// control the emulation (memory access, input, screenshots and savestates);
+// on top of it, a simple line-based language allows to write automation
+// scripts without recompiling the emulator (see Parse).
+package script
+
+import (
+	"fmt"
+	"io"
+	"ndsemu/emu"
+	"ndsemu/emu/debugger"
+	log "ndsemu/emu/logger"
+	"os"
+)
+
+var modScript = log.NewModule("script")
+
+// Cpu is a CPU that can be controlled by the scripting engine. Hooks are
+// implemented by chaining a debugger to the CPU core.
+type Cpu interface {
+	SetDebugger(dbg debugger.CpuDebugger)
+	GetDebugger() debugger.CpuDebugger
+
+	// ReadMem/WriteMem access the memory through the CPU bus, without
+	// triggering watchpoints.
+	ReadMem(addr uint32, buf []byte)
+	WriteMem(addr uint32, buf []byte)
+}
+
+// Input is the input fed into the emulated system by a script
+type Input struct {
+	Keys       uint16
+	PenDown    bool
+	PenX, PenY int
+}
+
+// Host is implemented by the emulator to provide the functionalities that
+// are specific to the emulated system.
+type Host interface {
+	// ResolveAddr parses an address as seen by the specified CPU; it can be
+	// a number or a symbol name, but also any other syntax that makes
+	// sense for the system (eg: I/O register names).
+	ResolveAddr(cpu string, s string) (uint32, error)
+
+	// KeyMask returns the mask of the button with the specified name (as
+	// used in Input.Keys).
+	KeyMask(name string) (uint16, error)
+
+	// SetInput is called at the beginning of the frames in which the script
+	// is providing input, and once more at the end with an empty input.
+	// The host should combine it with the input coming from the user.
+	SetInput(in Input)
+
+	Screenshot(fn string) error
+	SaveState(fn string) error
+	LoadState(fn string) error
+}
+
+// Hook is an installed hook; it can be used to remove it.
+type Hook struct {
+	cpu        int
+	start, end uint32
+	exec       func(cpu int, pc uint32)
+	mem        func(cpu int, addr uint32, val uint32, size int)
+	frame      func(frame int)
+}
+
+// Engine runs scripts and hooks. All its methods must be called from the
+// emulation goroutine (including from within the hooks), between frames or
+// while the CPUs are running.
+type Engine struct {
+	Out io.Writer // Output of the print commands (default: stdout)
+
+	host  Host
+	cpus  []*cpuHooks
+	names []string
+	frame int
+
+	frameHooks  []*Hook
+	input       Input
+	inputFrames int
+	inputActive bool
+
+	run      *runner
+	exited   bool
+	exitCode int
+}
+
+// New creates a scripting engine for the specified CPUs; names are the names
+// used to refer to the CPUs in scripts.
+func New(host Host, cpus []Cpu, names []string) *Engine {
+	e := &Engine{
+		Out:   os.Stdout,
+		host:  host,
+		names: names,
+	}
+	for i, cpu := range cpus {
+		e.cpus = append(e.cpus, &cpuHooks{e: e, idx: i, cpu: cpu})
+	}
+	return e
+}
+
+// CpuIndex returns the index of the CPU with the specified name
+func (e *Engine) CpuIndex(name string) (int, error) {
+	for i, n := range e.names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown cpu %q", name)
+}
+
+// Frame returns the number of the last completed frame
+func (e *Engine) Frame() int {
+	return e.frame
+}
+
+// OnFrame installs a hook called at the end of each frame
+func (e *Engine) OnFrame(fn func(frame int)) *Hook {
+	h := &Hook{cpu: -1, frame: fn}
+	e.frameHooks = append(e.frameHooks, h)
+	return h
+}
+
+// OnExec installs a hook called before the CPU executes the instruction at
+// the specified address.
+func (e *Engine) OnExec(cpu int, pc uint32, fn func(cpu int, pc uint32)) *Hook {
+	h := &Hook{cpu: cpu, start: pc, end: pc, exec: fn}
+	c := e.cpus[cpu].install()
+	if c.exec == nil {
+		c.exec = make(map[uint32][]*Hook)
+	}
+	c.exec[pc] = append(c.exec[pc], h)
+	return h
+}
+
+// OnRead installs a hook called before the CPU reads from the specified
+// address range (inclusive). This includes I/O registers.
+func (e *Engine) OnRead(cpu int, start, end uint32, fn func(cpu int, addr uint32, size int)) *Hook {
+	h := &Hook{cpu: cpu, start: start, end: end, mem: func(cpu int, addr, _ uint32, size int) {
+		fn(cpu, addr, size)
+	}}
+	c := e.cpus[cpu].install()
+	c.reads = append(c.reads, h)
+	return h
+}
+
+// OnWrite installs a hook called before the CPU writes to the specified
+// address range (inclusive). This includes I/O registers.
+func (e *Engine) OnWrite(cpu int, start, end uint32, fn func(cpu int, addr uint32, val uint32, size int)) *Hook {
+	h := &Hook{cpu: cpu, start: start, end: end, mem: fn}
+	c := e.cpus[cpu].install()
+	c.writes = append(c.writes, h)
+	return h
+}
+
+func removeHook(hooks []*Hook, h *Hook) []*Hook {
+	// Always build a new slice, as the hook might be removed while the
+	// hooks are being invoked.
+	res := make([]*Hook, 0, len(hooks))
+	for _, h2 := range hooks {
+		if h2 != h {
+			res = append(res, h2)
+		}
+	}
+	return res
+}
+
+// Remove removes a hook
+func (e *Engine) Remove(h *Hook) {
+	switch {
+	case h.frame != nil:
+		e.frameHooks = removeHook(e.frameHooks, h)
+	case h.exec != nil:
+		c := e.cpus[h.cpu]
+		c.exec[h.start] = removeHook(c.exec[h.start], h)
+		if len(c.exec[h.start]) == 0 {
+			delete(c.exec, h.start)
+		}
+	default:
+		c := e.cpus[h.cpu]
+		c.reads = removeHook(c.reads, h)
+		c.writes = removeHook(c.writes, h)
+	}
+}
+
+// Read reads a value of the specified size (1, 2 or 4 bytes) from memory
+func (e *Engine) Read(cpu int, addr uint32, size int) uint32 {
+	var buf [4]byte
+	e.cpus[cpu].cpu.ReadMem(addr, buf[:size])
+	return emu.Read32LE(buf[:])
+}
+
+// Write writes a value of the specified size (1, 2 or 4 bytes) into memory
+func (e *Engine) Write(cpu int, addr uint32, size int, val uint32) {
+	var buf [4]byte
+	emu.Write32LE(buf[:], val)
+	e.cpus[cpu].cpu.WriteMem(addr, buf[:size])
+}
+
+// SetInput feeds the specified input for the next n frames
+func (e *Engine) SetInput(in Input, n int) {
+	e.input = in
+	e.inputFrames = n
+}
+
+// Screenshot saves the screen of the last completed frame
+func (e *Engine) Screenshot(fn string) error { return e.host.Screenshot(fn) }
+
+// SaveState saves the state of the emulator into a savestate file
+func (e *Engine) SaveState(fn string) error { return e.host.SaveState(fn) }
+
+// LoadState loads the state of the emulator from a savestate file
+func (e *Engine) LoadState(fn string) error { return e.host.LoadState(fn) }
+
+// Exit requests the emulator to exit with the specified exit code at the end
+// of the current frame.
+func (e *Engine) Exit(code int) {
+	if !e.exited {
+		e.exited, e.exitCode = true, code
+	}
+}
+
+// Exited returns true if the emulator must exit, with the exit code
+func (e *Engine) Exited() (int, bool) {
+	return e.exitCode, e.exited
+}
+
+// BeginFrame must be called by the emulator before running each frame
+func (e *Engine) BeginFrame() {
+	if e.inputFrames > 0 {
+		e.host.SetInput(e.input)
+		e.inputFrames--
+		e.inputActive = true
+	} else if e.inputActive {
+		e.host.SetInput(Input{})
+		e.inputActive = false
+	}
+}
+
+// EndFrame must be called by the emulator at the end of each frame (frame is
+// the number of frames run so far). It runs the frame hooks and then resumes
+// the script being run, if any.
+func (e *Engine) EndFrame(frame int) {
+	e.frame = frame
+	for _, h := range e.frameHooks {
+		h.frame(frame)
+	}
+	if e.run != nil && !e.exited {
+		e.run.step()
+	}
+}
+
+type cpuHooks struct {
+	e         *Engine
+	idx       int
+	cpu       Cpu
+	next      debugger.CpuDebugger
+	installed bool
+
+	exec   map[uint32][]*Hook
+	reads  []*Hook
+	writes []*Hook
+}
+
+// install chains the hooks to the CPU debugger. This is done only when the
+// first hook is installed, as it slows down the emulation.
+func (c *cpuHooks) install() *cpuHooks {
+	if !c.installed {
+		c.next = c.cpu.GetDebugger()
+		c.cpu.SetDebugger(c)
+		c.installed = true
+	}
+	return c
+}
+
+func (c *cpuHooks) Trace(pc uint32) {
+	if len(c.exec) != 0 {
+		for _, h := range c.exec[pc] {
+			h.exec(c.idx, pc)
+		}
+	}
+	if c.next != nil {
+		c.next.Trace(pc)
+	}
+}
+
+func (c *cpuHooks) watch(hooks []*Hook, addr uint32, val uint32, size int) {
+	for _, h := range hooks {
+		if addr <= h.end && addr+uint32(size)-1 >= h.start {
+			h.mem(c.idx, addr, val, size)
+		}
+	}
+}
+
+func (c *cpuHooks) WatchRead(addr uint32, size int) {
+	c.watch(c.reads, addr, 0, size)
+	if c.next != nil {
+		c.next.WatchRead(addr, size)
+	}
+}
+
+func (c *cpuHooks) WatchWrite(addr uint32, val uint32, size int) {
+	c.watch(c.writes, addr, val, size)
+	if c.next != nil {
+		c.next.WatchWrite(addr, val, size)
+	}
+}
+
+func (c *cpuHooks) Break(msg string) {
+	if c.next != nil {
+		c.next.Break(msg)
+	} else {
+		log.ModCpu.FatalZ("debug breakpoint, exiting").String("msg", msg).End()
+	}
+}
@@ -0,0 +1,181 @@
+package script
+
+import (
+	"bytes"
+	"fmt"
+	"ndsemu/emu/debugger"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testCpu struct {
+	mem [0x100]byte
+	dbg debugger.CpuDebugger
+}
+
+func (c *testCpu) SetDebugger(dbg debugger.CpuDebugger) { c.dbg = dbg }
+func (c *testCpu) GetDebugger() debugger.CpuDebugger    { return c.dbg }
+func (c *testCpu) ReadMem(addr uint32, buf []byte)      { copy(buf, c.mem[addr:]) }
+func (c *testCpu) WriteMem(addr uint32, buf []byte)     { copy(c.mem[addr:], buf) }
+
+// write simulates a write by the emulated CPU
+func (c *testCpu) write(addr uint32, val uint8) {
+	if c.dbg != nil {
+		c.dbg.WatchWrite(addr, uint32(val), 1)
+	}
+	c.mem[addr] = val
+}
+
+type testHost struct {
+	inputs []Input
+	shots  []string
+}
+
+func (h *testHost) ResolveAddr(cpu string, s string) (uint32, error) {
+	if s == "main" {
+		return 0x10, nil
+	}
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unknown symbol %q", s)
+	}
+	return uint32(v), nil
+}
+
+func (h *testHost) KeyMask(name string) (uint16, error) {
+	switch name {
+	case "A":
+		return 1, nil
+	case "B":
+		return 2, nil
+	}
+	return 0, fmt.Errorf("invalid key %q", name)
+}
+
+func (h *testHost) SetInput(in Input)          { h.inputs = append(h.inputs, in) }
+func (h *testHost) Screenshot(fn string) error { h.shots = append(h.shots, fn); return nil }
+func (h *testHost) SaveState(fn string) error  { return nil }
+func (h *testHost) LoadState(fn string) error  { return nil }
+
+func newTestEngine() (*Engine, []*testCpu, *testHost, *bytes.Buffer) {
+	cpus := []*testCpu{new(testCpu), new(testCpu)}
+	host := new(testHost)
+	e := New(host, []Cpu{cpus[0], cpus[1]}, []string{"arm9", "arm7"})
+	out := new(bytes.Buffer)
+	e.Out = out
+	return e, cpus, host, out
+}
+
+func TestScriptParseErrors(t *testing.T) {
+	e, _, _, _ := newTestEngine()
+	for _, src := range []string{
+		"foo",
+		"wait x",
+		"wait pc arm11 0x10",
+		"press C",
+		"write arm9 0x10 3 1",
+		"assert arm9 0x10 4 =~ 1",
+		"on frame wait 1",
+		"on pc arm9 nosym print hello",
+		"exit 1 2",
+	} {
+		if _, err := e.Parse(strings.NewReader(src), "test"); err == nil {
+			t.Errorf("%q: expected error", src)
+		}
+	}
+}
+
+func TestScriptRun(t *testing.T) {
+	e, cpus, host, out := newTestEngine()
+	src := `
+# comment
+on write arm7 0x20-0x2F print write {addr}={val} at {frame}
+press A+B 2
+screenshot shot.png
+wait mem arm7 0x20 1 == 5 10
+write arm9 main 4 0x12345678
+peek arm9 0x10 2
+wait pc arm9 0x40
+print done {frame}
+exit 3
+`
+	s, err := e.Parse(strings.NewReader(src), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Run(s)
+
+	frame := 0
+	runFrame := func() {
+		e.BeginFrame()
+		if frame == 4 {
+			cpus[1].write(0x21, 5)
+			cpus[1].write(0x20, 5)
+		}
+		if frame == 6 {
+			cpus[0].dbg.Trace(0x40)
+		}
+		frame++
+		e.EndFrame(frame)
+	}
+
+	for i := 0; i < 20; i++ {
+		if _, exited := e.Exited(); exited {
+			break
+		}
+		runFrame()
+	}
+
+	if code, exited := e.Exited(); !exited || code != 3 {
+		t.Fatalf("invalid exit: %v %d", exited, code)
+	}
+	if frame != 7 {
+		t.Errorf("script exited at frame %d", frame)
+	}
+	exp := []Input{{Keys: 3}, {Keys: 3}, {}}
+	if fmt.Sprint(host.inputs) != fmt.Sprint(exp) {
+		t.Errorf("invalid inputs: %v", host.inputs)
+	}
+	if len(host.shots) != 1 || host.shots[0] != "shot.png" {
+		t.Errorf("invalid screenshots: %v", host.shots)
+	}
+	if v := e.Read(0, 0x10, 4); v != 0x12345678 {
+		t.Errorf("invalid memory value: %08x", v)
+	}
+	expout := "write 00000021=5 at 4\nwrite 00000020=5 at 4\narm9 00000010: 5678\ndone 7\n"
+	if out.String() != expout {
+		t.Errorf("invalid output:\n%s", out.String())
+	}
+}
+
+func TestScriptAssert(t *testing.T) {
+	e, _, _, _ := newTestEngine()
+	s, err := e.Parse(strings.NewReader("write arm9 0x10 1 7\nassert arm9 0x10 1 & 8\nexit 0"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Run(s)
+	e.EndFrame(1)
+	if code, exited := e.Exited(); !exited || code != 1 {
+		t.Errorf("assert did not fail: %v %d", exited, code)
+	}
+}
+
+func TestScriptTimeout(t *testing.T) {
+	e, _, _, _ := newTestEngine()
+	s, err := e.Parse(strings.NewReader("wait pc arm7 0x40 3"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Run(s)
+	for f := 1; f <= 4; f++ {
+		if _, exited := e.Exited(); exited {
+			t.Fatalf("timeout expired too early (frame %d)", f)
+		}
+		e.EndFrame(f)
+	}
+	if code, exited := e.Exited(); !exited || code != 1 {
+		t.Errorf("wait did not time out: %v %d", exited, code)
+	}
+}
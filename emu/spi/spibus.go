@@ -3,6 +3,7 @@ package spi
 import (
 	"fmt"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 type Bus struct {
@@ -117,3 +118,25 @@ func (spi *Bus) Reset() {
 		spi.tdev = nil
 	}
 }
+
+// Serialize saves or loads the status of the current transfer. The state of
+// the devices themselves is not included, as they are owned by the caller.
+func (spi *Bus) Serialize(st *savestate.Stream) {
+	st.Section("spi")
+	taddr := -1
+	for addr, dev := range spi.devs {
+		if spi.tdev != nil && dev == spi.tdev {
+			taddr = addr
+		}
+	}
+	st.Int(&taddr)
+	st.ByteSlice(&spi.req)
+	st.ByteSlice(&spi.reply)
+
+	if st.Loading() {
+		spi.tdev = nil
+		if taddr >= 0 {
+			spi.tdev = spi.devs[taddr]
+		}
+	}
+}
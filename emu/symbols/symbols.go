@@ -0,0 +1,262 @@
+// Package symbols loads symbol tables for the emulated programs, so that
+// addresses can be shown as function+offset in the debugging tools.
+//
+// Symbols can be loaded from ELF files (homebrew builds), or from text files
+// produced by linkers and reverse-engineering tools:
+//
+//	02000000 main                 ; no$gba .sym
+//	0x02000000  main              ; GNU ld .map
+package symbols
+
+import (
+	"bufio"
+	"bytes"
+	"debug/dwarf"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Symbol struct {
+	Addr uint32
+	Size uint32 // 0 if unknown (the symbol extends up to the next one)
+	Name string
+}
+
+// Table is a list of symbols sorted by address
+type Table struct {
+	syms   []Symbol
+	byName map[string]uint32
+}
+
+// New creates a table containing the specified symbols
+func New(syms []Symbol) *Table {
+	t := &Table{byName: make(map[string]uint32)}
+	for _, s := range syms {
+		if s.Name == "" {
+			continue
+		}
+		if _, found := t.byName[s.Name]; !found {
+			t.byName[s.Name] = s.Addr
+		}
+		t.syms = append(t.syms, s)
+	}
+
+	// On duplicates, keep the first symbol with a known size
+	sort.SliceStable(t.syms, func(i, j int) bool {
+		if t.syms[i].Addr != t.syms[j].Addr {
+			return t.syms[i].Addr < t.syms[j].Addr
+		}
+		return t.syms[i].Size != 0 && t.syms[j].Size == 0
+	})
+	uniq := t.syms[:0]
+	for i, s := range t.syms {
+		if i == 0 || s.Addr != t.syms[i-1].Addr {
+			uniq = append(uniq, s)
+		}
+	}
+	t.syms = uniq
+	return t
+}
+
+// Load loads a symbol table from a file, which can be either an ELF file or
+// a text file (.sym or .map).
+func Load(fn string) (*Table, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var t *Table
+	if bytes.HasPrefix(data, []byte(elf.ELFMAG)) {
+		t, err = loadElf(bytes.NewReader(data))
+	} else {
+		t, err = parseText(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn, err)
+	}
+	return t, nil
+}
+
+func loadElf(r io.ReaderAt) (*Table, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var syms []Symbol
+	esyms, err := f.Symbols()
+	if err != nil && err != elf.ErrNoSymbols {
+		return nil, err
+	}
+	for _, s := range esyms {
+		if s.Section == elf.SHN_UNDEF || s.Section >= elf.SHN_LORESERVE {
+			continue
+		}
+		// Skip ARM mapping symbols ($a, $t, $d)
+		if strings.HasPrefix(s.Name, "$") {
+			continue
+		}
+		addr := uint32(s.Value)
+		switch elf.ST_TYPE(s.Info) {
+		case elf.STT_FUNC:
+			// Thumb functions have bit 0 set
+			addr &^= 1
+		case elf.STT_OBJECT, elf.STT_NOTYPE:
+		default:
+			continue
+		}
+		syms = append(syms, Symbol{Addr: addr, Size: uint32(s.Size), Name: s.Name})
+	}
+
+	// Stripped binaries might still have debug info
+	if len(syms) == 0 {
+		if dw, err := f.DWARF(); err == nil {
+			syms = dwarfFuncs(dw)
+		}
+	}
+	return New(syms), nil
+}
+
+// dwarfFuncs extracts the functions described in the DWARF debug info
+func dwarfFuncs(dw *dwarf.Data) []Symbol {
+	var syms []Symbol
+	r := dw.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil || e == nil {
+			break
+		}
+		if e.Tag != dwarf.TagSubprogram {
+			continue
+		}
+		name, _ := e.Val(dwarf.AttrName).(string)
+		low, ok := e.Val(dwarf.AttrLowpc).(uint64)
+		if name == "" || !ok {
+			continue
+		}
+		size := uint64(0)
+		switch high := e.Val(dwarf.AttrHighpc).(type) {
+		case uint64:
+			size = high - low
+		case int64:
+			// DWARF4: high_pc is an offset from low_pc
+			size = uint64(high)
+		}
+		syms = append(syms, Symbol{Addr: uint32(low) &^ 1, Size: uint32(size), Name: name})
+	}
+	return syms
+}
+
+// parseText parses a text symbol file. Each line is made of an address
+// (hexadecimal, with or without 0x) followed by the symbol name; other lines
+// (like the section summaries in GNU ld map files) are ignored.
+func parseText(r io.Reader) (*Table, error) {
+	var syms []Symbol
+	scan := bufio.NewScanner(r)
+	for scan.Scan() {
+		line := scan.Text()
+		if idx := strings.IndexAny(line, ";#"); idx >= 0 {
+			line = line[:idx]
+		}
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			continue
+		}
+		addr, err := strconv.ParseUint(strings.TrimPrefix(f[0], "0x"), 16, 32)
+		if err != nil || !isIdent(f[1]) {
+			continue
+		}
+		syms = append(syms, Symbol{Addr: uint32(addr), Name: f[1]})
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+	return New(syms), nil
+}
+
+func isIdent(s string) bool {
+	for i, c := range s {
+		switch {
+		case c == '_' || c == '.' || c == '$' || c == ':' || c == '@':
+			// no$gba uses names starting with a dot for directives
+			// (.arm, .thumb, .byt:NNNN, etc.)
+			if i == 0 && c == '.' {
+				return false
+			}
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Len returns the number of symbols in the table
+func (t *Table) Len() int {
+	return len(t.syms)
+}
+
+// Lookup returns the symbol containing the specified address, and the offset
+// of the address within it. Symbols of unknown size are assumed to extend up
+// to the next symbol, within the same 16MB memory area.
+func (t *Table) Lookup(addr uint32) (*Symbol, uint32, bool) {
+	if t == nil {
+		return nil, 0, false
+	}
+	idx := sort.Search(len(t.syms), func(i int) bool {
+		return t.syms[i].Addr > addr
+	}) - 1
+	if idx < 0 {
+		return nil, 0, false
+	}
+	s := &t.syms[idx]
+	off := addr - s.Addr
+	if s.Size != 0 && off >= s.Size {
+		return nil, 0, false
+	}
+	if s.Size == 0 && s.Addr>>24 != addr>>24 {
+		return nil, 0, false
+	}
+	return s, off, true
+}
+
+// Format returns the address as "symbol+offset", or an empty string if no
+// symbol contains it.
+func (t *Table) Format(addr uint32) string {
+	s, off, ok := t.Lookup(addr)
+	if !ok {
+		return ""
+	}
+	if off == 0 {
+		return s.Name
+	}
+	return s.Name + "+0x" + strconv.FormatUint(uint64(off), 16)
+}
+
+// Resolve returns the address of a symbol. An offset can be specified after
+// the name (eg: "main+0x10").
+func (t *Table) Resolve(name string) (uint32, bool) {
+	if t == nil {
+		return 0, false
+	}
+	var off uint64
+	if idx := strings.LastIndexByte(name, '+'); idx >= 0 {
+		var err error
+		if off, err = strconv.ParseUint(name[idx+1:], 0, 32); err != nil {
+			return 0, false
+		}
+		name = name[:idx]
+	}
+	addr, found := t.byName[name]
+	return addr + uint32(off), found
+}
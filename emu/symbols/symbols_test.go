@@ -0,0 +1,71 @@
+package symbols
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseText(t *testing.T) {
+	// Mix of no$gba .sym and GNU ld .map syntax
+	data := `
+; no$gba symbols
+02000000 _start
+02000000 .arm
+02000100 main
+02000180 .byt:0010
+ .text          0x02000200       0x44 build/foo.o
+                0x02000200                foo_init
+                0x02000240                __end = .
+0x037F8000 arm7_main
+`
+	tab, err := parseText(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tab.Len() != 4 {
+		t.Errorf("invalid number of symbols: %d", tab.Len())
+	}
+
+	tests := []struct {
+		addr uint32
+		want string
+	}{
+		{0x02000000, "_start"},
+		{0x02000010, "_start+0x10"},
+		{0x02000104, "main+0x4"},
+		{0x02000200, "foo_init"},
+		{0x02100000, "foo_init+0xffe00"},
+		{0x01FFFFFF, ""},
+		{0x03000000, ""},
+		{0x037F8002, "arm7_main+0x2"},
+	}
+	for _, tt := range tests {
+		if got := tab.Format(tt.addr); got != tt.want {
+			t.Errorf("Format(%08x) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+
+	if addr, ok := tab.Resolve("main+0x10"); !ok || addr != 0x02000110 {
+		t.Errorf("invalid resolve: %08x %v", addr, ok)
+	}
+	if _, ok := tab.Resolve("missing"); ok {
+		t.Errorf("resolved missing symbol")
+	}
+}
+
+func TestLookupSize(t *testing.T) {
+	tab := New([]Symbol{
+		{Addr: 0x1000, Size: 0x10, Name: "a"},
+		{Addr: 0x1000, Name: "a_alias"},
+		{Addr: 0x1100, Size: 0x8, Name: "b"},
+	})
+	if got := tab.Format(0x100C); got != "a+0xc" {
+		t.Errorf("invalid lookup: %q", got)
+	}
+	if got := tab.Format(0x1010); got != "" {
+		t.Errorf("lookup outside of symbol: %q", got)
+	}
+	if addr, _ := tab.Resolve("a_alias"); addr != 0x1000 {
+		t.Errorf("invalid alias address: %08x", addr)
+	}
+}
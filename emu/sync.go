@@ -7,6 +7,8 @@ import (
 
 	"ndsemu/emu/fixed"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
+	"ndsemu/emu/symbols"
 )
 
 // A non-CPU subsystem is a frequency-based emulation component. It can be
@@ -67,6 +69,7 @@ type syncSubsystem struct {
 	Subsystem
 	scaler fixed.F8
 	name   string
+	syms   *symbols.Table
 }
 
 func (s syncSubsystem) Cycles() int64 {
@@ -141,6 +144,10 @@ type syncEvent struct {
 	Cb   func()
 }
 
+// Maximum number of pending events found in a savestate; anything bigger
+// means that the savestate is corrupted.
+const cMaxSavedEvents = 1 << 16
+
 type Sync struct {
 	cfg         *SyncConfig
 	mainClock   fixed.F8
@@ -232,6 +239,16 @@ func (s *Sync) AddCpu(cpu Cpu, name string) {
 	})
 }
 
+// SetSymbols sets the symbol table used to show the function being executed
+// by the specified CPU in log context.
+func (s *Sync) SetSymbols(name string, syms *symbols.Table) {
+	for i := range s.subCpus {
+		if s.subCpus[i].name == name {
+			s.subCpus[i].syms = syms
+		}
+	}
+}
+
 func (s *Sync) AddSubsystem(sub Subsystem, name string) {
 	s.subOthers = append(s.subOthers, syncSubsystem{
 		Subsystem: sub,
@@ -439,9 +456,55 @@ func (s *Sync) AddLogContext(entry *log.EntryZ) {
 	entry.Int64("_frame", s.frames)
 	if cur := s.runningSub; cur != nil {
 		if cpu, ok := cur.Subsystem.(Cpu); ok {
-			entry.Hex32("pc-"+cur.name, cpu.GetPC())
+			pc := cpu.GetPC()
+			entry.Hex32("pc-"+cur.name, pc)
+			if fn := cur.syms.Format(pc); fn != "" {
+				entry.String("fn-"+cur.name, fn)
+			}
 		} else {
 			entry.String("sub", fmt.Sprintf("%T", cur.Subsystem))
 		}
 	}
 }
+
+// Serialize saves or loads the current timing state and the pending sync
+// points. It must be called at frame boundary (outside of RunOneFrame).
+//
+// Events with a callback cannot be serialized, so they are not saved:
+// subsystems that schedule them are responsible for tracking their own
+// pending events and scheduling them again after a load.
+func (s *Sync) Serialize(st *savestate.Stream) {
+	if s.runningSub != nil {
+		st.SetError(errors.New("sync: cannot serialize while running"))
+		return
+	}
+
+	st.Section("sync")
+	st.Int64(&s.cycles)
+	st.Int64(&s.frames)
+
+	var whens []int64
+	for _, ev := range s.events {
+		if ev.Cb == nil {
+			whens = append(whens, ev.When)
+		}
+	}
+	n := len(whens)
+	st.Len(&n, cMaxSavedEvents)
+	if st.Err() != nil {
+		return
+	}
+	if st.Loading() {
+		whens = make([]int64, n)
+	}
+	for i := range whens {
+		st.Int64(&whens[i])
+	}
+
+	if st.Loading() {
+		s.events = s.events[:0]
+		for _, when := range whens {
+			s.events = append(s.events, syncEvent{When: when})
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package emu
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
 	"reflect"
 	"testing"
 
 	"ndsemu/emu/fixed"
+	"ndsemu/emu/savestate"
 )
 
 type testSubsystem struct {
@@ -90,3 +96,51 @@ func TestHVSyncs(t *testing.T) {
 		t.Errorf("wrong sub targets: got:%v, want:%v", tsub.targets, expTargets)
 	}
 }
+
+func TestSerializeCorrupted(t *testing.T) {
+	sync, err := NewSync(&SyncConfig{MainClock: 200, DotClockDivider: 2, HDots: 10, VDots: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sync.ScheduleSync(1000)
+
+	var buf bytes.Buffer
+	if err := savestate.Save(&buf, 1, sync); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The state ends with the number of pending events, followed by the
+	// (single) event
+	for _, tc := range []struct {
+		desc string
+		n    int64
+	}{
+		{"valid", 1},
+		{"negative", -1},
+		{"huge", 1 << 40},
+		{"truncated", 2},
+	} {
+		data := append([]byte{}, raw...)
+		binary.LittleEndian.PutUint64(data[len(data)-16:], uint64(tc.n))
+		var zbuf bytes.Buffer
+		gw := gzip.NewWriter(&zbuf)
+		gw.Write(data)
+		gw.Close()
+
+		err := savestate.Load(&zbuf, 1, sync)
+		var perr *savestate.PartialError
+		if tc.n == 1 && err != nil {
+			t.Errorf("%s: %v", tc.desc, err)
+		} else if tc.n != 1 && !errors.As(err, &perr) {
+			t.Errorf("%s: expected PartialError, got %v", tc.desc, err)
+		}
+	}
+}
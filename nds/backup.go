@@ -1,7 +1,8 @@
-package main
+package nds
 
 import (
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 	"ndsemu/emu/spi"
 	"os"
 
@@ -211,3 +212,24 @@ func (b *HwBackupRam) SpiEnd() {
 	modBackup.InfoZ("end transfer").End()
 	b.sram.Flush()
 }
+
+// Serialize saves or loads the backup state, including the memory contents.
+// When loading, the save file is overwritten with the contents found in
+// the state.
+func (b *HwBackupRam) Serialize(st *savestate.Stream) {
+	st.Section("backup")
+	st.Int(&b.addrSize)
+	st.Int(&b.addr)
+	st.ByteSlice(&b.wbuf)
+	st.Bool(&b.writeEnabled)
+	st.Bool(&b.autodetect)
+	st.Bool(&b.auxCntrWritten)
+
+	sram := []byte(b.sram)
+	st.ByteSlice(&sram)
+	if st.Loading() && st.Err() == nil && len(sram) > 0 {
+		b.checkSize(len(sram) - 1)
+		copy(b.sram, sram)
+		b.sram.Flush()
+	}
+}
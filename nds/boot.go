@@ -0,0 +1,542 @@
+package nds
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"ndsemu/e2d"
+	"ndsemu/emu/debugger"
+	log "ndsemu/emu/logger"
+	"ndsemu/homebrew"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/debug"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+)
+
+type CpuNum int
+
+const (
+	CpuNds9 CpuNum = 0
+	CpuNds7 CpuNum = 1
+)
+
+/*
+ * NDS9: ARM946E-S, architecture ARMv5TE, 66Mhz
+ * NDS7: ARM7TDMI, architecture ARMv4T, 33Mhz
+ *
+ */
+
+const cFirmwareDefault = "bios/firmware.bin"
+
+var (
+	nds7 *NDS7
+	nds9 *NDS9
+
+	// Functions called by Shutdown, in reverse order
+	shutdownFuncs []func()
+)
+
+func init() {
+	// For now, disable GC during JIT. This is required because the Go runtime
+	// doesn't like generated code on the Go stack without a stackmap.
+	// Use a hack because we need to do this before any goroutine is started
+	for _, arg := range os.Args {
+		if arg == "-jit" || arg == "-jit=true" {
+			debug.SetGCPercent(-1)
+		}
+	}
+}
+
+// Config holds the options shared by all frontends, that describe how the
+// emulator is booted. It is usually filled from the command line (see
+// AddFlags).
+type Config struct {
+	Roms      []string // NDS ROM (optionally followed by a GBA ROM), homebrew ROM or GBA ROM
+	SkipBios  bool     // skip the BIOS, and directly boot the game
+	Jit       bool     // use the JIT
+	HleBios   bool     // use the HLE BIOS even if the BIOS dumps are available
+	Firmware  string   // firmware dump (relative to the executable, if not absolute)
+	FwSave    string   // writable copy of the firmware (default: firmware dump + .sav)
+	FwProfile string   // TOML profile used to synthesize the firmware
+	Save      string   // backup memory file (default: ROM name + .sav)
+	HbrewFat  string   // FAT image to be mounted for homebrew ROM
+	Cheats    string   // cheat file (default: ROM name + .cht, if it exists)
+	State     string   // savestate file (default: ROM name + .state)
+	Load      bool     // load the savestate at startup
+	Record    string   // record input into this movie file
+	Play      string   // play back input from this movie file
+	Sym9      string   // ARM9 symbol file
+	Sym7      string   // ARM7 symbol file
+	Debug     bool     // run with the debugger
+	Gdb       string   // address of the GDB server
+	Trace     int      // number of instructions recorded by the tracer
+	TraceOut  string   // prefix of the instruction trace files
+	TraceBin  bool     // dump the instruction trace in binary format
+	GuestProf string   // prefix of the guest profile files
+	Script    string   // automation script
+	CpuProf   string   // host CPU profile file
+	Logging   string   // comma-separated list of modules to log
+	LogBin    string   // binary log file
+}
+
+// AddFlags registers the command line flags that fill in the configuration.
+// ROMs are not handled, as they are positional arguments.
+func (cfg *Config) AddFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&cfg.SkipBios, "s", false, "skip bios and run immediately")
+	fs.BoolVar(&cfg.Jit, "jit", false, "use JIT for emulation (unstable, eats memory)")
+	fs.BoolVar(&cfg.HleBios, "hle-bios", false, "use HLE BIOS even if BIOS dumps are available (requires -s)")
+	fs.StringVar(&cfg.Firmware, "firmware", cFirmwareDefault, "specify the firwmare file to use")
+	fs.StringVar(&cfg.FwSave, "firmware-save", "", "writable copy of the firmware, created at first boot (default: firmware file + .sav)")
+	fs.StringVar(&cfg.FwProfile, "fw-profile", "", "synthesize the firmware from the specified TOML profile (requires -s)")
+	fs.StringVar(&cfg.Save, "save", "", "backup memory file for the NDS ROM (default: ROM name + .sav)")
+	fs.StringVar(&cfg.HbrewFat, "homebrew-fat", "", "FAT image to be mounted for homebrew ROM")
+	fs.StringVar(&cfg.Cheats, "cheats", "", "cheat file for the NDS ROM (default: ROM name + .cht, if it exists)")
+	fs.StringVar(&cfg.State, "state", "", "savestate file (default: ROM name + .state); F5 saves, F7 loads")
+	fs.BoolVar(&cfg.Load, "load", false, "load the savestate at startup")
+	fs.StringVar(&cfg.Record, "record", "", "record input into the specified movie file")
+	fs.StringVar(&cfg.Play, "play", "", "play back input from the specified movie file")
+	fs.StringVar(&cfg.Sym9, "sym9", "", "load ARM9 symbols from the specified ELF, .sym or .map file")
+	fs.StringVar(&cfg.Sym7, "sym7", "", "load ARM7 symbols from the specified ELF, .sym or .map file")
+	fs.BoolVar(&cfg.Debug, "debug", false, "run with debugger")
+	fs.StringVar(&cfg.Gdb, "gdb", "", "run a GDB server on the specified address (eg: localhost:2345)")
+	fs.IntVar(&cfg.Trace, "trace", 0, "record the last N instructions of each CPU (dumped on crash, F9 and Ctrl-C)")
+	fs.StringVar(&cfg.TraceOut, "trace-out", "trace", "prefix of the instruction trace files")
+	fs.BoolVar(&cfg.TraceBin, "trace-bin", false, "dump the instruction trace in binary format")
+	fs.StringVar(&cfg.GuestProf, "guest-profile", "", "profile the guest code, writing pprof files with the specified prefix at exit (F8 writes them immediately)")
+	fs.StringVar(&cfg.Script, "script", "", "run the specified automation script (see emu/script)")
+	fs.StringVar(&cfg.CpuProf, "cpuprofile", "", "write cpu profile to file")
+	fs.StringVar(&cfg.Logging, "log", "", "enable logging for specified modules")
+	fs.StringVar(&cfg.LogBin, "log-bin", "", "write the log in binary format into the specified file (query it with emu/logger/ndslog)")
+}
+
+// Boot creates the emulator (Emu) and prepares it to run, according to the
+// configuration. If a movie is being recorded or played back, it is
+// returned. Shutdown must be called before exiting, to flush all the
+// output files.
+func Boot(cfg *Config) (*Movie, error) {
+	// Check whether there is a local firmware copy, otherwise
+	// create one (to handle read/write)
+	if cfg.Firmware[0] != '/' {
+		bindir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
+		cfg.Firmware = filepath.Join(bindir, cfg.Firmware)
+	}
+
+	// If there is no firmware dump (or a profile is explicitly requested),
+	// synthesize a firmware image with the user settings, which is enough
+	// for direct boot.
+	var fwimage []byte
+	if _, err := os.Stat(cfg.Firmware); err != nil || cfg.FwProfile != "" {
+		profile := DefaultFirmwareProfile()
+		if cfg.FwProfile != "" {
+			if profile, err = LoadFirmwareProfile(cfg.FwProfile); err != nil {
+				return nil, fmt.Errorf("cannot load firmware profile: %v", err)
+			}
+		} else {
+			log.ModEmu.WarnZ("firmware not found, using a synthesized image (only direct boot is supported)").Error("err", err).End()
+		}
+		if fwimage, err = SynthesizeFirmware(profile); err != nil {
+			return nil, fmt.Errorf("cannot synthesize firmware: %v", err)
+		}
+	}
+
+	firstboot := fwimage != nil
+	fwsav := cfg.FwSave
+	if fwsav == "" {
+		fwsav = cfg.Firmware + ".sav"
+	}
+	if _, err := os.Stat(fwsav); err != nil && fwimage == nil {
+		fw, err := os.ReadFile(cfg.Firmware)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load firwmare: %v", err)
+		}
+		err = os.WriteFile(fwsav, fw, 0777)
+		if err != nil {
+			return nil, fmt.Errorf("cannot save firwmare: %v", err)
+		}
+		firstboot = true
+	}
+
+	Emu = NewNDSEmulator(fwsav, cfg.Jit, cfg.HleBios)
+
+	if err := loadRoms(cfg.Roms, cfg.Save, cfg.HbrewFat); err != nil {
+		return nil, err
+	}
+
+	// Cheats are stored next to the ROM, like the backup memory
+	if cfg.Cheats == "" && len(cfg.Roms) > 0 {
+		if _, err := os.Stat(cfg.Roms[0] + ".cht"); err == nil {
+			cfg.Cheats = cfg.Roms[0] + ".cht"
+		}
+	}
+	if cfg.Cheats != "" {
+		if err := Emu.LoadCheats(cfg.Cheats); err != nil {
+			return nil, fmt.Errorf("cannot load cheats: %v", err)
+		}
+	}
+
+	if fwimage != nil {
+		Emu.Hw.Ff.MapFirmwareImage(fwimage)
+	} else if err := Emu.Hw.Ff.MapFirmwareFile(fwsav); err != nil {
+		return nil, err
+	}
+	if firstboot {
+		Emu.Hw.Rtc.ResetDefaults()
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		// The dumps are done by the emulation goroutine at the end of the
+		// frame, as the CPUs must not be running (see RunOneFrame).
+		<-c
+		atomic.StoreInt32(&Emu.interrupted, 1)
+
+		// If the emulation is not running (eg: stopped by GDB), a second
+		// Ctrl-C exits immediately.
+		<-c
+		log.ModEmu.WarnZ("interrupted, exiting without dumps").End()
+		os.Exit(1)
+	}()
+
+	if cfg.SkipBios {
+		if err := skipBios(); err != nil {
+			return nil, err
+		}
+	} else if Emu.Rom.Hle {
+		log.ModEmu.WarnZ("HLE BIOS cannot boot the system, use -s to directly boot the game").End()
+	} else if fwimage != nil {
+		log.ModEmu.WarnZ("synthesized firmware cannot boot the system, use -s to directly boot the game").End()
+	}
+
+	if cfg.State == "" {
+		cfg.State = "ndsemu.state"
+		if len(cfg.Roms) > 0 {
+			cfg.State = cfg.Roms[0] + ".state"
+		}
+	}
+	if cfg.Load {
+		if cfg.Play != "" {
+			return nil, errors.New("-load cannot be used with -play")
+		}
+		if err := Emu.LoadState(cfg.State); err != nil {
+			return nil, fmt.Errorf("cannot load savestate: %v", err)
+		}
+	}
+
+	var movie *Movie
+	if cfg.Record != "" && cfg.Play != "" {
+		return nil, errors.New("-record and -play are mutually exclusive")
+	}
+	if cfg.Record != "" {
+		var err error
+		if movie, err = RecordMovie(cfg.Record, Emu, cfg.Load); err != nil {
+			return nil, fmt.Errorf("cannot record movie: %v", err)
+		}
+		atShutdown(func() { movie.Close() })
+	}
+	if cfg.Play != "" {
+		var err error
+		if movie, err = PlayMovie(cfg.Play, Emu); err != nil {
+			return nil, fmt.Errorf("cannot play movie: %v", err)
+		}
+		atShutdown(func() { movie.Close() })
+	}
+
+	for cpu, fn := range map[string]string{"arm9": cfg.Sym9, "arm7": cfg.Sym7} {
+		if fn != "" {
+			if err := Emu.LoadSymbols(cpu, fn); err != nil {
+				return nil, fmt.Errorf("cannot load symbols: %v", err)
+			}
+		}
+	}
+
+	if cfg.Debug {
+		Emu.StartDebugger()
+	} else if cfg.Gdb != "" {
+		if cfg.Jit {
+			log.ModEmu.WarnZ("breakpoints are not reliable with JIT").End()
+		}
+		if err := Emu.StartGdbServer(cfg.Gdb); err != nil {
+			return nil, fmt.Errorf("cannot start GDB server: %v", err)
+		}
+	}
+	if cfg.Trace > 0 {
+		Emu.StartTracer(debugger.TraceConfig{
+			Size:   cfg.Trace,
+			Out:    cfg.TraceOut,
+			Binary: cfg.TraceBin,
+		})
+	}
+	if cfg.GuestProf != "" {
+		if cfg.Jit {
+			log.ModEmu.WarnZ("guest profile is not accurate with JIT").End()
+		}
+		Emu.StartProfiler(cfg.GuestProf)
+		atShutdown(Emu.DumpProfile)
+	}
+	if cfg.Script != "" {
+		if cfg.Jit {
+			log.ModEmu.WarnZ("script hooks are not reliable with JIT").End()
+		}
+		if err := Emu.StartScript(cfg.Script); err != nil {
+			return nil, fmt.Errorf("cannot load script: %v", err)
+		}
+	}
+
+	if cfg.CpuProf != "" {
+		f, err := os.Create(cfg.CpuProf)
+		if err != nil {
+			return nil, err
+		}
+		pprof.StartCPUProfile(f)
+		atShutdown(pprof.StopCPUProfile)
+	}
+
+	if cfg.Logging != "" {
+		var modmask log.ModuleMask
+		for _, modname := range strings.Split(cfg.Logging, ",") {
+			if modname == "all" {
+				modmask |= log.ModuleMaskAll
+			} else if m, found := log.ModuleByName(modname); found {
+				modmask |= m.Mask()
+			} else {
+				return nil, fmt.Errorf("invalid module name: %s", modname)
+			}
+		}
+		log.EnableDebugModules(modmask)
+	}
+
+	if cfg.LogBin != "" {
+		f, err := os.Create(cfg.LogBin)
+		if err != nil {
+			return nil, err
+		}
+		if err := log.SetBinaryOutput(f); err != nil {
+			return nil, err
+		}
+		atShutdown(func() {
+			log.FlushBinaryOutput()
+			f.Close()
+		})
+	}
+
+	return movie, nil
+}
+
+func atShutdown(f func()) {
+	shutdownFuncs = append(shutdownFuncs, f)
+}
+
+// Shutdown flushes and closes all the output files opened by Boot (movie,
+// profiles, binary log).
+func Shutdown() {
+	for i := len(shutdownFuncs) - 1; i >= 0; i-- {
+		shutdownFuncs[i]()
+	}
+	shutdownFuncs = nil
+}
+
+// DumpMemory writes the contents of the main memories (RAM, WRAM, VRAM, OAM
+// and texture memory) into files in the current directory, for debugging.
+func (emu *NDSEmulator) DumpMemory() {
+	f, err := os.Create("ram.dump")
+	if err == nil {
+		f.Write(emu.Mem.Ram[:])
+		f.Close()
+	}
+	f, err = os.Create("wram.dump")
+	if err == nil {
+		f.Write(emu.Hw.Mc.wram[:])
+		f.Write(emu.Mem.Wram[:])
+		f.Close()
+	}
+	for i := 0; i < len(emu.Hw.Mc.vram); i++ {
+		char := 'a' + i
+		f, err = os.Create(fmt.Sprintf("vram-%c.dump", char))
+		if err == nil {
+			f.Write(emu.Hw.Mc.vram[i][:])
+			f.Close()
+		}
+	}
+	f, err = os.Create("vram-bg-a.dump")
+	if err == nil {
+		v := emu.Hw.Mc.VramLinearBank(0, e2d.VramLinearBG, 0)
+		v.Dump(f)
+		v = emu.Hw.Mc.VramLinearBank(0, e2d.VramLinearBG, 256*1024)
+		v.Dump(f)
+		f.Close()
+	}
+	f, err = os.Create("vram-bg-b.dump")
+	if err == nil {
+		v := emu.Hw.Mc.VramLinearBank(1, e2d.VramLinearBG, 0)
+		v.Dump(f)
+		f.Truncate(128 * 1024)
+		f.Close()
+	}
+	f, err = os.Create("vram-bgextpal-a.dump")
+	if err == nil {
+		v := emu.Hw.Mc.VramLinearBank(0, e2d.VramLinearBGExtPal, 0)
+		v.Dump(f)
+		f.Close()
+	}
+	f, err = os.Create("vram-bgextpal-b.dump")
+	if err == nil {
+		v := emu.Hw.Mc.VramLinearBank(1, e2d.VramLinearBGExtPal, 0)
+		v.Dump(f)
+		f.Close()
+	}
+
+	f, err = os.Create("oam.dump")
+	if err == nil {
+		f.Write(emu.Mem.OamRam[:])
+		f.Close()
+	}
+
+	f, err = os.Create("texture.dump")
+	if err == nil {
+		texbank := emu.Hw.Mc.VramTextureBank()
+		for i := 0; i < 16; i++ {
+			f.Write(texbank.Slots[i])
+		}
+		f.Close()
+	}
+
+	f, err = os.Create("texpal.dump")
+	if err == nil {
+		texbank := emu.Hw.Mc.VramTexturePaletteBank()
+		for i := 0; i < 8; i++ {
+			f.Write(texbank.Slots[i])
+		}
+		f.Close()
+	}
+}
+
+// loadRoms maps the specified ROM files: either a NDS ROM (optionally followed
+// by a GBA ROM for slot 2), a homebrew NDS ROM (optionally with a FAT image),
+// or a GBA ROM. If savefile is empty, the backup memory of a NDS ROM is saved
+// next to the ROM itself.
+func loadRoms(roms []string, savefile string, fatImage string) error {
+	if len(roms) == 0 {
+		return nil
+	}
+
+	// Check if the NDS ROM is homebrew. If so, directly load it into slot2
+	// like PassMe does.
+	if hbrew, _ := homebrew.Detect(roms[0]); hbrew {
+		if err := Emu.Hw.Sl2.MapCartFile(roms[0]); err != nil {
+			return err
+		}
+		if len(roms) > 1 {
+			return errors.New("slot2 ROM specified but slot1 ROM is homebrew")
+		}
+		// FIXME: also load the ROM in slot1. Theoretically, for a full
+		// Passme emulation, the ROM in slot1 should be patched by PassMe,
+		// but it looks like the firmware we're using doesn't need it.
+		if err := Emu.Hw.Gc.MapCartFile(roms[0]); err != nil {
+			return err
+		}
+
+		// See if we are asked to load a FAT image as well. If so, we concatenate it
+		// to the ROM, and then do a DLDI patch to make libfat find it.
+		if fatImage != "" {
+			if err := Emu.Hw.Sl2.HomebrewMapFatFile(fatImage); err != nil {
+				return err
+			}
+
+			if err := homebrew.FcsrPatchDldi(Emu.Hw.Sl2.Rom); err != nil {
+				return err
+			}
+		}
+
+		// Activate IDEAS-compatibile debug output on both CPUs
+		// (use a special SWI to write messages in console)
+		homebrew.ActivateIdeasDebug(nds9.Cpu)
+		homebrew.ActivateIdeasDebug(nds7.Cpu)
+	} else if strings.HasSuffix(roms[0], ".nds") {
+		// Map Slot1 cart file (NDS ROM)
+		if err := Emu.Hw.Gc.MapCartFile(roms[0]); err != nil {
+			return err
+		}
+
+		// Map save file for Slot1
+		if savefile == "" {
+			savefile = roms[0] + ".sav"
+		}
+		if err := Emu.Hw.Bkp.MapSaveFile(savefile); err != nil {
+			return err
+		}
+
+		// If specified, map Slot2 cart file (GBA ROM)
+		if len(roms) > 1 {
+			if err := Emu.Hw.Sl2.MapCartFile(roms[1]); err != nil {
+				return err
+			}
+		}
+
+		if fatImage != "" {
+			return errors.New("cannot specify -homebrew-fat for non-homebrew ROM")
+		}
+	} else if strings.HasSuffix(roms[0], ".gba") {
+		if err := Emu.Hw.Sl2.MapCartFile(roms[0]); err != nil {
+			return err
+		}
+		if len(roms) > 1 {
+			return errors.New("cannot specify multiple ROMs after GBA rom")
+		}
+		if fatImage != "" {
+			return errors.New("cannot specify -homebrew-fat for non-homebrew ROM")
+		}
+	} else {
+		return fmt.Errorf("unrecognized ROM type: %s", roms[0])
+	}
+	return nil
+}
+
+// skipBios prepares the emulator to directly run the game on the gamecard,
+// bypassing the BIOS and the firmware boot process.
+func skipBios() error {
+	if err := InjectGamecard(Emu.Hw.Gc, Emu.Mem); err != nil {
+		return err
+	}
+
+	// User settings are copied by the firmware to 0x27FFC80
+	us, err := Emu.Hw.Ff.UserSettings()
+	if err != nil {
+		return err
+	}
+	copy(Emu.Mem.Ram[0x3FFC80:], us[:0x70])
+
+	// Shared wram: map everything to ARM7
+	Emu.Hw.Mc.WramCnt.Write8(0, 3)
+
+	// Set post-boot flag to 1
+	nds9.misc.PostFlg.Value = 1
+	nds7.misc7.PostFlg.Value = 1
+
+	nds9.Irq.Ime.Value = 0x1
+	nds7.Irq.Ime.Value = 0x1
+	nds9.Irq.Ie.Value = uint32(IrqIpcRecvFifo | IrqTimers | IrqVBlank)
+	nds7.Irq.Ie.Value = uint32(IrqIpcRecvFifo | IrqTimers | IrqVBlank)
+
+	// VRAM: map everything in "LCDC mode"
+	Emu.Hw.Mc.VramCntA.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntB.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntC.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntD.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntE.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntF.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntG.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntH.Write8(0, 0x80)
+	Emu.Hw.Mc.VramCntI.Write8(0, 0x80)
+
+	// Gamecard: skip directly to key2 status
+	Emu.Hw.Gc.stat = gcStatusKey2
+
+	nds9.Cp15.ConfigureControlReg(0x52078, 0x00FF085)
+	Emu.skipBios = true
+	return nil
+}
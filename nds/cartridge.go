@@ -1,4 +1,4 @@
-package main
+package nds
 
 import (
 	"bytes"
@@ -36,6 +36,15 @@ func (c *CartHeader) Read(r io.Reader) error {
 	return nil
 }
 
+// ReadCartHeader reads the header of the cartridge
+func ReadCartHeader(gc *Gamecard) (*CartHeader, error) {
+	ch := &CartHeader{}
+	if err := ch.Read(io.NewSectionReader(gc, 0, int64(gc.Size))); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
 func copyToRam(dst []byte, src io.ReaderAt, dstOff, srcOff, size uint32) error {
 	chunk := make([]byte, size)
 	if _, err := src.ReadAt(chunk, int64(srcOff)); err != nil {
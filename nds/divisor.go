@@ -1,8 +1,9 @@
-package main
+package nds
 
 import (
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 var modDiv = log.NewModule("divisor")
@@ -148,3 +149,9 @@ func (div *HwDivisor) ReadSQRTRES(_ uint32) uint32 {
 
 	return res
 }
+
+func (div *HwDivisor) Serialize(st *savestate.Stream) {
+	st.Section("div")
+	hwio.SerializeRegs(st, div)
+	st.Bool(&div.dirty)
+}
@@ -1,9 +1,10 @@
-package main
+package nds
 
 import (
 	"ndsemu/emu"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 type DmaEvent int
@@ -40,7 +41,7 @@ type HwDmaChannel struct {
 	DmaSad   hwio.Reg32 `hwio:"offset=0x00"`
 	DmaDad   hwio.Reg32 `hwio:"offset=0x04"`
 	DmaCount hwio.Reg16 `hwio:"offset=0x08"`
-	DmaCntrl hwio.Reg16 `hwio:"offset=0x0A,wcb"`
+	DmaCntrl hwio.Reg16 `hwio:"offset=0x0A,wcb,bits=DstCtrl:5-6|SrcCtrl:7-8|Repeat:9|Word:10|Mode:11-13|Irq:14|Enable:15"`
 
 	debugRepeat  bool
 	inProgress   bool
@@ -303,3 +304,18 @@ func (dma *HwDmaChannel) TriggerEvent(event DmaEvent) {
 		}
 	}
 }
+
+func (dma *HwDmaFill) Serialize(st *savestate.Stream) {
+	st.Section("dmafill")
+	hwio.SerializeRegs(st, dma)
+}
+
+func (dma *HwDmaChannel) Serialize(st *savestate.Stream) {
+	st.Section("dma")
+	hwio.SerializeRegs(st, dma)
+	st.Bool(&dma.debugRepeat)
+	st.Bool(&dma.inProgress)
+	evt := int(dma.pendingEvent)
+	st.Int(&evt)
+	dma.pendingEvent = DmaEvent(evt)
+}
@@ -1,17 +1,23 @@
-package main
+package nds
 
 import (
 	"fmt"
 	"ndsemu/arm"
+	"ndsemu/bios"
+	"ndsemu/cheat"
 	"ndsemu/e2d"
 	"ndsemu/emu"
 	"ndsemu/emu/debugger"
 	"ndsemu/emu/gfx"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/script"
+	"ndsemu/emu/symbols"
 	"ndsemu/raster3d"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"sync/atomic"
 
 	"github.com/BurntSushi/toml"
 )
@@ -35,6 +41,10 @@ type NDSRom struct {
 	Bios9   []byte
 	Bios7   []byte
 	BiosGba []byte
+
+	// True if the NDS BIOS is emulated at high level, in which case Bios9
+	// and Bios7 are just stubs.
+	Hle bool
 }
 
 type NDSHardware struct {
@@ -67,12 +77,22 @@ type NDSEmulator struct {
 	Mode EmuMode
 
 	dbg        *debugger.Debugger
+	gdb        *debugger.GdbServer
+	tracer     *debugger.Tracer
+	profiler   *debugger.Profiler
+	profOut    string
+	script     *script.Engine
+	cheats     cheat.List
+	syms       map[string]*symbols.Table
 	screen     gfx.Buffer
 	audio      []int16
 	framecount int
 	powcnt     uint32
+	input      MovieInput // Input set by the user for the current frame
+	skipBios   bool       // true if the BIOS boot was skipped (see skipBios)
 
 	switchingToGba bool
+	interrupted    int32 // set (atomically) on Ctrl-C, see Boot
 }
 
 var Emu *NDSEmulator
@@ -124,20 +144,23 @@ func NewNDSHardware(mem *NDSMemory, firmware string, dojit bool) *NDSHardware {
 	return hw
 }
 
-func NewNDSRom() *NDSRom {
+// NewNDSRom loads the BIOS dumps from the bios directory next to the
+// executable. If hleBios is true, or the dumps are missing, the HLE BIOS is
+// used instead.
+func NewNDSRom(hleBios bool) *NDSRom {
 	rom := new(NDSRom)
 	bindir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
 
-	bios9, err := os.ReadFile(filepath.Join(bindir, "bios/biosnds9.rom"))
-	if err != nil {
-		log.ModEmu.FatalZ("error loading rom").Error("err", err).End()
+	bios9, err9 := os.ReadFile(filepath.Join(bindir, "bios/biosnds9.rom"))
+	bios7, err7 := os.ReadFile(filepath.Join(bindir, "bios/biosnds7.rom"))
+	if hleBios || err9 != nil || err7 != nil {
+		if !hleBios {
+			log.ModEmu.WarnZ("NDS BIOS not found, using HLE (only direct boot is supported)").End()
+		}
+		bios9, bios7 = bios.Stub9(), bios.Stub7()
+		rom.Hle = true
 	}
 	rom.Bios9 = bios9
-
-	bios7, err := os.ReadFile(filepath.Join(bindir, "bios/biosnds7.rom"))
-	if err != nil {
-		log.ModEmu.FatalZ("error loading rom").Error("err", err).End()
-	}
 	rom.Bios7 = bios7
 
 	biosgba, err := os.ReadFile(filepath.Join(bindir, "bios/biosgba.rom"))
@@ -149,9 +172,9 @@ func NewNDSRom() *NDSRom {
 	return rom
 }
 
-func NewNDSEmulator(firmware string, dojit bool) *NDSEmulator {
+func NewNDSEmulator(firmware string, dojit bool, hleBios bool) *NDSEmulator {
 	mem := new(NDSMemory)
-	rom := NewNDSRom()
+	rom := NewNDSRom(hleBios)
 	hw := NewNDSHardware(mem, firmware, dojit)
 
 	// Initialize syncing system
@@ -183,6 +206,11 @@ func NewNDSEmulator(firmware string, dojit bool) *NDSEmulator {
 
 	emu.BreakFunc = e.DebugBreak
 
+	if rom.Hle {
+		bios.Activate9(nds9.Cpu, nds9.Cp15)
+		bios.Activate7(nds7.Cpu)
+	}
+
 	// Initialize the memory map and reset the CPUs
 	nds9.InitBus(e)
 	nds7.InitBus(e)
@@ -220,10 +248,34 @@ func (emu *NDSEmulator) switchToGba() {
 
 func (emu *NDSEmulator) StartDebugger() {
 	emu.dbg = debugger.New([]debugger.Cpu{nds7.Cpu, nds9.Cpu}, emu.Sync)
+	emu.dbg.SetSymbols(0, emu.syms["arm7"])
+	emu.dbg.SetSymbols(1, emu.syms["arm9"])
+	emu.dbg.SetIoTable(0, nds7.Bus)
+	emu.dbg.SetIoTable(1, nds9.Bus)
+
+	// Watchpoints can be specified either as a list of addresses (breaking
+	// on any access), or as tables with more options:
+	//
+	//   [[Watch]]
+	//   Addr = "0x027FFC3C"       # or a range: "0x2000000-0x20000FF"
+	//   Access = "w"              # "r", "w" or "rw" (default)
+	//   Size = 4                  # optional: 1, 2 or 4
+	//   Cpu = "arm9"              # optional: "arm9" or "arm7"
+	//   Value = "> 0x100"         # optional, only for writes
+	type WatchConfig struct {
+		Addr   string
+		Access string
+		Size   int
+		Cpu    string
+		Value  string
+	}
 
+	// Breakpoints can be specified as addresses or symbol names, optionally
+	// with an offset and the CPU ("arm7:irq_handler+0x10").
 	type DebugConfig struct {
 		Breakpoints []string
 		Watchpoints []string
+		Watch       []WatchConfig
 	}
 
 	cfg := &DebugConfig{}
@@ -231,11 +283,11 @@ func (emu *NDSEmulator) StartDebugger() {
 		log.ModEmu.WithField("error", err).Warnf("error loading debug.ini")
 	} else {
 		for _, bkp := range cfg.Breakpoints {
-			if b, err := strconv.ParseUint(bkp, 0, 32); err != nil {
+			if b, err := emu.resolveAddr(bkp); err != nil {
 				log.ModEmu.WithField("error", err).Fatalf("invalid breakpoint %q", bkp)
 			} else {
-				emu.dbg.AddBreakpoint(uint32(b))
-				log.ModEmu.WithField("break", fmt.Sprintf("0x%08x", uint32(b))).Warnf("add breakpoint")
+				emu.dbg.AddBreakpoint(b)
+				log.ModEmu.WithField("break", fmt.Sprintf("0x%08x", b)).Warnf("add breakpoint")
 			}
 		}
 		for _, bkp := range cfg.Watchpoints {
@@ -246,14 +298,171 @@ func (emu *NDSEmulator) StartDebugger() {
 				log.ModEmu.WithField("watch", fmt.Sprintf("0x%08x", uint32(b))).Warnf("add watchpoint")
 			}
 		}
+		for _, w := range cfg.Watch {
+			wp, err := parseWatchConfig(w.Addr, w.Access, w.Size, w.Cpu, w.Value)
+			if err != nil {
+				log.ModEmu.WithField("error", err).Fatalf("invalid watchpoint %q", w.Addr)
+			}
+			emu.dbg.AddWatch(wp)
+			log.ModEmu.WithField("watch", wp.String()).Warnf("add watchpoint")
+		}
 	}
 
 	go emu.dbg.Run()
 }
 
+// LoadSymbols loads the symbol table of a CPU ("arm9" or "arm7") from an ELF,
+// .sym or .map file.
+func (emu *NDSEmulator) LoadSymbols(cpu string, fn string) error {
+	syms, err := symbols.Load(fn)
+	if err != nil {
+		return err
+	}
+	if emu.syms == nil {
+		emu.syms = make(map[string]*symbols.Table)
+	}
+	emu.syms[cpu] = syms
+	emu.Sync.SetSymbols(cpu, syms)
+	log.ModEmu.WarnZ("symbols loaded").String("cpu", cpu).String("file", fn).Int("count", syms.Len()).End()
+	return nil
+}
+
+// LoadCheats loads the cheats for the current game from the specified file
+// (see cheat.Load); they are applied at the beginning of each frame.
+func (emu *NDSEmulator) LoadCheats(fn string) error {
+	ch, err := ReadCartHeader(emu.Hw.Gc)
+	if err != nil {
+		return err
+	}
+	gamecode := string(ch.Gamecode[:])
+	cheats, err := cheat.Load(fn, gamecode)
+	if err != nil {
+		return err
+	}
+	emu.cheats = cheats
+	log.ModEmu.WarnZ("cheats loaded").String("file", fn).String("game", gamecode).Int("count", len(cheats)).End()
+	return nil
+}
+
+// resolveAddr parses an address, which can be either a number or a symbol
+// name (with an optional offset). Symbols are looked up in the ARM9 table
+// first, unless the CPU is specified with a prefix ("arm7:name").
+func (emu *NDSEmulator) resolveAddr(s string) (uint32, error) {
+	if v, err := strconv.ParseUint(s, 0, 32); err == nil {
+		return uint32(v), nil
+	}
+	cpus := []string{"arm9", "arm7"}
+	if idx := strings.IndexByte(s, ':'); idx >= 0 {
+		cpus, s = []string{s[:idx]}, s[idx+1:]
+	}
+	for _, cpu := range cpus {
+		if addr, found := emu.syms[cpu].Resolve(s); found {
+			return addr, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown symbol %q", s)
+}
+
+func parseWatchConfig(addr, access string, size int, cpu, value string) (debugger.Watchpoint, error) {
+	var wp debugger.Watchpoint
+	var err error
+
+	if wp.Start, wp.End, err = debugger.ParseWatchRange(addr); err != nil {
+		return wp, err
+	}
+	switch access {
+	case "r":
+		wp.Read = true
+	case "w":
+		wp.Write = true
+	case "rw", "":
+		wp.Read, wp.Write = true, true
+	default:
+		return wp, fmt.Errorf("invalid access %q", access)
+	}
+	if size != 0 && size != 1 && size != 2 && size != 4 {
+		return wp, fmt.Errorf("invalid size %d", size)
+	}
+	wp.Size = size
+
+	// Index of the CPUs passed to the debugger
+	switch cpu {
+	case "":
+		wp.Cpu = -1
+	case "arm7":
+		wp.Cpu = 0
+	case "arm9":
+		wp.Cpu = 1
+	default:
+		return wp, fmt.Errorf("invalid cpu %q", cpu)
+	}
+
+	wp.Cond, err = debugger.ParseWatchCond(value)
+	return wp, err
+}
+
+// StartGdbServer starts a GDB server listening on the specified address.
+// The emulation is stopped until a client connects.
+func (emu *NDSEmulator) StartGdbServer(addr string) error {
+	emu.gdb = debugger.NewGdbServer(
+		[]debugger.GdbCpu{nds9.Cpu, nds7.Cpu},
+		[]string{"arm9", "arm7"})
+	return emu.gdb.ListenAndServe(addr)
+}
+
+// StartTracer starts recording the instructions executed by both CPUs. It
+// must be called after the debugger (if any) has been started.
+func (emu *NDSEmulator) StartTracer(cfg debugger.TraceConfig) {
+	emu.tracer = debugger.NewTracer(
+		[]debugger.TraceCpu{nds9.Cpu, nds7.Cpu},
+		[]string{"arm9", "arm7"},
+		cfg)
+}
+
+// StartProfiler starts profiling the guest code executed by both CPUs. The
+// profiles are written in pprof format by DumpProfile, into <out>-arm9.pb.gz
+// and <out>-arm7.pb.gz. Symbols must be loaded before.
+func (emu *NDSEmulator) StartProfiler(out string) {
+	emu.profiler = debugger.NewProfiler(
+		[]debugger.ProfileCpu{nds9.Cpu, nds7.Cpu},
+		[]string{"arm9", "arm7"})
+	emu.profiler.SetSymbols(0, emu.syms["arm9"])
+	emu.profiler.SetSymbols(1, emu.syms["arm7"])
+	emu.profOut = out
+}
+
+// DumpProfile writes the guest profile collected so far (if enabled). It must
+// be called between frames.
+func (emu *NDSEmulator) DumpProfile() {
+	if emu.profiler == nil {
+		return
+	}
+	if err := emu.profiler.Dump(emu.profOut); err != nil {
+		log.ModEmu.ErrorZ("cannot write guest profile").Error("err", err).End()
+	}
+}
+
+// DumpTrace dumps the instruction trace (if enabled)
+func (emu *NDSEmulator) DumpTrace() {
+	if emu.tracer == nil {
+		return
+	}
+	if err := emu.tracer.Dump(); err != nil {
+		log.ModEmu.ErrorZ("cannot dump trace").Error("err", err).End()
+	}
+}
+
+// Frame returns the number of frames emulated so far
+func (emu *NDSEmulator) Frame() int {
+	return emu.framecount
+}
+
 func (emu *NDSEmulator) DebugBreak(msg string) {
+	emu.DumpTrace()
 	if emu.dbg != nil {
 		emu.dbg.Break(msg)
+	} else if emu.gdb != nil {
+		emu.gdb.Break(msg)
 	} else {
 		log.ModEmu.ErrorZ(msg).End()
 		log.ModEmu.PanicZ("debugging breakpoint, aborting").End()
@@ -346,9 +555,18 @@ func (emu *NDSEmulator) RunOneFrame(screen gfx.Buffer, audio []int16) bool {
 
 	emu.screen = screen
 	emu.audio = audio
+	if emu.script != nil {
+		emu.script.BeginFrame()
+	}
+	// Like the Action Replay, apply cheats once per frame (after the input
+	// is known, as it is used by conditional codes)
+	emu.cheats.Run(nds9.Bus)
 	emu.Sync.RunOneFrame()
 	emu.audio = nil
 	emu.framecount++
+	if emu.script != nil {
+		emu.script.EndFrame(emu.framecount)
+	}
 
 	if emu.switchingToGba {
 		// Switching to Gba now (after frame end)
@@ -356,6 +574,13 @@ func (emu *NDSEmulator) RunOneFrame(screen gfx.Buffer, audio []int16) bool {
 		emu.switchingToGba = false
 	}
 
+	if atomic.LoadInt32(&emu.interrupted) != 0 {
+		emu.DumpTrace()
+		emu.DumpMemory()
+		Shutdown()
+		os.Exit(1)
+	}
+
 	return emu.Hw.Pow.PowerOff()
 }
 
@@ -1,4 +1,4 @@
-package main
+package nds
 
 import "encoding/binary"
 
@@ -0,0 +1,198 @@
+package nds
+
+import (
+	"encoding/binary"
+	"io"
+	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
+	"ndsemu/emu/spi"
+	"os"
+
+	"github.com/howeyc/crc16"
+)
+
+var modFw = log.NewModule("firmware")
+
+const (
+	FFCodeRead uint8 = 0x03
+	FFCodeRdsr uint8 = 0x05
+	FFCodeWren uint8 = 0x06
+	FFCodeWrdi uint8 = 0x04
+	FFCodePw   uint8 = 0x0A
+)
+
+// Storage for the contents of the flash: either a file, or a synthesized
+// image kept in memory.
+type flashStorage interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// memFlash is a flash image kept in memory
+type memFlash []byte
+
+func (m memFlash) ReadAt(buf []byte, off int64) (int, error) {
+	if off >= int64(len(m)) {
+		return 0, io.EOF
+	}
+	n := copy(buf, m[off:])
+	if n < len(buf) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (m memFlash) WriteAt(buf []byte, off int64) (int, error) {
+	if off >= int64(len(m)) {
+		return 0, io.ErrShortWrite
+	}
+	n := copy(m[off:], buf)
+	if n < len(buf) {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
+
+type HwFirmwareFlash struct {
+	f   flashStorage
+	wen bool
+
+	wbuf []byte
+	addr uint32
+}
+
+func NewHwFirmwareFlash() *HwFirmwareFlash {
+	return &HwFirmwareFlash{}
+}
+
+func (ff *HwFirmwareFlash) MapFirmwareFile(fn string) error {
+	f, err := os.OpenFile(fn, os.O_RDWR, 0777)
+	if err != nil {
+		return err
+	}
+	ff.f = f
+	return nil
+}
+
+// MapFirmwareImage maps a firmware image kept in memory (eg: synthesized
+// with SynthesizeFirmware). Writes to the flash are not persisted.
+func (ff *HwFirmwareFlash) MapFirmwareImage(data []byte) {
+	ff.f = memFlash(data)
+}
+
+// firmwareCRC16 computes the CRC16 used by the firmware to protect its data
+// structures. It is the same algorithm of the BIOS GetCRC16 function.
+func firmwareCRC16(crc uint16, data []byte) uint16 {
+	// crc16.Update inverts the CRC before and after the computation,
+	// while the firmware doesn't.
+	return ^crc16.Update(^crc, crc16.IBMTable, data)
+}
+
+// UserSettings returns the user settings stored in the flash (nickname,
+// language, touchscreen calibration, etc.). There are two copies of them,
+// and the most recent valid one is returned. This is what the firmware
+// copies into RAM at 0x27FFC80 during boot.
+func (ff *HwFirmwareFlash) UserSettings() ([]byte, error) {
+	var hdr [0x22]byte
+	if _, err := ff.f.ReadAt(hdr[:], 0); err != nil {
+		return nil, err
+	}
+	off := int64(binary.LittleEndian.Uint16(hdr[0x20:])) * 8
+
+	var best []byte
+	var bestCount uint16
+	for i := int64(0); i < 2; i++ {
+		us := make([]byte, cFwUserSettingsSize)
+		if _, err := ff.f.ReadAt(us, off+i*0x100); err != nil {
+			return nil, err
+		}
+		if firmwareCRC16(0xFFFF, us[:0x70]) != binary.LittleEndian.Uint16(us[0x72:]) {
+			continue
+		}
+		// The update counter is 7-bit and wraps around
+		count := binary.LittleEndian.Uint16(us[0x70:]) & 0x7F
+		if best == nil || count == (bestCount+1)&0x7F {
+			best, bestCount = us, count
+		}
+	}
+	if best == nil {
+		modFw.WarnZ("no valid user settings found").End()
+		best = make([]byte, cFwUserSettingsSize)
+	}
+	return best, nil
+}
+
+func (ff *HwFirmwareFlash) SpiBegin() {
+	ff.addr = 0
+	ff.wbuf = nil
+}
+
+func (ff *HwFirmwareFlash) SpiTransfer(data []byte) ([]byte, spi.ReqStatus) {
+	cmd := data[0]
+	switch cmd {
+	case 0:
+		// Dummy command that is sent as part of the last byte transfer
+		// FIXME: we could fix this at the spibus level
+		return nil, spi.ReqFinish
+
+	case FFCodeRead:
+		if len(data) < 4 {
+			return nil, spi.ReqContinue
+		}
+		if len(data) == 4 {
+			ff.addr = uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+			modFw.InfoZ("HEAD").Hex32("addr", ff.addr).End()
+		}
+
+		buf := make([]byte, 1024)
+		ff.f.ReadAt(buf, int64(ff.addr))
+		ff.addr += 1024
+		return buf, spi.ReqContinue
+	case FFCodeRdsr:
+		status := uint8(0)
+		if ff.wen {
+			status |= 2
+		}
+		modFw.InfoZ("read status").Hex8("val", status).End()
+		return []byte{status}, spi.ReqFinish
+	case FFCodeWren:
+		modFw.InfoZ("write enabled").End()
+		ff.wen = true
+		return nil, spi.ReqFinish
+	case FFCodeWrdi:
+		modFw.InfoZ("write disabled").End()
+		ff.wen = false
+		return nil, spi.ReqFinish
+	case FFCodePw:
+		if len(data) < 4 {
+			return nil, spi.ReqContinue
+		}
+		if len(data) == 4 {
+			ff.addr = uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+			modFw.InfoZ("WRITE").Hex32("addr", ff.addr).End()
+		}
+		// Put away buffer data; will be written just once, in SpiEnd
+		ff.wbuf = data[4:]
+		return nil, spi.ReqContinue
+	default:
+		modFw.ErrorZ("unsupported command").Hex8("cmd", cmd).End()
+		return nil, spi.ReqFinish
+	}
+}
+
+func (ff *HwFirmwareFlash) SpiEnd() {
+	if ff.wbuf != nil {
+		ff.f.WriteAt(ff.wbuf, int64(ff.addr))
+		ff.wbuf = nil
+	}
+}
+
+// Serialize saves or loads the status of the current transfer. The contents
+// of the flash are not part of the state, as they are persisted in the
+// firmware file (just like the real hardware).
+func (ff *HwFirmwareFlash) Serialize(st *savestate.Stream) {
+	st.Section("firmware")
+	st.Bool(&ff.wen)
+	st.ByteSlice(&ff.wbuf)
+	st.Uint32(&ff.addr)
+}
@@ -0,0 +1,50 @@
+package nds
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestSynthesizeFirmware(t *testing.T) {
+	p := DefaultFirmwareProfile()
+	p.Nickname = "Tester"
+	p.Language = "italian"
+
+	fw, err := SynthesizeFirmware(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fw) != cFwSize {
+		t.Fatalf("invalid firmware size: %d", len(fw))
+	}
+
+	// Wifi configuration must be protected by a valid CRC
+	wlen := int(binary.LittleEndian.Uint16(fw[0x2C:]))
+	if crc := firmwareCRC16(0, fw[0x2C:0x2C+wlen]); crc != binary.LittleEndian.Uint16(fw[0x2A:]) {
+		t.Errorf("invalid wifi config CRC: %04x", crc)
+	}
+
+	ff := NewHwFirmwareFlash()
+	ff.MapFirmwareImage(fw)
+	us, err := ff.UserSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnt := binary.LittleEndian.Uint16(us[0x70:]); cnt != 1 {
+		t.Errorf("most recent user settings not selected (counter: %d)", cnt)
+	}
+	if lang := binary.LittleEndian.Uint16(us[0x64:]) & 7; lang != 4 {
+		t.Errorf("invalid language: %d", lang)
+	}
+	if n := binary.LittleEndian.Uint16(us[0x1A:]); n != 6 {
+		t.Errorf("invalid nickname length: %d", n)
+	}
+	if us[0x06] != 'T' || us[0x08] != 'e' {
+		t.Errorf("invalid nickname: % x", us[0x06:0x1A])
+	}
+
+	p.Language = "klingon"
+	if _, err := SynthesizeFirmware(p); err == nil {
+		t.Errorf("invalid language not detected")
+	}
+}
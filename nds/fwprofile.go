@@ -0,0 +1,203 @@
+package nds
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	cFwSize             = 256 * 1024
+	cFwUserSettingsOff  = 0x3FE00
+	cFwUserSettingsSize = 0x74
+	cFwWifiConfigLen    = 0x138
+)
+
+// FirmwareProfile describes the user settings used to synthesize a firmware
+// image, when no dump of the original firmware is available. It is loaded
+// from a TOML file, like this:
+//
+//	nickname = "ndsemu"
+//	message = "Hello!"
+//	language = "english"
+//	color = 11
+//	birthday = [4, 1]    # month, day
+//
+//	# Touchscreen calibration: ADC values read at two screen points
+//	[calibration]
+//	adc1 = [0x200, 0x200]
+//	scr1 = [0x20, 0x20]
+//	adc2 = [0xE00, 0x800]
+//	scr2 = [0xE0, 0x80]
+//
+// All the fields are optional.
+type FirmwareProfile struct {
+	Nickname    string
+	Message     string
+	Language    string
+	Color       int
+	Birthday    [2]int
+	MAC         string
+	Calibration struct {
+		Adc1 [2]int
+		Scr1 [2]int
+		Adc2 [2]int
+		Scr2 [2]int
+	}
+}
+
+var fwLanguages = []string{
+	"japanese", "english", "french", "german", "italian", "spanish", "chinese",
+}
+
+// DefaultFirmwareProfile returns the profile used when none is specified.
+func DefaultFirmwareProfile() *FirmwareProfile {
+	p := &FirmwareProfile{
+		Nickname: "ndsemu",
+		Language: "english",
+		Color:    11,
+		Birthday: [2]int{1, 1},
+		MAC:      "00:09:BF:12:34:56",
+	}
+	p.Calibration.Adc1 = [2]int{0x200, 0x200}
+	p.Calibration.Scr1 = [2]int{0x20, 0x20}
+	p.Calibration.Adc2 = [2]int{0xE00, 0x800}
+	p.Calibration.Scr2 = [2]int{0xE0, 0x80}
+	return p
+}
+
+// LoadFirmwareProfile loads a profile from a TOML file. Fields that are not
+// specified keep their default values.
+func LoadFirmwareProfile(fn string) (*FirmwareProfile, error) {
+	p := DefaultFirmwareProfile()
+	if _, err := toml.DecodeFile(fn, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func putUtf16(buf []byte, s string, maxlen int) int {
+	str := utf16.Encode([]rune(s))
+	if len(str) > maxlen {
+		str = str[:maxlen]
+	}
+	for i, c := range str {
+		binary.LittleEndian.PutUint16(buf[i*2:], c)
+	}
+	return len(str)
+}
+
+// userSettings builds the user settings area described by the profile
+func (p *FirmwareProfile) userSettings() ([]byte, error) {
+	lang := -1
+	for i, l := range fwLanguages {
+		if strings.EqualFold(l, p.Language) {
+			lang = i
+		}
+	}
+	if lang < 0 {
+		return nil, fmt.Errorf("invalid language: %q", p.Language)
+	}
+	if p.Color < 0 || p.Color > 15 {
+		return nil, fmt.Errorf("invalid favorite color: %d", p.Color)
+	}
+	if p.Birthday[0] < 1 || p.Birthday[0] > 12 || p.Birthday[1] < 1 || p.Birthday[1] > 31 {
+		return nil, fmt.Errorf("invalid birthday: %v", p.Birthday)
+	}
+
+	us := make([]byte, cFwUserSettingsSize)
+	binary.LittleEndian.PutUint16(us[0x00:], 5) // version
+	us[0x02] = uint8(p.Color)
+	us[0x03] = uint8(p.Birthday[0])
+	us[0x04] = uint8(p.Birthday[1])
+	n := putUtf16(us[0x06:], p.Nickname, 10)
+	binary.LittleEndian.PutUint16(us[0x1A:], uint16(n))
+	n = putUtf16(us[0x1C:], p.Message, 26)
+	binary.LittleEndian.PutUint16(us[0x50:], uint16(n))
+
+	cal := &p.Calibration
+	binary.LittleEndian.PutUint16(us[0x58:], uint16(cal.Adc1[0]))
+	binary.LittleEndian.PutUint16(us[0x5A:], uint16(cal.Adc1[1]))
+	us[0x5C] = uint8(cal.Scr1[0])
+	us[0x5D] = uint8(cal.Scr1[1])
+	binary.LittleEndian.PutUint16(us[0x5E:], uint16(cal.Adc2[0]))
+	binary.LittleEndian.PutUint16(us[0x60:], uint16(cal.Adc2[1]))
+	us[0x62] = uint8(cal.Scr2[0])
+	us[0x63] = uint8(cal.Scr2[1])
+
+	// Language, plus: backlight at max level, and the settings-complete
+	// flags (otherwise the firmware would ask the user to fill them in).
+	binary.LittleEndian.PutUint16(us[0x64:], uint16(lang)|3<<4|0xFC00)
+	for i := 0x6C; i < 0x70; i++ {
+		us[i] = 0xFF
+	}
+	binary.LittleEndian.PutUint16(us[0x72:], firmwareCRC16(0xFFFF, us[:0x70]))
+	return us, nil
+}
+
+func parseMAC(s string) ([6]byte, error) {
+	var mac [6]byte
+	_, err := fmt.Sscanf(s, "%02x:%02x:%02x:%02x:%02x:%02x",
+		&mac[0], &mac[1], &mac[2], &mac[3], &mac[4], &mac[5])
+	if err != nil {
+		return mac, fmt.Errorf("invalid MAC address: %q", s)
+	}
+	return mac, nil
+}
+
+// SynthesizeFirmware creates a firmware image containing the header, the
+// wifi configuration and the user settings described by the profile. The
+// image contains no boot code, so it can only be used with direct boot.
+func SynthesizeFirmware(p *FirmwareProfile) ([]byte, error) {
+	us, err := p.userSettings()
+	if err != nil {
+		return nil, err
+	}
+	mac, err := parseMAC(p.MAC)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unused areas of the flash are erased
+	fw := make([]byte, cFwSize)
+	for i := range fw {
+		fw[i] = 0xFF
+	}
+
+	// Header
+	hdr := fw[:0x200]
+	for i := 0; i < 0x1D; i++ {
+		hdr[i] = 0
+	}
+	copy(hdr[0x08:], "MACP")
+	hdr[0x1D] = 0xFF // console type: NDS
+	binary.LittleEndian.PutUint16(hdr[0x20:], cFwUserSettingsOff/8)
+
+	// Wifi configuration, protected by a CRC16
+	wifi := hdr[0x2C : 0x2C+cFwWifiConfigLen]
+	for i := range wifi {
+		wifi[i] = 0
+	}
+	binary.LittleEndian.PutUint16(wifi[0x00:], cFwWifiConfigLen)
+	wifi[0x03] = 5 // version
+	copy(wifi[0x36-0x2C:], mac[:])
+	binary.LittleEndian.PutUint16(wifi[0x3C-0x2C:], 0x3FFE) // enabled channels: 1-13
+	binary.LittleEndian.PutUint16(wifi[0x3E-0x2C:], 0xFFFF)
+	wifi[0x40-0x2C] = 2    // RF chip type
+	wifi[0x41-0x2C] = 0x18 // bits per RF entry
+	wifi[0x42-0x2C] = 0x0C // number of RF entries
+	wifi[0x43-0x2C] = 1
+	binary.LittleEndian.PutUint16(hdr[0x2A:], firmwareCRC16(0, wifi))
+
+	// Two copies of the user settings, the second one being the most
+	// recent (update counter 1).
+	copy(fw[cFwUserSettingsOff:], us)
+	binary.LittleEndian.PutUint16(us[0x70:], 1)
+	binary.LittleEndian.PutUint16(us[0x72:], firmwareCRC16(0xFFFF, us[:0x70]))
+	copy(fw[cFwUserSettingsOff+0x100:], us)
+
+	return fw, nil
+}
@@ -1,11 +1,13 @@
-package main
+package nds
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"ndsemu/emu"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 	"ndsemu/emu/spi"
 	"os"
 
@@ -47,6 +49,10 @@ type Gamecard struct {
 	key2       Key2
 	secAreaOff int
 
+	// Instant at which the next word of data will be ready (0 if no
+	// transfer is in progress)
+	xferNext int64
+
 	spi spi.Bus
 	bkp *HwBackupRam
 }
@@ -73,12 +79,15 @@ func NewGamecard(biosfn string, bkp *HwBackupRam) *Gamecard {
 	gc.spi.AddDevice(0, bkp)
 	gc.bkp = bkp
 
-	f, err := os.Open(biosfn)
-	if err != nil {
-		panic(err)
+	// The KEY1 encryption tables are stored in the ARM7 BIOS. If the
+	// BIOS is missing, KEY1 commands won't work, but games can still be
+	// started with direct boot (which doesn't use them).
+	if f, err := os.Open(biosfn); err == nil {
+		f.ReadAt(gc.key1Tables[:], 0x30)
+		f.Close()
+	} else {
+		modGamecard.WarnZ("cannot load KEY1 tables").Error("err", err).End()
 	}
-	f.ReadAt(gc.key1Tables[:], 0x30)
-	f.Close()
 
 	gc.chipid[0] = 0xFF
 	gc.chipid[1] = 0xFF
@@ -418,16 +427,19 @@ func (gc *Gamecard) xferByte(first bool) {
 		clkrate *= int64(gc.RomCtrl.Value&0x1FFF) + 4 + 4
 	}
 
-	cycles := Emu.Sync.Cycles()
-	Emu.Sync.ScheduleEvent(cycles+clkrate, func() {
-		data := binary.LittleEndian.Uint32(gc.buf[0:4])
-		gc.buf = gc.buf[4:]
-		gc.CardData.Value = data
+	gc.xferNext = Emu.Sync.Cycles() + clkrate
+	Emu.Sync.ScheduleEvent(gc.xferNext, gc.xferReady)
+}
+
+func (gc *Gamecard) xferReady() {
+	gc.xferNext = 0
+	data := binary.LittleEndian.Uint32(gc.buf[0:4])
+	gc.buf = gc.buf[4:]
+	gc.CardData.Value = data
 
-		gc.RomCtrl.Value |= (1 << 23) // signal data available
-		nds9.TriggerDmaEvent(DmaEventGamecard)
-		nds7.TriggerDmaEvent(DmaEventGamecard)
-	})
+	gc.RomCtrl.Value |= (1 << 23) // signal data available
+	nds9.TriggerDmaEvent(DmaEventGamecard)
+	nds7.TriggerDmaEvent(DmaEventGamecard)
 }
 
 func (gc *Gamecard) WriteGCCOMMAND(_, val uint64) {
@@ -444,3 +456,33 @@ func (gc *Gamecard) ReadCARDDATA(_ uint32) uint32 {
 
 	return gc.CardData.Value
 }
+
+// Serialize saves or loads the gamecard state. The ROM image is not part
+// of the state, so the same ROM must be inserted when a state is loaded.
+func (gc *Gamecard) Serialize(st *savestate.Stream) {
+	st.Section("gamecard")
+	size := gc.Size
+	st.Uint64(&size)
+	if size != gc.Size {
+		st.SetError(fmt.Errorf("gamecard: different ROM in savestate (size: %d)", size))
+		return
+	}
+
+	hwio.SerializeRegs(st, gc)
+	st.Bytes(gc.chipid[:])
+	stat := int(gc.stat)
+	st.Int(&stat)
+	gc.stat = gcStatus(stat)
+	st.ByteSlice(&gc.buf)
+	st.Uint64(&gc.key2.x)
+	st.Uint64(&gc.key2.y)
+	st.Int(&gc.secAreaOff)
+	st.Int64(&gc.xferNext)
+	gc.spi.Serialize(st)
+
+	// Callback events are not serialized by emu.Sync, so the pending
+	// transfer must be scheduled again
+	if st.Loading() && gc.xferNext != 0 {
+		Emu.Sync.ScheduleEvent(gc.xferNext, gc.xferReady)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package nds
 
 import (
 	"encoding/binary"
@@ -17,7 +17,7 @@ func TestEncryption(t *testing.T) {
 	f.ReadAt(data, 0x30)
 	f.Close()
 
-	c := NewKey1(data, []byte("AZEP"))
+	c := NewKey1(data, []byte("AZEP"), false)
 
 	var test [8]byte
 	binary.BigEndian.PutUint64(test[:], 0x2229b690c67c17ff)
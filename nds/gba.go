@@ -1,4 +1,4 @@
-package main
+package nds
 
 import (
 	"ndsemu/e2d"
@@ -1,10 +1,12 @@
-package main
+package nds
 
 import (
 	"encoding/binary"
+	"fmt"
 	"ndsemu/emu/fixed"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 	"ndsemu/raster3d"
 )
 
@@ -95,7 +97,7 @@ type HwGeometry struct {
 	DirMtx  hwio.Mem `hwio:"bank=0,offset=0x280,size=0x40,readonly"`
 
 	// Bank 1 (0x4000600). Status and results
-	GxStat     hwio.Reg32 `hwio:"bank=1,offset=0,rwmask=0xC0008000,rcb,wcb"`
+	GxStat     hwio.Reg32 `hwio:"bank=1,offset=0,rwmask=0xC0008000,rcb,wcb,bits=TestBusy:0|BoxTest:1|PosStack:8-12|ProjStack:13|StackBusy:14|StackErr:15|FifoCount:16-24|FifoLessHalf:25|FifoEmpty:26|Busy:27|FifoIrq:30-31"`
 	RamCount   hwio.Reg32 `hwio:"bank=1,offset=4,readonly,rcb"`
 	PosResultX hwio.Reg32 `hwio:"bank=1,offset=0x20,readonly,rcb"`
 	PosResultY hwio.Reg32 `hwio:"bank=1,offset=0x24,readonly,rcb"`
@@ -105,16 +107,6 @@ type HwGeometry struct {
 	VecResultY hwio.Reg16 `hwio:"bank=1,offset=0x32,readonly,rcb"`
 	VecResultZ hwio.Reg16 `hwio:"bank=1,offset=0x34,readonly,rcb"`
 
-	// Bank 2 (0x4000300). Various tables and parameters
-	Edge0 hwio.Reg16 `hwio:"bank=2,offset=0x30,writeonly"`
-	Edge1 hwio.Reg16 `hwio:"bank=2,offset=0x32,writeonly"`
-	Edge2 hwio.Reg16 `hwio:"bank=2,offset=0x34,writeonly"`
-	Edge3 hwio.Reg16 `hwio:"bank=2,offset=0x36,writeonly"`
-	Edge4 hwio.Reg16 `hwio:"bank=2,offset=0x38,writeonly"`
-	Edge5 hwio.Reg16 `hwio:"bank=2,offset=0x3A,writeonly"`
-	Edge6 hwio.Reg16 `hwio:"bank=2,offset=0x3C,writeonly"`
-	Edge7 hwio.Reg16 `hwio:"bank=2,offset=0x3E,writeonly"`
-
 	fifoRegCmd uint32
 	fifoRegCnt int
 
@@ -134,6 +126,13 @@ type HwGeometry struct {
 		start  int64 // cycles at which the frame started (last SwapBuffers)
 		numcmd int   // number of commands sent
 	}
+
+	// Capture of one frame's worth of commands (see CaptureScene)
+	capture struct {
+		fn   func(*raster3d.Scene)
+		on   bool
+		cmds []string
+	}
 }
 
 func NewHwGeometry(irq *HwIrq, e3d *raster3d.HwEngine3d) *HwGeometry {
@@ -161,8 +160,10 @@ func (g *HwGeometry) ReadGXSTAT(val uint32) uint32 {
 		val |= (1 << 0)
 	}
 
-	// FIXME: for now, always return OK to "box test" command (not implemented)
-	val |= 1 << 1
+	// Bit 1: result of the last box test (true if the box is visible)
+	if g.gx.boxTestResult {
+		val |= 1 << 1
+	}
 
 	if g.fifo.LessThanHalfFull() {
 		val |= (1 << 25)
@@ -462,6 +463,10 @@ func (g *HwGeometry) Run(target int64) {
 			g.cycles = tlastarg
 		}
 
+		if g.capture.on {
+			g.capture.cmds = append(g.capture.cmds, gxCmdString(g.curcmd[:nparms+1], desc.parms))
+		}
+
 		if desc.exec == nil {
 			// modGx.WithField("cmd", g.fifo[0].code).Error("unimplemented command")
 		} else {
@@ -481,6 +486,9 @@ func (g *HwGeometry) Run(target int64) {
 			g.cycles += dpd
 			g.framestats.numcmd = 0
 			g.framestats.start = g.cycles + cycles
+			if g.capture.fn != nil && !g.capture.on {
+				g.startCapture()
+			}
 		} else {
 			g.framestats.numcmd++
 		}
@@ -509,3 +517,49 @@ func (g *HwGeometry) Run(target int64) {
 		binary.LittleEndian.PutUint32(g.DirMtx.Data[i*4:i*4+4], uint32(v))
 	}
 }
+
+// CaptureScene requests a capture of the next full 3D frame: all the
+// commands executed between the next two SwapBuffers are logged, and fn is
+// called with the resulting scene.
+func (g *HwGeometry) CaptureScene(fn func(*raster3d.Scene)) {
+	g.capture.fn = fn
+}
+
+func (g *HwGeometry) startCapture() {
+	fn := g.capture.fn
+	g.capture.on = true
+	g.capture.cmds = nil
+	g.gx.e3d.CaptureNextScene(func(s *raster3d.Scene) {
+		s.Commands = g.capture.cmds
+		g.capture.fn, g.capture.on, g.capture.cmds = nil, false, nil
+		fn(s)
+	})
+}
+
+func gxCmdString(cmd []GxCmd, nparms int) string {
+	s := cmd[0].code.String()
+	for i := 0; i < nparms; i++ {
+		s += fmt.Sprintf(" %08x", cmd[i].parm)
+	}
+	return s
+}
+
+func (g *HwGeometry) Serialize(st *savestate.Stream) {
+	st.Section("geometry")
+	hwio.SerializeRegs(st, g)
+	st.Uint32(&g.fifoRegCmd)
+	st.Int(&g.fifoRegCnt)
+	st.Bool(&g.busy)
+	st.Int64(&g.cycles)
+	for i := range g.fifo.cmds {
+		cmd := &g.fifo.cmds[i]
+		st.Int64(&cmd.when)
+		st.Uint8((*uint8)(&cmd.code))
+		st.Uint32(&cmd.parm)
+	}
+	st.Int64(&g.fifo.r)
+	st.Int64(&g.fifo.w)
+	st.Int64(&g.framestats.start)
+	st.Int(&g.framestats.numcmd)
+	g.gx.serialize(st)
+}
@@ -0,0 +1,127 @@
+package nds
+
+import (
+	"fmt"
+	"image"
+	"ndsemu/e2d"
+	"ndsemu/raster3d"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GfxView exports the contents of VRAM of both 2D engines as a set of PNG
+// files (palettes, tile sheets, BG layers and sprites) plus a text listing
+// of the OAM attributes, and the textures used by the 3D engine. Files are
+// overwritten at each dump, and written atomically, so that they can be
+// watched with an image viewer that reloads them automatically.
+type GfxView struct {
+	Dir string
+}
+
+func (gv *GfxView) save(name string, img image.Image) error {
+	fn := filepath.Join(gv.Dir, name)
+	if err := savePng(fn+".tmp", img); err != nil {
+		return err
+	}
+	return os.Rename(fn+".tmp", fn)
+}
+
+func (gv *GfxView) dumpEngine(e *e2d.HwEngine2d) error {
+	name := func(s string) string {
+		return fmt.Sprintf("%s-%c.png", s, 'a'+e.Idx)
+	}
+
+	if err := gv.save(name("palette"), e.ViewPalettes()); err != nil {
+		return err
+	}
+	if err := gv.save(name("extpal"), e.ViewExtPalettes()); err != nil {
+		return err
+	}
+
+	for i := 0; i < 4; i++ {
+		bg := fmt.Sprintf("bg%d", i)
+		if img := e.ViewBgLayer(i); img != nil {
+			if err := gv.save(name(bg), img); err != nil {
+				return err
+			}
+		}
+		// Tiles are shown in the mode currently used by the layer, with
+		// palette 0; sheets for the other modes are generated as well as
+		// the same VRAM area might be used by different layers.
+		for _, mode := range []e2d.TileMode{e2d.Tile16, e2d.Tile256, e2d.Tile256ExtPal} {
+			img := e.ViewBgTiles(i, mode, 0)
+			if err := gv.save(name("tiles-"+bg+"-"+mode.String()), img); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, mode := range []e2d.TileMode{e2d.Tile16, e2d.Tile256, e2d.Tile256ExtPal} {
+		if err := gv.save(name("tiles-obj-"+mode.String()), e.ViewObjTiles(mode, 0)); err != nil {
+			return err
+		}
+	}
+
+	if err := gv.save(name("sprites"), e.ViewSprites()); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, o := range e.OamEntries() {
+		sb.WriteString(o.String())
+		sb.WriteByte('\n')
+	}
+	fn := filepath.Join(gv.Dir, fmt.Sprintf("oam-%c.txt", 'a'+e.Idx))
+	if err := os.WriteFile(fn+".tmp", []byte(sb.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(fn+".tmp", fn)
+}
+
+// dumpTextures exports all the textures used by the current 3D frame into
+// the "textures" subdirectory, together with an index (textures.txt) that
+// describes the parameters of each of them.
+func (gv *GfxView) dumpTextures(e3d *raster3d.HwEngine3d) error {
+	dir := filepath.Join(gv.Dir, "textures")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, t := range e3d.Textures() {
+		name := fmt.Sprintf("tex-%05x-%v-%dx%d", t.VramTexOffset, t.Format, t.Width, t.Height)
+		if t.Format != raster3d.TexDirect {
+			name += fmt.Sprintf("-pal%05x", t.VramPalOffset)
+		}
+		if t.ColorKey {
+			name += "-ck"
+		}
+		name += ".png"
+
+		img, err := e3d.DecodeTexture(t.Texture)
+		if err != nil {
+			fmt.Fprintf(&sb, "%-40s %s (%d polys): %v\n", "-", t.String(), t.NumPolygons, err)
+			continue
+		}
+		if err := gv.save(filepath.Join("textures", name), img); err != nil {
+			return err
+		}
+		fmt.Fprintf(&sb, "%-40s %s (%d polys)\n", name, t.String(), t.NumPolygons)
+	}
+	return os.WriteFile(filepath.Join(dir, "textures.txt"), []byte(sb.String()), 0644)
+}
+
+// Dump writes the views of both engines, and the textures of the 3D engine,
+// into the output directory
+func (gv *GfxView) Dump() error {
+	if err := os.MkdirAll(gv.Dir, 0755); err != nil {
+		return err
+	}
+	for _, e := range Emu.Hw.E2d {
+		if err := gv.dumpEngine(e); err != nil {
+			return err
+		}
+	}
+	return gv.dumpTextures(Emu.Hw.E3d)
+}
@@ -1,8 +1,9 @@
-package main
+package nds
 
 import (
 	"fmt"
 	"ndsemu/emu/fixed"
+	"ndsemu/emu/savestate"
 	"ndsemu/raster3d"
 )
 
@@ -219,7 +220,8 @@ type GeometryEngine struct {
 	}
 	vcnt int
 
-	// Pos/Vec test results
+	// Box/Pos/Vec test results
+	boxTestResult bool
 	posTestResult vector
 	vecTestResult vector
 
@@ -884,6 +886,94 @@ func (gx *GeometryEngine) cmdShininess(parms []GxCmd) {
 	}
 }
 
+// boxFaces lists the corners of each face of the box tested by BOX_TEST
+// (bit 0/1/2 of the corner index select the far X/Y/Z coordinate)
+var boxFaces = [6][4]int{
+	{0, 2, 6, 4}, {1, 3, 7, 5}, // X
+	{0, 1, 5, 4}, {2, 3, 7, 6}, // Y
+	{0, 1, 3, 2}, {4, 5, 7, 6}, // Z
+}
+
+func (gx *GeometryEngine) cmdBoxTest(parms []GxCmd) {
+	var pos, size [3]int32
+	pos[0] = int32(int16(parms[0].parm & 0xFFFF))
+	pos[1] = int32(int16(parms[0].parm >> 16))
+	pos[2] = int32(int16(parms[1].parm & 0xFFFF))
+	size[0] = int32(int16(parms[1].parm >> 16))
+	size[1] = int32(int16(parms[2].parm & 0xFFFF))
+	size[2] = int32(int16(parms[2].parm >> 16))
+
+	var corners [8][4]int64
+	for i := range corners {
+		var v vector
+		for j := 0; j < 3; j++ {
+			v[j].V = pos[j]
+			if i&(1<<uint(j)) != 0 {
+				v[j].V += size[j]
+			}
+		}
+		v[3].V = 1 << 12
+		cv := gx.clipmtx.VecMul(v)
+		for j := range cv {
+			corners[i][j] = int64(cv[j].V)
+		}
+	}
+
+	// The box is visible if any part of its faces survives clipping against
+	// the view volume. Notice that a box that fully encloses the view volume
+	// is not visible, as all its faces are outside of it.
+	gx.boxTestResult = false
+	for _, face := range boxFaces {
+		poly := make([][4]int64, 0, 16)
+		for _, c := range face {
+			poly = append(poly, corners[c])
+		}
+		if len(clipBoxFace(poly)) != 0 {
+			gx.boxTestResult = true
+			break
+		}
+	}
+	modGx.DebugZ("box test").Hex32("p0", parms[0].parm).Hex32("p1", parms[1].parm).
+		Hex32("p2", parms[2].parm).Bool("res", gx.boxTestResult).End()
+}
+
+// clipBoxFace clips a polygon in clip-space coordinates against the view
+// volume (-w <= x,y,z <= w), and returns the visible part (if any)
+func clipBoxFace(poly [][4]int64) [][4]int64 {
+	for plane := 0; plane < 6; plane++ {
+		axis, sign := plane/2, int64(1-2*(plane&1))
+
+		// Distance from the plane; negative means outside
+		dist := func(v [4]int64) int64 { return v[3] + sign*v[axis] }
+
+		var clipped [][4]int64
+		last := poly[len(poly)-1]
+		for _, v := range poly {
+			d0, d1 := dist(last), dist(v)
+			if (d0 < 0) != (d1 < 0) {
+				// Coordinates are 32-bit, so distances and deltas are up to
+				// 33-bit and their product could overflow: compute the ratio
+				// first (0 <= ratio <= 1, as 0.24 fixed point).
+				ratio := (d0 << 24) / (d0 - d1)
+				var vout [4]int64
+				for j := range vout {
+					vout[j] = last[j] + ((v[j]-last[j])*ratio)>>24
+				}
+				clipped = append(clipped, vout)
+			}
+			if d1 >= 0 {
+				clipped = append(clipped, v)
+			}
+			last = v
+		}
+		if len(clipped) == 0 {
+			return nil
+		}
+		poly = clipped
+	}
+	return poly
+}
+
 func (gx *GeometryEngine) cmdPosTest(parms []GxCmd) {
 	var v vector
 	v[0].V = int32(int16(parms[0].parm & 0xFFFF))
@@ -1019,7 +1109,7 @@ var gxCmdDescs = []GxCmdDesc{
 	// 0x6C
 	{0, 0, nil}, {0, 0, nil}, {0, 0, nil}, {0, 0, nil},
 	// 0x70
-	{0, 0, nil}, {2, 9, (*GeometryEngine).cmdPosTest}, {1, 5, (*GeometryEngine).cmdVecTest}, {0, 0, nil},
+	{3, 103, (*GeometryEngine).cmdBoxTest}, {2, 9, (*GeometryEngine).cmdPosTest}, {1, 5, (*GeometryEngine).cmdVecTest}, {0, 0, nil},
 	// 0x74
 	{0, 0, nil}, {0, 0, nil}, {0, 0, nil}, {0, 0, nil},
 	// 0x78
@@ -1027,3 +1117,51 @@ var gxCmdDescs = []GxCmdDesc{
 	// 0x7C
 	{0, 0, nil}, {0, 0, nil}, {0, 0, nil}, {0, 0, nil},
 }
+
+func (gx *GeometryEngine) serialize(st *savestate.Stream) {
+	st.Section("gx")
+	st.Int(&gx.mtxmode)
+	st.Value(&gx.mtx)
+	st.Value(&gx.clipmtx)
+	st.Value(&gx.mtxStackProj)
+	st.Value(&gx.mtxStackPos)
+	st.Value(&gx.mtxStackDir)
+	st.Value(&gx.mtxStackTex)
+	st.Int(&gx.mtxStackProjPtr)
+	st.Int(&gx.mtxStackPosPtr)
+	st.Int(&gx.mtxStackTexPtr)
+	st.Bool(&gx.mtxStackOverflow)
+
+	st.Int(&gx.vx0)
+	st.Int(&gx.vy0)
+	st.Int(&gx.vx1)
+	st.Int(&gx.vy1)
+
+	st.Value(&gx.material)
+	for i := range gx.lights {
+		st.Value(&gx.lights[i].dir)
+		st.Value(&gx.lights[i].half)
+		st.Value(&gx.lights[i].color)
+	}
+	st.Value(&gx.specTable)
+	st.Bool(&gx.specTableOn)
+
+	gx.texinfo.Serialize(st)
+	st.Int(&gx.textrans)
+
+	st.Uint32(&gx.polyattr)
+	st.Int(&gx.displist.primtype)
+	st.Value(&gx.displist.color)
+	st.Uint32(&gx.displist.polyattr)
+	st.Value(&gx.displist.s)
+	st.Value(&gx.displist.t)
+	st.Value(&gx.displist.s0)
+	st.Value(&gx.displist.t0)
+	st.Int(&gx.displist.cnt)
+	st.Value(&gx.displist.lastvtx)
+	st.Int(&gx.vcnt)
+
+	st.Bool(&gx.boxTestResult)
+	st.Value(&gx.posTestResult)
+	st.Value(&gx.vecTestResult)
+}
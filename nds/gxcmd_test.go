@@ -0,0 +1,62 @@
+package nds
+
+import "testing"
+
+// boxTestParms packs the parameters of BOX_TEST (1.3.12 fixed point)
+func boxTestParms(x, y, z, w, h, d int16) []GxCmd {
+	return []GxCmd{
+		{parm: uint32(uint16(x)) | uint32(uint16(y))<<16},
+		{parm: uint32(uint16(z)) | uint32(uint16(w))<<16},
+		{parm: uint32(uint16(h)) | uint32(uint16(d))<<16},
+	}
+}
+
+func TestBoxTest(t *testing.T) {
+	// With an identity clip matrix, the view volume is -1..1 (-0x1000..0x1000)
+	// on all axes.
+	gx := &GeometryEngine{clipmtx: newMatrixIdentity()}
+
+	for _, tc := range []struct {
+		desc    string
+		x, y, z int16
+		w, h, d int16
+		exp     bool
+	}{
+		{"fully inside", -0x800, -0x800, -0x800, 0x1000, 0x1000, 0x1000, true},
+		{"outside, +X", 0x2000, -0x800, -0x800, 0x800, 0x800, 0x800, false},
+		{"outside, -X", -0x3000, -0x800, -0x800, 0x800, 0x800, 0x800, false},
+		{"outside, +Y", -0x800, 0x2000, -0x800, 0x800, 0x800, 0x800, false},
+		{"outside, -Y", -0x800, -0x3000, -0x800, 0x800, 0x800, 0x800, false},
+		{"outside, +Z", -0x800, -0x800, 0x2000, 0x800, 0x800, 0x800, false},
+		{"outside, -Z", -0x800, -0x800, -0x3000, 0x800, 0x800, 0x800, false},
+		{"straddling +X", 0x800, -0x800, -0x800, 0x1000, 0x1000, 0x1000, true},
+		{"straddling -Z", -0x800, -0x800, -0x1800, 0x1000, 0x1000, 0x1000, true},
+		{"negative size, inside", 0x800, 0x800, 0x800, -0x1000, -0x1000, -0x1000, true},
+		{"enclosing the view volume", -0x2000, -0x2000, -0x2000, 0x4000, 0x4000, 0x4000, false},
+	} {
+		gx.boxTestResult = !tc.exp
+		gx.cmdBoxTest(boxTestParms(tc.x, tc.y, tc.z, tc.w, tc.h, tc.d))
+		if gx.boxTestResult != tc.exp {
+			t.Errorf("%s: result = %v, want %v", tc.desc, gx.boxTestResult, tc.exp)
+		}
+	}
+}
+
+func TestClipBoxFaceLargeCoords(t *testing.T) {
+	// A face crossing the X planes, with coordinates near the 32-bit limit:
+	// the intersections must still lie on the planes (with the precision of
+	// the intersection ratio, 1/2^24 of the edge length).
+	const w, x, y = 1 << 30, 1<<31 - 1, 1 << 28
+	const eps = 1 << (33 - 24)
+	poly := clipBoxFace([][4]int64{
+		{-x, 0, 0, w}, {x, 0, 0, w}, {x, y, 0, w}, {-x, y, 0, w},
+	})
+	if len(poly) == 0 {
+		t.Fatal("face clipped away")
+	}
+	for _, v := range poly {
+		if v[0] < -w-eps || v[0] > w+eps || v[1] < 0 || v[1] > y {
+			t.Errorf("vertex outside the view volume: %v", v)
+		}
+	}
+}
@@ -1,6 +1,6 @@
 // Code generated by "stringer -type GxCmdCode"; DO NOT EDIT.
 
-package main
+package nds
 
 import "fmt"
 
@@ -0,0 +1,149 @@
+package nds
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"ndsemu/emu/gfx"
+	log "ndsemu/emu/logger"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	ScreenWidth  = 256
+	ScreenHeight = 192 + 90 + 192
+	FramesPerSec = 60
+)
+
+// HeadlessEpoch is the date/time of the RTC at the start of a headless run
+// (unless a movie is played back, that has its own), so that the emulation
+// is reproducible.
+var HeadlessEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// HeadlessConfig configures RunHeadless.
+type HeadlessConfig struct {
+	Frames     int          // Number of frames to run (0 = no limit)
+	AudioOut   string       // If not empty, file where audio is dumped (raw, 16-bit stereo)
+	ShotDir    string       // Directory where screenshots are saved
+	ShotFrames map[int]bool // Frames after which a screenshot is taken
+	GfxView    string       // If not empty, directory where graphic views are written at each frame
+}
+
+// ScreenImage converts the emulator screen (both screens, plus the gap in
+// between) into an image.
+func ScreenImage(screen gfx.Buffer) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, screen.Width, screen.Height))
+	for y := 0; y < screen.Height; y++ {
+		src := screen.LineAsSlice(y)
+		dst := img.Pix[y*img.Stride : y*img.Stride+screen.Width*4]
+		copy(dst, src)
+		// Alpha is not used by the emulator, force it to opaque
+		for x := 3; x < len(dst); x += 4 {
+			dst[x] = 0xFF
+		}
+	}
+	return img
+}
+
+func savePng(fn string, img image.Image) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ScreenshotName returns the name of the file used for the screenshot taken
+// after the specified frame in headless mode.
+func ScreenshotName(frame int) string {
+	return fmt.Sprintf("frame-%06d.png", frame)
+}
+
+// RunHeadless runs the emulation without opening any window or audio device,
+// so that it can be used for automated testing. The emulation stops after
+// the configured number of frames, when the movie being played back (if any)
+// is finished, when a script requests it, or when the system is powered off.
+// It returns the exit code of the process.
+func RunHeadless(cfg HeadlessConfig, movie *Movie) int {
+	if movie == nil {
+		Emu.Hw.Rtc.SetEmulatedClock(Emu.Sync, HeadlessEpoch)
+	}
+
+	var audioOut *bufio.Writer
+	if cfg.AudioOut != "" {
+		f, err := os.Create(cfg.AudioOut)
+		if err != nil {
+			log.ModEmu.ErrorZ("cannot create audio file").Error("err", err).End()
+			return 1
+		}
+		defer f.Close()
+		audioOut = bufio.NewWriter(f)
+		defer audioOut.Flush()
+	}
+
+	screen := gfx.NewBufferMem(ScreenWidth, ScreenHeight)
+	audio := make([]int16, (AudioFreq/FramesPerSec+1)*2)
+
+	for i := 0; cfg.Frames == 0 || i < cfg.Frames; i++ {
+		if movie != nil {
+			in, err := movie.Process(MovieInput{})
+			if err == io.EOF {
+				log.ModEmu.WarnZ("movie finished").Int("frames", movie.Frame()).End()
+				return 0
+			} else if err != nil {
+				log.ModEmu.ErrorZ("movie error").Error("err", err).End()
+				return 1
+			}
+			Emu.SetInput(in)
+		}
+
+		// Compute the number of audio samples for this frame in the same
+		// way the SDL output does, so that the emulation is identical
+		fc := i % FramesPerSec
+		ns := AudioFreq*(fc+1)/FramesPerSec - AudioFreq*fc/FramesPerSec
+		abuf := audio[:ns*2]
+		for j := range abuf {
+			abuf[j] = 0
+		}
+
+		exit := Emu.RunOneFrame(screen, abuf)
+		if audioOut != nil {
+			if err := binary.Write(audioOut, binary.LittleEndian, abuf); err != nil {
+				log.ModEmu.ErrorZ("cannot write audio").Error("err", err).End()
+				return 1
+			}
+		}
+		if cfg.ShotFrames[i+1] {
+			fn := filepath.Join(cfg.ShotDir, ScreenshotName(i+1))
+			if err := savePng(fn, ScreenImage(screen)); err != nil {
+				log.ModEmu.ErrorZ("cannot save screenshot").Error("err", err).End()
+				return 1
+			}
+		}
+		if cfg.GfxView != "" {
+			gv := GfxView{Dir: cfg.GfxView}
+			if err := gv.Dump(); err != nil {
+				log.ModEmu.ErrorZ("cannot write graphic views").Error("err", err).End()
+				return 1
+			}
+		}
+		if code, ok := Emu.ScriptExited(); ok {
+			log.ModEmu.WarnZ("exit requested by script").Int("frames", i+1).Int("code", code).End()
+			return code
+		}
+		if exit {
+			log.ModEmu.WarnZ("system was powered off").Int("frames", i+1).End()
+			return 0
+		}
+	}
+	return 0
+}
@@ -1,8 +1,9 @@
-package main
+package nds
 
 import (
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 var modIpc = log.NewModule("ipc")
@@ -41,8 +42,8 @@ func (ipc *ipcFifo) Pop() uint32 {
 type HwIpc struct {
 	HwIrq [2]*HwIrq
 
-	Ipc9Sync     hwio.Reg16 `hwio:"bank=0,offset=0x0,rwmask=0xFF00,wcb"`
-	Ipc7Sync     hwio.Reg16 `hwio:"bank=2,offset=0x0,rwmask=0xFF00,wcb"`
+	Ipc9Sync     hwio.Reg16 `hwio:"bank=0,offset=0x0,rwmask=0xFF00,wcb,bits=In:0-3|Out:8-11|SendIrq:13|Irq:14"`
+	Ipc7Sync     hwio.Reg16 `hwio:"bank=2,offset=0x0,rwmask=0xFF00,wcb,bits=In:0-3|Out:8-11|SendIrq:13|Irq:14"`
 	Ipc9FifoCnt  hwio.Reg16 `hwio:"bank=0,offset=0x4,rcb,wcb"`
 	Ipc7FifoCnt  hwio.Reg16 `hwio:"bank=2,offset=0x4,rcb,wcb"`
 	Ipc9FifoSend hwio.Reg32 `hwio:"bank=0,offset=0x8,writeonly,wcb"`
@@ -203,3 +204,18 @@ func (ipc *HwIpc) readIPCFIFORECV(cpunum CpuNum) uint32 {
 	ipc.updateIrqFlags()
 	return value
 }
+
+func (ipc *HwIpc) Serialize(st *savestate.Stream) {
+	st.Section("ipc")
+	hwio.SerializeRegs(st, ipc)
+	for i := range ipc.data {
+		st.Uint32Slice(&ipc.data[i].fifo)
+		st.Bool(&ipc.data[i].emptyIrq)
+		st.Bool(&ipc.data[i].dataIrq)
+		st.Uint32(&ipc.data[i].last)
+		st.Bool(&ipc.enable[i])
+		st.Bool(&ipc.err[i])
+		st.Bool(&ipc.irqEmptyFlag[i])
+		st.Bool(&ipc.irqDataFlag[i])
+	}
+}
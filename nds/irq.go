@@ -1,9 +1,10 @@
-package main
+package nds
 
 import (
 	"ndsemu/arm"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 type HwIrq struct {
@@ -11,8 +12,8 @@ type HwIrq struct {
 	Cpu  *arm.Cpu
 
 	Ime hwio.Reg32 `hwio:"offset=0x08,rwmask=0x1,wcb"`
-	Ie  hwio.Reg32 `hwio:"offset=0x10,wcb"`
-	If  hwio.Reg32 `hwio:"offset=0x14,wcb"`
+	Ie  hwio.Reg32 `hwio:"offset=0x10,wcb,bits=Vblank:0|Hblank:1|Vcount:2|Tm0:3|Tm1:4|Tm2:5|Tm3:6|Sio:7|Dma0:8|Dma1:9|Dma2:10|Dma3:11|Key:12|Slot2:13|IpcSync:16|IpcSend:17|IpcRecv:18|Card:19|CardIreq:20|Gx:21|Lid:22|Spi:23|Wifi:24"`
+	If  hwio.Reg32 `hwio:"offset=0x14,wcb,bits=Vblank:0|Hblank:1|Vcount:2|Tm0:3|Tm1:4|Tm2:5|Tm3:6|Sio:7|Dma0:8|Dma1:9|Dma2:10|Dma3:11|Key:12|Slot2:13|IpcSync:16|IpcSend:17|IpcRecv:18|Card:19|CardIreq:20|Gx:21|Lid:22|Spi:23|Wifi:24"`
 
 	// Mask of level-triggerd IRQs (can't be asserted by CPU)
 	lvlirq uint32
@@ -111,3 +112,9 @@ func (irq *HwIrq) Assert(irqtype IrqType, set bool) {
 	}
 	irq.updateLineStatus()
 }
+
+func (irq *HwIrq) Serialize(st *savestate.Stream) {
+	st.Section(irq.Name)
+	hwio.SerializeRegs(st, irq)
+	st.Uint32(&irq.lvlirq)
+}
@@ -0,0 +1,94 @@
+package nds
+
+import (
+	"ndsemu/emu/host"
+	"ndsemu/emu/hwio"
+	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
+)
+
+type HwKey struct {
+	KeyIn    hwio.Reg16 `hwio:"bank=0,offset=0x0,reset=0x3FF,readonly,rcb,bits=A:0|B:1|Select:2|Start:3|Right:4|Left:5|Up:6|Down:7|R:8|L:9"`
+	KeyCnt   hwio.Reg16 `hwio:"bank=0,offset=0x2,wcb"`
+	ExtKeyIn hwio.Reg16 `hwio:"bank=1,offset=0x6,reset=0x7F,readonly,rcb"`
+
+	keys    uint16
+	penDown bool
+}
+
+// Mapping between the keyboard and the NDS buttons. The index within the
+// array is the bit used in the button mask accepted by HwKey.SetKeys: bits
+// 0-9 match the layout of KEYIN, while bits 10-11 are X/Y (bits 0-1 of
+// EXTKEYIN).
+var keyMapping = [12]int{
+	host.SCANCODE_Z,      // A
+	host.SCANCODE_X,      // B
+	host.SCANCODE_RSHIFT, // Select
+	host.SCANCODE_RETURN, // Start
+	host.SCANCODE_RIGHT,  // Right
+	host.SCANCODE_LEFT,   // Left
+	host.SCANCODE_UP,     // Up
+	host.SCANCODE_DOWN,   // Down
+	host.SCANCODE_A,      // R
+	host.SCANCODE_S,      // L
+	host.SCANCODE_D,      // X
+	host.SCANCODE_C,      // Y
+}
+
+// ReadKeyboard returns the mask of the NDS buttons currently pressed on the
+// keyboard, in the format accepted by HwKey.SetKeys.
+func ReadKeyboard() uint16 {
+	var keys uint16
+	for i, sc := range keyMapping {
+		if host.KeyboardState()[sc] != 0 {
+			keys |= 1 << uint(i)
+		}
+	}
+	return keys
+}
+
+func NewHwKey() *HwKey {
+	key := new(HwKey)
+	hwio.MustInitRegs(key)
+	return key
+}
+
+func (key *HwKey) SetPenDown(value bool) {
+	key.penDown = value
+}
+
+// SetKeys sets the mask of the buttons that are currently pressed (see
+// ReadKeyboard for the format). Buttons are latched once per frame, so
+// that input is deterministic with respect to the emulation, and can be
+// recorded and replayed.
+func (key *HwKey) SetKeys(keys uint16) {
+	key.keys = keys
+}
+
+func (key *HwKey) WriteKEYCNT(_, val uint16) {
+	if val&(1<<14) != 0 {
+		log.ModInput.FatalZ("key interrupt not implemented").End()
+	}
+}
+
+func (key *HwKey) ReadKEYIN(val uint16) uint16 {
+	val &^= key.keys & 0x3FF
+	log.ModInput.InfoZ("read KEYIN").Hex16("val", val).End()
+	return val
+}
+
+func (key *HwKey) ReadEXTKEYIN(val uint16) uint16 {
+	val &^= (key.keys >> 10) & 3
+	if key.penDown {
+		val &^= 1 << 6
+	}
+	log.ModInput.InfoZ("read EXTKEYIN").Hex16("val", val).End()
+	return val
+}
+
+func (key *HwKey) Serialize(st *savestate.Stream) {
+	st.Section("key")
+	hwio.SerializeRegs(st, key)
+	st.Uint16(&key.keys)
+	st.Bool(&key.penDown)
+}
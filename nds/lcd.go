@@ -1,8 +1,9 @@
-package main
+package nds
 
 import (
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 var modLcd = log.NewModule("lcd")
@@ -92,3 +93,8 @@ func (lcd *HwLcd) SyncEvent(x, y int) {
 		// panic("unreachable")
 	}
 }
+
+func (lcd *HwLcd) Serialize(st *savestate.Stream) {
+	st.Section("lcd")
+	hwio.SerializeRegs(st, lcd)
+}
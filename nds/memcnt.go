@@ -1,10 +1,11 @@
-package main
+package nds
 
 import (
 	"fmt"
 	"ndsemu/e2d"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 	"ndsemu/raster3d"
 )
 
@@ -119,16 +120,16 @@ type HwMemoryController struct {
 
 	// Registers accessible by NDS9. Contrary to GBATEK, these are
 	// actually R/W registers.
-	VramCntA hwio.Reg8 `hwio:"bank=0,offset=0x0,rwmask=0x9f,wcb"`
-	VramCntB hwio.Reg8 `hwio:"bank=0,offset=0x1,rwmask=0x9f,wcb"`
-	VramCntC hwio.Reg8 `hwio:"bank=0,offset=0x2,rwmask=0x9f,wcb"`
-	VramCntD hwio.Reg8 `hwio:"bank=0,offset=0x3,rwmask=0x9f,wcb"`
-	VramCntE hwio.Reg8 `hwio:"bank=0,offset=0x4,rwmask=0x9f,wcb"`
-	VramCntF hwio.Reg8 `hwio:"bank=0,offset=0x5,rwmask=0x9f,wcb"`
-	VramCntG hwio.Reg8 `hwio:"bank=0,offset=0x6,rwmask=0x9f,wcb"`
+	VramCntA hwio.Reg8 `hwio:"bank=0,offset=0x0,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
+	VramCntB hwio.Reg8 `hwio:"bank=0,offset=0x1,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
+	VramCntC hwio.Reg8 `hwio:"bank=0,offset=0x2,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
+	VramCntD hwio.Reg8 `hwio:"bank=0,offset=0x3,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
+	VramCntE hwio.Reg8 `hwio:"bank=0,offset=0x4,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
+	VramCntF hwio.Reg8 `hwio:"bank=0,offset=0x5,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
+	VramCntG hwio.Reg8 `hwio:"bank=0,offset=0x6,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
 	WramCnt  hwio.Reg8 `hwio:"bank=0,offset=0x7,rwmask=0x3,wcb"`
-	VramCntH hwio.Reg8 `hwio:"bank=0,offset=0x8,rwmask=0x9f,wcb"`
-	VramCntI hwio.Reg8 `hwio:"bank=0,offset=0x9,rwmask=0x9f,wcb"`
+	VramCntH hwio.Reg8 `hwio:"bank=0,offset=0x8,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
+	VramCntI hwio.Reg8 `hwio:"bank=0,offset=0x9,rwmask=0x9f,wcb,bits=Mst:0-2|Offset:3-4|Enable:7"`
 
 	// Read-only access by NDS7
 	VramStat hwio.Reg8 `hwio:"bank=1,offset=0x0,readonly,rcb"`
@@ -635,3 +636,41 @@ func (mc *HwMemoryController) VramTexturePaletteBank() (tpb raster3d.VramTexture
 	}
 	return
 }
+
+// Serialize saves or loads the memory controller state. When loading, all
+// the memory mappings controlled by the registers are applied again.
+func (mc *HwMemoryController) Serialize(st *savestate.Stream) {
+	st.Section("memcnt")
+
+	hwio.SerializeRegs(st, mc)
+	st.Bytes(mc.wram[:])
+	if !st.Loading() || st.Err() != nil {
+		return
+	}
+
+	// Remap VRAM banks. First disable all of them, so that the mapping
+	// is not affected by banks that were previously overlapping.
+	vramcnt := []*hwio.Reg8{
+		&mc.VramCntA, &mc.VramCntB, &mc.VramCntC, &mc.VramCntD, &mc.VramCntE,
+		&mc.VramCntF, &mc.VramCntG, &mc.VramCntH, &mc.VramCntI,
+	}
+	for i := range vramcnt {
+		mc.writeVRAMCNT(byte('A'+i), 0)
+	}
+	for i, reg := range vramcnt {
+		mc.writeVRAMCNT(byte('A'+i), reg.Value)
+	}
+
+	mc.WriteWRAMCNT(0, mc.WramCnt.Value)
+
+	// Remap gamecard and GBA slot. WriteEXMEMCNT only acts on changed bits,
+	// so simulate a write that flips both of them. The gamecard must be
+	// unmapped first, as it is not unmapped from the destination bus.
+	nds9.Bus.UnmapBank(0x40001A0, Emu.Hw.Gc, 0)
+	nds9.Bus.UnmapBank(0x4100010, Emu.Hw.Gc, 1)
+	nds7.Bus.UnmapBank(0x40001A0, Emu.Hw.Gc, 0)
+	nds7.Bus.UnmapBank(0x4100010, Emu.Hw.Gc, 1)
+	stat := mc.ExMemStat.Value
+	mc.WriteEXMEMCNT(mc.ExMemCnt.Value^(1<<11|1<<7), mc.ExMemCnt.Value)
+	mc.ExMemStat.Value = stat
+}
@@ -0,0 +1,247 @@
+package nds
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"ndsemu/emu/savestate"
+	"os"
+	"time"
+)
+
+// A movie is a recording of the input fed to the emulator, frame by frame,
+// that can be replayed to reproduce exactly the same emulation.
+//
+// The movie is anchored either to power-on, or to a savestate which is
+// embedded within the movie itself. Since the RTC would otherwise introduce
+// non-determinism, the RTC time is recorded at the beginning of the movie,
+// and during both recording and playback it is advanced according to the
+// emulated time.
+//
+// Notice that the contents of the firmware flash are not part of the movie
+// (as they are not part of savestates), so the firmware should not be
+// changed between recording and playback.
+//
+// File format (all values are little-endian):
+//
+//	header:
+//	  magic      [8]byte    "NDSMOVIE"
+//	  version    uint32     cMovieVersion
+//	  gameid     [16]byte   game title and code (from the ROM header)
+//	  skipbios   uint8      1 if recorded with BIOS skipping (-s)
+//	  clock      int64      RTC time at the beginning (Unix nanoseconds)
+//	  statelen   uint32     length of the embedded savestate (0: power-on)
+//	  state      [statelen]byte
+//	frames (until EOF):
+//	  keys       uint16     pressed buttons (see HwKey.SetKeys)
+//	  pendown    uint8      1 if the pen is touching the screen
+//	  penx       int16      pen coordinates (see HwTouchScreen.SetPen)
+//	  peny       int16
+const (
+	cMovieMagic   = "NDSMOVIE"
+	cMovieVersion = 1
+)
+
+// MovieInput is the input for a single frame
+type MovieInput struct {
+	Keys       uint16
+	PenDown    bool
+	PenX, PenY int
+}
+
+// SetInput feeds the input of the next frame into the emulated hardware.
+func (emu *NDSEmulator) SetInput(in MovieInput) {
+	emu.input = in
+	emu.applyInput(in)
+}
+
+func (emu *NDSEmulator) applyInput(in MovieInput) {
+	emu.Hw.Key.SetKeys(in.Keys)
+	emu.Hw.Key.SetPenDown(in.PenDown)
+	emu.Hw.Tsc.SetPen(in.PenDown, in.PenX, in.PenY)
+}
+
+type movieHeader struct {
+	Magic    [8]byte
+	Version  uint32
+	GameId   [16]byte
+	SkipBios uint8
+	Clock    int64
+}
+
+type movieFrame struct {
+	Keys    uint16
+	PenDown uint8
+	PenX    int16
+	PenY    int16
+}
+
+type Movie struct {
+	f      *os.File
+	r      *bufio.Reader
+	record bool
+	frame  int
+}
+
+func movieGameId(emu *NDSEmulator) (id [16]byte) {
+	if emu.Hw.Gc.Size >= 16 {
+		emu.Hw.Gc.ReadAt(id[:], 0)
+	}
+	return
+}
+
+// RecordMovie starts recording a new movie into the specified file. The
+// recording is anchored to the current emulator state: if withState is
+// false, the emulator must be at power-on; otherwise, a savestate is taken
+// and embedded into the movie.
+func RecordMovie(fn string, emu *NDSEmulator, withState bool) (*Movie, error) {
+	var state bytes.Buffer
+	if withState {
+		if err := savestate.Save(&state, cSaveStateVersion, emu); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Create(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	hdr := movieHeader{
+		Version: cMovieVersion,
+		GameId:  movieGameId(emu),
+		Clock:   now.UnixNano(),
+	}
+	copy(hdr.Magic[:], cMovieMagic)
+	if emu.skipBios {
+		hdr.SkipBios = 1
+	}
+
+	w := bufio.NewWriter(f)
+	binary.Write(w, binary.LittleEndian, &hdr)
+	binary.Write(w, binary.LittleEndian, uint32(state.Len()))
+	w.Write(state.Bytes())
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	m := &Movie{f: f, record: true}
+	emu.Hw.Rtc.SetEmulatedClock(emu.Sync, now)
+	return m, nil
+}
+
+// PlayMovie opens a movie for playback. If the movie is anchored to a
+// savestate, the savestate is loaded; otherwise, the emulator must be at
+// power-on.
+func PlayMovie(fn string, emu *NDSEmulator) (*Movie, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	m := &Movie{f: f, r: bufio.NewReader(f)}
+
+	var hdr movieHeader
+	var statelen uint32
+	if err := binary.Read(m.r, binary.LittleEndian, &hdr); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if string(hdr.Magic[:]) != cMovieMagic {
+		f.Close()
+		return nil, errors.New("not a movie file")
+	}
+	if hdr.Version != cMovieVersion {
+		f.Close()
+		return nil, fmt.Errorf("unsupported movie version %d", hdr.Version)
+	}
+	if hdr.GameId != movieGameId(emu) {
+		f.Close()
+		return nil, fmt.Errorf("movie was recorded with a different game (%q)",
+			bytes.TrimRight(hdr.GameId[:], "\x00"))
+	}
+	if (hdr.SkipBios != 0) != emu.skipBios {
+		f.Close()
+		return nil, fmt.Errorf("movie must be played with -s=%v", hdr.SkipBios != 0)
+	}
+
+	if err := binary.Read(m.r, binary.LittleEndian, &statelen); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if statelen != 0 {
+		err := savestate.Load(io.LimitReader(m.r, int64(statelen)), cSaveStateVersion, emu)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	emu.Hw.Rtc.SetEmulatedClock(emu.Sync, time.Unix(0, hdr.Clock))
+	return m, nil
+}
+
+// Recording returns true if the movie is being recorded, false if it is
+// being played back.
+func (m *Movie) Recording() bool {
+	return m.record
+}
+
+// Frame returns the number of frames recorded or played back so far.
+func (m *Movie) Frame() int {
+	return m.frame
+}
+
+// Record appends the input of a frame to the movie being recorded. Frames
+// are written unbuffered, so that the movie is usable even if the emulator
+// crashes.
+func (m *Movie) Record(in MovieInput) error {
+	fr := movieFrame{
+		Keys: in.Keys,
+		PenX: int16(in.PenX),
+		PenY: int16(in.PenY),
+	}
+	if in.PenDown {
+		fr.PenDown = 1
+	}
+	m.frame++
+	return binary.Write(m.f, binary.LittleEndian, &fr)
+}
+
+// Next returns the input of the next frame of the movie being played back.
+// It returns io.EOF at the end of the movie.
+func (m *Movie) Next() (MovieInput, error) {
+	var fr movieFrame
+	if err := binary.Read(m.r, binary.LittleEndian, &fr); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return MovieInput{}, err
+	}
+	m.frame++
+	return MovieInput{
+		Keys:    fr.Keys,
+		PenDown: fr.PenDown != 0,
+		PenX:    int(fr.PenX),
+		PenY:    int(fr.PenY),
+	}, nil
+}
+
+// Process handles the input of a single frame. When recording, in is
+// appended to the movie and returned unchanged; when playing back, in is
+// ignored and the recorded input is returned instead. At the end of the
+// playback, io.EOF is returned.
+func (m *Movie) Process(in MovieInput) (MovieInput, error) {
+	if m.record {
+		return in, m.Record(in)
+	}
+	return m.Next()
+}
+
+func (m *Movie) Close() error {
+	return m.f.Close()
+}
@@ -1,10 +1,11 @@
-package main
+package nds
 
 import (
 	"ndsemu/arm"
 	"ndsemu/emu/fixed"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 type NDS7 struct {
@@ -186,3 +187,15 @@ type miscRegsGba struct {
 func (m *miscRegsGba) WriteHALTCNT(_, _ uint8) {
 	nds7.Cpu.SetLine(arm.LineHalt, true)
 }
+
+func (n *NDS7) Serialize(st *savestate.Stream) {
+	st.Section("nds7")
+	n.Cpu.Serialize(st)
+	n.Irq.Serialize(st)
+	n.Timers.Serialize(st)
+	for _, dma := range n.Dma {
+		dma.Serialize(st)
+	}
+	hwio.SerializeRegs(st, &n.misc7)
+	hwio.SerializeRegs(st, &n.miscgba)
+}
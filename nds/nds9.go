@@ -1,9 +1,10 @@
-package main
+package nds
 
 import (
 	"ndsemu/arm"
 	"ndsemu/emu/fixed"
 	"ndsemu/emu/hwio"
+	"ndsemu/emu/savestate"
 )
 
 type NDS9 struct {
@@ -53,8 +54,7 @@ func (n *NDS9) InitBus(emu *NDSEmulator) {
 	n.Bus.MapMemorySlice(0x07000000, 0x07FFFFFF, emu.Mem.OamRam[:], false)
 	n.Bus.MapMemorySlice(0xFFFF0000, 0xFFFF7FFF, emu.Rom.Bios9, true)
 
-	n.Bus.MapReg8(0x4000300, &n.misc.PostFlg)
-	n.Bus.MapReg32(0x4000304, &n.misc.PowCnt)
+	n.Bus.MapBank(0x4000300, &n.misc, 0)
 	n.Bus.MapBank(0x4000000, emu.Hw.Lcd9, 0)
 	n.Bus.MapBank(0x4000000, emu.Hw.E2d[0], 0)
 	n.Bus.MapBank(0x4000000, emu.Hw.E2d[0], 1)
@@ -76,7 +76,6 @@ func (n *NDS9) InitBus(emu *NDSEmulator) {
 	n.Bus.MapBank(0x40000D4, n.Dma[3], 0)
 	n.Bus.MapBank(0x40000E0, n.DmaFill, 0)
 	n.Bus.MapBank(0x4000180, emu.Hw.Ipc, 0)
-	n.Bus.MapBank(0x4000300, emu.Hw.Geom, 2)
 	n.Bus.MapBank(0x4000400, emu.Hw.Geom, 0)
 	n.Bus.MapBank(0x4000600, emu.Hw.Geom, 1)
 	n.Bus.MapBank(0x4001000, emu.Hw.E2d[1], 0)
@@ -116,6 +115,18 @@ func (n *NDS9) TriggerDmaEvent(event DmaEvent) {
 }
 
 type miscRegs9 struct {
-	PostFlg hwio.Reg8  `hwio:"rwmask=3"`
-	PowCnt  hwio.Reg32 `hwio:"rwmask=0x820F"`
+	PostFlg hwio.Reg8  `hwio:"offset=0x0,rwmask=3"`
+	PowCnt  hwio.Reg32 `hwio:"offset=0x4,rwmask=0x820F,bits=Lcd:0|Gfx2dA:1|Render3d:2|Geom3d:3|Gfx2dB:9|Swap:15"`
+}
+
+func (n *NDS9) Serialize(st *savestate.Stream) {
+	st.Section("nds9")
+	n.Cpu.Serialize(st)
+	n.Irq.Serialize(st)
+	n.Timers.Serialize(st)
+	for _, dma := range n.Dma {
+		dma.Serialize(st)
+	}
+	n.DmaFill.Serialize(st)
+	hwio.SerializeRegs(st, &n.misc)
 }
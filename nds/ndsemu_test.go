@@ -1,4 +1,4 @@
-package main
+package nds
 
 import (
 	"ndsemu/emu/gfx"
@@ -19,14 +19,16 @@ func BenchmarkCpuSpeed(b *testing.B) {
 	f.Close()
 	defer os.Remove(f.Name())
 
+	audio := make([]int16, (AudioFreq/FramesPerSec+1)*2)
+
 	for i := 0; i < b.N; i++ {
-		Emu = NewNDSEmulator(f.Name())
+		Emu = NewNDSEmulator(f.Name(), false, false)
 		Emu.Hw.Gc.MapCartFile("roms/phoenixwright.nds")
 		Emu.Hw.Ff.MapFirmwareFile("bios/firmware.bin")
 		Emu.Hw.Rtc.ResetDefaults()
 
 		for j := 0; j < 300; j++ {
-			Emu.RunOneFrame(screen)
+			Emu.RunOneFrame(screen, audio)
 		}
 	}
 }
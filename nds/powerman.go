@@ -1,7 +1,8 @@
-package main
+package nds
 
 import (
-	"ndsemu/emu/hw"
+	"ndsemu/emu/host"
+	"ndsemu/emu/savestate"
 	"ndsemu/emu/spi"
 
 	log "ndsemu/emu/logger"
@@ -57,7 +58,7 @@ func (ff *HwPowerMan) SpiTransfer(data []byte) ([]byte, spi.ReqStatus) {
 		case 1:
 			// Bit 0: if set, battery is finishing
 			val := uint8(0)
-			if hw.ReadBatteryStatus != nil && hw.ReadBatteryStatus() < 10 {
+			if host.ReadBatteryStatus != nil && host.ReadBatteryStatus() < 10 {
 				val |= 1
 			}
 			return []byte{val}, spi.ReqFinish
@@ -76,3 +77,10 @@ func (ff *HwPowerMan) SpiTransfer(data []byte) ([]byte, spi.ReqStatus) {
 
 func (ff *HwPowerMan) SpiBegin() {}
 func (ff *HwPowerMan) SpiEnd()   {}
+
+func (pow *HwPowerMan) Serialize(st *savestate.Stream) {
+	st.Section("powerman")
+	st.Uint8(&pow.cntrl)
+	st.Bool(&pow.mic)
+	st.Int(&pow.micgain)
+}
@@ -0,0 +1,310 @@
+package nds
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	imgcolor "image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// The regression test suite is described by a TOML manifest, like this:
+//
+//	# Directory containing the golden images (relative to the manifest)
+//	golden = "golden"
+//
+//	[[test]]
+//	name = "pw-title"
+//	rom = "roms/phoenixwright.nds"   # relative to the manifest
+//	args = ["-s"]                    # extra command line arguments
+//	movie = "movies/pw-title.movie"  # optional, scripted input
+//	frames = [120, 600]
+//
+//	# Optional: compare the screen at a frame with a hash (SHA-1 of the
+//	# RGBA pixels) instead of a golden image.
+//	[[test.hash]]
+//	frame = 120
+//	sha1 = "..."
+//
+// Each test is run in a separate headless emulator process, taking a
+// screenshot of both screens at the specified frames. Screenshots are
+// compared against the golden image <golden>/<name>-<frame>.png or,
+// if there is none, against the specified hash.
+// On mismatch, both the actual screenshot and an image highlighting the
+// differences are written into the output directory.
+type regressManifest struct {
+	Golden string
+	Tests  []regressTest `toml:"test"`
+}
+
+type regressTest struct {
+	Name   string
+	Rom    string
+	Args   []string
+	Movie  string
+	Frames []int
+	Hashes []regressHash `toml:"hash"`
+}
+
+type regressHash struct {
+	Frame int
+	Sha1  string
+}
+
+func loadPng(fn string) (*image.RGBA, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba, nil
+	}
+	rgba := image.NewRGBA(img.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return rgba, nil
+}
+
+func imageHash(img *image.RGBA) string {
+	h := sha1.New()
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		off := img.PixOffset(img.Rect.Min.X, y)
+		h.Write(img.Pix[off : off+img.Rect.Dx()*4])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// diffImage compares two images. It returns the number of different pixels,
+// and an image where different pixels are red, while equal pixels are shown
+// dimmed.
+func diffImage(got, exp *image.RGBA) (int, *image.RGBA) {
+	if got.Bounds() != exp.Bounds() {
+		return got.Bounds().Dx() * got.Bounds().Dy(), got
+	}
+
+	ndiff := 0
+	diff := image.NewRGBA(got.Bounds())
+	for y := got.Rect.Min.Y; y < got.Rect.Max.Y; y++ {
+		for x := got.Rect.Min.X; x < got.Rect.Max.X; x++ {
+			c1, c2 := got.RGBAAt(x, y), exp.RGBAAt(x, y)
+			if c1 != c2 {
+				diff.SetRGBA(x, y, imgcolor.RGBA{0xFF, 0, 0, 0xFF})
+				ndiff++
+			} else {
+				diff.SetRGBA(x, y, imgcolor.RGBA{c1.R / 4, c1.G / 4, c1.B / 4, 0xFF})
+			}
+		}
+	}
+	return ndiff, diff
+}
+
+func copyFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// runRegressTest runs a single test in a headless emulator, taking the
+// screenshots into dir.
+func runRegressTest(t *regressTest, basedir, dir string) error {
+	if len(t.Frames) == 0 {
+		return errors.New("no frames specified")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	var frames []string
+	maxframe := 0
+	for _, f := range t.Frames {
+		frames = append(frames, strconv.Itoa(f))
+		if f > maxframe {
+			maxframe = f
+		}
+	}
+
+	args := append([]string{}, t.Args...)
+	args = append(args,
+		"-frames", strconv.Itoa(maxframe),
+		"-shot-dir", dir,
+		"-shot-frames", strings.Join(frames, ","),
+		// Always start from an empty backup memory and a pristine copy
+		// of the firmware, so that the test is not affected by previous
+		// runs
+		"-save", filepath.Join(dir, "backup.sav"),
+		"-firmware-save", filepath.Join(dir, "firmware.sav"),
+	)
+	if t.Movie != "" {
+		args = append(args, "-play", filepath.Join(basedir, t.Movie))
+	}
+	args = append(args, filepath.Join(basedir, t.Rom))
+
+	cmd := exec.Command(exe, args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		// Show the last part of the output, that usually contains
+		// the reason of the failure
+		if len(out) > 2048 {
+			out = out[len(out)-2048:]
+		}
+		return fmt.Errorf("%v\n%s", err, out)
+	}
+	return nil
+}
+
+// checkRegressTest compares the screenshots taken by a test (in dir) against
+// the golden images or hashes, returning the number of passed and failed
+// checks.
+func checkRegressTest(t *regressTest, dir, golden, outdir string, update bool) (npass, nfail int) {
+	hashes := make(map[int]string)
+	for _, h := range t.Hashes {
+		hashes[h.Frame] = h.Sha1
+	}
+
+	sort.Ints(t.Frames)
+	for _, frame := range t.Frames {
+		name := fmt.Sprintf("%s-%d", t.Name, frame)
+		shot := filepath.Join(dir, ScreenshotName(frame))
+		goldfn := filepath.Join(golden, name+".png")
+
+		got, err := loadPng(shot)
+		if err != nil {
+			fmt.Printf("FAIL %s: no screenshot (%v)\n", name, err)
+			nfail++
+			continue
+		}
+
+		if update {
+			if _, found := hashes[frame]; found {
+				fmt.Printf("UPDATE %s: sha1 = %q\n", name, imageHash(got))
+			} else if err := copyFile(goldfn, shot); err != nil {
+				fmt.Printf("FAIL %s: %v\n", name, err)
+				nfail++
+				continue
+			} else {
+				fmt.Printf("UPDATE %s\n", name)
+			}
+			npass++
+			continue
+		}
+
+		exp, err := loadPng(goldfn)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Printf("FAIL %s: cannot load golden image (%v)\n", name, err)
+			nfail++
+			continue
+		}
+
+		var ndiff int
+		var diff *image.RGBA
+		if exp != nil {
+			ndiff, diff = diffImage(got, exp)
+		} else if h, found := hashes[frame]; found {
+			if imageHash(got) != h {
+				ndiff = -1
+			}
+		} else {
+			fmt.Printf("FAIL %s: no golden image or hash\n", name)
+			copyFile(filepath.Join(outdir, name+".png"), shot)
+			nfail++
+			continue
+		}
+
+		if ndiff == 0 {
+			fmt.Printf("PASS %s\n", name)
+			npass++
+			continue
+		}
+
+		nfail++
+		copyFile(filepath.Join(outdir, name+".png"), shot)
+		if diff != nil {
+			savePng(filepath.Join(outdir, name+"-diff.png"), diff)
+			fmt.Printf("FAIL %s: %d pixels differ\n", name, ndiff)
+		} else {
+			fmt.Printf("FAIL %s: hash mismatch\n", name)
+		}
+	}
+	return
+}
+
+// RunRegress runs the regression test suite described by the specified
+// manifest. If update is true, golden images and hashes are regenerated
+// instead of being checked. Screenshots of failed tests are written into
+// outdir. It returns the exit code of the process.
+func RunRegress(manifest string, outdir string, update bool) int {
+	var cfg regressManifest
+	if _, err := toml.DecodeFile(manifest, &cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot load regression manifest: %v\n", err)
+		return 1
+	}
+
+	basedir := filepath.Dir(manifest)
+	golden := filepath.Join(basedir, cfg.Golden)
+	if err := os.MkdirAll(outdir, 0777); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if update {
+		if err := os.MkdirAll(golden, 0777); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	npass, nfail := 0, 0
+	for i := range cfg.Tests {
+		t := &cfg.Tests[i]
+		tmpdir, err := os.MkdirTemp("", "ndsemu-regress")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		if err := runRegressTest(t, basedir, tmpdir); err != nil {
+			fmt.Printf("FAIL %s: %v\n", t.Name, err)
+			nfail++
+		} else {
+			p, f := checkRegressTest(t, tmpdir, golden, outdir, update)
+			npass += p
+			nfail += f
+		}
+		os.RemoveAll(tmpdir)
+	}
+
+	fmt.Printf("%d passed, %d failed\n", npass, nfail)
+	if nfail != 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package nds
+
+import (
+	"image"
+	imgcolor "image/color"
+	"testing"
+)
+
+func TestDiffImage(t *testing.T) {
+	img1 := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	img2 := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	img1.SetRGBA(3, 4, imgcolor.RGBA{1, 2, 3, 0xFF})
+	img2.SetRGBA(3, 4, imgcolor.RGBA{1, 2, 3, 0xFF})
+
+	if n, _ := diffImage(img1, img2); n != 0 {
+		t.Errorf("equal images: %d pixels differ", n)
+	}
+	if imageHash(img1) != imageHash(img2) {
+		t.Errorf("equal images have different hashes")
+	}
+
+	img2.SetRGBA(5, 6, imgcolor.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
+	n, diff := diffImage(img1, img2)
+	if n != 1 {
+		t.Errorf("invalid number of different pixels: %d", n)
+	}
+	if c := diff.RGBAAt(5, 6); c != (imgcolor.RGBA{0xFF, 0, 0, 0xFF}) {
+		t.Errorf("different pixel not highlighted: %v", c)
+	}
+	if imageHash(img1) == imageHash(img2) {
+		t.Errorf("different images have the same hash")
+	}
+
+	img3 := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	if n, _ := diffImage(img1, img3); n != 16*8 {
+		t.Errorf("images of different size: %d pixels differ", n)
+	}
+}
@@ -1,8 +1,10 @@
-package main
+package nds
 
 import (
+	"ndsemu/emu"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 	"time"
 )
 
@@ -106,6 +108,10 @@ type HwRtc struct {
 		hour      byte
 		minOrFreq byte
 	}
+
+	// Source of the current date/time. It defaults to the host clock, but
+	// can be replaced to make the emulation deterministic (eg: movies).
+	Clock func() time.Time
 }
 
 func NewHwRtc() *HwRtc {
@@ -113,10 +119,25 @@ func NewHwRtc() *HwRtc {
 	rtc.regStatus1 = 0x00 // 0x80: reset to defaults
 	rtc.regStatus2 = 0x00
 	rtc.HwSerial3W.dev = rtc
+	rtc.Clock = time.Now
 	hwio.MustInitRegs(&rtc.HwSerial3W)
 	return rtc
 }
 
+// SetEmulatedClock makes the RTC deterministic: the current time is
+// computed from the specified start time, advanced by the emulated time
+// elapsed since this call (instead of the host clock).
+func (rtc *HwRtc) SetEmulatedClock(sync *emu.Sync, start time.Time) {
+	cycles := sync.Cycles()
+	rtc.Clock = func() time.Time {
+		elapsed := sync.Cycles() - cycles
+		// Split seconds and remainder, as elapsed*1e9 would overflow after
+		// a few minutes of emulation
+		return start.Add(time.Duration(elapsed/cEmuClock)*time.Second +
+			time.Duration((elapsed%cEmuClock)*1e9/cEmuClock))
+	}
+}
+
 func (rtc *HwRtc) ResetDefaults() {
 	rtc.regStatus1 = 0x80
 	rtc.regStatus2 = 0x00
@@ -248,7 +269,7 @@ func (rtc *HwRtc) WriteData(val uint8) {
 		rtc.buf = append(rtc.buf, rtc.regStatus2)
 
 	case RtcRegDatetime, RtcRegTime:
-		now := time.Now()
+		now := rtc.Clock()
 
 		var hour uint8
 		if rtc.regStatus1&2 != 0 {
@@ -302,3 +323,27 @@ func (rtc *HwRtc) WriteData(val uint8) {
 
 	modRtc.Infof("read %q: %x", rtcRegnames[reg], rtc.buf)
 }
+
+func (s *HwSerial3W) serialize(st *savestate.Stream) {
+	hwio.SerializeRegs(st, s)
+	st.Bool(&s.cs)
+	st.Bool(&s.clk)
+	st.Bool(&s.datadir)
+	st.Uint8(&s.data)
+	st.Int(&s.cnt)
+}
+
+func (rtc *HwRtc) Serialize(st *savestate.Stream) {
+	st.Section("rtc")
+	rtc.HwSerial3W.serialize(st)
+	st.Uint8(&rtc.regStatus1)
+	st.Uint8(&rtc.regStatus2)
+	st.Bool(&rtc.writing)
+	st.ByteSlice(&rtc.buf)
+	st.Int(&rtc.idx)
+	for i := range rtc.alarms {
+		st.Uint8(&rtc.alarms[i].dow)
+		st.Uint8(&rtc.alarms[i].hour)
+		st.Uint8(&rtc.alarms[i].minOrFreq)
+	}
+}
@@ -0,0 +1,104 @@
+package nds
+
+import (
+	"errors"
+	"ndsemu/emu/savestate"
+	"os"
+)
+
+// Version of the savestate format. This must be bumped every time the
+// serialized state changes in an incompatible way (eg: a field is added to
+// any device), so that old savestates are refused instead of being loaded
+// incorrectly.
+const cSaveStateVersion = 4
+
+// Serialize saves or loads the whole emulator state. It must be called at
+// frame boundary (that is, outside of RunOneFrame).
+//
+// The ROMs (BIOS, gamecard and slot-2 cartridge) and the firmware are not
+// part of the state, so the same files must be in use when a state is loaded.
+func (emu *NDSEmulator) Serialize(st *savestate.Stream) {
+	if emu.Mode != ModeNds || emu.switchingToGba {
+		st.SetError(errors.New("savestates are not supported in GBA mode"))
+		return
+	}
+
+	st.Section("emu")
+	st.Int(&emu.framecount)
+	st.Uint32(&emu.powcnt)
+
+	st.Section("mem")
+	st.Bytes(emu.Mem.Ram[:])
+	st.Bytes(emu.Mem.Vram[:])
+	st.Bytes(emu.Mem.Wram[:])
+	st.Bytes(emu.Mem.PaletteRam[:])
+	st.Bytes(emu.Mem.OamRam[:])
+
+	// Sync must be restored before any device that needs to schedule
+	// events, and the memory controller before any device that caches
+	// pointers to memory.
+	emu.Sync.Serialize(st)
+	emu.Hw.Mc.Serialize(st)
+
+	nds9.Serialize(st)
+	nds7.Serialize(st)
+
+	hw := emu.Hw
+	hw.E2d[0].Serialize(st)
+	hw.E2d[1].Serialize(st)
+	hw.E3d.Serialize(st)
+	hw.Lcd9.Serialize(st)
+	hw.Lcd7.Serialize(st)
+	hw.Ipc.Serialize(st)
+	hw.Div.Serialize(st)
+	hw.Rtc.Serialize(st)
+	hw.Wifi.Serialize(st)
+	hw.Spi.Serialize(st)
+	hw.Gc.Serialize(st)
+	hw.Bkp.Serialize(st)
+	hw.Ff.Serialize(st)
+	hw.Tsc.Serialize(st)
+	hw.Pow.Serialize(st)
+	hw.Key.Serialize(st)
+	hw.Snd.Serialize(st)
+	hw.Geom.Serialize(st)
+	hw.Sl2.Serialize(st)
+
+	if st.Loading() {
+		hw.E3d.SetVram(hw.Mc.VramTextureBank(), hw.Mc.VramTexturePaletteBank())
+	}
+}
+
+// SaveState writes a savestate of the current emulator state into the
+// specified file.
+func (emu *NDSEmulator) SaveState(fn string) error {
+	// Write into a temporary file first, so that an existing savestate
+	// is not destroyed in case of errors.
+	f, err := os.Create(fn + ".tmp")
+	if err != nil {
+		return err
+	}
+	if err := savestate.Save(f, cSaveStateVersion, emu); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), fn)
+}
+
+// LoadState restores the emulator state from the specified savestate file.
+// If a *savestate.PartialError is returned, the emulator state is undefined;
+// for any other error (eg: missing file, or savestate.ErrVersion), the
+// emulator is not modified.
+func (emu *NDSEmulator) LoadState(fn string) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return savestate.Load(f, cSaveStateVersion, emu)
+}
@@ -0,0 +1,99 @@
+package nds
+
+import (
+	"ndsemu/emu/host"
+	log "ndsemu/emu/logger"
+	"ndsemu/raster3d"
+	"os"
+)
+
+// SceneInspector is a tool to debug the 3D engine. It captures a whole frame
+// (geometry commands and resulting polygons), writes it as text and as a
+// Wavefront OBJ, and then allows to select a polygon of the captured scene to
+// highlight it on screen. It is driven by hotkeys:
+//
+//	F11        capture the next frame
+//	F12        toggle wireframe mode
+//	PgUp/PgDn  highlight the previous/next polygon of the captured scene
+//	Home       stop highlighting
+type SceneInspector struct {
+	Prefix string // Prefix of the output files
+
+	scene *raster3d.Scene
+	sel   int
+	keys  [5]uint8
+}
+
+var sceneInspectorKeys = [5]int{
+	host.SCANCODE_F11, host.SCANCODE_F12, host.SCANCODE_PAGEUP, host.SCANCODE_PAGEDOWN, host.SCANCODE_HOME,
+}
+
+// Poll checks the hotkeys (only on key press)
+func (si *SceneInspector) Poll(keys []uint8) {
+	for i, k := range sceneInspectorKeys {
+		if keys[k] != 0 && si.keys[i] == 0 {
+			si.handleKey(k)
+		}
+		si.keys[i] = keys[k]
+	}
+}
+
+func (si *SceneInspector) handleKey(key int) {
+	e3d := Emu.Hw.E3d
+	switch key {
+	case host.SCANCODE_F11:
+		Emu.Hw.Geom.CaptureScene(si.captured)
+		log.ModEmu.WarnZ("capturing next 3D frame").End()
+	case host.SCANCODE_F12:
+		e3d.SetWireframe(!e3d.Wireframe())
+	case host.SCANCODE_PAGEUP:
+		si.selectPoly(si.sel - 1)
+	case host.SCANCODE_PAGEDOWN:
+		si.selectPoly(si.sel + 1)
+	case host.SCANCODE_HOME:
+		si.sel = -1
+		e3d.SetHighlight(-1)
+	}
+}
+
+func (si *SceneInspector) selectPoly(idx int) {
+	if si.scene == nil || len(si.scene.Polygons) == 0 {
+		log.ModEmu.WarnZ("no 3D scene captured (press F11)").End()
+		return
+	}
+	n := len(si.scene.Polygons)
+	si.sel = (idx + n) % n
+	Emu.Hw.E3d.SetHighlight(si.sel)
+
+	p := &si.scene.Polygons[si.sel]
+	log.ModEmu.WarnZ("polygon").Int("idx", si.sel).String("attrs", p.String()).End()
+	for i := range p.Vtx {
+		log.ModEmu.WarnZ("  vertex").Int("n", i).String("vtx", p.Vtx[i].String()).End()
+	}
+}
+
+func (si *SceneInspector) captured(s *raster3d.Scene) {
+	si.scene = s
+	si.sel = -1
+
+	write := func(fn string, w func(f *os.File) error) {
+		f, err := os.Create(fn)
+		if err == nil {
+			err = w(f)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err != nil {
+			log.ModEmu.ErrorZ("cannot write 3D scene").String("file", fn).Error("err", err).End()
+		}
+	}
+	write(si.Prefix+".txt", func(f *os.File) error { return s.WriteText(f) })
+	write(si.Prefix+".obj", func(f *os.File) error { return s.WriteObj(f) })
+
+	log.ModEmu.WarnZ("3D frame captured").
+		Int("cmds", len(s.Commands)).
+		Int("polys", len(s.Polygons)).
+		String("file", si.Prefix+".txt").
+		End()
+}
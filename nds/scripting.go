@@ -0,0 +1,100 @@
+package nds
+
+import (
+	"fmt"
+	"ndsemu/emu/hwio"
+	"ndsemu/emu/script"
+	"strings"
+)
+
+// Names of the buttons in scripts, in the order of the bits of MovieInput.Keys
+var scriptKeyNames = []string{
+	"A", "B", "SELECT", "START", "RIGHT", "LEFT", "UP", "DOWN", "R", "L", "X", "Y",
+}
+
+// scriptHost implements script.Host for the NDS emulator
+type scriptHost struct {
+	emu *NDSEmulator
+}
+
+// ResolveAddr resolves an address like NDSEmulator.resolveAddr; additionally,
+// I/O registers can be referred to by name, with the "io:" prefix (eg:
+// "io:KeyIn").
+func (h scriptHost) ResolveAddr(cpu string, s string) (uint32, error) {
+	if name := strings.TrimPrefix(s, "io:"); name != s {
+		var bus *hwio.Table
+		switch cpu {
+		case "arm9":
+			bus = nds9.Bus
+		case "arm7":
+			bus = nds7.Bus
+		}
+		for _, r := range bus.Regs(0x04000000, 0x04FFFFFF) {
+			if strings.EqualFold(r.Name, name) {
+				return r.Addr, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown I/O register %q", name)
+	}
+	if !strings.Contains(s, ":") {
+		// Prefer the symbols of the CPU being referred to
+		if addr, err := h.emu.resolveAddr(cpu + ":" + s); err == nil {
+			return addr, nil
+		}
+	}
+	return h.emu.resolveAddr(s)
+}
+
+func (h scriptHost) KeyMask(name string) (uint16, error) {
+	for i, n := range scriptKeyNames {
+		if strings.EqualFold(n, name) {
+			return 1 << uint(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key %q", name)
+}
+
+// SetInput adds the input of the script to the one of the user
+func (h scriptHost) SetInput(in script.Input) {
+	merged := h.emu.input
+	merged.Keys |= in.Keys
+	if in.PenDown {
+		merged.PenDown, merged.PenX, merged.PenY = true, in.PenX, in.PenY
+	}
+	h.emu.applyInput(merged)
+}
+
+func (h scriptHost) Screenshot(fn string) error {
+	return savePng(fn, ScreenImage(h.emu.screen))
+}
+
+func (h scriptHost) SaveState(fn string) error { return h.emu.SaveState(fn) }
+func (h scriptHost) LoadState(fn string) error { return h.emu.LoadState(fn) }
+
+// Script returns the scripting engine, which allows to install hooks and to
+// control the emulation from Go code. The engine is created on first use;
+// scripts and hooks are run from within RunOneFrame.
+func (emu *NDSEmulator) Script() *script.Engine {
+	if emu.script == nil {
+		emu.script = script.New(scriptHost{emu},
+			[]script.Cpu{nds9.Cpu, nds7.Cpu},
+			[]string{"arm9", "arm7"})
+	}
+	return emu.script
+}
+
+// StartScript loads an automation script (see package script) and starts
+// running it. It must be called after the symbols have been loaded and the
+// debugger (if any) has been started.
+func (emu *NDSEmulator) StartScript(fn string) error {
+	return emu.Script().Load(fn)
+}
+
+// ScriptExited returns true if a script requested the emulator to exit, with
+// the exit code.
+func (emu *NDSEmulator) ScriptExited() (int, bool) {
+	if emu.script == nil {
+		return 0, false
+	}
+	return emu.script.Exited()
+}
@@ -1,7 +1,8 @@
-package main
+package nds
 
 import (
 	"io"
+	"ndsemu/emu/savestate"
 	"ndsemu/homebrew"
 	"os"
 )
@@ -84,3 +85,10 @@ func (slot *HwSlot2) HomebrewMapFatFile(fn string) error {
 func (slot *HwSlot2) UnmapCart() {
 	slot.Rom = highz[:]
 }
+
+// Serialize saves or loads the contents of the slot-2 RAM. The ROM is not
+// part of the state and must be mapped by the caller.
+func (slot *HwSlot2) Serialize(st *savestate.Stream) {
+	st.Section("slot2")
+	st.Bytes(slot.Ram[:])
+}
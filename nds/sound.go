@@ -1,12 +1,13 @@
-package main
+package nds
 
 import (
 	"encoding/binary"
 	"hash/crc64"
 	"ndsemu/emu"
-	"ndsemu/emu/hw"
+	"ndsemu/emu/host"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 
 	"github.com/hashicorp/golang-lru/simplelru"
 )
@@ -45,6 +46,7 @@ type HwSound struct {
 
 	voice [16]struct {
 		mem   []byte
+		addr  uint32 // address of mem in the bus (PCM modes)
 		tmr   uint32
 		pos   uint
 		on    bool
@@ -134,6 +136,7 @@ func (snd *HwSound) startChannel(idx int) {
 
 	v.on = false // will put true at the end of the function, if no error
 	v.mem = ptr[:length]
+	v.addr = ch.SndSad.Value
 	v.pos = 0
 	v.delay = 3
 	v.tmr = uint32(ch.SndTmr.Value)
@@ -345,19 +348,19 @@ func (snd *HwSound) step() (uint16, uint16) {
 	}
 
 	scans := []int{
-		hw.SCANCODE_0,
-		hw.SCANCODE_1,
-		hw.SCANCODE_2,
-		hw.SCANCODE_3,
-		hw.SCANCODE_4,
-		hw.SCANCODE_5,
-		hw.SCANCODE_6,
-		hw.SCANCODE_7,
-		hw.SCANCODE_8,
-		hw.SCANCODE_9,
+		host.SCANCODE_0,
+		host.SCANCODE_1,
+		host.SCANCODE_2,
+		host.SCANCODE_3,
+		host.SCANCODE_4,
+		host.SCANCODE_5,
+		host.SCANCODE_6,
+		host.SCANCODE_7,
+		host.SCANCODE_8,
+		host.SCANCODE_9,
 	}
 
-	keys := hw.GetKeyboardState()
+	keys := host.KeyboardState()
 	mask := 0xFFFF
 	pressed := 0
 	for i := 0; i < len(scans); i++ {
@@ -547,3 +550,54 @@ func (snd *HwSound) step() (uint16, uint16) {
 
 	return uint16(lmix), uint16(rmix)
 }
+
+func (snd *HwSound) Serialize(st *savestate.Stream) {
+	st.Section("sound")
+	hwio.SerializeRegs(st, snd)
+	for i := range snd.Ch {
+		hwio.SerializeRegs(st, &snd.Ch[i])
+	}
+
+	for i := range snd.voice {
+		v := &snd.voice[i]
+		st.Uint32(&v.tmr)
+		pos := uint64(v.pos)
+		st.Uint64(&pos)
+		v.pos = uint(pos)
+		st.Bool(&v.on)
+		st.Int(&v.mode)
+		st.Int(&v.loop)
+		st.Int(&v.delay)
+		st.Uint32(&v.addr)
+
+		// PCM voices play directly from memory, so we just need to remember
+		// the memory address. ADPCM and PSG voices play from a private
+		// buffer, whose contents are saved.
+		switch v.mode {
+		case kMode8bit, kMode16bit:
+			length := len(v.mem)
+			mem := snd.Bus.FetchPointer(v.addr)
+			st.Len(&length, len(mem))
+			if st.Loading() && st.Err() == nil {
+				v.mem = nil
+				if length > 0 {
+					v.mem = mem[:length]
+				}
+			}
+		default:
+			st.ByteSlice(&v.mem)
+		}
+	}
+
+	for i := range snd.capture {
+		c := &snd.capture[i]
+		st.Bool(&c.on)
+		st.Uint32(&c.tmr)
+		st.Uint32(&c.reset)
+		st.Uint32(&c.wpos)
+		st.Bool(&c.loop)
+		st.Bool(&c.bit8)
+		st.Bool(&c.add)
+		st.Bool(&c.single)
+	}
+}
@@ -1,8 +1,9 @@
-package main
+package nds
 
 import (
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 	"ndsemu/emu/spi"
 )
 
@@ -50,3 +51,9 @@ func (spi *HwSpiBus) WriteSPIDATA(_, val uint8) {
 		spi.EndTransfer()
 	}
 }
+
+func (spi *HwSpiBus) Serialize(st *savestate.Stream) {
+	st.Section("spibus")
+	hwio.SerializeRegs(st, spi)
+	spi.Bus.Serialize(st)
+}
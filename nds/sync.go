@@ -1,4 +1,4 @@
-package main
+package nds
 
 import (
 	"ndsemu/emu"
@@ -23,7 +23,7 @@ const (
 	// This value is probably not precise, but it works for now
 	// (at least until we don't understand why the formula below
 	// requires a magic adjustment)
-	cAudioFreq = 32768
+	AudioFreq = 32768
 
 	// Calculate how much the audio timers increment for every
 	// sound tick. Theoretically, the formula below is correct
@@ -31,7 +31,7 @@ const (
 	// statics in some games, and it's still not perfect, so we're not
 	// fully understanding this yet.
 	cAudioBugAdjust     = 2
-	cTimerStepPerSample = uint32((cEmuClock / 2 / cAudioFreq) + cAudioBugAdjust)
+	cTimerStepPerSample = uint32((cEmuClock / 2 / AudioFreq) + cAudioBugAdjust)
 )
 
 // NDS SYNC
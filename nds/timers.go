@@ -1,17 +1,18 @@
-package main
+package nds
 
 import (
 	"fmt"
 	"ndsemu/emu/fixed"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 const cTimerClock = cBusClock
 
 type HwTimer struct {
 	Reload  hwio.Reg16 `hwio:"offset=0x0,rcb,wcb"`
-	Control hwio.Reg16 `hwio:"offset=0x2,rwmask=0xC7,wcb"`
+	Control hwio.Reg16 `hwio:"offset=0x2,rwmask=0xC7,wcb,bits=Prescaler:0-1|CountUp:2|Irq:6|Start:7"`
 	counter uint16
 
 	name   string
@@ -239,3 +240,16 @@ func (t *HwTimers) Run(target int64) {
 		}
 	}
 }
+
+func (t *HwTimers) Serialize(st *savestate.Stream) {
+	st.Section("timers")
+	for i := range t.Timers {
+		t := &t.Timers[i]
+		hwio.SerializeRegs(st, t)
+		st.Uint16(&t.counter)
+		st.Int64(&t.cycles)
+		st.Bool(&t.irqt)
+		// The sync point itself is saved by emu.Sync
+		st.Int64(&t.sync)
+	}
+}
@@ -1,8 +1,9 @@
-package main
+package nds
 
 import (
 	"encoding/binary"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 	"ndsemu/emu/spi"
 )
 
@@ -109,3 +110,10 @@ func (ff *HwTouchScreen) SpiTransfer(data []byte) ([]byte, spi.ReqStatus) {
 
 func (ff *HwTouchScreen) SpiBegin() {}
 func (ff *HwTouchScreen) SpiEnd()   {}
+
+func (ff *HwTouchScreen) Serialize(st *savestate.Stream) {
+	st.Section("tsc")
+	st.Int(&ff.penX)
+	st.Int(&ff.penY)
+	st.Bool(&ff.penDown)
+}
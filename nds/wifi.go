@@ -1,10 +1,11 @@
-package main
+package nds
 
 import (
 	"encoding/binary"
 	"math/rand"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
+	"ndsemu/emu/savestate"
 )
 
 var modWifi = log.NewModule("wifi")
@@ -31,6 +32,7 @@ type HwWifi struct {
 
 	Random hwio.Reg16 `hwio:"offset=0x044,readonly,rcb"`
 	rand   *rand.Rand
+	nrand  int // number of values extracted from rand (for savestates)
 
 	WifiRam hwio.Mem `hwio:"bank=1,offset=0,size=0x2000,rw8=off,rw16,rw32"`
 }
@@ -82,6 +84,7 @@ func (wf *HwWifi) WriteBASEBANDCNT(_, val uint16) {
 }
 
 func (wf *HwWifi) ReadRANDOM(_ uint16) uint16 {
+	wf.nrand++
 	return uint16(wf.rand.Uint32()) & 0x3FF
 }
 
@@ -107,3 +110,18 @@ func (wf *HwWifi) ReadWRXBUFRDDATA(_ uint16) uint16 {
 	wf.WRxBufRdAddr.Value = off
 	return val
 }
+
+func (wf *HwWifi) Serialize(st *savestate.Stream) {
+	st.Section("wifi")
+	hwio.SerializeRegs(st, wf)
+	st.Bytes(wf.bbRegs[:])
+	st.Int(&wf.nrand)
+
+	if st.Loading() {
+		// Bring the random generator to the same point of the sequence
+		wf.rand = rand.New(rand.NewSource(0))
+		for i := 0; i < wf.nrand; i++ {
+			wf.rand.Uint32()
+		}
+	}
+}
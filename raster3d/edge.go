@@ -0,0 +1,160 @@
+package raster3d
+
+import (
+	"ndsemu/emu"
+	"ndsemu/emu/gfx"
+)
+
+// rearPlane holds the values the buffers are cleared with before drawing
+// each line. Pixels outside of the screen are considered part of the rear
+// plane by edge marking and anti-aliasing.
+type rearPlane struct {
+	color uint32
+	alpha uint8
+	depth uint32
+	attr  uint32
+}
+
+// setSpanAttr computes the edge attributes of the span of the polygon that
+// lies in line y, before it is drawn by the polyfiller. Only opaque polygons
+// have edges, as translucent polygons are not subject to edge marking and
+// anti-aliasing.
+//
+// The coverage of the leftmost and rightmost pixels is estimated from the
+// position of the edge within the pixel, biased by half a pixel so that
+// edges lying on a pixel boundary (like vertical edges, as vertices have
+// integer screen coordinates) are fully covered.
+func (poly *Polygon) setSpanAttr(y int32) {
+	if poly.UseAlpha() {
+		poly.spanAttr = [3]uint32{}
+		return
+	}
+
+	var inner uint32
+	if y == poly.vtx[0].y.TruncInt32() {
+		inner |= PAEdgeTop
+	}
+	if y == poly.vtx[2].y.TruncInt32() {
+		inner |= PAEdgeBottom
+	}
+
+	xl, xr := poly.left[LerpX].Cur(), poly.right[LerpX].Cur()
+	x0, x1 := xl.NearInt32(), xr.NearInt32()
+	lcov := edgeCoverage(int64(x0+1)<<32 - xl.V)
+	rcov := edgeCoverage(xr.V - int64(x1-1)<<32)
+
+	left := inner | PAEdgeLeft | lcov<<PACoverageShift
+	right := inner | PAEdgeRight | rcov<<PACoverageShift
+	if x0 == x1 {
+		// Single pixel: both edges, with the smallest coverage
+		if rcov < lcov {
+			lcov = rcov
+		}
+		left = inner | PAEdgeLeft | PAEdgeRight | lcov<<PACoverageShift
+		right = left
+	}
+	poly.spanAttr = [3]uint32{left, inner, right}
+}
+
+// edgeCoverage converts the covered part of a pixel (in 32.32 fixed point)
+// into a 5-bit coverage value
+func edgeCoverage(v int64) uint32 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1<<32 {
+		return 31
+	}
+	return uint32(v >> 27)
+}
+
+// edgeMarking draws the edges of the opaque polygons in line y with the
+// color of their polygon ID group (EdgeTable has a color for each group of
+// 8 IDs). A pixel is on an edge if one of the 4 surrounding pixels belongs to
+// a polygon with a different ID, and it is behind it.
+func (e3d *HwEngine3d) edgeMarking(y int, rear *rearPlane) {
+	line := gfx.NewLine(e3d.backbuf[4*256*y:])
+
+	// Attribute and depth of a pixel, including the rear plane around the
+	// screen
+	pixel := func(x, y int) (uint32, uint32) {
+		if x < 0 || x >= 256 || y < 0 || y >= 192 {
+			return rear.attr, rear.depth
+		}
+		off := 4 * (256*y + x)
+		return gfx.NewLine(e3d.attrbuf[off:]).Get32(0), gfx.NewLine(e3d.zbuf[off:]).Get32(0)
+	}
+
+	for x := 0; x < 256; x++ {
+		attr, z := pixel(x, y)
+		if attr&PAEdgeMask == 0 {
+			continue
+		}
+		id := attr & PAPolyID
+		for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+			nattr, nz := pixel(n[0], n[1])
+			if nattr&PAPolyID != id && z < nz {
+				col := emu.Read16LE(e3d.EdgeTable.Data[(id>>27)*2:])
+				line.Set32(x, uint32(col&0x7FFF)|0x80000000)
+				break
+			}
+		}
+	}
+}
+
+// antiAlias blends the left and right edge pixels of the opaque polygons in
+// line y with the pixel just outside of the edge, according to their
+// coverage. As for edge marking, only edges over a polygon with a different
+// ID (or over the rear plane) are anti-aliased, so that the internal edges
+// of a model are not blurred.
+func (e3d *HwEngine3d) antiAlias(y int, rear *rearPlane) {
+	line := gfx.NewLine(e3d.backbuf[4*256*y:])
+	zbuffer := gfx.NewLine(e3d.zbuf[4*256*y:])
+	abuffer := gfx.NewLine(e3d.abuf[256*y:])
+	attrbuffer := gfx.NewLine(e3d.attrbuf[4*256*y:])
+
+	// Blend with the original pixels, before anti-aliasing
+	var colors [256]uint32
+	var alphas [256]uint8
+	for x := 0; x < 256; x++ {
+		colors[x] = line.Get32(x)
+		alphas[x] = abuffer.Get8(x)
+	}
+
+	for x := 0; x < 256; x++ {
+		attr := attrbuffer.Get32(x)
+		if attr&(PAEdgeLeft|PAEdgeRight) == 0 {
+			continue
+		}
+		cov := (attr & PACoverage) >> PACoverageShift
+		if cov >= 31 {
+			continue
+		}
+
+		nx := x + 1
+		if attr&PAEdgeLeft != 0 {
+			nx = x - 1
+		}
+		ncol, nalpha, nattr, nz := rear.color, rear.alpha, rear.attr, rear.depth
+		if nx >= 0 && nx < 256 {
+			ncol, nalpha = colors[nx], alphas[nx]
+			nattr, nz = attrbuffer.Get32(nx), zbuffer.Get32(nx)
+		}
+		if nattr&PAPolyID == attr&PAPolyID || zbuffer.Get32(x) >= nz {
+			continue
+		}
+
+		px, npx := uint16(colors[x]), uint16(ncol)
+		r := aaMix(px&0x1F, npx&0x1F, cov)
+		g := aaMix((px>>5)&0x1F, (npx>>5)&0x1F, cov)
+		b := aaMix((px>>10)&0x1F, (npx>>10)&0x1F, cov)
+		line.Set32(x, uint32(r|g<<5|b<<10)|0x80000000)
+		abuffer.Set8(x, uint8(aaMix(uint16(alphas[x]), uint16(nalpha), cov)))
+	}
+}
+
+// aaMix blends two 5-bit components with the specified coverage (0-31) of
+// the first one
+func aaMix(c, n uint16, cov uint32) uint16 {
+	return uint16((uint32(c)*cov + uint32(n)*(31-cov)) / 31)
+}
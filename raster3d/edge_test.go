@@ -0,0 +1,165 @@
+package raster3d
+
+import (
+	"testing"
+
+	"ndsemu/emu/gfx"
+)
+
+// testPixel is the content of all the buffers for a pixel
+type testPixel struct {
+	color uint32
+	alpha uint8
+	z     uint32
+	attr  uint32
+}
+
+func setTestPixel(e3d *HwEngine3d, x, y int, p testPixel) {
+	off := 256*y + x
+	gfx.NewLine(e3d.backbuf[4*off:]).Set32(0, p.color)
+	gfx.NewLine(e3d.zbuf[4*off:]).Set32(0, p.z)
+	gfx.NewLine(e3d.attrbuf[4*off:]).Set32(0, p.attr)
+	e3d.abuf[off] = p.alpha
+}
+
+func getTestPixel(e3d *HwEngine3d, x, y int) testPixel {
+	off := 256*y + x
+	return testPixel{
+		color: gfx.NewLine(e3d.backbuf[4*off:]).Get32(0),
+		z:     gfx.NewLine(e3d.zbuf[4*off:]).Get32(0),
+		attr:  gfx.NewLine(e3d.attrbuf[4*off:]).Get32(0),
+		alpha: e3d.abuf[off],
+	}
+}
+
+// newEdgeEngine returns an engine whose buffers are cleared with the rear
+// plane, and whose edge colors are 0x1000+N for group N.
+func newEdgeEngine(rear *rearPlane) *HwEngine3d {
+	e3d := NewHwEngine3d()
+	for y := 0; y < 192; y++ {
+		for x := 0; x < 256; x++ {
+			setTestPixel(e3d, x, y, testPixel{rear.color, rear.alpha, rear.depth, rear.attr})
+		}
+	}
+	for i := 0; i < 8; i++ {
+		e3d.EdgeTable.Data[i*2] = uint8(i)
+		e3d.EdgeTable.Data[i*2+1] = 0x10
+	}
+	return e3d
+}
+
+func polyID(id uint32) uint32 { return id << 24 }
+
+func TestEdgeMarking(t *testing.T) {
+	const y = 10
+	rear := &rearPlane{color: 0x80000000, alpha: 0, depth: 0xFFFFFF, attr: polyID(0)}
+	const color = 0x80007FFF
+
+	for _, tc := range []struct {
+		desc string
+		x    int
+		attr uint32 // attributes of the pixel
+		nid  uint32 // ID of the neighbour
+		nz   uint32 // depth of the neighbour
+		n    [2]int // position of the neighbour, relative to the pixel
+		exp  uint32 // expected color
+	}{
+		{"different ID, behind", 100, PAEdgeLeft | polyID(9), 10, 0x2000, [2]int{-1, 0}, 0x80001001},
+		{"different ID, behind (right)", 100, PAEdgeRight | polyID(9), 10, 0x2000, [2]int{1, 0}, 0x80001001},
+		{"different ID, behind (top)", 100, PAEdgeTop | polyID(9), 10, 0x2000, [2]int{0, -1}, 0x80001001},
+		{"different ID, behind (bottom)", 100, PAEdgeBottom | polyID(9), 10, 0x2000, [2]int{0, 1}, 0x80001001},
+		{"different ID, in front", 100, PAEdgeLeft | polyID(9), 10, 0x800, [2]int{-1, 0}, color},
+		{"different ID, same depth", 100, PAEdgeLeft | polyID(9), 10, 0x1000, [2]int{-1, 0}, color},
+		{"same ID", 100, PAEdgeLeft | polyID(9), 9, 0x2000, [2]int{-1, 0}, color},
+		{"not an edge", 100, polyID(9), 10, 0x2000, [2]int{-1, 0}, color},
+		{"color of ID group", 100, PAEdgeLeft | polyID(0x2F), 0, 0x2000, [2]int{-1, 0}, 0x80001005},
+		{"rear plane outside the screen", 0, PAEdgeLeft | polyID(9), 9, 0x2000, [2]int{1, 0}, 0x80001001},
+	} {
+		e3d := newEdgeEngine(rear)
+
+		// Surround the pixel with pixels of the same polygon, except
+		// for the neighbour being tested.
+		for _, n := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			if tc.x+n[0] >= 0 {
+				setTestPixel(e3d, tc.x+n[0], y+n[1], testPixel{color, 31, 0x2000, tc.attr & PAPolyID})
+			}
+		}
+		setTestPixel(e3d, tc.x+tc.n[0], y+tc.n[1], testPixel{color, 31, tc.nz, polyID(tc.nid)})
+		setTestPixel(e3d, tc.x, y, testPixel{color, 31, 0x1000, tc.attr})
+
+		e3d.edgeMarking(y, rear)
+		if got := getTestPixel(e3d, tc.x, y).color; got != tc.exp {
+			t.Errorf("%s: color = %#x, want %#x", tc.desc, got, tc.exp)
+		}
+	}
+}
+
+func TestAntiAlias(t *testing.T) {
+	const y = 10
+	rear := &rearPlane{color: 0x80000000 | 31<<5, alpha: 0, depth: 0xFFFFFF, attr: polyID(0)}
+
+	// The pixel is red (opaque), the neighbour is blue (transparent)
+	pixel := testPixel{0x80000000 | 31, 31, 0x1000, polyID(9)}
+	neigh := testPixel{0x80000000 | 31<<10, 0, 0x2000, polyID(10)}
+
+	for _, tc := range []struct {
+		desc     string
+		x        int
+		nx       int    // position of the neighbour
+		attr     uint32 // edge attributes of the pixel
+		nid      uint32 // ID of the neighbour
+		nz       uint32 // depth of the neighbour
+		expColor uint32
+		expAlpha uint8
+	}{
+		{"left edge", 100, 99, PAEdgeLeft | 15<<PACoverageShift, 10, 0x2000, 0x80000000 | 15 | 16<<10, 15},
+		{"right edge", 100, 101, PAEdgeRight | 15<<PACoverageShift, 10, 0x2000, 0x80000000 | 15 | 16<<10, 15},
+		{"low coverage", 100, 99, PAEdgeLeft | 1<<PACoverageShift, 10, 0x2000, 0x80000000 | 1 | 30<<10, 1},
+		{"high coverage", 100, 99, PAEdgeLeft | 30<<PACoverageShift, 10, 0x2000, 0x80000000 | 30 | 1<<10, 30},
+		{"full coverage", 100, 99, PAEdgeLeft | 31<<PACoverageShift, 10, 0x2000, pixel.color, 31},
+		{"same ID", 100, 99, PAEdgeLeft | 15<<PACoverageShift, 9, 0x2000, pixel.color, 31},
+		{"neighbour in front", 100, 99, PAEdgeLeft | 15<<PACoverageShift, 10, 0x800, pixel.color, 31},
+		{"top edge only", 100, 99, PAEdgeTop | 15<<PACoverageShift, 10, 0x2000, pixel.color, 31},
+		{"rear plane outside the screen", 0, 1, PAEdgeLeft | 15<<PACoverageShift, 9, 0x2000, 0x80000000 | 15 | 16<<5, 15},
+	} {
+		e3d := newEdgeEngine(rear)
+		p := pixel
+		p.attr |= tc.attr
+		n := neigh
+		n.attr, n.z = polyID(tc.nid), tc.nz
+		setTestPixel(e3d, tc.nx, y, n)
+		setTestPixel(e3d, tc.x, y, p)
+
+		e3d.antiAlias(y, rear)
+		got := getTestPixel(e3d, tc.x, y)
+		if got.color != tc.expColor {
+			t.Errorf("%s: color = %#x, want %#x", tc.desc, got.color, tc.expColor)
+		}
+		if got.alpha != tc.expAlpha {
+			t.Errorf("%s: alpha = %d, want %d", tc.desc, got.alpha, tc.expAlpha)
+		}
+		if n := getTestPixel(e3d, tc.nx, y); n.color != neigh.color {
+			t.Errorf("%s: neighbour modified: %#x", tc.desc, n.color)
+		}
+	}
+}
+
+func TestAntiAliasOriginalColors(t *testing.T) {
+	// Two adjacent left edges (of three polygons, each one in front of the
+	// previous one): the second one is blended with the original color of
+	// the first one, not with the already blended one.
+	const y = 10
+	rear := &rearPlane{color: 0x80000000, depth: 0xFFFFFF}
+	e3d := newEdgeEngine(rear)
+	setTestPixel(e3d, 99, y, testPixel{0x80000000 | 31<<5, 31, 0x2000, polyID(8)})
+	setTestPixel(e3d, 100, y, testPixel{0x80000000 | 31, 31, 0x1000, polyID(9) | PAEdgeLeft | 15<<PACoverageShift})
+	setTestPixel(e3d, 101, y, testPixel{0x80000000 | 31<<10, 31, 0x800, polyID(10) | PAEdgeLeft | 15<<PACoverageShift})
+
+	e3d.antiAlias(y, rear)
+	if got := getTestPixel(e3d, 100, y).color; got != 0x80000000|15|16<<5 {
+		t.Errorf("pixel 100: color = %#x", got)
+	}
+	if got := getTestPixel(e3d, 101, y).color; got != 0x80000000|16|15<<10 {
+		t.Errorf("pixel 101: color = %#x", got)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"ndsemu/emu/fixed"
 	"ndsemu/emu/gfx"
-	"ndsemu/emu/hw"
+	"ndsemu/emu/host"
 	"ndsemu/emu/hwio"
 	log "ndsemu/emu/logger"
 	"ndsemu/raster3d/fillerconfig"
@@ -30,13 +30,14 @@ func (b *buffer3d) Reset() {
 }
 
 type HwEngine3d struct {
-	Disp3dCnt  hwio.Reg32 `hwio:"offset=0,rwmask=0x7FFF"`
+	Disp3dCnt  hwio.Reg32 `hwio:"offset=0,rwmask=0x7FFF,bits=Texture:0|Highlight:1|AlphaTest:2|Blend:3|AntiAlias:4|Edge:5|FogAlpha:6|Fog:7|FogShift:8-11|Underflow:12|Overflow:13|RearBitmap:14"`
 	ToonTable  hwio.Mem   `hwio:"bank=1,offset=0x80,size=0x40,writeonly"`
 	ClearColor hwio.Reg32 `hwio:"bank=1,offset=0x50,writeonly"`
 	ClearDepth hwio.Reg32 `hwio:"bank=1,offset=0x54,writeonly"`
 	FogColor   hwio.Reg32 `hwio:"bank=1,offset=0x58,writeonly"`
 	FogOffset  hwio.Reg32 `hwio:"bank=1,offset=0x5C,rwmask=0x7FFF,writeonly"`
 	FogTable   hwio.Mem   `hwio:"bank=1,offset=0x60,size=0x20,writeonly"`
+	EdgeTable  hwio.Mem   `hwio:"bank=1,offset=0x30,size=0x10,writeonly"`
 
 	// Registeres shared with e2d
 	dispcnt *uint32
@@ -65,9 +66,18 @@ type HwEngine3d struct {
 	texCache texCache
 
 	backbuf [256 * 192 * 4]uint8
+	zbuf    [256 * 192 * 4]uint8 // depth buffer
+	abuf    [256 * 192]uint8     // alpha buffer
+	attrbuf [256 * 192 * 4]uint8 // pixel attributes (PA* flags)
 	backY   int32
+	drawing sync.WaitGroup
 
 	framecnt int
+
+	// Debugging aids (see inspect.go)
+	captureFn    func(*Scene)
+	dbgWireframe atomic.Bool
+	dbgHighlight atomic.Int32
 }
 
 func NewHwEngine3d() *HwEngine3d {
@@ -82,6 +92,7 @@ func NewHwEngine3d() *HwEngine3d {
 	}
 	e3d.next = e3d.pool.Get().(buffer3d)
 	e3d.nextCh = make(chan buffer3d, 1)
+	e3d.dbgHighlight.Store(-1)
 
 	return e3d
 }
@@ -191,12 +202,13 @@ func (e3d *HwEngine3d) CmdPolygon(cmd Primitive_Polygon) {
 
 		// Do backface culling and ignore polygon if it should
 		// not be drawn.
-		// Notice that we can'd to culling on quads as they could be concave
+		// Notice that we can'd to culling on quads as they could be concave.
+		// Polygons with no area (drawn as segments) are considered front-facing.
 		d0x := trivtxs[0].x.SubFixed(trivtxs[1].x)
 		d0y := trivtxs[0].y.SubFixed(trivtxs[1].y)
 		d1x := trivtxs[2].x.SubFixed(trivtxs[1].x)
 		d1y := trivtxs[2].y.SubFixed(trivtxs[1].y)
-		if int64(d0x.V)*int64(d1y.V) <= int64(d1x.V)*int64(d0y.V) {
+		if int64(d0x.V)*int64(d1y.V) < int64(d1x.V)*int64(d0y.V) {
 			// Facing the back: see if we must render the back
 			if flags&PFRenderBack == 0 {
 				continue
@@ -651,6 +663,10 @@ func (e3d *HwEngine3d) CmdSwapBuffers(cmd Primitive_SwapBuffers) {
 
 	// Debug dump of scene
 	// e3d.dumpNextScene()
+	if fn := e3d.captureFn; fn != nil {
+		e3d.captureFn = nil
+		fn(e3d.captureScene())
+	}
 
 	e3d.framecnt++
 
@@ -669,6 +685,9 @@ func (e3d *HwEngine3d) drawScene() {
 	texMappingEnabled := e3d.Disp3dCnt.Value&(1<<0) != 0
 	highlightEnabled := e3d.Disp3dCnt.Value&(1<<1) != 0
 	alphaBlendingEnabled := e3d.Disp3dCnt.Value&(1<<3) != 0
+	wireframe := e3d.dbgWireframe.Load()
+	highlight := int(e3d.dbgHighlight.Load())
+	fog := e3d.fogConfig()
 
 	// Initialize rasterizer.
 	var polyPerLine [192][]uint16
@@ -684,18 +703,15 @@ func (e3d *HwEngine3d) drawScene() {
 			poly.right[idx].Reset()
 		}
 
-		// If the polygon degrades to a segment, skip it for now (we don't support segments)
-		v0, v1, v2 := poly.vtx[0], poly.vtx[1], poly.vtx[2]
-		if (v0.x == v1.x && v0.y == v1.y) || (v1.x == v2.x && v1.y == v2.y) {
-			// FIXME: implement segments
-			continue
-		}
+		// Polygons without area are drawn as segments (see setSegmentSpan)
+		poly.line = poly.isSegment()
 
 		// Update the per-line polygon list, by adding this polygon's index
-		// to the lines in which it is visible.
-		y0 := v0.y.TruncInt32()
-		y1 := v2.y.TruncInt32()
-		if poly.UseAlpha() {
+		// to the lines in which it is visible. Segments always include their
+		// last line.
+		y0 := poly.vtx[0].y.TruncInt32()
+		y1 := poly.vtx[2].y.TruncInt32()
+		if poly.UseAlpha() && !poly.line {
 			y1--
 		}
 		for j := y0; j <= y1; j++ {
@@ -751,7 +767,11 @@ func (e3d *HwEngine3d) drawScene() {
 			fcfg.TexFormat = 0
 		}
 
-		if alphaBlendingEnabled {
+		if wireframe {
+			// Wireframe polygons are drawn by drawEdges(), so the filler
+			// is never invoked.
+			fcfg.FillMode = fillerconfig.FillModeWireframe
+		} else if alphaBlendingEnabled {
 			fcfg.FillMode = fillerconfig.FillModeAlpha
 		} else {
 			fcfg.FillMode = fillerconfig.FillModeSolid
@@ -772,32 +792,47 @@ func (e3d *HwEngine3d) drawScene() {
 	// could not be ready.
 	e3d.texCache.Update(e3d.cur.Pram, e3d)
 
+	rear := rearPlane{
+		color: (e3d.ClearColor.Value & 0x7FFF) | 0x80000000,
+		alpha: uint8(e3d.ClearColor.Value>>16) & 0x1F,
+		depth: (e3d.ClearDepth.Value * 0x200) + 0x1FF, // gbatek is wrong
+		attr:  e3d.ClearColor.Value & (PAFog | PAPolyID),
+	}
+
 	for y := 0; y < 192; y++ {
 		if e3d.Disp3dCnt.Value&(1<<14) != 0 {
 			panic("bitmap")
 		}
 
 		line := gfx.NewLine(e3d.backbuf[4*256*y:])
-
-		clearColor := (e3d.ClearColor.Value & 0x7FFF) | 0x80000000
-		clearAlpha := uint8(e3d.ClearColor.Value>>16) & 0x1F
-		clearDepth := uint32(e3d.ClearDepth.Value)
-		clearDepth = (clearDepth * 0x200) + 0x1FF // gbatek is wrong
-
-		var abuf [256]byte
-		var zbuf [256 * 4]byte
-		zbuffer := gfx.NewLine(zbuf[:])
-		abuffer := gfx.NewLine(abuf[:])
+		zbuffer := gfx.NewLine(e3d.zbuf[4*256*y:])
+		abuffer := gfx.NewLine(e3d.abuf[256*y:])
+		attrbuffer := gfx.NewLine(e3d.attrbuf[4*256*y:])
 		for i := 0; i < 256; i++ {
-			line.Set32(i, clearColor)
-			abuffer.Set8(i, clearAlpha)
-			zbuffer.Set32(i, clearDepth)
+			line.Set32(i, rear.color)
+			abuffer.Set8(i, rear.alpha)
+			zbuffer.Set32(i, rear.depth)
+			attrbuffer.Set32(i, rear.attr)
 		}
 
 		// Draw polygons that are visibile in this line
+		prevMask := false
 		for _, idx := range polyPerLine[y] {
 			poly := &e3d.cur.Pram[idx]
 
+			// The stencil buffer is cleared at the beginning of each group
+			// of shadow masks
+			mask := poly.IsShadowMask()
+			if mask && !prevMask {
+				for i := 0; i < 256; i++ {
+					attrbuffer.Set32(i, attrbuffer.Get32(i)&^PAStencil)
+				}
+			}
+			prevMask = mask
+
+			if poly.line {
+				poly.setSegmentSpan(int32(y))
+			}
 			x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 			if x0 < 0 || x1 >= 256 || x1 < x0 {
 				fmt.Printf("%v,%v\n", poly.vtx[0].x.TruncInt32(), poly.vtx[0].y.TruncInt32())
@@ -807,8 +842,11 @@ func (e3d *HwEngine3d) drawScene() {
 				fmt.Printf("right lerps: %v\n", poly.right)
 				fmt.Printf("x0,x1=%v,%v   y=%v, hy=%v\n", x0, x1, y, poly.hy)
 				// panic("out of bounds")
+			} else if wireframe {
+				e3d.drawEdges(poly, int32(y), poly.vtx[0].colorRGB555(), line, abuffer)
 			} else {
-				poly.filler(e3d, poly, line, zbuffer, abuffer)
+				poly.setSpanAttr(int32(y))
+				poly.filler(e3d, poly, line, zbuffer, abuffer, attrbuffer)
 			}
 
 			if int32(y) < poly.hy {
@@ -824,26 +862,58 @@ func (e3d *HwEngine3d) drawScene() {
 			}
 		}
 
-		// Now mark pixels with alpha 0 as fully transparent,
-		// and embed 5-bit alpha in pixel in other cases.
-		// This will be used for 3d/2d transparency
-		for i := 0; i < 256; i++ {
-			alpha := abuffer.Get8(i)
-			if alpha == 0 {
-				line.Set32(i, 0)
-			} else {
-				line.Set32(i, line.Get32(i)|uint32(alpha)<<16|1<<24)
-			}
+		// Edge marking needs the pixels of the line below, so the final
+		// pass is delayed by one line.
+		if y > 0 {
+			e3d.finishLine(y-1, &rear, fog, highlight)
 		}
+	}
+	e3d.finishLine(191, &rear, fog, highlight)
+}
 
-		atomic.StoreInt32(&e3d.backY, int32(y))
+// finishLine runs the final pass over a line that has been fully drawn,
+// applying edge marking, fog and anti-aliasing, and then makes it available
+// to the 2D engine.
+func (e3d *HwEngine3d) finishLine(y int, rear *rearPlane, fog *fogConfig, highlight int) {
+	line := gfx.NewLine(e3d.backbuf[4*256*y:])
+	zbuffer := gfx.NewLine(e3d.zbuf[4*256*y:])
+	abuffer := gfx.NewLine(e3d.abuf[256*y:])
+	attrbuffer := gfx.NewLine(e3d.attrbuf[4*256*y:])
+
+	if e3d.Disp3dCnt.Value&(1<<5) != 0 {
+		e3d.edgeMarking(y, rear)
 	}
+	if fog != nil {
+		fog.apply(line, zbuffer, abuffer, attrbuffer)
+	}
+	if e3d.Disp3dCnt.Value&(1<<4) != 0 {
+		e3d.antiAlias(y, rear)
+	}
+
+	// Draw the highlighted polygon on top of everything else
+	if highlight >= 0 && highlight < len(e3d.cur.Pram) {
+		e3d.drawEdges(&e3d.cur.Pram[highlight], int32(y), 0x7C1F, line, abuffer)
+	}
+
+	// Now mark pixels with alpha 0 as fully transparent,
+	// and embed 5-bit alpha in pixel in other cases.
+	// This will be used for 3d/2d transparency
+	for i := 0; i < 256; i++ {
+		alpha := abuffer.Get8(i)
+		if alpha == 0 {
+			line.Set32(i, 0)
+		} else {
+			line.Set32(i, line.Get32(i)|uint32(alpha)<<16|1<<24)
+		}
+	}
+
+	atomic.StoreInt32(&e3d.backY, int32(y))
 }
 
 func (e3d *HwEngine3d) Draw3D(lidx int) func(gfx.Line) {
 	y := int32(0)
 
-	keystate := hw.GetKeyboardState()
+	keystate := host.KeyboardState()
 
 	return func(out gfx.Line) {
 		xofs := int(*e3d.bg0xofs & 511)
@@ -860,7 +930,7 @@ func (e3d *HwEngine3d) Draw3D(lidx int) func(gfx.Line) {
 			return
 		}
 
-		if keystate[hw.SCANCODE_5] != 0 {
+		if keystate[host.SCANCODE_5] != 0 {
 			y++
 			return
 		}
@@ -891,7 +961,11 @@ func (e3d *HwEngine3d) SetVram(tex VramTextureBank, pal VramTexturePaletteBank)
 
 func (e3d *HwEngine3d) BeginFrame() {
 	e3d.backY = -1
-	go e3d.drawScene()
+	e3d.drawing.Add(1)
+	go func() {
+		defer e3d.drawing.Done()
+		e3d.drawScene()
+	}()
 }
 
 func (e3d *HwEngine3d) EndFrame() {
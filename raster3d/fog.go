@@ -0,0 +1,98 @@
+package raster3d
+
+import "ndsemu/emu/gfx"
+
+// fogConfig is the fog configuration used while drawing a frame.
+//
+// The fog density is defined by the 32 entries of FogTable (0-127, where 127
+// means full fog), which are placed at depths FogOffset + step*(N+1), with
+// step = 0x400 >> FogShift (in units of the 15-bit depth programmed in the
+// registers). The density is linearly interpolated between entries, and it's
+// clamped to the first/last entry outside of the table.
+type fogConfig struct {
+	alphaOnly bool
+	color     uint16 // RGB555
+	alpha     uint8  // 5-bit
+	offset    uint32 // in depth buffer units (24-bit)
+	step      uint32 // in depth buffer units (24-bit); 0 if the shift is too large
+	table     [34]int64
+}
+
+// fogConfig returns the current fog configuration, or nil if fog is disabled
+func (e3d *HwEngine3d) fogConfig() *fogConfig {
+	cnt := e3d.Disp3dCnt.Value
+	if cnt&(1<<7) == 0 {
+		return nil
+	}
+
+	fc := &fogConfig{
+		alphaOnly: cnt&(1<<6) != 0,
+		color:     uint16(e3d.FogColor.Value & 0x7FFF),
+		alpha:     uint8(e3d.FogColor.Value>>16) & 0x1F,
+		offset:    (e3d.FogOffset.Value & 0x7FFF) * 0x200,
+		step:      (0x400 >> ((cnt >> 8) & 0xF)) * 0x200,
+	}
+
+	// Entry 0 is also used for depths before the first entry, and entry 31
+	// for depths after the last entry
+	fc.table[0] = int64(e3d.FogTable.Data[0] & 0x7F)
+	for i := 0; i < 32; i++ {
+		fc.table[i+1] = int64(e3d.FogTable.Data[i] & 0x7F)
+	}
+	fc.table[33] = fc.table[32]
+	return fc
+}
+
+// density returns the fog density (0-128) for the specified depth
+func (fc *fogConfig) density(z uint32) int64 {
+	var d int64
+	switch {
+	case z < fc.offset:
+		d = fc.table[0]
+	case fc.step == 0:
+		d = fc.table[33]
+	default:
+		z -= fc.offset
+		idx, frac := z/fc.step, int64(z%fc.step)
+		if idx >= 32 {
+			d = fc.table[33]
+		} else {
+			d = (fc.table[idx]*(int64(fc.step)-frac) + fc.table[idx+1]*frac) / int64(fc.step)
+		}
+	}
+
+	// 127 is full fog
+	if d >= 127 {
+		d = 128
+	}
+	return d
+}
+
+// apply blends the pixels of a line that have the fog attribute with the fog
+// color and alpha, according to the density at their depth.
+func (fc *fogConfig) apply(line, zbuf, abuf, attr gfx.Line) {
+	for i := 0; i < 256; i++ {
+		if attr.Get32(i)&PAFog == 0 {
+			continue
+		}
+		d := fc.density(zbuf.Get32(i))
+		if d == 0 {
+			continue
+		}
+
+		abuf.Set8(i, uint8(fogMix(uint16(fc.alpha), uint16(abuf.Get8(i)), d)))
+		if !fc.alphaOnly {
+			px := uint16(line.Get32(i))
+			r := fogMix(fc.color&0x1F, px&0x1F, d)
+			g := fogMix((fc.color>>5)&0x1F, (px>>5)&0x1F, d)
+			b := fogMix((fc.color>>10)&0x1F, (px>>10)&0x1F, d)
+			line.Set32(i, uint32(r|g<<5|b<<10)|0x80000000)
+		}
+	}
+}
+
+// fogMix blends a 5-bit component with the fog, using a density in the
+// range 0-128
+func fogMix(fog, c uint16, d int64) uint16 {
+	return uint16((int64(fog)*d + int64(c)*(128-d)) >> 7)
+}
@@ -0,0 +1,128 @@
+package raster3d
+
+import (
+	"testing"
+
+	"ndsemu/emu/gfx"
+)
+
+// newFogEngine returns an engine with fog enabled and the specified
+// configuration. FogTable entry i is set to table[i].
+func newFogEngine(shift uint32, offset uint32, table [32]uint8) *HwEngine3d {
+	e3d := NewHwEngine3d()
+	e3d.Disp3dCnt.Value = 1<<7 | shift<<8
+	e3d.FogOffset.Value = offset
+	copy(e3d.FogTable.Data, table[:])
+	return e3d
+}
+
+func TestFogDensity(t *testing.T) {
+	// Entry N is 10+3*N; with offset 0x10 and shift 2, it is placed at depth
+	// 0x2000 + 0x20000*(N+1) in depth buffer units.
+	var table [32]uint8
+	for i := range table {
+		table[i] = uint8(10 + 3*i)
+	}
+	const offset, step = 0x2000, 0x20000
+
+	for _, tc := range []struct {
+		desc  string
+		shift uint32
+		table [32]uint8
+		z     uint32
+		exp   int64
+	}{
+		{"zero depth", 2, table, 0, 10},
+		{"before offset", 2, table, offset - 1, 10},
+		{"at offset", 2, table, offset, 10},
+		{"before entry 0", 2, table, offset + step/2, 10},
+		{"entry 0", 2, table, offset + step, 10},
+		{"entry 5", 2, table, offset + step*6, 25},
+		{"entry 30", 2, table, offset + step*31, 100},
+		{"entry 31", 2, table, offset + step*32, 103},
+		{"between 5 and 6 (1/2)", 2, table, offset + step*6 + step/2, 26},
+		{"between 5 and 6 (1/4)", 2, table, offset + step*6 + step/4, 25},
+		{"between 5 and 6 (3/4)", 2, table, offset + step*6 + step*3/4, 27},
+		{"past entry 31", 2, table, offset + step*33, 103},
+		{"max depth", 2, table, 0xFFFFFF, 103},
+		{"step 0, before offset", 11, table, offset - 1, 10},
+		{"step 0, at offset", 11, table, offset, 103},
+		{"step 0, max shift", 15, table, 0xFFFFFF, 103},
+		{"full fog", 2, [32]uint8{31: 127}, 0xFFFFFF, 128},
+		{"almost full fog", 2, [32]uint8{31: 126}, 0xFFFFFF, 126},
+		{"high bit ignored", 2, [32]uint8{31: 0x80 | 5}, 0xFFFFFF, 5},
+	} {
+		fc := newFogEngine(tc.shift, 0x10, tc.table).fogConfig()
+		if got := fc.density(tc.z); got != tc.exp {
+			t.Errorf("%s: density(%#x) = %d, want %d", tc.desc, tc.z, got, tc.exp)
+		}
+	}
+}
+
+func TestFogDisabled(t *testing.T) {
+	e3d := newFogEngine(0, 0, [32]uint8{})
+	e3d.Disp3dCnt.Value &^= 1 << 7
+	if fc := e3d.fogConfig(); fc != nil {
+		t.Errorf("fog config returned while fog is disabled: %+v", fc)
+	}
+}
+
+func TestFogApply(t *testing.T) {
+	const (
+		px    = 31 | 0<<5 | 16<<10 // pixel color (RGB555)
+		alpha = 31                 // pixel alpha
+		fog   = 0 | 31<<5 | 8<<10  // fog color (RGB555)
+		falph = 3                  // fog alpha
+	)
+
+	for _, tc := range []struct {
+		desc      string
+		alphaOnly bool
+		density   uint8
+		pixattr   uint32
+		expColor  uint32
+		expAlpha  uint8
+	}{
+		{"no fog attribute", false, 64, 0, px, alpha},
+		{"zero density", false, 0, PAFog, px, alpha},
+		{"color+alpha", false, 64, PAFog, 0x80000000 | 15 | 15<<5 | 12<<10, 17},
+		{"alpha only", true, 64, PAFog, px, 17},
+		{"full fog", false, 127, PAFog, 0x80000000 | fog, falph},
+		{"full fog, alpha only", true, 127, PAFog, px, falph},
+	} {
+		var table [32]uint8
+		for i := range table {
+			table[i] = tc.density
+		}
+		e3d := newFogEngine(0, 0, table)
+		e3d.FogColor.Value = falph<<16 | fog
+		if tc.alphaOnly {
+			e3d.Disp3dCnt.Value |= 1 << 6
+		}
+		fc := e3d.fogConfig()
+
+		var linebuf, zbuf, attrbuf [256 * 4]byte
+		var abuf [256]byte
+		line, zline := gfx.NewLine(linebuf[:]), gfx.NewLine(zbuf[:])
+		aline, attrline := gfx.NewLine(abuf[:]), gfx.NewLine(attrbuf[:])
+		for x := 0; x < 256; x++ {
+			line.Set32(x, px)
+			zline.Set32(x, 0x1000)
+			aline.Set8(x, alpha)
+			attrline.Set32(x, tc.pixattr)
+		}
+		// Only the pixels with the fog attribute are affected
+		attrline.Set32(255, 0)
+
+		fc.apply(line, zline, aline, attrline)
+		if got := line.Get32(0); got != tc.expColor {
+			t.Errorf("%s: color = %#x, want %#x", tc.desc, got, tc.expColor)
+		}
+		if got := aline.Get8(0); got != tc.expAlpha {
+			t.Errorf("%s: alpha = %d, want %d", tc.desc, got, tc.expAlpha)
+		}
+		if line.Get32(255) != px || aline.Get8(255) != alpha {
+			t.Errorf("%s: fog applied to pixel without fog attribute", tc.desc)
+		}
+	}
+}
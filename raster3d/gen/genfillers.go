@@ -38,8 +38,10 @@ func (g *Generator) genFiller(cfg *fillerconfig.FillerConfig) {
 	}
 
 	fmt.Fprintf(g, "x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()\n")
-	fmt.Fprintf(g, "nx := x1-x0; if nx==0 {return}\n")
-	fmt.Fprintf(g, "if poly.UseAlpha() { x1-=1 }\n")
+	fmt.Fprintf(g, "nx := x1-x0\n")
+	// Segments have single-pixel spans, and their last pixel is always drawn
+	fmt.Fprintf(g, "if nx==0 { if !poly.line {return}; nx = 1 }\n")
+	fmt.Fprintf(g, "if poly.UseAlpha() && !poly.line { x1-=1 }\n")
 	fmt.Fprintf(g, "d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()\n")
 	fmt.Fprintf(g, "dd := d1.SubFixed(d0).Div(nx)\n")
 	fmt.Fprintf(g, "r0, r1 := poly.left[LerpR].Cur(), poly.right[LerpR].Cur()\n")
@@ -69,6 +71,11 @@ func (g *Generator) genFiller(cfg *fillerconfig.FillerConfig) {
 		fmt.Fprintf(g, "polyalpha := uint8(poly.flags.Alpha())<<1\n")
 	}
 	fmt.Fprintf(g, "zalpha := e3d.Disp3dCnt.Value & (1<<11) != 0\n")
+	fmt.Fprintf(g, "pattr := uint32(poly.flags) & (PAFog|PAPolyID)\n")
+	fmt.Fprintf(g, "lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]\n")
+	if cfg.ColorMode == fillerconfig.ColorModeShadow {
+		fmt.Fprintf(g, "shadowmask := poly.IsShadowMask()\n")
+	}
 
 	// Pre pixel loop
 	switch cfg.TexFormat {
@@ -99,8 +106,11 @@ func (g *Generator) genFiller(cfg *fillerconfig.FillerConfig) {
 	fmt.Fprintf(g, "out.Add32(int(x0))\n")
 	fmt.Fprintf(g, "zbuf.Add32(int(x0))\n")
 	fmt.Fprintf(g, "abuf.Add8(int(x0))\n")
+	fmt.Fprintf(g, "attr.Add32(int(x0))\n")
 	fmt.Fprintf(g, "for x:=x0; x<=x1; x++ {\n")
 	fmt.Fprintf(g, "drawz := true\n")
+	fmt.Fprintf(g, "pxattr := iattr\n")
+	fmt.Fprintf(g, "if x == x0 { pxattr = lattr } else if x == x1 { pxattr = rattr }\n")
 	fmt.Fprintf(g, "var pxa uint8\n")
 	fmt.Fprintf(g, "pxa = 63\n")
 	if cfg.TexCoords == fillerconfig.TexCoordsFull {
@@ -112,7 +122,19 @@ func (g *Generator) genFiller(cfg *fillerconfig.FillerConfig) {
 	const zshift = 32 - 12
 	fmt.Fprintf(g, "// zbuffer check\n")
 	fmt.Fprintf(g, "z := d0.Inv()\n")
-	fmt.Fprintf(g, "if int32(z.V>>%d) >= int32(zbuf.Get32(0)) { goto next }\n", zshift)
+	if cfg.ColorMode == fillerconfig.ColorModeShadow {
+		// Shadow polygons: masks (ID 0) are never drawn, and set the stencil
+		// where the depth test fails; the others are drawn only where the
+		// stencil is set, over polygons with a different ID.
+		fmt.Fprintf(g, "if int32(z.V>>%d) >= int32(zbuf.Get32(0)) {\n", zshift)
+		fmt.Fprintf(g, "if shadowmask { attr.Set32(0, attr.Get32(0)|PAStencil) }\n")
+		fmt.Fprintf(g, "goto next\n")
+		fmt.Fprintf(g, "}\n")
+		fmt.Fprintf(g, "if shadowmask { goto next }\n")
+		fmt.Fprintf(g, "if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID { goto next }\n")
+	} else {
+		fmt.Fprintf(g, "if int32(z.V>>%d) >= int32(zbuf.Get32(0)) { goto next }\n", zshift)
+	}
 
 	if cfg.TexFormat > 0 {
 		// texture coords
@@ -268,6 +290,9 @@ func (g *Generator) genFiller(cfg *fillerconfig.FillerConfig) {
 		fmt.Fprintf(g, "if bkga != 0 { px = rgbAlphaMix(px, bkg, pxa) }\n")
 		fmt.Fprintf(g, "if pxa < bkga { pxa = bkga }\n")
 		fmt.Fprintf(g, "drawz = zalpha\n")
+		// Translucent pixels keep the attributes of the opaque pixel below
+		// them, and the fog flag only if both pixels have it
+		fmt.Fprintf(g, "pxattr = attr.Get32(0) & (pattr | ^PAFog)\n")
 		fmt.Fprintf(g, "}\n")
 	}
 
@@ -276,12 +301,14 @@ func (g *Generator) genFiller(cfg *fillerconfig.FillerConfig) {
 	fmt.Fprintf(g, "out.Set32(0, uint32(px)|0x80000000)\n")
 	fmt.Fprintf(g, "abuf.Set8(0, pxa)\n")
 	fmt.Fprintf(g, "if drawz { zbuf.Set32(0, uint32(z.V>>%d)) }\n", zshift)
+	fmt.Fprintf(g, "attr.Set32(0, pxattr)\n")
 
 	// Pixel loop footer
 	fmt.Fprintf(g, "next:\n")
 	fmt.Fprintf(g, "out.Add32(1)\n")
 	fmt.Fprintf(g, "zbuf.Add32(1)\n")
 	fmt.Fprintf(g, "abuf.Add8(1)\n")
+	fmt.Fprintf(g, "attr.Add32(1)\n")
 	fmt.Fprintf(g, "d0 = d0.AddFixed(dd)\n")
 	fmt.Fprintf(g, "r0 = r0.AddFixed(dr)\n")
 	fmt.Fprintf(g, "g0 = g0.AddFixed(dg)\n")
@@ -325,7 +352,7 @@ func (g *Generator) Run() {
 		} else {
 			dups[i] = i
 			digests[sum] = i
-			fmt.Fprintf(g.out, "func (e3d *HwEngine3d) filler_%03x(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {\n", i)
+			fmt.Fprintf(g.out, "func (e3d *HwEngine3d) filler_%03x(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {\n", i)
 			fmt.Fprintf(g.out, "// %+v\n", cfg)
 			g.out.Write(buf.Bytes())
 			fmt.Fprintf(g.out, "}\n\n")
@@ -333,7 +360,7 @@ func (g *Generator) Run() {
 	}
 
 	g.Writer = g.out
-	fmt.Fprintf(g, "var polygonFillerTable = [%d]func(*HwEngine3d,*Polygon,gfx.Line,gfx.Line,gfx.Line) {\n",
+	fmt.Fprintf(g, "var polygonFillerTable = [%d]func(*HwEngine3d,*Polygon,gfx.Line,gfx.Line,gfx.Line,gfx.Line) {\n",
 		fillerconfig.FillerKeyMax)
 
 	for i := uint(0); i < fillerconfig.FillerKeyMax; i++ {
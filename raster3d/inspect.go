@@ -0,0 +1,238 @@
+package raster3d
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"ndsemu/emu/gfx"
+)
+
+// Scene is a snapshot of the polygons of a 3D frame, captured after clipping,
+// screen transformation and sorting, which is the exact list of polygons that
+// the rasterizer draws.
+type Scene struct {
+	Frame       int
+	NumVertices int      // Number of vertices in Vertex RAM (before clipping)
+	Commands    []string // Geometry commands that generated the scene (if available)
+	Polygons    []ScenePolygon
+}
+
+// SceneVertex is a vertex of a captured polygon
+type SceneVertex struct {
+	X, Y, Z, W float64 // Coordinates in clip space
+	SX, SY     int32   // Screen coordinates
+	S, T       float64 // Texture coordinates (in texels)
+	R, G, B    int32   // Vertex color (0-63), after lighting
+	Clipped    bool    // Vertex was generated by clipping
+}
+
+// ScenePolygon is a triangle of a captured scene. Quads and clipped polygons
+// are split into triangles by the rasterizer.
+type ScenePolygon struct {
+	Attr PolygonFlags // Polygon attributes (see POLYGON_ATTR)
+	Tex  Texture
+	Vtx  [3]SceneVertex
+}
+
+func (p *ScenePolygon) String() string {
+	s := fmt.Sprintf("attr=%08x alpha=%d mode=%d id=%d", uint32(p.Attr), p.Attr.Alpha(),
+		p.Attr.ColorMode(), (p.Attr>>24)&0x3F)
+	if p.Attr&PFRenderBack != 0 {
+		s += " back"
+	}
+	if p.Attr&PFRenderFront != 0 {
+		s += " front"
+	}
+	if p.Tex.Format != TexNone {
+		s += fmt.Sprintf(" tex=%v:%dx%d@%x pal=%x", p.Tex.Format, p.Tex.Width, p.Tex.Height,
+			p.Tex.VramTexOffset, p.Tex.VramPalOffset)
+		if p.Tex.Flags&TexSRepeat != 0 {
+			s += " reps"
+		}
+		if p.Tex.Flags&TexTRepeat != 0 {
+			s += " rept"
+		}
+		if p.Tex.Flags&TexSFlip != 0 {
+			s += " flips"
+		}
+		if p.Tex.Flags&TexTFlip != 0 {
+			s += " flipt"
+		}
+		if p.Tex.ColorKey {
+			s += " colorkey"
+		}
+	}
+	return s
+}
+
+func (v *SceneVertex) String() string {
+	s := fmt.Sprintf("clip=(%.3f,%.3f,%.3f,%.3f) scr=(%d,%d) tex=(%.2f,%.2f) rgb=(%d,%d,%d)",
+		v.X, v.Y, v.Z, v.W, v.SX, v.SY, v.S, v.T, v.R, v.G, v.B)
+	if v.Clipped {
+		s += " clipped"
+	}
+	return s
+}
+
+// WriteText writes a description of the scene: the list of geometry commands
+// followed by the list of polygons with their attributes and vertices.
+func (s *Scene) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "frame %d: %d commands, %d vertices, %d polygons\n",
+		s.Frame, len(s.Commands), s.NumVertices, len(s.Polygons))
+	if len(s.Commands) > 0 {
+		fmt.Fprintf(bw, "\ncommands:\n")
+		for _, c := range s.Commands {
+			fmt.Fprintf(bw, "    %s\n", c)
+		}
+	}
+	fmt.Fprintf(bw, "\npolygons:\n")
+	for i := range s.Polygons {
+		p := &s.Polygons[i]
+		fmt.Fprintf(bw, "%5d: %s\n", i, p.String())
+		for j := range p.Vtx {
+			fmt.Fprintf(bw, "       %s\n", p.Vtx[j].String())
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteObj exports the scene as a Wavefront OBJ file. Vertices are exported
+// in normalized device coordinates (after perspective divide), with their
+// colors as extra components; texture coordinates are normalized to the
+// texture size.
+func (s *Scene) WriteObj(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# ndsemu 3D scene, frame %d, %d polygons\n", s.Frame, len(s.Polygons))
+	for i := range s.Polygons {
+		p := &s.Polygons[i]
+		for _, v := range p.Vtx {
+			wdiv := v.W
+			if wdiv == 0 {
+				wdiv = 1
+			}
+			fmt.Fprintf(bw, "v %f %f %f %f %f %f\n", v.X/wdiv, v.Y/wdiv, v.Z/wdiv,
+				float64(v.R)/63, float64(v.G)/63, float64(v.B)/63)
+		}
+		for _, v := range p.Vtx {
+			var s, t float64
+			if p.Tex.Width != 0 && p.Tex.Height != 0 {
+				s, t = v.S/float64(p.Tex.Width), 1-v.T/float64(p.Tex.Height)
+			}
+			fmt.Fprintf(bw, "vt %f %f\n", s, t)
+		}
+	}
+	for i := range s.Polygons {
+		fmt.Fprintf(bw, "# poly %d: %s\n", i, s.Polygons[i].String())
+		b := i*3 + 1
+		fmt.Fprintf(bw, "f %d/%d %d/%d %d/%d\n", b, b, b+1, b+1, b+2, b+2)
+	}
+	return bw.Flush()
+}
+
+// CaptureNextScene requests a snapshot of the next scene. fn is called with
+// the captured scene when the scene is complete (that is, on the next
+// SwapBuffers), from the goroutine that feeds the primitives.
+func (e3d *HwEngine3d) CaptureNextScene(fn func(*Scene)) {
+	e3d.captureFn = fn
+}
+
+func (e3d *HwEngine3d) captureScene() *Scene {
+	s := &Scene{
+		Frame:       e3d.framecnt,
+		NumVertices: len(e3d.next.Vram),
+		Polygons:    make([]ScenePolygon, len(e3d.next.Pram)),
+	}
+	clipped := make(map[*Vertex]bool, len(e3d.next.ClipVram))
+	for i := range e3d.next.ClipVram {
+		clipped[&e3d.next.ClipVram[i]] = true
+	}
+	for i := range e3d.next.Pram {
+		poly := &e3d.next.Pram[i]
+		sp := &s.Polygons[i]
+		sp.Attr = poly.flags
+		sp.Tex = poly.tex
+		for j, v := range poly.vtx {
+			sv := &sp.Vtx[j]
+			sv.X, sv.Y, sv.Z, sv.W = v.cx.ToFloat64(), v.cy.ToFloat64(), v.cz.ToFloat64(), v.cw.ToFloat64()
+			sv.SX, sv.SY = v.x.TruncInt32(), v.y.TruncInt32()
+			sv.R, sv.G, sv.B = v.r.TruncInt32(), v.g.TruncInt32(), v.b.TruncInt32()
+			// Texture coordinates have been already divided by depth
+			// (see polysSetDepth)
+			if v.d.V != 0 {
+				sv.S = v.s.DivFixed(v.d).ToFloat64()
+				sv.T = v.t.DivFixed(v.d).ToFloat64()
+			}
+			sv.Clipped = clipped[v]
+		}
+	}
+	return s
+}
+
+// SetWireframe enables or disables the wireframe debug mode, in which the
+// polygons are not filled, and only their edges are drawn (ignoring depth).
+func (e3d *HwEngine3d) SetWireframe(enable bool) {
+	e3d.dbgWireframe.Store(enable)
+}
+
+// Wireframe returns true if the wireframe debug mode is enabled
+func (e3d *HwEngine3d) Wireframe() bool {
+	return e3d.dbgWireframe.Load()
+}
+
+// SetHighlight selects a polygon (by its index in the scene, see Scene) whose
+// edges are drawn on top of the scene; -1 disables highlighting.
+func (e3d *HwEngine3d) SetHighlight(idx int) {
+	e3d.dbgHighlight.Store(int32(idx))
+}
+
+// drawEdges draws the part of the edges of the polygon that lies in the
+// screen line y, with the specified color (RGB555). Pixels are drawn over
+// the existing ones, ignoring the depth buffer.
+func (e3d *HwEngine3d) drawEdges(poly *Polygon, y int32, col uint32, line gfx.Line, abuffer gfx.Line) {
+	edges := [3][2]*Vertex{
+		{poly.vtx[0], poly.vtx[1]},
+		{poly.vtx[1], poly.vtx[2]},
+		{poly.vtx[0], poly.vtx[2]},
+	}
+	for _, e := range edges {
+		x0, y0 := e[0].x.TruncInt32(), e[0].y.TruncInt32()
+		x1, y1 := e[1].x.TruncInt32(), e[1].y.TruncInt32()
+		if y0 > y1 {
+			x0, y0, x1, y1 = x1, y1, x0, y0
+		}
+		if y < y0 || y > y1 {
+			continue
+		}
+
+		// Compute the horizontal span covered by the edge within this
+		// line, so that the edge is drawn without holes.
+		xa, xb := x0, x1
+		if y1 != y0 {
+			xa = x0 + (x1-x0)*(y-y0)/(y1-y0)
+			xb = xa
+			if y < y1 {
+				xb = x0 + (x1-x0)*(y+1-y0)/(y1-y0)
+			}
+		}
+		if xa > xb {
+			xa, xb = xb, xa
+		}
+		if xa < 0 {
+			xa = 0
+		}
+		if xb > 255 {
+			xb = 255
+		}
+		for x := xa; x <= xb; x++ {
+			line.Set32(int(x), col|0x80000000)
+			abuffer.Set8(int(x), 0x1F)
+		}
+	}
+}
+
+// colorRGB555 returns the vertex color converted to RGB555
+func (v *Vertex) colorRGB555() uint32 {
+	r, g, b := uint32(v.r.TruncInt32())>>1, uint32(v.g.TruncInt32())>>1, uint32(v.b.TruncInt32())>>1
+	return r | g<<5 | b<<10
+}
@@ -1,17 +1,20 @@
-// Generated on 2017-05-28 00:53:35.604936721 +0200 CEST
+// Generated on 2026-10-17 02:39:41.325056841 +0000 UTC
 package raster3d
 
 import "ndsemu/emu/gfx"
 import "ndsemu/emu"
 
-func (e3d *HwEngine3d) filler_000(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_000(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23,13 +26,22 @@ func (e3d *HwEngine3d) filler_000(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	b0, b1 := poly.left[LerpB].Cur(), poly.right[LerpB].Cur()
 	db := b1.SubFixed(b0).Div(nx)
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -49,10 +61,12 @@ func (e3d *HwEngine3d) filler_000(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -70,14 +84,17 @@ func (e3d *HwEngine3d) filler_000(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     002 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 //     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_003(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_003(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -98,14 +115,23 @@ func (e3d *HwEngine3d) filler_003(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -149,10 +175,12 @@ func (e3d *HwEngine3d) filler_003(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -164,14 +192,17 @@ func (e3d *HwEngine3d) filler_003(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_004(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_004(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -191,14 +222,23 @@ func (e3d *HwEngine3d) filler_004(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -237,10 +277,12 @@ func (e3d *HwEngine3d) filler_004(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -252,14 +294,17 @@ func (e3d *HwEngine3d) filler_004(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_005(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_005(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -278,14 +323,23 @@ func (e3d *HwEngine3d) filler_005(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -322,10 +376,12 @@ func (e3d *HwEngine3d) filler_005(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -337,14 +393,17 @@ func (e3d *HwEngine3d) filler_005(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_006(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_006(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -365,6 +424,8 @@ func (e3d *HwEngine3d) filler_006(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -372,8 +433,15 @@ func (e3d *HwEngine3d) filler_006(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -416,10 +484,12 @@ func (e3d *HwEngine3d) filler_006(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -431,14 +501,17 @@ func (e3d *HwEngine3d) filler_006(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_007(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_007(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -458,6 +531,8 @@ func (e3d *HwEngine3d) filler_007(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -465,8 +540,15 @@ func (e3d *HwEngine3d) filler_007(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -504,10 +586,12 @@ func (e3d *HwEngine3d) filler_007(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -519,14 +603,17 @@ func (e3d *HwEngine3d) filler_007(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_008(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_008(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -545,6 +632,8 @@ func (e3d *HwEngine3d) filler_008(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -552,8 +641,15 @@ func (e3d *HwEngine3d) filler_008(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -589,10 +685,12 @@ func (e3d *HwEngine3d) filler_008(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -604,14 +702,17 @@ func (e3d *HwEngine3d) filler_008(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_009(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_009(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -632,6 +733,8 @@ func (e3d *HwEngine3d) filler_009(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -639,8 +742,15 @@ func (e3d *HwEngine3d) filler_009(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -683,10 +793,12 @@ func (e3d *HwEngine3d) filler_009(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -698,14 +810,17 @@ func (e3d *HwEngine3d) filler_009(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_00a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_00a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -725,6 +840,8 @@ func (e3d *HwEngine3d) filler_00a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -732,8 +849,15 @@ func (e3d *HwEngine3d) filler_00a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -771,10 +895,12 @@ func (e3d *HwEngine3d) filler_00a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -786,14 +912,17 @@ func (e3d *HwEngine3d) filler_00a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_00b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_00b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -812,6 +941,8 @@ func (e3d *HwEngine3d) filler_00b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -819,8 +950,15 @@ func (e3d *HwEngine3d) filler_00b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -856,10 +994,12 @@ func (e3d *HwEngine3d) filler_00b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -871,14 +1011,17 @@ func (e3d *HwEngine3d) filler_00b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_00c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_00c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -899,14 +1042,23 @@ func (e3d *HwEngine3d) filler_00c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -947,10 +1099,12 @@ func (e3d *HwEngine3d) filler_00c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -962,14 +1116,17 @@ func (e3d *HwEngine3d) filler_00c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_00d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_00d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -989,14 +1146,23 @@ func (e3d *HwEngine3d) filler_00d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1032,10 +1198,12 @@ func (e3d *HwEngine3d) filler_00d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1047,14 +1215,17 @@ func (e3d *HwEngine3d) filler_00d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_00e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_00e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1073,14 +1244,23 @@ func (e3d *HwEngine3d) filler_00e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1114,10 +1294,12 @@ func (e3d *HwEngine3d) filler_00e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1129,14 +1311,17 @@ func (e3d *HwEngine3d) filler_00e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_00f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_00f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1156,6 +1341,8 @@ func (e3d *HwEngine3d) filler_00f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -1164,8 +1351,15 @@ func (e3d *HwEngine3d) filler_00f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -1209,10 +1403,12 @@ func (e3d *HwEngine3d) filler_00f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1224,14 +1420,17 @@ func (e3d *HwEngine3d) filler_00f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_010(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_010(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1250,6 +1449,8 @@ func (e3d *HwEngine3d) filler_010(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -1258,8 +1459,15 @@ func (e3d *HwEngine3d) filler_010(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1298,10 +1506,12 @@ func (e3d *HwEngine3d) filler_010(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1313,14 +1523,17 @@ func (e3d *HwEngine3d) filler_010(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_011(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_011(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1338,6 +1551,8 @@ func (e3d *HwEngine3d) filler_011(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -1346,8 +1561,15 @@ func (e3d *HwEngine3d) filler_011(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1384,10 +1606,12 @@ func (e3d *HwEngine3d) filler_011(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1399,14 +1623,17 @@ func (e3d *HwEngine3d) filler_011(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_012(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_012(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1427,14 +1654,23 @@ func (e3d *HwEngine3d) filler_012(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -1478,10 +1714,12 @@ func (e3d *HwEngine3d) filler_012(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1493,14 +1731,17 @@ func (e3d *HwEngine3d) filler_012(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_013(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_013(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1520,14 +1761,23 @@ func (e3d *HwEngine3d) filler_013(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1566,10 +1816,12 @@ func (e3d *HwEngine3d) filler_013(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1581,14 +1833,17 @@ func (e3d *HwEngine3d) filler_013(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_014(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_014(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1607,14 +1862,23 @@ func (e3d *HwEngine3d) filler_014(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1651,10 +1915,12 @@ func (e3d *HwEngine3d) filler_014(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1666,14 +1932,17 @@ func (e3d *HwEngine3d) filler_014(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_015(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_015(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1693,6 +1962,8 @@ func (e3d *HwEngine3d) filler_015(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -1700,8 +1971,15 @@ func (e3d *HwEngine3d) filler_015(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -1745,10 +2023,12 @@ func (e3d *HwEngine3d) filler_015(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1760,14 +2040,17 @@ func (e3d *HwEngine3d) filler_015(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_016(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_016(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1786,6 +2069,8 @@ func (e3d *HwEngine3d) filler_016(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -1793,8 +2078,15 @@ func (e3d *HwEngine3d) filler_016(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1833,10 +2125,12 @@ func (e3d *HwEngine3d) filler_016(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1848,14 +2142,17 @@ func (e3d *HwEngine3d) filler_016(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_017(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_017(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1873,6 +2170,8 @@ func (e3d *HwEngine3d) filler_017(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -1880,8 +2179,15 @@ func (e3d *HwEngine3d) filler_017(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -1918,10 +2224,12 @@ func (e3d *HwEngine3d) filler_017(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -1957,14 +2265,17 @@ func (e3d *HwEngine3d) filler_017(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     01d -> {TexFormat:1 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:2}
 //     005 -> {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_01e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_01e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -1985,6 +2296,8 @@ func (e3d *HwEngine3d) filler_01e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -1992,8 +2305,15 @@ func (e3d *HwEngine3d) filler_01e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -2040,10 +2360,12 @@ func (e3d *HwEngine3d) filler_01e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2055,14 +2377,17 @@ func (e3d *HwEngine3d) filler_01e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_01f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_01f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2082,6 +2407,8 @@ func (e3d *HwEngine3d) filler_01f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -2089,8 +2416,15 @@ func (e3d *HwEngine3d) filler_01f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -2132,10 +2466,12 @@ func (e3d *HwEngine3d) filler_01f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2147,14 +2483,17 @@ func (e3d *HwEngine3d) filler_01f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_020(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_020(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2173,6 +2512,8 @@ func (e3d *HwEngine3d) filler_020(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -2180,8 +2521,15 @@ func (e3d *HwEngine3d) filler_020(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -2221,10 +2569,12 @@ func (e3d *HwEngine3d) filler_020(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2236,14 +2586,17 @@ func (e3d *HwEngine3d) filler_020(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_021(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_021(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2264,6 +2617,8 @@ func (e3d *HwEngine3d) filler_021(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -2271,8 +2626,15 @@ func (e3d *HwEngine3d) filler_021(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -2319,10 +2681,12 @@ func (e3d *HwEngine3d) filler_021(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2334,14 +2698,17 @@ func (e3d *HwEngine3d) filler_021(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_022(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_022(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2361,6 +2728,8 @@ func (e3d *HwEngine3d) filler_022(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -2368,8 +2737,15 @@ func (e3d *HwEngine3d) filler_022(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -2411,10 +2787,12 @@ func (e3d *HwEngine3d) filler_022(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2426,14 +2804,17 @@ func (e3d *HwEngine3d) filler_022(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_023(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_023(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2452,6 +2833,8 @@ func (e3d *HwEngine3d) filler_023(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -2459,8 +2842,15 @@ func (e3d *HwEngine3d) filler_023(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -2500,10 +2890,12 @@ func (e3d *HwEngine3d) filler_023(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2515,14 +2907,17 @@ func (e3d *HwEngine3d) filler_023(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_024(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_024(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2543,14 +2938,23 @@ func (e3d *HwEngine3d) filler_024(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -2594,10 +2998,12 @@ func (e3d *HwEngine3d) filler_024(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2609,14 +3015,17 @@ func (e3d *HwEngine3d) filler_024(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_025(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_025(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2636,14 +3045,23 @@ func (e3d *HwEngine3d) filler_025(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -2682,10 +3100,12 @@ func (e3d *HwEngine3d) filler_025(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2697,14 +3117,17 @@ func (e3d *HwEngine3d) filler_025(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_026(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_026(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2723,14 +3146,23 @@ func (e3d *HwEngine3d) filler_026(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -2767,10 +3199,12 @@ func (e3d *HwEngine3d) filler_026(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2818,14 +3252,17 @@ func (e3d *HwEngine3d) filler_026(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     02f -> {TexFormat:7 ColorKey:1 FillMode:0 ColorMode:0 TexCoords:2}
 //     017 -> {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_030(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_030(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2838,13 +3275,22 @@ func (e3d *HwEngine3d) filler_030(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	db := b1.SubFixed(b0).Div(nx)
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -2869,6 +3315,7 @@ func (e3d *HwEngine3d) filler_030(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -2876,10 +3323,12 @@ func (e3d *HwEngine3d) filler_030(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -2897,14 +3346,17 @@ func (e3d *HwEngine3d) filler_030(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     032 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 //     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_033(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_033(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -2926,14 +3378,23 @@ func (e3d *HwEngine3d) filler_033(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -2982,6 +3443,7 @@ func (e3d *HwEngine3d) filler_033(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -2989,10 +3451,12 @@ func (e3d *HwEngine3d) filler_033(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3004,14 +3468,17 @@ func (e3d *HwEngine3d) filler_033(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_034(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_034(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3032,14 +3499,23 @@ func (e3d *HwEngine3d) filler_034(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -3083,6 +3559,7 @@ func (e3d *HwEngine3d) filler_034(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3090,10 +3567,12 @@ func (e3d *HwEngine3d) filler_034(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3105,14 +3584,17 @@ func (e3d *HwEngine3d) filler_034(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_035(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_035(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3132,14 +3614,23 @@ func (e3d *HwEngine3d) filler_035(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -3181,6 +3672,7 @@ func (e3d *HwEngine3d) filler_035(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3188,10 +3680,12 @@ func (e3d *HwEngine3d) filler_035(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3203,14 +3697,17 @@ func (e3d *HwEngine3d) filler_035(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_036(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_036(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3232,6 +3729,8 @@ func (e3d *HwEngine3d) filler_036(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -3239,8 +3738,15 @@ func (e3d *HwEngine3d) filler_036(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -3288,6 +3794,7 @@ func (e3d *HwEngine3d) filler_036(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3295,10 +3802,12 @@ func (e3d *HwEngine3d) filler_036(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3310,14 +3819,17 @@ func (e3d *HwEngine3d) filler_036(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_037(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_037(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3338,6 +3850,8 @@ func (e3d *HwEngine3d) filler_037(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -3345,8 +3859,15 @@ func (e3d *HwEngine3d) filler_037(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -3389,6 +3910,7 @@ func (e3d *HwEngine3d) filler_037(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3396,10 +3918,12 @@ func (e3d *HwEngine3d) filler_037(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3411,14 +3935,17 @@ func (e3d *HwEngine3d) filler_037(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_038(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_038(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3438,6 +3965,8 @@ func (e3d *HwEngine3d) filler_038(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -3445,8 +3974,15 @@ func (e3d *HwEngine3d) filler_038(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -3487,6 +4023,7 @@ func (e3d *HwEngine3d) filler_038(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3494,10 +4031,12 @@ func (e3d *HwEngine3d) filler_038(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3509,14 +4048,17 @@ func (e3d *HwEngine3d) filler_038(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_039(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_039(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3538,6 +4080,8 @@ func (e3d *HwEngine3d) filler_039(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -3545,8 +4089,15 @@ func (e3d *HwEngine3d) filler_039(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -3594,6 +4145,7 @@ func (e3d *HwEngine3d) filler_039(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3601,10 +4153,12 @@ func (e3d *HwEngine3d) filler_039(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3616,14 +4170,17 @@ func (e3d *HwEngine3d) filler_039(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_03a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_03a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3644,6 +4201,8 @@ func (e3d *HwEngine3d) filler_03a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -3651,8 +4210,15 @@ func (e3d *HwEngine3d) filler_03a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -3695,6 +4261,7 @@ func (e3d *HwEngine3d) filler_03a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3702,10 +4269,12 @@ func (e3d *HwEngine3d) filler_03a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3717,14 +4286,17 @@ func (e3d *HwEngine3d) filler_03a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_03b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_03b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3744,6 +4316,8 @@ func (e3d *HwEngine3d) filler_03b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -3751,8 +4325,15 @@ func (e3d *HwEngine3d) filler_03b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -3793,6 +4374,7 @@ func (e3d *HwEngine3d) filler_03b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3800,10 +4382,12 @@ func (e3d *HwEngine3d) filler_03b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3815,14 +4399,17 @@ func (e3d *HwEngine3d) filler_03b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_03c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_03c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3844,14 +4431,23 @@ func (e3d *HwEngine3d) filler_03c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -3897,6 +4493,7 @@ func (e3d *HwEngine3d) filler_03c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -3904,10 +4501,12 @@ func (e3d *HwEngine3d) filler_03c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -3919,14 +4518,17 @@ func (e3d *HwEngine3d) filler_03c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_03d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_03d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -3947,14 +4549,23 @@ func (e3d *HwEngine3d) filler_03d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -3995,6 +4606,7 @@ func (e3d *HwEngine3d) filler_03d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4002,10 +4614,12 @@ func (e3d *HwEngine3d) filler_03d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4017,14 +4631,17 @@ func (e3d *HwEngine3d) filler_03d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_03e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_03e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4044,14 +4661,23 @@ func (e3d *HwEngine3d) filler_03e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -4090,6 +4716,7 @@ func (e3d *HwEngine3d) filler_03e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4097,10 +4724,12 @@ func (e3d *HwEngine3d) filler_03e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4112,14 +4741,17 @@ func (e3d *HwEngine3d) filler_03e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_03f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_03f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4140,6 +4772,8 @@ func (e3d *HwEngine3d) filler_03f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -4148,8 +4782,15 @@ func (e3d *HwEngine3d) filler_03f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -4198,6 +4839,7 @@ func (e3d *HwEngine3d) filler_03f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4205,10 +4847,12 @@ func (e3d *HwEngine3d) filler_03f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4220,14 +4864,17 @@ func (e3d *HwEngine3d) filler_03f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_040(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_040(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4247,6 +4894,8 @@ func (e3d *HwEngine3d) filler_040(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -4255,8 +4904,15 @@ func (e3d *HwEngine3d) filler_040(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -4300,6 +4956,7 @@ func (e3d *HwEngine3d) filler_040(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4307,10 +4964,12 @@ func (e3d *HwEngine3d) filler_040(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4322,14 +4981,17 @@ func (e3d *HwEngine3d) filler_040(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_041(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_041(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4348,6 +5010,8 @@ func (e3d *HwEngine3d) filler_041(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -4356,8 +5020,15 @@ func (e3d *HwEngine3d) filler_041(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -4399,6 +5070,7 @@ func (e3d *HwEngine3d) filler_041(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4406,10 +5078,12 @@ func (e3d *HwEngine3d) filler_041(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4421,14 +5095,17 @@ func (e3d *HwEngine3d) filler_041(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_042(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_042(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4450,14 +5127,23 @@ func (e3d *HwEngine3d) filler_042(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -4506,6 +5192,7 @@ func (e3d *HwEngine3d) filler_042(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4513,10 +5200,12 @@ func (e3d *HwEngine3d) filler_042(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4528,14 +5217,17 @@ func (e3d *HwEngine3d) filler_042(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_043(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_043(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4556,14 +5248,23 @@ func (e3d *HwEngine3d) filler_043(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -4607,6 +5308,7 @@ func (e3d *HwEngine3d) filler_043(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4614,10 +5316,12 @@ func (e3d *HwEngine3d) filler_043(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4629,14 +5333,17 @@ func (e3d *HwEngine3d) filler_043(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_044(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_044(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4656,14 +5363,23 @@ func (e3d *HwEngine3d) filler_044(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -4705,6 +5421,7 @@ func (e3d *HwEngine3d) filler_044(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4712,10 +5429,12 @@ func (e3d *HwEngine3d) filler_044(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4727,14 +5446,17 @@ func (e3d *HwEngine3d) filler_044(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_045(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_045(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4755,6 +5477,8 @@ func (e3d *HwEngine3d) filler_045(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -4762,8 +5486,15 @@ func (e3d *HwEngine3d) filler_045(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -4812,6 +5543,7 @@ func (e3d *HwEngine3d) filler_045(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4819,10 +5551,12 @@ func (e3d *HwEngine3d) filler_045(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4834,14 +5568,17 @@ func (e3d *HwEngine3d) filler_045(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_046(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_046(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4861,6 +5598,8 @@ func (e3d *HwEngine3d) filler_046(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -4868,8 +5607,15 @@ func (e3d *HwEngine3d) filler_046(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -4913,6 +5659,7 @@ func (e3d *HwEngine3d) filler_046(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -4920,10 +5667,12 @@ func (e3d *HwEngine3d) filler_046(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -4935,14 +5684,17 @@ func (e3d *HwEngine3d) filler_046(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_047(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_047(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -4961,6 +5713,8 @@ func (e3d *HwEngine3d) filler_047(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -4968,8 +5722,15 @@ func (e3d *HwEngine3d) filler_047(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -5011,6 +5772,7 @@ func (e3d *HwEngine3d) filler_047(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5018,10 +5780,12 @@ func (e3d *HwEngine3d) filler_047(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5057,14 +5821,17 @@ func (e3d *HwEngine3d) filler_047(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     04d -> {TexFormat:1 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:2}
 //     035 -> {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_04e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_04e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5086,6 +5853,8 @@ func (e3d *HwEngine3d) filler_04e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -5093,8 +5862,15 @@ func (e3d *HwEngine3d) filler_04e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -5146,6 +5922,7 @@ func (e3d *HwEngine3d) filler_04e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5153,10 +5930,12 @@ func (e3d *HwEngine3d) filler_04e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5168,14 +5947,17 @@ func (e3d *HwEngine3d) filler_04e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_04f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_04f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5196,6 +5978,8 @@ func (e3d *HwEngine3d) filler_04f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -5203,8 +5987,15 @@ func (e3d *HwEngine3d) filler_04f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -5251,6 +6042,7 @@ func (e3d *HwEngine3d) filler_04f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5258,10 +6050,12 @@ func (e3d *HwEngine3d) filler_04f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5273,14 +6067,17 @@ func (e3d *HwEngine3d) filler_04f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_050(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_050(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5300,6 +6097,8 @@ func (e3d *HwEngine3d) filler_050(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -5307,8 +6106,15 @@ func (e3d *HwEngine3d) filler_050(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -5353,6 +6159,7 @@ func (e3d *HwEngine3d) filler_050(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5360,10 +6167,12 @@ func (e3d *HwEngine3d) filler_050(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5375,14 +6184,17 @@ func (e3d *HwEngine3d) filler_050(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_051(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_051(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5404,6 +6216,8 @@ func (e3d *HwEngine3d) filler_051(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -5411,8 +6225,15 @@ func (e3d *HwEngine3d) filler_051(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -5464,6 +6285,7 @@ func (e3d *HwEngine3d) filler_051(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5471,10 +6293,12 @@ func (e3d *HwEngine3d) filler_051(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5486,14 +6310,17 @@ func (e3d *HwEngine3d) filler_051(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_052(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_052(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5514,6 +6341,8 @@ func (e3d *HwEngine3d) filler_052(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -5521,8 +6350,15 @@ func (e3d *HwEngine3d) filler_052(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -5569,6 +6405,7 @@ func (e3d *HwEngine3d) filler_052(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5576,10 +6413,12 @@ func (e3d *HwEngine3d) filler_052(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5591,14 +6430,17 @@ func (e3d *HwEngine3d) filler_052(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_053(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_053(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5618,6 +6460,8 @@ func (e3d *HwEngine3d) filler_053(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -5625,8 +6469,15 @@ func (e3d *HwEngine3d) filler_053(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -5671,6 +6522,7 @@ func (e3d *HwEngine3d) filler_053(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5678,10 +6530,12 @@ func (e3d *HwEngine3d) filler_053(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5693,14 +6547,17 @@ func (e3d *HwEngine3d) filler_053(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_054(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_054(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5722,14 +6579,23 @@ func (e3d *HwEngine3d) filler_054(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -5778,6 +6644,7 @@ func (e3d *HwEngine3d) filler_054(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5785,10 +6652,12 @@ func (e3d *HwEngine3d) filler_054(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5800,14 +6669,17 @@ func (e3d *HwEngine3d) filler_054(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_055(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_055(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5828,14 +6700,23 @@ func (e3d *HwEngine3d) filler_055(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -5879,6 +6760,7 @@ func (e3d *HwEngine3d) filler_055(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5886,10 +6768,12 @@ func (e3d *HwEngine3d) filler_055(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -5901,14 +6785,17 @@ func (e3d *HwEngine3d) filler_055(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_056(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_056(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:0 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -5928,14 +6815,23 @@ func (e3d *HwEngine3d) filler_056(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -5977,6 +6873,7 @@ func (e3d *HwEngine3d) filler_056(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -5984,10 +6881,12 @@ func (e3d *HwEngine3d) filler_056(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -6431,14 +7330,17 @@ func (e3d *HwEngine3d) filler_056(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     0c2 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 //     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_0c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -6459,14 +7361,23 @@ func (e3d *HwEngine3d) filler_0c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -6508,10 +7419,12 @@ func (e3d *HwEngine3d) filler_0c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -6523,14 +7436,17 @@ func (e3d *HwEngine3d) filler_0c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -6550,14 +7466,23 @@ func (e3d *HwEngine3d) filler_0c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -6594,10 +7519,12 @@ func (e3d *HwEngine3d) filler_0c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -6609,14 +7536,17 @@ func (e3d *HwEngine3d) filler_0c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -6635,14 +7565,23 @@ func (e3d *HwEngine3d) filler_0c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -6677,10 +7616,12 @@ func (e3d *HwEngine3d) filler_0c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -6692,14 +7633,17 @@ func (e3d *HwEngine3d) filler_0c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -6720,6 +7664,8 @@ func (e3d *HwEngine3d) filler_0c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -6727,8 +7673,15 @@ func (e3d *HwEngine3d) filler_0c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -6769,10 +7722,12 @@ func (e3d *HwEngine3d) filler_0c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -6784,14 +7739,17 @@ func (e3d *HwEngine3d) filler_0c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -6811,6 +7769,8 @@ func (e3d *HwEngine3d) filler_0c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -6818,8 +7778,15 @@ func (e3d *HwEngine3d) filler_0c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -6855,10 +7822,12 @@ func (e3d *HwEngine3d) filler_0c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -6870,14 +7839,17 @@ func (e3d *HwEngine3d) filler_0c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0c8(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0c8(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -6896,6 +7868,8 @@ func (e3d *HwEngine3d) filler_0c8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -6903,8 +7877,15 @@ func (e3d *HwEngine3d) filler_0c8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -6938,10 +7919,12 @@ func (e3d *HwEngine3d) filler_0c8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -6953,14 +7936,17 @@ func (e3d *HwEngine3d) filler_0c8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0c9(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0c9(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -6981,6 +7967,8 @@ func (e3d *HwEngine3d) filler_0c9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -6988,8 +7976,15 @@ func (e3d *HwEngine3d) filler_0c9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -7030,10 +8025,12 @@ func (e3d *HwEngine3d) filler_0c9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7045,14 +8042,17 @@ func (e3d *HwEngine3d) filler_0c9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0ca(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0ca(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7072,6 +8072,8 @@ func (e3d *HwEngine3d) filler_0ca(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -7079,8 +8081,15 @@ func (e3d *HwEngine3d) filler_0ca(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7116,10 +8125,12 @@ func (e3d *HwEngine3d) filler_0ca(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7131,14 +8142,17 @@ func (e3d *HwEngine3d) filler_0ca(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0cb(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0cb(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7157,6 +8171,8 @@ func (e3d *HwEngine3d) filler_0cb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -7164,8 +8180,15 @@ func (e3d *HwEngine3d) filler_0cb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7199,10 +8222,12 @@ func (e3d *HwEngine3d) filler_0cb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7214,14 +8239,17 @@ func (e3d *HwEngine3d) filler_0cb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0cc(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0cc(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7242,14 +8270,23 @@ func (e3d *HwEngine3d) filler_0cc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -7288,10 +8325,12 @@ func (e3d *HwEngine3d) filler_0cc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7303,14 +8342,17 @@ func (e3d *HwEngine3d) filler_0cc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0cd(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0cd(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7330,14 +8372,23 @@ func (e3d *HwEngine3d) filler_0cd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7371,10 +8422,12 @@ func (e3d *HwEngine3d) filler_0cd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7386,14 +8439,17 @@ func (e3d *HwEngine3d) filler_0cd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7412,14 +8468,23 @@ func (e3d *HwEngine3d) filler_0ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7451,10 +8516,12 @@ func (e3d *HwEngine3d) filler_0ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7466,14 +8533,17 @@ func (e3d *HwEngine3d) filler_0ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7493,6 +8563,8 @@ func (e3d *HwEngine3d) filler_0cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -7501,8 +8573,15 @@ func (e3d *HwEngine3d) filler_0cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -7544,10 +8623,12 @@ func (e3d *HwEngine3d) filler_0cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7559,14 +8640,17 @@ func (e3d *HwEngine3d) filler_0cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7585,6 +8669,8 @@ func (e3d *HwEngine3d) filler_0d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -7593,8 +8679,15 @@ func (e3d *HwEngine3d) filler_0d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7631,10 +8724,12 @@ func (e3d *HwEngine3d) filler_0d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7646,14 +8741,17 @@ func (e3d *HwEngine3d) filler_0d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7671,6 +8769,8 @@ func (e3d *HwEngine3d) filler_0d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -7679,8 +8779,15 @@ func (e3d *HwEngine3d) filler_0d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7715,10 +8822,12 @@ func (e3d *HwEngine3d) filler_0d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7730,14 +8839,17 @@ func (e3d *HwEngine3d) filler_0d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7758,14 +8870,23 @@ func (e3d *HwEngine3d) filler_0d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -7807,10 +8928,12 @@ func (e3d *HwEngine3d) filler_0d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7822,14 +8945,17 @@ func (e3d *HwEngine3d) filler_0d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7849,14 +8975,23 @@ func (e3d *HwEngine3d) filler_0d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7893,10 +9028,12 @@ func (e3d *HwEngine3d) filler_0d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7908,14 +9045,17 @@ func (e3d *HwEngine3d) filler_0d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -7934,14 +9074,23 @@ func (e3d *HwEngine3d) filler_0d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -7976,10 +9125,12 @@ func (e3d *HwEngine3d) filler_0d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -7991,14 +9142,17 @@ func (e3d *HwEngine3d) filler_0d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8018,6 +9172,8 @@ func (e3d *HwEngine3d) filler_0d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -8025,8 +9181,15 @@ func (e3d *HwEngine3d) filler_0d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -8068,10 +9231,12 @@ func (e3d *HwEngine3d) filler_0d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8083,14 +9248,17 @@ func (e3d *HwEngine3d) filler_0d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8109,6 +9277,8 @@ func (e3d *HwEngine3d) filler_0d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -8116,8 +9286,15 @@ func (e3d *HwEngine3d) filler_0d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -8154,10 +9331,12 @@ func (e3d *HwEngine3d) filler_0d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8169,14 +9348,17 @@ func (e3d *HwEngine3d) filler_0d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0d7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0d7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8194,6 +9376,8 @@ func (e3d *HwEngine3d) filler_0d7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -8201,8 +9385,15 @@ func (e3d *HwEngine3d) filler_0d7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -8237,10 +9428,12 @@ func (e3d *HwEngine3d) filler_0d7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8276,14 +9469,17 @@ func (e3d *HwEngine3d) filler_0d7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     0dd -> {TexFormat:1 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:2}
 //     0c5 -> {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:1 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_0de(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0de(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8304,6 +9500,8 @@ func (e3d *HwEngine3d) filler_0de(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -8311,8 +9509,15 @@ func (e3d *HwEngine3d) filler_0de(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -8357,10 +9562,12 @@ func (e3d *HwEngine3d) filler_0de(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8372,14 +9579,17 @@ func (e3d *HwEngine3d) filler_0de(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0df(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0df(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8399,6 +9609,8 @@ func (e3d *HwEngine3d) filler_0df(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -8406,8 +9618,15 @@ func (e3d *HwEngine3d) filler_0df(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -8447,10 +9666,12 @@ func (e3d *HwEngine3d) filler_0df(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8462,14 +9683,17 @@ func (e3d *HwEngine3d) filler_0df(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0e0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0e0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8488,6 +9712,8 @@ func (e3d *HwEngine3d) filler_0e0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -8495,8 +9721,15 @@ func (e3d *HwEngine3d) filler_0e0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -8534,10 +9767,12 @@ func (e3d *HwEngine3d) filler_0e0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8549,14 +9784,17 @@ func (e3d *HwEngine3d) filler_0e0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0e1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0e1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8577,6 +9815,8 @@ func (e3d *HwEngine3d) filler_0e1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -8584,8 +9824,15 @@ func (e3d *HwEngine3d) filler_0e1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -8630,10 +9877,12 @@ func (e3d *HwEngine3d) filler_0e1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8645,14 +9894,17 @@ func (e3d *HwEngine3d) filler_0e1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0e2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0e2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8672,6 +9924,8 @@ func (e3d *HwEngine3d) filler_0e2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -8679,8 +9933,15 @@ func (e3d *HwEngine3d) filler_0e2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -8720,10 +9981,12 @@ func (e3d *HwEngine3d) filler_0e2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8735,14 +9998,17 @@ func (e3d *HwEngine3d) filler_0e2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0e3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0e3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8761,6 +10027,8 @@ func (e3d *HwEngine3d) filler_0e3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -8768,8 +10036,15 @@ func (e3d *HwEngine3d) filler_0e3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -8807,10 +10082,12 @@ func (e3d *HwEngine3d) filler_0e3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8822,14 +10099,17 @@ func (e3d *HwEngine3d) filler_0e3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0e4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0e4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8850,14 +10130,23 @@ func (e3d *HwEngine3d) filler_0e4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -8899,10 +10188,12 @@ func (e3d *HwEngine3d) filler_0e4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -8914,14 +10205,17 @@ func (e3d *HwEngine3d) filler_0e4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0e5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0e5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -8941,14 +10235,23 @@ func (e3d *HwEngine3d) filler_0e5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -8985,10 +10288,12 @@ func (e3d *HwEngine3d) filler_0e5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9000,14 +10305,17 @@ func (e3d *HwEngine3d) filler_0e5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0e6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0e6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9026,14 +10334,23 @@ func (e3d *HwEngine3d) filler_0e6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -9068,10 +10385,12 @@ func (e3d *HwEngine3d) filler_0e6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9131,14 +10450,17 @@ func (e3d *HwEngine3d) filler_0e6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     0f2 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 //     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_0f3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0f3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9160,14 +10482,23 @@ func (e3d *HwEngine3d) filler_0f3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -9214,6 +10545,7 @@ func (e3d *HwEngine3d) filler_0f3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9221,10 +10553,12 @@ func (e3d *HwEngine3d) filler_0f3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9236,14 +10570,17 @@ func (e3d *HwEngine3d) filler_0f3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0f4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0f4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9264,14 +10601,23 @@ func (e3d *HwEngine3d) filler_0f4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -9313,6 +10659,7 @@ func (e3d *HwEngine3d) filler_0f4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9320,10 +10667,12 @@ func (e3d *HwEngine3d) filler_0f4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9335,14 +10684,17 @@ func (e3d *HwEngine3d) filler_0f4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0f5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0f5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9362,14 +10714,23 @@ func (e3d *HwEngine3d) filler_0f5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -9409,6 +10770,7 @@ func (e3d *HwEngine3d) filler_0f5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9416,10 +10778,12 @@ func (e3d *HwEngine3d) filler_0f5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9431,14 +10795,17 @@ func (e3d *HwEngine3d) filler_0f5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0f6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0f6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9460,6 +10827,8 @@ func (e3d *HwEngine3d) filler_0f6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -9467,8 +10836,15 @@ func (e3d *HwEngine3d) filler_0f6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -9514,6 +10890,7 @@ func (e3d *HwEngine3d) filler_0f6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9521,10 +10898,12 @@ func (e3d *HwEngine3d) filler_0f6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9536,14 +10915,17 @@ func (e3d *HwEngine3d) filler_0f6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0f7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0f7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9564,6 +10946,8 @@ func (e3d *HwEngine3d) filler_0f7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -9571,8 +10955,15 @@ func (e3d *HwEngine3d) filler_0f7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -9613,6 +11004,7 @@ func (e3d *HwEngine3d) filler_0f7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9620,10 +11012,12 @@ func (e3d *HwEngine3d) filler_0f7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9635,14 +11029,17 @@ func (e3d *HwEngine3d) filler_0f7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0f8(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0f8(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9662,6 +11059,8 @@ func (e3d *HwEngine3d) filler_0f8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -9669,8 +11068,15 @@ func (e3d *HwEngine3d) filler_0f8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -9709,6 +11115,7 @@ func (e3d *HwEngine3d) filler_0f8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9716,10 +11123,12 @@ func (e3d *HwEngine3d) filler_0f8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9731,14 +11140,17 @@ func (e3d *HwEngine3d) filler_0f8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0f9(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0f9(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9760,6 +11172,8 @@ func (e3d *HwEngine3d) filler_0f9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -9767,8 +11181,15 @@ func (e3d *HwEngine3d) filler_0f9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -9814,6 +11235,7 @@ func (e3d *HwEngine3d) filler_0f9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9821,10 +11243,12 @@ func (e3d *HwEngine3d) filler_0f9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9836,14 +11260,17 @@ func (e3d *HwEngine3d) filler_0f9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0fa(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0fa(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9864,6 +11291,8 @@ func (e3d *HwEngine3d) filler_0fa(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -9871,8 +11300,15 @@ func (e3d *HwEngine3d) filler_0fa(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -9913,6 +11349,7 @@ func (e3d *HwEngine3d) filler_0fa(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -9920,10 +11357,12 @@ func (e3d *HwEngine3d) filler_0fa(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -9935,14 +11374,17 @@ func (e3d *HwEngine3d) filler_0fa(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0fb(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0fb(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -9962,6 +11404,8 @@ func (e3d *HwEngine3d) filler_0fb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -9969,8 +11413,15 @@ func (e3d *HwEngine3d) filler_0fb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -10009,6 +11460,7 @@ func (e3d *HwEngine3d) filler_0fb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10016,10 +11468,12 @@ func (e3d *HwEngine3d) filler_0fb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10031,14 +11485,17 @@ func (e3d *HwEngine3d) filler_0fb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0fc(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0fc(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10060,14 +11517,23 @@ func (e3d *HwEngine3d) filler_0fc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -10111,6 +11577,7 @@ func (e3d *HwEngine3d) filler_0fc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10118,10 +11585,12 @@ func (e3d *HwEngine3d) filler_0fc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10133,14 +11602,17 @@ func (e3d *HwEngine3d) filler_0fc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0fd(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0fd(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10161,14 +11633,23 @@ func (e3d *HwEngine3d) filler_0fd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -10207,6 +11688,7 @@ func (e3d *HwEngine3d) filler_0fd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10214,10 +11696,12 @@ func (e3d *HwEngine3d) filler_0fd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10229,14 +11713,17 @@ func (e3d *HwEngine3d) filler_0fd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0fe(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0fe(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10256,14 +11743,23 @@ func (e3d *HwEngine3d) filler_0fe(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -10300,6 +11796,7 @@ func (e3d *HwEngine3d) filler_0fe(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10307,10 +11804,12 @@ func (e3d *HwEngine3d) filler_0fe(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10322,14 +11821,17 @@ func (e3d *HwEngine3d) filler_0fe(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_0ff(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_0ff(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10350,6 +11852,8 @@ func (e3d *HwEngine3d) filler_0ff(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -10358,8 +11862,15 @@ func (e3d *HwEngine3d) filler_0ff(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -10406,6 +11917,7 @@ func (e3d *HwEngine3d) filler_0ff(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10413,10 +11925,12 @@ func (e3d *HwEngine3d) filler_0ff(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10428,14 +11942,17 @@ func (e3d *HwEngine3d) filler_0ff(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_100(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_100(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10455,6 +11972,8 @@ func (e3d *HwEngine3d) filler_100(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -10463,8 +11982,15 @@ func (e3d *HwEngine3d) filler_100(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -10506,6 +12032,7 @@ func (e3d *HwEngine3d) filler_100(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10513,10 +12040,12 @@ func (e3d *HwEngine3d) filler_100(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10528,14 +12057,17 @@ func (e3d *HwEngine3d) filler_100(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_101(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_101(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10554,6 +12086,8 @@ func (e3d *HwEngine3d) filler_101(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -10562,8 +12096,15 @@ func (e3d *HwEngine3d) filler_101(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -10603,6 +12144,7 @@ func (e3d *HwEngine3d) filler_101(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10610,10 +12152,12 @@ func (e3d *HwEngine3d) filler_101(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10625,14 +12169,17 @@ func (e3d *HwEngine3d) filler_101(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_102(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_102(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10654,14 +12201,23 @@ func (e3d *HwEngine3d) filler_102(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -10708,6 +12264,7 @@ func (e3d *HwEngine3d) filler_102(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10715,10 +12272,12 @@ func (e3d *HwEngine3d) filler_102(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10730,14 +12289,17 @@ func (e3d *HwEngine3d) filler_102(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_103(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_103(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10758,14 +12320,23 @@ func (e3d *HwEngine3d) filler_103(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -10807,6 +12378,7 @@ func (e3d *HwEngine3d) filler_103(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10814,10 +12386,12 @@ func (e3d *HwEngine3d) filler_103(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10829,14 +12403,17 @@ func (e3d *HwEngine3d) filler_103(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_104(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_104(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10856,14 +12433,23 @@ func (e3d *HwEngine3d) filler_104(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -10903,6 +12489,7 @@ func (e3d *HwEngine3d) filler_104(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -10910,10 +12497,12 @@ func (e3d *HwEngine3d) filler_104(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -10925,14 +12514,17 @@ func (e3d *HwEngine3d) filler_104(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_105(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_105(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -10953,6 +12545,8 @@ func (e3d *HwEngine3d) filler_105(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -10960,8 +12554,15 @@ func (e3d *HwEngine3d) filler_105(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -11008,6 +12609,7 @@ func (e3d *HwEngine3d) filler_105(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11015,10 +12617,12 @@ func (e3d *HwEngine3d) filler_105(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11030,14 +12634,17 @@ func (e3d *HwEngine3d) filler_105(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_106(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_106(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11057,6 +12664,8 @@ func (e3d *HwEngine3d) filler_106(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -11064,8 +12673,15 @@ func (e3d *HwEngine3d) filler_106(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -11107,6 +12723,7 @@ func (e3d *HwEngine3d) filler_106(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11114,10 +12731,12 @@ func (e3d *HwEngine3d) filler_106(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11129,14 +12748,17 @@ func (e3d *HwEngine3d) filler_106(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_107(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_107(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11155,6 +12777,8 @@ func (e3d *HwEngine3d) filler_107(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -11162,8 +12786,15 @@ func (e3d *HwEngine3d) filler_107(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -11203,6 +12834,7 @@ func (e3d *HwEngine3d) filler_107(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11210,10 +12842,12 @@ func (e3d *HwEngine3d) filler_107(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11249,14 +12883,17 @@ func (e3d *HwEngine3d) filler_107(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     10d -> {TexFormat:1 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:2}
 //     0f5 -> {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:1 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_10e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_10e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11278,6 +12915,8 @@ func (e3d *HwEngine3d) filler_10e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -11285,8 +12924,15 @@ func (e3d *HwEngine3d) filler_10e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -11336,6 +12982,7 @@ func (e3d *HwEngine3d) filler_10e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11343,10 +12990,12 @@ func (e3d *HwEngine3d) filler_10e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11358,14 +13007,17 @@ func (e3d *HwEngine3d) filler_10e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_10f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_10f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11386,6 +13038,8 @@ func (e3d *HwEngine3d) filler_10f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -11393,8 +13047,15 @@ func (e3d *HwEngine3d) filler_10f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -11439,6 +13100,7 @@ func (e3d *HwEngine3d) filler_10f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11446,10 +13108,12 @@ func (e3d *HwEngine3d) filler_10f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11461,14 +13125,17 @@ func (e3d *HwEngine3d) filler_10f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_110(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_110(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11488,6 +13155,8 @@ func (e3d *HwEngine3d) filler_110(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -11495,8 +13164,15 @@ func (e3d *HwEngine3d) filler_110(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -11539,6 +13215,7 @@ func (e3d *HwEngine3d) filler_110(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11546,10 +13223,12 @@ func (e3d *HwEngine3d) filler_110(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11561,14 +13240,17 @@ func (e3d *HwEngine3d) filler_110(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_111(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_111(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11590,6 +13272,8 @@ func (e3d *HwEngine3d) filler_111(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -11597,8 +13281,15 @@ func (e3d *HwEngine3d) filler_111(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -11648,6 +13339,7 @@ func (e3d *HwEngine3d) filler_111(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11655,10 +13347,12 @@ func (e3d *HwEngine3d) filler_111(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11670,14 +13364,17 @@ func (e3d *HwEngine3d) filler_111(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_112(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_112(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11698,6 +13395,8 @@ func (e3d *HwEngine3d) filler_112(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -11705,8 +13404,15 @@ func (e3d *HwEngine3d) filler_112(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -11751,6 +13457,7 @@ func (e3d *HwEngine3d) filler_112(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11758,10 +13465,12 @@ func (e3d *HwEngine3d) filler_112(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11773,14 +13482,17 @@ func (e3d *HwEngine3d) filler_112(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_113(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_113(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11800,6 +13512,8 @@ func (e3d *HwEngine3d) filler_113(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -11807,8 +13521,15 @@ func (e3d *HwEngine3d) filler_113(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -11851,6 +13572,7 @@ func (e3d *HwEngine3d) filler_113(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11858,10 +13580,12 @@ func (e3d *HwEngine3d) filler_113(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11873,14 +13597,17 @@ func (e3d *HwEngine3d) filler_113(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_114(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_114(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -11902,14 +13629,23 @@ func (e3d *HwEngine3d) filler_114(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -11956,6 +13692,7 @@ func (e3d *HwEngine3d) filler_114(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -11963,10 +13700,12 @@ func (e3d *HwEngine3d) filler_114(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -11978,14 +13717,17 @@ func (e3d *HwEngine3d) filler_114(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_115(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_115(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -12006,14 +13748,23 @@ func (e3d *HwEngine3d) filler_115(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -12055,6 +13806,7 @@ func (e3d *HwEngine3d) filler_115(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -12062,10 +13814,12 @@ func (e3d *HwEngine3d) filler_115(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -12077,14 +13831,17 @@ func (e3d *HwEngine3d) filler_115(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_116(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_116(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:1 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -12104,14 +13861,23 @@ func (e3d *HwEngine3d) filler_116(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -12151,6 +13917,7 @@ func (e3d *HwEngine3d) filler_116(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -12158,10 +13925,12 @@ func (e3d *HwEngine3d) filler_116(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -12605,14 +14374,17 @@ func (e3d *HwEngine3d) filler_116(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     182 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 //     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_183(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_183(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -12633,14 +14405,23 @@ func (e3d *HwEngine3d) filler_183(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -12685,10 +14466,12 @@ func (e3d *HwEngine3d) filler_183(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -12700,14 +14483,17 @@ func (e3d *HwEngine3d) filler_183(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_184(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_184(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -12727,14 +14513,23 @@ func (e3d *HwEngine3d) filler_184(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -12774,10 +14569,12 @@ func (e3d *HwEngine3d) filler_184(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -12789,14 +14586,17 @@ func (e3d *HwEngine3d) filler_184(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_185(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_185(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -12815,14 +14615,23 @@ func (e3d *HwEngine3d) filler_185(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -12860,10 +14669,12 @@ func (e3d *HwEngine3d) filler_185(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -12875,14 +14686,17 @@ func (e3d *HwEngine3d) filler_185(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_186(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_186(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -12903,6 +14717,8 @@ func (e3d *HwEngine3d) filler_186(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -12910,8 +14726,15 @@ func (e3d *HwEngine3d) filler_186(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -12955,10 +14778,12 @@ func (e3d *HwEngine3d) filler_186(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -12970,14 +14795,17 @@ func (e3d *HwEngine3d) filler_186(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_187(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_187(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -12997,6 +14825,8 @@ func (e3d *HwEngine3d) filler_187(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -13004,8 +14834,15 @@ func (e3d *HwEngine3d) filler_187(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13044,10 +14881,12 @@ func (e3d *HwEngine3d) filler_187(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13059,14 +14898,17 @@ func (e3d *HwEngine3d) filler_187(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_188(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_188(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13085,6 +14927,8 @@ func (e3d *HwEngine3d) filler_188(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -13092,8 +14936,15 @@ func (e3d *HwEngine3d) filler_188(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13130,10 +14981,12 @@ func (e3d *HwEngine3d) filler_188(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13145,14 +14998,17 @@ func (e3d *HwEngine3d) filler_188(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_189(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_189(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13173,6 +15029,8 @@ func (e3d *HwEngine3d) filler_189(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -13180,8 +15038,15 @@ func (e3d *HwEngine3d) filler_189(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -13225,10 +15090,12 @@ func (e3d *HwEngine3d) filler_189(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13240,14 +15107,17 @@ func (e3d *HwEngine3d) filler_189(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_18a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_18a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13267,6 +15137,8 @@ func (e3d *HwEngine3d) filler_18a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -13274,8 +15146,15 @@ func (e3d *HwEngine3d) filler_18a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13314,10 +15193,12 @@ func (e3d *HwEngine3d) filler_18a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13329,14 +15210,17 @@ func (e3d *HwEngine3d) filler_18a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_18b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_18b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13355,6 +15239,8 @@ func (e3d *HwEngine3d) filler_18b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -13362,8 +15248,15 @@ func (e3d *HwEngine3d) filler_18b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13400,10 +15293,12 @@ func (e3d *HwEngine3d) filler_18b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13415,14 +15310,17 @@ func (e3d *HwEngine3d) filler_18b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_18c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_18c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13443,14 +15341,23 @@ func (e3d *HwEngine3d) filler_18c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -13492,10 +15399,12 @@ func (e3d *HwEngine3d) filler_18c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13507,14 +15416,17 @@ func (e3d *HwEngine3d) filler_18c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_18d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_18d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13534,14 +15446,23 @@ func (e3d *HwEngine3d) filler_18d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13578,10 +15499,12 @@ func (e3d *HwEngine3d) filler_18d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13593,14 +15516,17 @@ func (e3d *HwEngine3d) filler_18d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_18e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_18e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13619,14 +15545,23 @@ func (e3d *HwEngine3d) filler_18e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13661,10 +15596,12 @@ func (e3d *HwEngine3d) filler_18e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13676,14 +15613,17 @@ func (e3d *HwEngine3d) filler_18e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_18f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_18f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13703,6 +15643,8 @@ func (e3d *HwEngine3d) filler_18f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -13711,8 +15653,15 @@ func (e3d *HwEngine3d) filler_18f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -13757,10 +15706,12 @@ func (e3d *HwEngine3d) filler_18f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13772,14 +15723,17 @@ func (e3d *HwEngine3d) filler_18f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_190(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_190(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13798,6 +15752,8 @@ func (e3d *HwEngine3d) filler_190(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -13806,8 +15762,15 @@ func (e3d *HwEngine3d) filler_190(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13847,10 +15810,12 @@ func (e3d *HwEngine3d) filler_190(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13862,14 +15827,17 @@ func (e3d *HwEngine3d) filler_190(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_191(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_191(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13887,6 +15855,8 @@ func (e3d *HwEngine3d) filler_191(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -13895,8 +15865,15 @@ func (e3d *HwEngine3d) filler_191(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -13934,10 +15911,12 @@ func (e3d *HwEngine3d) filler_191(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -13949,14 +15928,17 @@ func (e3d *HwEngine3d) filler_191(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_192(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_192(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -13977,14 +15959,23 @@ func (e3d *HwEngine3d) filler_192(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -14029,10 +16020,12 @@ func (e3d *HwEngine3d) filler_192(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14044,14 +16037,17 @@ func (e3d *HwEngine3d) filler_192(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_193(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_193(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14071,14 +16067,23 @@ func (e3d *HwEngine3d) filler_193(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -14118,10 +16123,12 @@ func (e3d *HwEngine3d) filler_193(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14133,14 +16140,17 @@ func (e3d *HwEngine3d) filler_193(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_194(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_194(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14159,14 +16169,23 @@ func (e3d *HwEngine3d) filler_194(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -14204,10 +16223,12 @@ func (e3d *HwEngine3d) filler_194(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14219,14 +16240,17 @@ func (e3d *HwEngine3d) filler_194(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_195(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_195(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14246,6 +16270,8 @@ func (e3d *HwEngine3d) filler_195(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -14253,8 +16279,15 @@ func (e3d *HwEngine3d) filler_195(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -14299,10 +16332,12 @@ func (e3d *HwEngine3d) filler_195(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14314,14 +16349,17 @@ func (e3d *HwEngine3d) filler_195(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_196(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_196(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14340,6 +16378,8 @@ func (e3d *HwEngine3d) filler_196(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -14347,8 +16387,15 @@ func (e3d *HwEngine3d) filler_196(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -14388,10 +16435,12 @@ func (e3d *HwEngine3d) filler_196(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14403,14 +16452,17 @@ func (e3d *HwEngine3d) filler_196(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_197(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_197(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14428,6 +16480,8 @@ func (e3d *HwEngine3d) filler_197(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -14435,8 +16489,15 @@ func (e3d *HwEngine3d) filler_197(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -14474,10 +16535,12 @@ func (e3d *HwEngine3d) filler_197(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14513,14 +16576,17 @@ func (e3d *HwEngine3d) filler_197(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     19d -> {TexFormat:1 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:2}
 //     185 -> {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_19e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_19e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14541,6 +16607,8 @@ func (e3d *HwEngine3d) filler_19e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -14548,8 +16616,15 @@ func (e3d *HwEngine3d) filler_19e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -14597,10 +16672,12 @@ func (e3d *HwEngine3d) filler_19e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14612,14 +16689,17 @@ func (e3d *HwEngine3d) filler_19e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_19f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_19f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14639,6 +16719,8 @@ func (e3d *HwEngine3d) filler_19f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -14646,8 +16728,15 @@ func (e3d *HwEngine3d) filler_19f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -14690,10 +16779,12 @@ func (e3d *HwEngine3d) filler_19f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14705,14 +16796,17 @@ func (e3d *HwEngine3d) filler_19f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1a0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1a0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14731,6 +16825,8 @@ func (e3d *HwEngine3d) filler_1a0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -14738,8 +16834,15 @@ func (e3d *HwEngine3d) filler_1a0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -14780,10 +16883,12 @@ func (e3d *HwEngine3d) filler_1a0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14795,14 +16900,17 @@ func (e3d *HwEngine3d) filler_1a0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1a1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1a1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14823,6 +16931,8 @@ func (e3d *HwEngine3d) filler_1a1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -14830,8 +16940,15 @@ func (e3d *HwEngine3d) filler_1a1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -14879,10 +16996,12 @@ func (e3d *HwEngine3d) filler_1a1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14894,14 +17013,17 @@ func (e3d *HwEngine3d) filler_1a1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1a2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1a2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -14921,6 +17043,8 @@ func (e3d *HwEngine3d) filler_1a2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -14928,8 +17052,15 @@ func (e3d *HwEngine3d) filler_1a2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -14972,10 +17103,12 @@ func (e3d *HwEngine3d) filler_1a2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -14987,14 +17120,17 @@ func (e3d *HwEngine3d) filler_1a2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1a3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1a3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15013,6 +17149,8 @@ func (e3d *HwEngine3d) filler_1a3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -15020,8 +17158,15 @@ func (e3d *HwEngine3d) filler_1a3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -15062,10 +17207,12 @@ func (e3d *HwEngine3d) filler_1a3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15077,14 +17224,17 @@ func (e3d *HwEngine3d) filler_1a3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1a4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1a4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15105,14 +17255,23 @@ func (e3d *HwEngine3d) filler_1a4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -15157,10 +17316,12 @@ func (e3d *HwEngine3d) filler_1a4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15172,14 +17333,17 @@ func (e3d *HwEngine3d) filler_1a4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1a5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1a5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15199,14 +17363,23 @@ func (e3d *HwEngine3d) filler_1a5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -15246,10 +17419,12 @@ func (e3d *HwEngine3d) filler_1a5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15261,14 +17436,17 @@ func (e3d *HwEngine3d) filler_1a5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1a6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1a6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15287,14 +17465,23 @@ func (e3d *HwEngine3d) filler_1a6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -15332,10 +17519,12 @@ func (e3d *HwEngine3d) filler_1a6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15395,14 +17584,17 @@ func (e3d *HwEngine3d) filler_1a6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     1b2 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 //     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_1b3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1b3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15424,14 +17616,23 @@ func (e3d *HwEngine3d) filler_1b3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -15481,6 +17682,7 @@ func (e3d *HwEngine3d) filler_1b3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -15488,10 +17690,12 @@ func (e3d *HwEngine3d) filler_1b3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15503,14 +17707,17 @@ func (e3d *HwEngine3d) filler_1b3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1b4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1b4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15531,14 +17738,23 @@ func (e3d *HwEngine3d) filler_1b4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -15583,6 +17799,7 @@ func (e3d *HwEngine3d) filler_1b4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -15590,10 +17807,12 @@ func (e3d *HwEngine3d) filler_1b4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15605,14 +17824,17 @@ func (e3d *HwEngine3d) filler_1b4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1b5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1b5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15632,14 +17854,23 @@ func (e3d *HwEngine3d) filler_1b5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -15682,6 +17913,7 @@ func (e3d *HwEngine3d) filler_1b5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -15689,10 +17921,12 @@ func (e3d *HwEngine3d) filler_1b5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15704,14 +17938,17 @@ func (e3d *HwEngine3d) filler_1b5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1b6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1b6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15733,6 +17970,8 @@ func (e3d *HwEngine3d) filler_1b6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -15740,8 +17979,15 @@ func (e3d *HwEngine3d) filler_1b6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -15790,6 +18036,7 @@ func (e3d *HwEngine3d) filler_1b6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -15797,10 +18044,12 @@ func (e3d *HwEngine3d) filler_1b6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15812,14 +18061,17 @@ func (e3d *HwEngine3d) filler_1b6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1b7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1b7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15840,6 +18092,8 @@ func (e3d *HwEngine3d) filler_1b7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -15847,8 +18101,15 @@ func (e3d *HwEngine3d) filler_1b7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -15892,6 +18153,7 @@ func (e3d *HwEngine3d) filler_1b7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -15899,10 +18161,12 @@ func (e3d *HwEngine3d) filler_1b7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -15914,14 +18178,17 @@ func (e3d *HwEngine3d) filler_1b7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1b8(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1b8(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -15941,6 +18208,8 @@ func (e3d *HwEngine3d) filler_1b8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -15948,8 +18217,15 @@ func (e3d *HwEngine3d) filler_1b8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -15991,6 +18267,7 @@ func (e3d *HwEngine3d) filler_1b8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -15998,10 +18275,12 @@ func (e3d *HwEngine3d) filler_1b8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16013,14 +18292,17 @@ func (e3d *HwEngine3d) filler_1b8(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1b9(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1b9(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16042,6 +18324,8 @@ func (e3d *HwEngine3d) filler_1b9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -16049,8 +18333,15 @@ func (e3d *HwEngine3d) filler_1b9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -16099,6 +18390,7 @@ func (e3d *HwEngine3d) filler_1b9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16106,10 +18398,12 @@ func (e3d *HwEngine3d) filler_1b9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16121,14 +18415,17 @@ func (e3d *HwEngine3d) filler_1b9(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1ba(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1ba(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16149,6 +18446,8 @@ func (e3d *HwEngine3d) filler_1ba(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -16156,8 +18455,15 @@ func (e3d *HwEngine3d) filler_1ba(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -16201,6 +18507,7 @@ func (e3d *HwEngine3d) filler_1ba(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16208,10 +18515,12 @@ func (e3d *HwEngine3d) filler_1ba(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16223,14 +18532,17 @@ func (e3d *HwEngine3d) filler_1ba(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1bb(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1bb(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16250,6 +18562,8 @@ func (e3d *HwEngine3d) filler_1bb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -16257,8 +18571,15 @@ func (e3d *HwEngine3d) filler_1bb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -16300,6 +18621,7 @@ func (e3d *HwEngine3d) filler_1bb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16307,10 +18629,12 @@ func (e3d *HwEngine3d) filler_1bb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16322,14 +18646,17 @@ func (e3d *HwEngine3d) filler_1bb(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1bc(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1bc(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16351,14 +18678,23 @@ func (e3d *HwEngine3d) filler_1bc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -16405,6 +18741,7 @@ func (e3d *HwEngine3d) filler_1bc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16412,10 +18749,12 @@ func (e3d *HwEngine3d) filler_1bc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16427,14 +18766,17 @@ func (e3d *HwEngine3d) filler_1bc(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1bd(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1bd(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16455,14 +18797,23 @@ func (e3d *HwEngine3d) filler_1bd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -16504,6 +18855,7 @@ func (e3d *HwEngine3d) filler_1bd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16511,10 +18863,12 @@ func (e3d *HwEngine3d) filler_1bd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16526,14 +18880,17 @@ func (e3d *HwEngine3d) filler_1bd(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1be(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1be(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16553,14 +18910,23 @@ func (e3d *HwEngine3d) filler_1be(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -16600,6 +18966,7 @@ func (e3d *HwEngine3d) filler_1be(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16607,10 +18974,12 @@ func (e3d *HwEngine3d) filler_1be(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16622,14 +18991,17 @@ func (e3d *HwEngine3d) filler_1be(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1bf(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1bf(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16650,6 +19022,8 @@ func (e3d *HwEngine3d) filler_1bf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -16658,8 +19032,15 @@ func (e3d *HwEngine3d) filler_1bf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -16709,6 +19090,7 @@ func (e3d *HwEngine3d) filler_1bf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16716,10 +19098,12 @@ func (e3d *HwEngine3d) filler_1bf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16731,14 +19115,17 @@ func (e3d *HwEngine3d) filler_1bf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16758,6 +19145,8 @@ func (e3d *HwEngine3d) filler_1c0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -16766,8 +19155,15 @@ func (e3d *HwEngine3d) filler_1c0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -16812,6 +19208,7 @@ func (e3d *HwEngine3d) filler_1c0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16819,10 +19216,12 @@ func (e3d *HwEngine3d) filler_1c0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16834,14 +19233,17 @@ func (e3d *HwEngine3d) filler_1c0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16860,6 +19262,8 @@ func (e3d *HwEngine3d) filler_1c1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -16868,8 +19272,15 @@ func (e3d *HwEngine3d) filler_1c1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -16912,6 +19323,7 @@ func (e3d *HwEngine3d) filler_1c1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -16919,10 +19331,12 @@ func (e3d *HwEngine3d) filler_1c1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -16934,14 +19348,17 @@ func (e3d *HwEngine3d) filler_1c1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -16963,14 +19380,23 @@ func (e3d *HwEngine3d) filler_1c2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -17020,6 +19446,7 @@ func (e3d *HwEngine3d) filler_1c2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17027,10 +19454,12 @@ func (e3d *HwEngine3d) filler_1c2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17042,14 +19471,17 @@ func (e3d *HwEngine3d) filler_1c2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17070,14 +19502,23 @@ func (e3d *HwEngine3d) filler_1c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -17122,6 +19563,7 @@ func (e3d *HwEngine3d) filler_1c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17129,10 +19571,12 @@ func (e3d *HwEngine3d) filler_1c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17144,14 +19588,17 @@ func (e3d *HwEngine3d) filler_1c3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17171,14 +19618,23 @@ func (e3d *HwEngine3d) filler_1c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -17221,6 +19677,7 @@ func (e3d *HwEngine3d) filler_1c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17228,10 +19685,12 @@ func (e3d *HwEngine3d) filler_1c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17243,14 +19702,17 @@ func (e3d *HwEngine3d) filler_1c4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17271,6 +19733,8 @@ func (e3d *HwEngine3d) filler_1c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -17278,8 +19742,15 @@ func (e3d *HwEngine3d) filler_1c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -17329,6 +19800,7 @@ func (e3d *HwEngine3d) filler_1c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17336,10 +19808,12 @@ func (e3d *HwEngine3d) filler_1c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17351,14 +19825,17 @@ func (e3d *HwEngine3d) filler_1c5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17378,6 +19855,8 @@ func (e3d *HwEngine3d) filler_1c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -17385,8 +19864,15 @@ func (e3d *HwEngine3d) filler_1c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -17431,6 +19917,7 @@ func (e3d *HwEngine3d) filler_1c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17438,10 +19925,12 @@ func (e3d *HwEngine3d) filler_1c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17453,14 +19942,17 @@ func (e3d *HwEngine3d) filler_1c6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17479,6 +19971,8 @@ func (e3d *HwEngine3d) filler_1c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -17486,8 +19980,15 @@ func (e3d *HwEngine3d) filler_1c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -17530,6 +20031,7 @@ func (e3d *HwEngine3d) filler_1c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17537,10 +20039,12 @@ func (e3d *HwEngine3d) filler_1c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17576,14 +20080,17 @@ func (e3d *HwEngine3d) filler_1c7(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     1cd -> {TexFormat:1 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:2}
 //     1b5 -> {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:2 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_1ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17605,6 +20112,8 @@ func (e3d *HwEngine3d) filler_1ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -17612,8 +20121,15 @@ func (e3d *HwEngine3d) filler_1ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -17666,6 +20182,7 @@ func (e3d *HwEngine3d) filler_1ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17673,10 +20190,12 @@ func (e3d *HwEngine3d) filler_1ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17688,14 +20207,17 @@ func (e3d *HwEngine3d) filler_1ce(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17716,6 +20238,8 @@ func (e3d *HwEngine3d) filler_1cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -17723,8 +20247,15 @@ func (e3d *HwEngine3d) filler_1cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -17772,6 +20303,7 @@ func (e3d *HwEngine3d) filler_1cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17779,10 +20311,12 @@ func (e3d *HwEngine3d) filler_1cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17794,14 +20328,17 @@ func (e3d *HwEngine3d) filler_1cf(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17821,6 +20358,8 @@ func (e3d *HwEngine3d) filler_1d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -17828,8 +20367,15 @@ func (e3d *HwEngine3d) filler_1d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -17875,6 +20421,7 @@ func (e3d *HwEngine3d) filler_1d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17882,10 +20429,12 @@ func (e3d *HwEngine3d) filler_1d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -17897,14 +20446,17 @@ func (e3d *HwEngine3d) filler_1d0(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -17926,6 +20478,8 @@ func (e3d *HwEngine3d) filler_1d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -17933,8 +20487,15 @@ func (e3d *HwEngine3d) filler_1d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -17987,6 +20548,7 @@ func (e3d *HwEngine3d) filler_1d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -17994,10 +20556,12 @@ func (e3d *HwEngine3d) filler_1d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -18009,14 +20573,17 @@ func (e3d *HwEngine3d) filler_1d1(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -18037,6 +20604,8 @@ func (e3d *HwEngine3d) filler_1d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -18044,8 +20613,15 @@ func (e3d *HwEngine3d) filler_1d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -18093,6 +20669,7 @@ func (e3d *HwEngine3d) filler_1d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -18100,10 +20677,12 @@ func (e3d *HwEngine3d) filler_1d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -18115,14 +20694,17 @@ func (e3d *HwEngine3d) filler_1d2(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -18142,6 +20724,8 @@ func (e3d *HwEngine3d) filler_1d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -18149,8 +20733,15 @@ func (e3d *HwEngine3d) filler_1d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -18196,6 +20787,7 @@ func (e3d *HwEngine3d) filler_1d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -18203,10 +20795,12 @@ func (e3d *HwEngine3d) filler_1d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -18218,14 +20812,17 @@ func (e3d *HwEngine3d) filler_1d3(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -18247,14 +20844,23 @@ func (e3d *HwEngine3d) filler_1d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -18304,6 +20910,7 @@ func (e3d *HwEngine3d) filler_1d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -18311,10 +20918,12 @@ func (e3d *HwEngine3d) filler_1d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -18326,14 +20935,17 @@ func (e3d *HwEngine3d) filler_1d4(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -18354,14 +20966,23 @@ func (e3d *HwEngine3d) filler_1d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -18406,6 +21027,7 @@ func (e3d *HwEngine3d) filler_1d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -18413,10 +21035,12 @@ func (e3d *HwEngine3d) filler_1d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -18428,14 +21052,17 @@ func (e3d *HwEngine3d) filler_1d5(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_1d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_1d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:2 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -18455,14 +21082,23 @@ func (e3d *HwEngine3d) filler_1d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -18505,6 +21141,7 @@ func (e3d *HwEngine3d) filler_1d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -18512,10 +21149,12 @@ func (e3d *HwEngine3d) filler_1d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -18947,26 +21586,107 @@ func (e3d *HwEngine3d) filler_1d6(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     23f -> {TexFormat:7 ColorKey:1 FillMode:3 ColorMode:2 TexCoords:2}
 //     197 -> {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:2 TexCoords:2}
 
-// filler_240 skipped, because of identical polyfiller:
-//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+func (e3d *HwEngine3d) filler_240(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
+	// {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
+	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
+	nx := x1 - x0
+	if nx == 0 {
+		if !poly.line {
+			return
+		}
+		nx = 1
+	}
+	if poly.UseAlpha() && !poly.line {
+		x1 -= 1
+	}
+	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
+	dd := d1.SubFixed(d0).Div(nx)
+	r0, r1 := poly.left[LerpR].Cur(), poly.right[LerpR].Cur()
+	dr := r1.SubFixed(r0).Div(nx)
+	g0, g1 := poly.left[LerpG].Cur(), poly.right[LerpG].Cur()
+	dg := g1.SubFixed(g0).Div(nx)
+	b0, b1 := poly.left[LerpB].Cur(), poly.right[LerpB].Cur()
+	db := b1.SubFixed(b0).Div(nx)
+	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
+	var px uint16
+	var px0 uint8
+	out.Add32(int(x0))
+	zbuf.Add32(int(x0))
+	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
+	for x := x0; x <= x1; x++ {
+		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
+		var pxa uint8
+		pxa = 63
+		// zbuffer check
+		z := d0.Inv()
+		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
+			goto next
+		}
+		px = uint16(r0.TruncInt32()>>1) | uint16(g0.TruncInt32()>>1)<<5 | uint16(b0.TruncInt32()>>1)<<10
+		// alpha blending with background
+		if pxa == 0 {
+			goto next
+		}
+		pxa >>= 1
+		// draw color and alpha
+		out.Set32(0, uint32(px)|0x80000000)
+		abuf.Set8(0, pxa)
+		if drawz {
+			zbuf.Set32(0, uint32(z.V>>20))
+		}
+		attr.Set32(0, pxattr)
+	next:
+		out.Add32(1)
+		zbuf.Add32(1)
+		abuf.Add8(1)
+		attr.Add32(1)
+		d0 = d0.AddFixed(dd)
+		r0 = r0.AddFixed(dr)
+		g0 = g0.AddFixed(dg)
+		b0 = b0.AddFixed(db)
+	}
+	_ = px0
+	_ = zalpha
+}
 
 // filler_241 skipped, because of identical polyfiller:
 //     241 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_242 skipped, because of identical polyfiller:
 //     242 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_243(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_243(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -18987,20 +21707,39 @@ func (e3d *HwEngine3d) filler_243(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19033,10 +21772,12 @@ func (e3d *HwEngine3d) filler_243(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19048,14 +21789,17 @@ func (e3d *HwEngine3d) filler_243(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_244(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_244(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19075,19 +21819,38 @@ func (e3d *HwEngine3d) filler_244(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19116,10 +21879,12 @@ func (e3d *HwEngine3d) filler_244(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19131,14 +21896,17 @@ func (e3d *HwEngine3d) filler_244(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_245(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_245(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19157,19 +21925,38 @@ func (e3d *HwEngine3d) filler_245(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19196,10 +21983,12 @@ func (e3d *HwEngine3d) filler_245(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19211,14 +22000,17 @@ func (e3d *HwEngine3d) filler_245(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_246(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_246(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19239,6 +22031,9 @@ func (e3d *HwEngine3d) filler_246(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -19246,14 +22041,30 @@ func (e3d *HwEngine3d) filler_246(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19285,10 +22096,12 @@ func (e3d *HwEngine3d) filler_246(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19300,14 +22113,17 @@ func (e3d *HwEngine3d) filler_246(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_247(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_247(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19327,6 +22143,9 @@ func (e3d *HwEngine3d) filler_247(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -19334,13 +22153,29 @@ func (e3d *HwEngine3d) filler_247(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19368,10 +22203,12 @@ func (e3d *HwEngine3d) filler_247(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19383,14 +22220,17 @@ func (e3d *HwEngine3d) filler_247(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_248(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_248(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19409,6 +22249,9 @@ func (e3d *HwEngine3d) filler_248(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -19416,13 +22259,29 @@ func (e3d *HwEngine3d) filler_248(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19448,10 +22307,12 @@ func (e3d *HwEngine3d) filler_248(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19463,14 +22324,17 @@ func (e3d *HwEngine3d) filler_248(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_249(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_249(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19491,6 +22355,9 @@ func (e3d *HwEngine3d) filler_249(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -19498,14 +22365,30 @@ func (e3d *HwEngine3d) filler_249(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19537,10 +22420,12 @@ func (e3d *HwEngine3d) filler_249(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19552,14 +22437,17 @@ func (e3d *HwEngine3d) filler_249(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_24a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_24a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19579,6 +22467,9 @@ func (e3d *HwEngine3d) filler_24a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -19586,13 +22477,29 @@ func (e3d *HwEngine3d) filler_24a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19620,10 +22527,12 @@ func (e3d *HwEngine3d) filler_24a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19635,14 +22544,17 @@ func (e3d *HwEngine3d) filler_24a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_24b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_24b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19661,6 +22573,9 @@ func (e3d *HwEngine3d) filler_24b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -19668,13 +22583,29 @@ func (e3d *HwEngine3d) filler_24b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19700,10 +22631,12 @@ func (e3d *HwEngine3d) filler_24b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19715,14 +22648,17 @@ func (e3d *HwEngine3d) filler_24b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_24c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_24c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19743,20 +22679,39 @@ func (e3d *HwEngine3d) filler_24c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19786,10 +22741,12 @@ func (e3d *HwEngine3d) filler_24c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19801,14 +22758,17 @@ func (e3d *HwEngine3d) filler_24c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_24d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_24d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19828,19 +22788,38 @@ func (e3d *HwEngine3d) filler_24d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19866,10 +22845,12 @@ func (e3d *HwEngine3d) filler_24d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19881,14 +22862,17 @@ func (e3d *HwEngine3d) filler_24d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_24e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_24e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19907,19 +22891,38 @@ func (e3d *HwEngine3d) filler_24e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -19943,10 +22946,12 @@ func (e3d *HwEngine3d) filler_24e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -19958,14 +22963,17 @@ func (e3d *HwEngine3d) filler_24e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_24f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_24f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -19985,6 +22993,9 @@ func (e3d *HwEngine3d) filler_24f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -19993,14 +23004,30 @@ func (e3d *HwEngine3d) filler_24f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20033,10 +23060,12 @@ func (e3d *HwEngine3d) filler_24f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20048,14 +23077,17 @@ func (e3d *HwEngine3d) filler_24f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_250(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_250(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20074,6 +23106,9 @@ func (e3d *HwEngine3d) filler_250(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -20082,13 +23117,29 @@ func (e3d *HwEngine3d) filler_250(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20117,10 +23168,12 @@ func (e3d *HwEngine3d) filler_250(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20132,14 +23185,17 @@ func (e3d *HwEngine3d) filler_250(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_251(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_251(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20157,6 +23213,9 @@ func (e3d *HwEngine3d) filler_251(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -20165,13 +23224,29 @@ func (e3d *HwEngine3d) filler_251(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20198,10 +23273,12 @@ func (e3d *HwEngine3d) filler_251(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20213,14 +23290,17 @@ func (e3d *HwEngine3d) filler_251(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_252(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_252(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20241,20 +23321,39 @@ func (e3d *HwEngine3d) filler_252(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20287,10 +23386,12 @@ func (e3d *HwEngine3d) filler_252(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20302,14 +23403,17 @@ func (e3d *HwEngine3d) filler_252(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_253(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_253(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20329,19 +23433,38 @@ func (e3d *HwEngine3d) filler_253(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20370,10 +23493,12 @@ func (e3d *HwEngine3d) filler_253(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20385,14 +23510,17 @@ func (e3d *HwEngine3d) filler_253(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_254(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_254(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20411,19 +23539,38 @@ func (e3d *HwEngine3d) filler_254(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20450,10 +23597,12 @@ func (e3d *HwEngine3d) filler_254(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20465,14 +23614,17 @@ func (e3d *HwEngine3d) filler_254(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_255(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_255(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20492,6 +23644,9 @@ func (e3d *HwEngine3d) filler_255(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -20499,14 +23654,30 @@ func (e3d *HwEngine3d) filler_255(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20539,10 +23710,12 @@ func (e3d *HwEngine3d) filler_255(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20554,14 +23727,17 @@ func (e3d *HwEngine3d) filler_255(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_256(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_256(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20580,6 +23756,9 @@ func (e3d *HwEngine3d) filler_256(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -20587,13 +23766,29 @@ func (e3d *HwEngine3d) filler_256(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20622,10 +23817,12 @@ func (e3d *HwEngine3d) filler_256(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20637,14 +23834,17 @@ func (e3d *HwEngine3d) filler_256(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_257(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_257(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20662,6 +23862,9 @@ func (e3d *HwEngine3d) filler_257(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -20669,13 +23872,29 @@ func (e3d *HwEngine3d) filler_257(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20702,10 +23921,12 @@ func (e3d *HwEngine3d) filler_257(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20719,15 +23940,15 @@ func (e3d *HwEngine3d) filler_257(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 
 // filler_258 skipped, because of identical polyfiller:
 //     258 -> {TexFormat:0 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_259 skipped, because of identical polyfiller:
 //     259 -> {TexFormat:0 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_25a skipped, because of identical polyfiller:
 //     25a -> {TexFormat:0 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_25b skipped, because of identical polyfiller:
 //     25b -> {TexFormat:1 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:0}
@@ -20741,14 +23962,17 @@ func (e3d *HwEngine3d) filler_257(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     25d -> {TexFormat:1 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
 //     245 -> {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_25e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_25e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20769,6 +23993,9 @@ func (e3d *HwEngine3d) filler_25e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -20776,14 +24003,30 @@ func (e3d *HwEngine3d) filler_25e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20819,10 +24062,12 @@ func (e3d *HwEngine3d) filler_25e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20834,14 +24079,17 @@ func (e3d *HwEngine3d) filler_25e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_25f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_25f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20861,6 +24109,9 @@ func (e3d *HwEngine3d) filler_25f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -20868,13 +24119,29 @@ func (e3d *HwEngine3d) filler_25f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20906,10 +24173,12 @@ func (e3d *HwEngine3d) filler_25f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -20921,14 +24190,17 @@ func (e3d *HwEngine3d) filler_25f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_260(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_260(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -20947,6 +24219,9 @@ func (e3d *HwEngine3d) filler_260(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -20954,13 +24229,29 @@ func (e3d *HwEngine3d) filler_260(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -20990,10 +24281,12 @@ func (e3d *HwEngine3d) filler_260(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21005,14 +24298,17 @@ func (e3d *HwEngine3d) filler_260(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_261(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_261(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21033,6 +24329,9 @@ func (e3d *HwEngine3d) filler_261(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -21040,14 +24339,30 @@ func (e3d *HwEngine3d) filler_261(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21083,10 +24398,12 @@ func (e3d *HwEngine3d) filler_261(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21098,14 +24415,17 @@ func (e3d *HwEngine3d) filler_261(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_262(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_262(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21125,6 +24445,9 @@ func (e3d *HwEngine3d) filler_262(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -21132,13 +24455,29 @@ func (e3d *HwEngine3d) filler_262(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21170,10 +24509,12 @@ func (e3d *HwEngine3d) filler_262(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21185,14 +24526,17 @@ func (e3d *HwEngine3d) filler_262(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_263(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_263(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21211,6 +24555,9 @@ func (e3d *HwEngine3d) filler_263(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -21218,13 +24565,29 @@ func (e3d *HwEngine3d) filler_263(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21254,10 +24617,12 @@ func (e3d *HwEngine3d) filler_263(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21269,14 +24634,17 @@ func (e3d *HwEngine3d) filler_263(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_264(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_264(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21297,20 +24665,39 @@ func (e3d *HwEngine3d) filler_264(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21343,10 +24730,12 @@ func (e3d *HwEngine3d) filler_264(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21358,14 +24747,17 @@ func (e3d *HwEngine3d) filler_264(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_265(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_265(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21385,19 +24777,38 @@ func (e3d *HwEngine3d) filler_265(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21426,10 +24837,12 @@ func (e3d *HwEngine3d) filler_265(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21441,14 +24854,17 @@ func (e3d *HwEngine3d) filler_265(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_266(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_266(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21467,19 +24883,38 @@ func (e3d *HwEngine3d) filler_266(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21506,10 +24941,12 @@ func (e3d *HwEngine3d) filler_266(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21557,26 +24994,121 @@ func (e3d *HwEngine3d) filler_266(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     26f -> {TexFormat:7 ColorKey:1 FillMode:0 ColorMode:3 TexCoords:2}
 //     257 -> {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:2}
 
-// filler_270 skipped, because of identical polyfiller:
-//     270 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
-//     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
+func (e3d *HwEngine3d) filler_270(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
+	// {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
+	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
+	nx := x1 - x0
+	if nx == 0 {
+		if !poly.line {
+			return
+		}
+		nx = 1
+	}
+	if poly.UseAlpha() && !poly.line {
+		x1 -= 1
+	}
+	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
+	dd := d1.SubFixed(d0).Div(nx)
+	r0, r1 := poly.left[LerpR].Cur(), poly.right[LerpR].Cur()
+	dr := r1.SubFixed(r0).Div(nx)
+	g0, g1 := poly.left[LerpG].Cur(), poly.right[LerpG].Cur()
+	dg := g1.SubFixed(g0).Div(nx)
+	b0, b1 := poly.left[LerpB].Cur(), poly.right[LerpB].Cur()
+	db := b1.SubFixed(b0).Div(nx)
+	polyalpha := uint8(poly.flags.Alpha()) << 1
+	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
+	var px uint16
+	var px0 uint8
+	out.Add32(int(x0))
+	zbuf.Add32(int(x0))
+	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
+	for x := x0; x <= x1; x++ {
+		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
+		var pxa uint8
+		pxa = 63
+		// zbuffer check
+		z := d0.Inv()
+		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
+			goto next
+		}
+		px = uint16(r0.TruncInt32()>>1) | uint16(g0.TruncInt32()>>1)<<5 | uint16(b0.TruncInt32()>>1)<<10
+		pxa = polyalpha
+		// alpha blending with background
+		if pxa == 0 {
+			goto next
+		}
+		pxa >>= 1
+		if pxa != 31 {
+			bkg := uint16(out.Get32(0))
+			bkga := abuf.Get8(0)
+			if bkga != 0 {
+				px = rgbAlphaMix(px, bkg, pxa)
+			}
+			if pxa < bkga {
+				pxa = bkga
+			}
+			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
+		}
+		// draw color and alpha
+		out.Set32(0, uint32(px)|0x80000000)
+		abuf.Set8(0, pxa)
+		if drawz {
+			zbuf.Set32(0, uint32(z.V>>20))
+		}
+		attr.Set32(0, pxattr)
+	next:
+		out.Add32(1)
+		zbuf.Add32(1)
+		abuf.Add8(1)
+		attr.Add32(1)
+		d0 = d0.AddFixed(dd)
+		r0 = r0.AddFixed(dr)
+		g0 = g0.AddFixed(dg)
+		b0 = b0.AddFixed(db)
+	}
+	_ = px0
+	_ = zalpha
+}
 
 // filler_271 skipped, because of identical polyfiller:
 //     271 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
-//     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
+//     270 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 
 // filler_272 skipped, because of identical polyfiller:
 //     272 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
-//     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
+//     270 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_273(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_273(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21598,20 +25130,39 @@ func (e3d *HwEngine3d) filler_273(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21649,6 +25200,7 @@ func (e3d *HwEngine3d) filler_273(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -21656,10 +25208,12 @@ func (e3d *HwEngine3d) filler_273(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21671,14 +25225,17 @@ func (e3d *HwEngine3d) filler_273(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_274(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_274(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21699,19 +25256,38 @@ func (e3d *HwEngine3d) filler_274(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21745,6 +25321,7 @@ func (e3d *HwEngine3d) filler_274(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -21752,10 +25329,12 @@ func (e3d *HwEngine3d) filler_274(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21767,14 +25346,17 @@ func (e3d *HwEngine3d) filler_274(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_275(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_275(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21794,19 +25376,38 @@ func (e3d *HwEngine3d) filler_275(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21838,6 +25439,7 @@ func (e3d *HwEngine3d) filler_275(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -21845,10 +25447,12 @@ func (e3d *HwEngine3d) filler_275(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21860,14 +25464,17 @@ func (e3d *HwEngine3d) filler_275(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_276(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_276(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21889,6 +25496,9 @@ func (e3d *HwEngine3d) filler_276(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -21896,14 +25506,30 @@ func (e3d *HwEngine3d) filler_276(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -21940,6 +25566,7 @@ func (e3d *HwEngine3d) filler_276(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -21947,10 +25574,12 @@ func (e3d *HwEngine3d) filler_276(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -21962,14 +25591,17 @@ func (e3d *HwEngine3d) filler_276(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_277(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_277(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -21990,6 +25622,9 @@ func (e3d *HwEngine3d) filler_277(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -21997,13 +25632,29 @@ func (e3d *HwEngine3d) filler_277(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22036,6 +25687,7 @@ func (e3d *HwEngine3d) filler_277(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22043,10 +25695,12 @@ func (e3d *HwEngine3d) filler_277(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22058,14 +25712,17 @@ func (e3d *HwEngine3d) filler_277(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_278(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_278(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22085,6 +25742,9 @@ func (e3d *HwEngine3d) filler_278(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -22092,13 +25752,29 @@ func (e3d *HwEngine3d) filler_278(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22129,6 +25805,7 @@ func (e3d *HwEngine3d) filler_278(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22136,10 +25813,12 @@ func (e3d *HwEngine3d) filler_278(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22151,14 +25830,17 @@ func (e3d *HwEngine3d) filler_278(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_279(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_279(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22180,6 +25862,9 @@ func (e3d *HwEngine3d) filler_279(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -22187,14 +25872,30 @@ func (e3d *HwEngine3d) filler_279(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22231,6 +25932,7 @@ func (e3d *HwEngine3d) filler_279(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22238,10 +25940,12 @@ func (e3d *HwEngine3d) filler_279(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22253,14 +25957,17 @@ func (e3d *HwEngine3d) filler_279(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_27a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_27a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22281,6 +25988,9 @@ func (e3d *HwEngine3d) filler_27a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -22288,13 +25998,29 @@ func (e3d *HwEngine3d) filler_27a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22327,6 +26053,7 @@ func (e3d *HwEngine3d) filler_27a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22334,10 +26061,12 @@ func (e3d *HwEngine3d) filler_27a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22349,14 +26078,17 @@ func (e3d *HwEngine3d) filler_27a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_27b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_27b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22376,6 +26108,9 @@ func (e3d *HwEngine3d) filler_27b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -22383,13 +26118,29 @@ func (e3d *HwEngine3d) filler_27b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22420,6 +26171,7 @@ func (e3d *HwEngine3d) filler_27b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22427,10 +26179,12 @@ func (e3d *HwEngine3d) filler_27b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22442,14 +26196,17 @@ func (e3d *HwEngine3d) filler_27b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_27c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_27c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22471,20 +26228,39 @@ func (e3d *HwEngine3d) filler_27c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22519,6 +26295,7 @@ func (e3d *HwEngine3d) filler_27c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22526,10 +26303,12 @@ func (e3d *HwEngine3d) filler_27c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22541,14 +26320,17 @@ func (e3d *HwEngine3d) filler_27c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_27d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_27d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22569,19 +26351,38 @@ func (e3d *HwEngine3d) filler_27d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22612,6 +26413,7 @@ func (e3d *HwEngine3d) filler_27d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22619,10 +26421,12 @@ func (e3d *HwEngine3d) filler_27d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22634,14 +26438,17 @@ func (e3d *HwEngine3d) filler_27d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_27e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_27e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22661,19 +26468,38 @@ func (e3d *HwEngine3d) filler_27e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22702,6 +26528,7 @@ func (e3d *HwEngine3d) filler_27e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22709,10 +26536,12 @@ func (e3d *HwEngine3d) filler_27e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22724,14 +26553,17 @@ func (e3d *HwEngine3d) filler_27e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_27f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_27f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22752,6 +26584,9 @@ func (e3d *HwEngine3d) filler_27f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -22760,14 +26595,30 @@ func (e3d *HwEngine3d) filler_27f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22805,6 +26656,7 @@ func (e3d *HwEngine3d) filler_27f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22812,10 +26664,12 @@ func (e3d *HwEngine3d) filler_27f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22827,14 +26681,17 @@ func (e3d *HwEngine3d) filler_27f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_280(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_280(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22854,6 +26711,9 @@ func (e3d *HwEngine3d) filler_280(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -22862,13 +26722,29 @@ func (e3d *HwEngine3d) filler_280(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22902,6 +26778,7 @@ func (e3d *HwEngine3d) filler_280(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -22909,10 +26786,12 @@ func (e3d *HwEngine3d) filler_280(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -22924,14 +26803,17 @@ func (e3d *HwEngine3d) filler_280(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_281(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_281(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -22950,6 +26832,9 @@ func (e3d *HwEngine3d) filler_281(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -22958,13 +26843,29 @@ func (e3d *HwEngine3d) filler_281(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -22996,6 +26897,7 @@ func (e3d *HwEngine3d) filler_281(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23003,10 +26905,12 @@ func (e3d *HwEngine3d) filler_281(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23018,14 +26922,17 @@ func (e3d *HwEngine3d) filler_281(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_282(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_282(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23047,20 +26954,39 @@ func (e3d *HwEngine3d) filler_282(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23098,6 +27024,7 @@ func (e3d *HwEngine3d) filler_282(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23105,10 +27032,12 @@ func (e3d *HwEngine3d) filler_282(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23120,14 +27049,17 @@ func (e3d *HwEngine3d) filler_282(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_283(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_283(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23148,19 +27080,38 @@ func (e3d *HwEngine3d) filler_283(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23194,6 +27145,7 @@ func (e3d *HwEngine3d) filler_283(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23201,10 +27153,12 @@ func (e3d *HwEngine3d) filler_283(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23216,14 +27170,17 @@ func (e3d *HwEngine3d) filler_283(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_284(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_284(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23243,19 +27200,38 @@ func (e3d *HwEngine3d) filler_284(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23287,6 +27263,7 @@ func (e3d *HwEngine3d) filler_284(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23294,10 +27271,12 @@ func (e3d *HwEngine3d) filler_284(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23309,14 +27288,17 @@ func (e3d *HwEngine3d) filler_284(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_285(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_285(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23337,6 +27319,9 @@ func (e3d *HwEngine3d) filler_285(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -23344,14 +27329,30 @@ func (e3d *HwEngine3d) filler_285(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23389,6 +27390,7 @@ func (e3d *HwEngine3d) filler_285(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23396,10 +27398,12 @@ func (e3d *HwEngine3d) filler_285(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23411,14 +27415,17 @@ func (e3d *HwEngine3d) filler_285(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_286(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_286(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23438,6 +27445,9 @@ func (e3d *HwEngine3d) filler_286(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -23445,13 +27455,29 @@ func (e3d *HwEngine3d) filler_286(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23485,6 +27511,7 @@ func (e3d *HwEngine3d) filler_286(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23492,10 +27519,12 @@ func (e3d *HwEngine3d) filler_286(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23507,14 +27536,17 @@ func (e3d *HwEngine3d) filler_286(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23533,6 +27565,9 @@ func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -23540,13 +27575,29 @@ func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23578,6 +27629,7 @@ func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23585,10 +27637,12 @@ func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23602,15 +27656,15 @@ func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 
 // filler_288 skipped, because of identical polyfiller:
 //     288 -> {TexFormat:0 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:2}
-//     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
+//     270 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 
 // filler_289 skipped, because of identical polyfiller:
 //     289 -> {TexFormat:0 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:2}
-//     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
+//     270 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 
 // filler_28a skipped, because of identical polyfiller:
 //     28a -> {TexFormat:0 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:2}
-//     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
+//     270 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:0}
 
 // filler_28b skipped, because of identical polyfiller:
 //     28b -> {TexFormat:1 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:0}
@@ -23624,14 +27678,17 @@ func (e3d *HwEngine3d) filler_287(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     28d -> {TexFormat:1 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:2}
 //     275 -> {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:3 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_28e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_28e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23653,6 +27710,9 @@ func (e3d *HwEngine3d) filler_28e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -23660,14 +27720,30 @@ func (e3d *HwEngine3d) filler_28e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23708,6 +27784,7 @@ func (e3d *HwEngine3d) filler_28e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23715,10 +27792,12 @@ func (e3d *HwEngine3d) filler_28e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23730,14 +27809,17 @@ func (e3d *HwEngine3d) filler_28e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_28f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_28f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23758,6 +27840,9 @@ func (e3d *HwEngine3d) filler_28f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -23765,13 +27850,29 @@ func (e3d *HwEngine3d) filler_28f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23808,6 +27909,7 @@ func (e3d *HwEngine3d) filler_28f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23815,10 +27917,12 @@ func (e3d *HwEngine3d) filler_28f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23830,14 +27934,17 @@ func (e3d *HwEngine3d) filler_28f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_290(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_290(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23857,6 +27964,9 @@ func (e3d *HwEngine3d) filler_290(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -23864,13 +27974,29 @@ func (e3d *HwEngine3d) filler_290(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -23905,6 +28031,7 @@ func (e3d *HwEngine3d) filler_290(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -23912,10 +28039,12 @@ func (e3d *HwEngine3d) filler_290(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -23927,14 +28056,17 @@ func (e3d *HwEngine3d) filler_290(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_291(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_291(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -23956,6 +28088,9 @@ func (e3d *HwEngine3d) filler_291(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -23963,14 +28098,30 @@ func (e3d *HwEngine3d) filler_291(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -24011,6 +28162,7 @@ func (e3d *HwEngine3d) filler_291(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -24018,10 +28170,12 @@ func (e3d *HwEngine3d) filler_291(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -24033,14 +28187,17 @@ func (e3d *HwEngine3d) filler_291(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_292(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_292(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -24061,6 +28218,9 @@ func (e3d *HwEngine3d) filler_292(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -24068,13 +28228,29 @@ func (e3d *HwEngine3d) filler_292(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -24111,6 +28287,7 @@ func (e3d *HwEngine3d) filler_292(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -24118,10 +28295,12 @@ func (e3d *HwEngine3d) filler_292(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -24133,14 +28312,17 @@ func (e3d *HwEngine3d) filler_292(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_293(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_293(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -24160,6 +28342,9 @@ func (e3d *HwEngine3d) filler_293(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -24167,13 +28352,29 @@ func (e3d *HwEngine3d) filler_293(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -24208,6 +28409,7 @@ func (e3d *HwEngine3d) filler_293(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -24215,10 +28417,12 @@ func (e3d *HwEngine3d) filler_293(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -24230,14 +28434,17 @@ func (e3d *HwEngine3d) filler_293(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_294(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_294(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -24259,20 +28466,39 @@ func (e3d *HwEngine3d) filler_294(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -24310,6 +28536,7 @@ func (e3d *HwEngine3d) filler_294(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -24317,10 +28544,12 @@ func (e3d *HwEngine3d) filler_294(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -24332,14 +28561,17 @@ func (e3d *HwEngine3d) filler_294(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_295(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_295(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -24360,19 +28592,38 @@ func (e3d *HwEngine3d) filler_295(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -24406,6 +28657,7 @@ func (e3d *HwEngine3d) filler_295(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -24413,10 +28665,12 @@ func (e3d *HwEngine3d) filler_295(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -24428,14 +28682,17 @@ func (e3d *HwEngine3d) filler_295(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:3 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -24455,19 +28712,38 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
+	shadowmask := poly.IsShadowMask()
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
 		z := d0.Inv()
 		if int32(z.V>>20) >= int32(zbuf.Get32(0)) {
+			if shadowmask {
+				attr.Set32(0, attr.Get32(0)|PAStencil)
+			}
+			goto next
+		}
+		if shadowmask {
+			goto next
+		}
+		if dst := attr.Get32(0); dst&PAStencil == 0 || dst&PAPolyID == pattr&PAPolyID {
 			goto next
 		}
 		// texel coords
@@ -24499,6 +28775,7 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -24506,10 +28783,12 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -24559,15 +28838,15 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 
 // filler_2a0 skipped, because of identical polyfiller:
 //     2a0 -> {TexFormat:0 ColorKey:0 FillMode:2 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2a1 skipped, because of identical polyfiller:
 //     2a1 -> {TexFormat:0 ColorKey:0 FillMode:2 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2a2 skipped, because of identical polyfiller:
 //     2a2 -> {TexFormat:0 ColorKey:0 FillMode:2 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2a3 skipped, because of identical polyfiller:
 //     2a3 -> {TexFormat:1 ColorKey:0 FillMode:2 ColorMode:3 TexCoords:0}
@@ -24655,15 +28934,15 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 
 // filler_2b8 skipped, because of identical polyfiller:
 //     2b8 -> {TexFormat:0 ColorKey:1 FillMode:2 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2b9 skipped, because of identical polyfiller:
 //     2b9 -> {TexFormat:0 ColorKey:1 FillMode:2 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2ba skipped, because of identical polyfiller:
 //     2ba -> {TexFormat:0 ColorKey:1 FillMode:2 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2bb skipped, because of identical polyfiller:
 //     2bb -> {TexFormat:1 ColorKey:1 FillMode:2 ColorMode:3 TexCoords:0}
@@ -24751,15 +29030,15 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 
 // filler_2d0 skipped, because of identical polyfiller:
 //     2d0 -> {TexFormat:0 ColorKey:0 FillMode:3 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2d1 skipped, because of identical polyfiller:
 //     2d1 -> {TexFormat:0 ColorKey:0 FillMode:3 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2d2 skipped, because of identical polyfiller:
 //     2d2 -> {TexFormat:0 ColorKey:0 FillMode:3 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2d3 skipped, because of identical polyfiller:
 //     2d3 -> {TexFormat:1 ColorKey:0 FillMode:3 ColorMode:3 TexCoords:0}
@@ -24847,15 +29126,15 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 
 // filler_2e8 skipped, because of identical polyfiller:
 //     2e8 -> {TexFormat:0 ColorKey:1 FillMode:3 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2e9 skipped, because of identical polyfiller:
 //     2e9 -> {TexFormat:0 ColorKey:1 FillMode:3 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2ea skipped, because of identical polyfiller:
 //     2ea -> {TexFormat:0 ColorKey:1 FillMode:3 ColorMode:3 TexCoords:2}
-//     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
+//     240 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:3 TexCoords:0}
 
 // filler_2eb skipped, because of identical polyfiller:
 //     2eb -> {TexFormat:1 ColorKey:1 FillMode:3 ColorMode:3 TexCoords:0}
@@ -24953,14 +29232,17 @@ func (e3d *HwEngine3d) filler_296(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     302 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 //     000 -> {TexFormat:0 ColorKey:0 FillMode:0 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_303(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_303(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -24981,14 +29263,23 @@ func (e3d *HwEngine3d) filler_303(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -25045,10 +29336,12 @@ func (e3d *HwEngine3d) filler_303(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25060,14 +29353,17 @@ func (e3d *HwEngine3d) filler_303(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_304(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_304(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25087,14 +29383,23 @@ func (e3d *HwEngine3d) filler_304(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -25146,10 +29451,12 @@ func (e3d *HwEngine3d) filler_304(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25161,14 +29468,17 @@ func (e3d *HwEngine3d) filler_304(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_305(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_305(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25187,14 +29497,23 @@ func (e3d *HwEngine3d) filler_305(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -25244,10 +29563,12 @@ func (e3d *HwEngine3d) filler_305(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25259,14 +29580,17 @@ func (e3d *HwEngine3d) filler_305(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_306(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_306(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25287,6 +29611,8 @@ func (e3d *HwEngine3d) filler_306(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -25294,8 +29620,15 @@ func (e3d *HwEngine3d) filler_306(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -25351,10 +29684,12 @@ func (e3d *HwEngine3d) filler_306(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25366,14 +29701,17 @@ func (e3d *HwEngine3d) filler_306(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_307(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_307(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25393,6 +29731,8 @@ func (e3d *HwEngine3d) filler_307(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -25400,8 +29740,15 @@ func (e3d *HwEngine3d) filler_307(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -25452,10 +29799,12 @@ func (e3d *HwEngine3d) filler_307(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25467,14 +29816,17 @@ func (e3d *HwEngine3d) filler_307(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_308(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_308(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25493,6 +29845,8 @@ func (e3d *HwEngine3d) filler_308(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -25500,8 +29854,15 @@ func (e3d *HwEngine3d) filler_308(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -25550,10 +29911,12 @@ func (e3d *HwEngine3d) filler_308(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25565,14 +29928,17 @@ func (e3d *HwEngine3d) filler_308(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_309(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_309(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25593,6 +29959,8 @@ func (e3d *HwEngine3d) filler_309(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -25600,8 +29968,15 @@ func (e3d *HwEngine3d) filler_309(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -25657,10 +30032,12 @@ func (e3d *HwEngine3d) filler_309(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25672,14 +30049,17 @@ func (e3d *HwEngine3d) filler_309(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_30a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_30a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25699,6 +30079,8 @@ func (e3d *HwEngine3d) filler_30a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -25706,8 +30088,15 @@ func (e3d *HwEngine3d) filler_30a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -25758,10 +30147,12 @@ func (e3d *HwEngine3d) filler_30a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25773,14 +30164,17 @@ func (e3d *HwEngine3d) filler_30a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_30b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_30b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25799,6 +30193,8 @@ func (e3d *HwEngine3d) filler_30b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -25806,8 +30202,15 @@ func (e3d *HwEngine3d) filler_30b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -25856,10 +30259,12 @@ func (e3d *HwEngine3d) filler_30b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25871,14 +30276,17 @@ func (e3d *HwEngine3d) filler_30b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_30c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_30c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -25899,14 +30307,23 @@ func (e3d *HwEngine3d) filler_30c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -25960,10 +30377,12 @@ func (e3d *HwEngine3d) filler_30c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -25975,14 +30394,17 @@ func (e3d *HwEngine3d) filler_30c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_30d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_30d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26002,14 +30424,23 @@ func (e3d *HwEngine3d) filler_30d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -26058,10 +30489,12 @@ func (e3d *HwEngine3d) filler_30d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26073,14 +30506,17 @@ func (e3d *HwEngine3d) filler_30d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_30e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_30e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26099,14 +30535,23 @@ func (e3d *HwEngine3d) filler_30e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -26153,10 +30598,12 @@ func (e3d *HwEngine3d) filler_30e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26168,14 +30615,17 @@ func (e3d *HwEngine3d) filler_30e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_30f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_30f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26195,6 +30645,8 @@ func (e3d *HwEngine3d) filler_30f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -26203,8 +30655,15 @@ func (e3d *HwEngine3d) filler_30f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -26261,10 +30720,12 @@ func (e3d *HwEngine3d) filler_30f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26276,14 +30737,17 @@ func (e3d *HwEngine3d) filler_30f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_310(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_310(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26302,6 +30766,8 @@ func (e3d *HwEngine3d) filler_310(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -26310,8 +30776,15 @@ func (e3d *HwEngine3d) filler_310(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -26363,10 +30836,12 @@ func (e3d *HwEngine3d) filler_310(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26378,14 +30853,17 @@ func (e3d *HwEngine3d) filler_310(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_311(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_311(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26403,6 +30881,8 @@ func (e3d *HwEngine3d) filler_311(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -26411,8 +30891,15 @@ func (e3d *HwEngine3d) filler_311(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -26462,10 +30949,12 @@ func (e3d *HwEngine3d) filler_311(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26477,14 +30966,17 @@ func (e3d *HwEngine3d) filler_311(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_312(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_312(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26505,14 +30997,23 @@ func (e3d *HwEngine3d) filler_312(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -26569,10 +31070,12 @@ func (e3d *HwEngine3d) filler_312(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26584,14 +31087,17 @@ func (e3d *HwEngine3d) filler_312(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_313(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_313(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26611,14 +31117,23 @@ func (e3d *HwEngine3d) filler_313(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -26670,10 +31185,12 @@ func (e3d *HwEngine3d) filler_313(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26685,14 +31202,17 @@ func (e3d *HwEngine3d) filler_313(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_314(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_314(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26711,14 +31231,23 @@ func (e3d *HwEngine3d) filler_314(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -26768,10 +31297,12 @@ func (e3d *HwEngine3d) filler_314(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26783,14 +31314,17 @@ func (e3d *HwEngine3d) filler_314(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_315(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_315(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26810,6 +31344,8 @@ func (e3d *HwEngine3d) filler_315(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -26817,8 +31353,15 @@ func (e3d *HwEngine3d) filler_315(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -26875,10 +31418,12 @@ func (e3d *HwEngine3d) filler_315(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26890,14 +31435,17 @@ func (e3d *HwEngine3d) filler_315(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_316(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_316(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -26916,6 +31464,8 @@ func (e3d *HwEngine3d) filler_316(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -26923,8 +31473,15 @@ func (e3d *HwEngine3d) filler_316(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -26976,10 +31533,12 @@ func (e3d *HwEngine3d) filler_316(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -26991,14 +31550,17 @@ func (e3d *HwEngine3d) filler_316(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_317(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_317(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27016,6 +31578,8 @@ func (e3d *HwEngine3d) filler_317(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -27023,8 +31587,15 @@ func (e3d *HwEngine3d) filler_317(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -27074,10 +31645,12 @@ func (e3d *HwEngine3d) filler_317(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27113,14 +31686,17 @@ func (e3d *HwEngine3d) filler_317(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     31d -> {TexFormat:1 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:2}
 //     305 -> {TexFormat:1 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_31e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_31e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27141,6 +31717,8 @@ func (e3d *HwEngine3d) filler_31e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -27148,8 +31726,15 @@ func (e3d *HwEngine3d) filler_31e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -27209,10 +31794,12 @@ func (e3d *HwEngine3d) filler_31e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27224,14 +31811,17 @@ func (e3d *HwEngine3d) filler_31e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_31f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_31f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27251,6 +31841,8 @@ func (e3d *HwEngine3d) filler_31f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -27258,8 +31850,15 @@ func (e3d *HwEngine3d) filler_31f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -27314,10 +31913,12 @@ func (e3d *HwEngine3d) filler_31f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27329,14 +31930,17 @@ func (e3d *HwEngine3d) filler_31f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_320(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_320(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27355,6 +31959,8 @@ func (e3d *HwEngine3d) filler_320(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -27362,8 +31968,15 @@ func (e3d *HwEngine3d) filler_320(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -27416,10 +32029,12 @@ func (e3d *HwEngine3d) filler_320(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27431,14 +32046,17 @@ func (e3d *HwEngine3d) filler_320(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_321(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_321(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27459,6 +32077,8 @@ func (e3d *HwEngine3d) filler_321(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -27466,8 +32086,15 @@ func (e3d *HwEngine3d) filler_321(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -27527,10 +32154,12 @@ func (e3d *HwEngine3d) filler_321(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27542,14 +32171,17 @@ func (e3d *HwEngine3d) filler_321(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_322(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_322(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27569,6 +32201,8 @@ func (e3d *HwEngine3d) filler_322(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -27576,8 +32210,15 @@ func (e3d *HwEngine3d) filler_322(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -27632,10 +32273,12 @@ func (e3d *HwEngine3d) filler_322(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27647,14 +32290,17 @@ func (e3d *HwEngine3d) filler_322(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_323(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_323(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27673,6 +32319,8 @@ func (e3d *HwEngine3d) filler_323(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -27680,8 +32328,15 @@ func (e3d *HwEngine3d) filler_323(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -27734,10 +32389,12 @@ func (e3d *HwEngine3d) filler_323(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27749,14 +32406,17 @@ func (e3d *HwEngine3d) filler_323(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_324(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_324(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27777,14 +32437,23 @@ func (e3d *HwEngine3d) filler_324(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -27841,10 +32510,12 @@ func (e3d *HwEngine3d) filler_324(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27856,14 +32527,17 @@ func (e3d *HwEngine3d) filler_324(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_325(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_325(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27883,14 +32557,23 @@ func (e3d *HwEngine3d) filler_325(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	sflip, tflip := poly.tex.SFlipMask, poly.tex.TFlipMask
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -27942,10 +32625,12 @@ func (e3d *HwEngine3d) filler_325(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -27957,14 +32642,17 @@ func (e3d *HwEngine3d) filler_325(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_326(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_326(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:0 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -27983,14 +32671,23 @@ func (e3d *HwEngine3d) filler_326(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	ds, dt := s1.SubFixed(s0).Div(nx), t1.SubFixed(t0).Div(nx)
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -28040,10 +32737,12 @@ func (e3d *HwEngine3d) filler_326(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28103,14 +32802,17 @@ func (e3d *HwEngine3d) filler_326(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     332 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 //     030 -> {TexFormat:0 ColorKey:0 FillMode:1 ColorMode:0 TexCoords:0}
 
-func (e3d *HwEngine3d) filler_333(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_333(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28132,14 +32834,23 @@ func (e3d *HwEngine3d) filler_333(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -28201,6 +32912,7 @@ func (e3d *HwEngine3d) filler_333(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -28208,10 +32920,12 @@ func (e3d *HwEngine3d) filler_333(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28223,14 +32937,17 @@ func (e3d *HwEngine3d) filler_333(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_334(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_334(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28251,14 +32968,23 @@ func (e3d *HwEngine3d) filler_334(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -28315,6 +33041,7 @@ func (e3d *HwEngine3d) filler_334(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -28322,10 +33049,12 @@ func (e3d *HwEngine3d) filler_334(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28337,14 +33066,17 @@ func (e3d *HwEngine3d) filler_334(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_335(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_335(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28364,14 +33096,23 @@ func (e3d *HwEngine3d) filler_335(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -28426,6 +33167,7 @@ func (e3d *HwEngine3d) filler_335(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -28433,10 +33175,12 @@ func (e3d *HwEngine3d) filler_335(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28448,14 +33192,17 @@ func (e3d *HwEngine3d) filler_335(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_336(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_336(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28477,6 +33224,8 @@ func (e3d *HwEngine3d) filler_336(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -28484,8 +33233,15 @@ func (e3d *HwEngine3d) filler_336(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -28546,6 +33302,7 @@ func (e3d *HwEngine3d) filler_336(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -28553,10 +33310,12 @@ func (e3d *HwEngine3d) filler_336(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28568,14 +33327,17 @@ func (e3d *HwEngine3d) filler_336(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_337(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_337(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28596,6 +33358,8 @@ func (e3d *HwEngine3d) filler_337(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -28603,8 +33367,15 @@ func (e3d *HwEngine3d) filler_337(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -28660,6 +33431,7 @@ func (e3d *HwEngine3d) filler_337(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -28667,10 +33439,12 @@ func (e3d *HwEngine3d) filler_337(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28682,14 +33456,17 @@ func (e3d *HwEngine3d) filler_337(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_338(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_338(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28709,6 +33486,8 @@ func (e3d *HwEngine3d) filler_338(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -28716,8 +33495,15 @@ func (e3d *HwEngine3d) filler_338(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -28771,6 +33557,7 @@ func (e3d *HwEngine3d) filler_338(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -28778,10 +33565,12 @@ func (e3d *HwEngine3d) filler_338(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28793,14 +33582,17 @@ func (e3d *HwEngine3d) filler_338(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_339(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_339(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28822,6 +33614,8 @@ func (e3d *HwEngine3d) filler_339(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -28829,8 +33623,15 @@ func (e3d *HwEngine3d) filler_339(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -28891,6 +33692,7 @@ func (e3d *HwEngine3d) filler_339(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -28898,10 +33700,12 @@ func (e3d *HwEngine3d) filler_339(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -28913,14 +33717,17 @@ func (e3d *HwEngine3d) filler_339(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_33a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_33a(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -28941,6 +33748,8 @@ func (e3d *HwEngine3d) filler_33a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -28948,8 +33757,15 @@ func (e3d *HwEngine3d) filler_33a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -29005,6 +33821,7 @@ func (e3d *HwEngine3d) filler_33a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29012,10 +33829,12 @@ func (e3d *HwEngine3d) filler_33a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29027,14 +33846,17 @@ func (e3d *HwEngine3d) filler_33a(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_33b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_33b(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29054,6 +33876,8 @@ func (e3d *HwEngine3d) filler_33b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -29061,8 +33885,15 @@ func (e3d *HwEngine3d) filler_33b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -29116,6 +33947,7 @@ func (e3d *HwEngine3d) filler_33b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29123,10 +33955,12 @@ func (e3d *HwEngine3d) filler_33b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29138,14 +33972,17 @@ func (e3d *HwEngine3d) filler_33b(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_33c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_33c(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29167,14 +34004,23 @@ func (e3d *HwEngine3d) filler_33c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -29233,6 +34079,7 @@ func (e3d *HwEngine3d) filler_33c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29240,10 +34087,12 @@ func (e3d *HwEngine3d) filler_33c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29255,14 +34104,17 @@ func (e3d *HwEngine3d) filler_33c(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_33d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_33d(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29283,14 +34135,23 @@ func (e3d *HwEngine3d) filler_33d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -29344,6 +34205,7 @@ func (e3d *HwEngine3d) filler_33d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29351,10 +34213,12 @@ func (e3d *HwEngine3d) filler_33d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29366,14 +34230,17 @@ func (e3d *HwEngine3d) filler_33d(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_33e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_33e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29393,14 +34260,23 @@ func (e3d *HwEngine3d) filler_33e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -29452,6 +34328,7 @@ func (e3d *HwEngine3d) filler_33e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29459,10 +34336,12 @@ func (e3d *HwEngine3d) filler_33e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29474,14 +34353,17 @@ func (e3d *HwEngine3d) filler_33e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_33f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_33f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29502,6 +34384,8 @@ func (e3d *HwEngine3d) filler_33f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -29510,8 +34394,15 @@ func (e3d *HwEngine3d) filler_33f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -29573,6 +34464,7 @@ func (e3d *HwEngine3d) filler_33f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29580,10 +34472,12 @@ func (e3d *HwEngine3d) filler_33f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29595,14 +34489,17 @@ func (e3d *HwEngine3d) filler_33f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_340(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_340(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29622,6 +34519,8 @@ func (e3d *HwEngine3d) filler_340(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -29630,8 +34529,15 @@ func (e3d *HwEngine3d) filler_340(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -29688,6 +34594,7 @@ func (e3d *HwEngine3d) filler_340(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29695,10 +34602,12 @@ func (e3d *HwEngine3d) filler_340(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29710,14 +34619,17 @@ func (e3d *HwEngine3d) filler_340(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_341(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_341(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:5 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29736,6 +34648,8 @@ func (e3d *HwEngine3d) filler_341(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	decompTexBuf := e3d.texCache.Get(texoff)
 	decompTex := gfx.NewLine(decompTexBuf)
 	var px uint16
@@ -29744,8 +34658,15 @@ func (e3d *HwEngine3d) filler_341(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -29800,6 +34721,7 @@ func (e3d *HwEngine3d) filler_341(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29807,10 +34729,12 @@ func (e3d *HwEngine3d) filler_341(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29822,14 +34746,17 @@ func (e3d *HwEngine3d) filler_341(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_342(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_342(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29851,14 +34778,23 @@ func (e3d *HwEngine3d) filler_342(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -29920,6 +34856,7 @@ func (e3d *HwEngine3d) filler_342(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -29927,10 +34864,12 @@ func (e3d *HwEngine3d) filler_342(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -29942,14 +34881,17 @@ func (e3d *HwEngine3d) filler_342(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_343(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_343(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -29970,14 +34912,23 @@ func (e3d *HwEngine3d) filler_343(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -30034,6 +34985,7 @@ func (e3d *HwEngine3d) filler_343(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30041,10 +34993,12 @@ func (e3d *HwEngine3d) filler_343(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30056,14 +35010,17 @@ func (e3d *HwEngine3d) filler_343(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_344(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_344(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:6 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30083,14 +35040,23 @@ func (e3d *HwEngine3d) filler_344(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -30145,6 +35111,7 @@ func (e3d *HwEngine3d) filler_344(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30152,10 +35119,12 @@ func (e3d *HwEngine3d) filler_344(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30167,14 +35136,17 @@ func (e3d *HwEngine3d) filler_344(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_345(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_345(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30195,6 +35167,8 @@ func (e3d *HwEngine3d) filler_345(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -30202,8 +35176,15 @@ func (e3d *HwEngine3d) filler_345(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -30265,6 +35246,7 @@ func (e3d *HwEngine3d) filler_345(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30272,10 +35254,12 @@ func (e3d *HwEngine3d) filler_345(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30287,14 +35271,17 @@ func (e3d *HwEngine3d) filler_345(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_346(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_346(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30314,6 +35301,8 @@ func (e3d *HwEngine3d) filler_346(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -30321,8 +35310,15 @@ func (e3d *HwEngine3d) filler_346(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -30379,6 +35375,7 @@ func (e3d *HwEngine3d) filler_346(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30386,10 +35383,12 @@ func (e3d *HwEngine3d) filler_346(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30401,14 +35400,17 @@ func (e3d *HwEngine3d) filler_346(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_347(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_347(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:7 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30427,6 +35429,8 @@ func (e3d *HwEngine3d) filler_347(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift += 1
 	var px uint16
 	var px0 uint8
@@ -30434,8 +35438,15 @@ func (e3d *HwEngine3d) filler_347(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -30490,6 +35501,7 @@ func (e3d *HwEngine3d) filler_347(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30497,10 +35509,12 @@ func (e3d *HwEngine3d) filler_347(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30536,14 +35550,17 @@ func (e3d *HwEngine3d) filler_347(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     34d -> {TexFormat:1 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:2}
 //     335 -> {TexFormat:1 ColorKey:0 FillMode:1 ColorMode:4 TexCoords:2}
 
-func (e3d *HwEngine3d) filler_34e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_34e(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30565,6 +35582,8 @@ func (e3d *HwEngine3d) filler_34e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -30572,8 +35591,15 @@ func (e3d *HwEngine3d) filler_34e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -30638,6 +35664,7 @@ func (e3d *HwEngine3d) filler_34e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30645,10 +35672,12 @@ func (e3d *HwEngine3d) filler_34e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30660,14 +35689,17 @@ func (e3d *HwEngine3d) filler_34e(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_34f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_34f(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30688,6 +35720,8 @@ func (e3d *HwEngine3d) filler_34f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -30695,8 +35729,15 @@ func (e3d *HwEngine3d) filler_34f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -30756,6 +35797,7 @@ func (e3d *HwEngine3d) filler_34f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30763,10 +35805,12 @@ func (e3d *HwEngine3d) filler_34f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30778,14 +35822,17 @@ func (e3d *HwEngine3d) filler_34f(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_350(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_350(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:2 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30805,6 +35852,8 @@ func (e3d *HwEngine3d) filler_350(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 2
 	var px uint16
 	var px0 uint8
@@ -30812,8 +35861,15 @@ func (e3d *HwEngine3d) filler_350(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -30871,6 +35927,7 @@ func (e3d *HwEngine3d) filler_350(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -30878,10 +35935,12 @@ func (e3d *HwEngine3d) filler_350(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -30893,14 +35952,17 @@ func (e3d *HwEngine3d) filler_350(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_351(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_351(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -30922,6 +35984,8 @@ func (e3d *HwEngine3d) filler_351(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -30929,8 +35993,15 @@ func (e3d *HwEngine3d) filler_351(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -30995,6 +36066,7 @@ func (e3d *HwEngine3d) filler_351(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -31002,10 +36074,12 @@ func (e3d *HwEngine3d) filler_351(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -31017,14 +36091,17 @@ func (e3d *HwEngine3d) filler_351(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_352(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_352(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -31045,6 +36122,8 @@ func (e3d *HwEngine3d) filler_352(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -31052,8 +36131,15 @@ func (e3d *HwEngine3d) filler_352(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -31113,6 +36199,7 @@ func (e3d *HwEngine3d) filler_352(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -31120,10 +36207,12 @@ func (e3d *HwEngine3d) filler_352(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -31135,14 +36224,17 @@ func (e3d *HwEngine3d) filler_352(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_353(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_353(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:3 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -31162,6 +36254,8 @@ func (e3d *HwEngine3d) filler_353(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	tshift -= 1
 	var px uint16
 	var px0 uint8
@@ -31169,8 +36263,15 @@ func (e3d *HwEngine3d) filler_353(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -31228,6 +36329,7 @@ func (e3d *HwEngine3d) filler_353(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -31235,10 +36337,12 @@ func (e3d *HwEngine3d) filler_353(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -31250,14 +36354,17 @@ func (e3d *HwEngine3d) filler_353(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_354(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_354(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:0}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -31279,14 +36386,23 @@ func (e3d *HwEngine3d) filler_354(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		var doclamps, doclampt uint32
@@ -31348,6 +36464,7 @@ func (e3d *HwEngine3d) filler_354(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -31355,10 +36472,12 @@ func (e3d *HwEngine3d) filler_354(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -31370,14 +36489,17 @@ func (e3d *HwEngine3d) filler_354(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_355(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_355(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:1}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -31398,14 +36520,23 @@ func (e3d *HwEngine3d) filler_355(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -31462,6 +36593,7 @@ func (e3d *HwEngine3d) filler_355(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -31469,10 +36601,12 @@ func (e3d *HwEngine3d) filler_355(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -31484,14 +36618,17 @@ func (e3d *HwEngine3d) filler_355(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	_ = zalpha
 }
 
-func (e3d *HwEngine3d) filler_356(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line) {
+func (e3d *HwEngine3d) filler_356(poly *Polygon, out gfx.Line, zbuf gfx.Line, abuf gfx.Line, attr gfx.Line) {
 	// {TexFormat:4 ColorKey:1 FillMode:1 ColorMode:4 TexCoords:2}
 	x0, x1 := poly.left[LerpX].Cur().NearInt32(), poly.right[LerpX].Cur().NearInt32()
 	nx := x1 - x0
 	if nx == 0 {
-		return
+		if !poly.line {
+			return
+		}
+		nx = 1
 	}
-	if poly.UseAlpha() {
+	if poly.UseAlpha() && !poly.line {
 		x1 -= 1
 	}
 	d0, d1 := poly.left[LerpD].Cur(), poly.right[LerpD].Cur()
@@ -31511,14 +36648,23 @@ func (e3d *HwEngine3d) filler_356(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 	smask, tmask := poly.tex.Width-1, poly.tex.Height-1
 	polyalpha := uint8(poly.flags.Alpha()) << 1
 	zalpha := e3d.Disp3dCnt.Value&(1<<11) != 0
+	pattr := uint32(poly.flags) & (PAFog | PAPolyID)
+	lattr, iattr, rattr := pattr|poly.spanAttr[0], pattr|poly.spanAttr[1], pattr|poly.spanAttr[2]
 	var px uint16
 	var px0 uint8
 	var s, t uint32
 	out.Add32(int(x0))
 	zbuf.Add32(int(x0))
 	abuf.Add8(int(x0))
+	attr.Add32(int(x0))
 	for x := x0; x <= x1; x++ {
 		drawz := true
+		pxattr := iattr
+		if x == x0 {
+			pxattr = lattr
+		} else if x == x1 {
+			pxattr = rattr
+		}
 		var pxa uint8
 		pxa = 63
 		// zbuffer check
@@ -31573,6 +36719,7 @@ func (e3d *HwEngine3d) filler_356(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 				pxa = bkga
 			}
 			drawz = zalpha
+			pxattr = attr.Get32(0) & (pattr | ^PAFog)
 		}
 		// draw color and alpha
 		out.Set32(0, uint32(px)|0x80000000)
@@ -31580,10 +36727,12 @@ func (e3d *HwEngine3d) filler_356(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 		if drawz {
 			zbuf.Set32(0, uint32(z.V>>20))
 		}
+		attr.Set32(0, pxattr)
 	next:
 		out.Add32(1)
 		zbuf.Add32(1)
 		abuf.Add8(1)
+		attr.Add32(1)
 		d0 = d0.AddFixed(dd)
 		r0 = r0.AddFixed(dr)
 		g0 = g0.AddFixed(dg)
@@ -32015,7 +37164,7 @@ func (e3d *HwEngine3d) filler_356(poly *Polygon, out gfx.Line, zbuf gfx.Line, ab
 //     3bf -> {TexFormat:7 ColorKey:1 FillMode:3 ColorMode:4 TexCoords:2}
 //     317 -> {TexFormat:7 ColorKey:0 FillMode:0 ColorMode:4 TexCoords:2}
 
-var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gfx.Line){
+var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gfx.Line, gfx.Line){
 	(*HwEngine3d).filler_000,
 	(*HwEngine3d).filler_000,
 	(*HwEngine3d).filler_000,
@@ -32592,9 +37741,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_195,
 	(*HwEngine3d).filler_196,
 	(*HwEngine3d).filler_197,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
 	(*HwEngine3d).filler_243,
 	(*HwEngine3d).filler_244,
 	(*HwEngine3d).filler_245,
@@ -32616,9 +37765,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_255,
 	(*HwEngine3d).filler_256,
 	(*HwEngine3d).filler_257,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
 	(*HwEngine3d).filler_243,
 	(*HwEngine3d).filler_244,
 	(*HwEngine3d).filler_245,
@@ -32640,9 +37789,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_255,
 	(*HwEngine3d).filler_256,
 	(*HwEngine3d).filler_257,
-	(*HwEngine3d).filler_030,
-	(*HwEngine3d).filler_030,
-	(*HwEngine3d).filler_030,
+	(*HwEngine3d).filler_270,
+	(*HwEngine3d).filler_270,
+	(*HwEngine3d).filler_270,
 	(*HwEngine3d).filler_273,
 	(*HwEngine3d).filler_274,
 	(*HwEngine3d).filler_275,
@@ -32664,9 +37813,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_285,
 	(*HwEngine3d).filler_286,
 	(*HwEngine3d).filler_287,
-	(*HwEngine3d).filler_030,
-	(*HwEngine3d).filler_030,
-	(*HwEngine3d).filler_030,
+	(*HwEngine3d).filler_270,
+	(*HwEngine3d).filler_270,
+	(*HwEngine3d).filler_270,
 	(*HwEngine3d).filler_273,
 	(*HwEngine3d).filler_274,
 	(*HwEngine3d).filler_275,
@@ -32688,9 +37837,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_285,
 	(*HwEngine3d).filler_286,
 	(*HwEngine3d).filler_287,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
 	(*HwEngine3d).filler_243,
 	(*HwEngine3d).filler_244,
 	(*HwEngine3d).filler_245,
@@ -32712,9 +37861,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_255,
 	(*HwEngine3d).filler_256,
 	(*HwEngine3d).filler_257,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
 	(*HwEngine3d).filler_243,
 	(*HwEngine3d).filler_244,
 	(*HwEngine3d).filler_245,
@@ -32736,9 +37885,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_255,
 	(*HwEngine3d).filler_256,
 	(*HwEngine3d).filler_257,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
 	(*HwEngine3d).filler_243,
 	(*HwEngine3d).filler_244,
 	(*HwEngine3d).filler_245,
@@ -32760,9 +37909,9 @@ var polygonFillerTable = [960]func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gf
 	(*HwEngine3d).filler_255,
 	(*HwEngine3d).filler_256,
 	(*HwEngine3d).filler_257,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
-	(*HwEngine3d).filler_000,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
+	(*HwEngine3d).filler_240,
 	(*HwEngine3d).filler_243,
 	(*HwEngine3d).filler_244,
 	(*HwEngine3d).filler_245,
@@ -0,0 +1,110 @@
+package raster3d
+
+import "ndsemu/emu/fixed"
+
+// isSegment returns true if the polygon has no area (its vertices are
+// collinear, or some of them coincide). The hardware draws these polygons
+// as 1-pixel wide segments, and games use them on purpose for wireframes,
+// lasers and similar effects.
+func (poly *Polygon) isSegment() bool {
+	v0, v1, v2 := poly.vtx[0], poly.vtx[1], poly.vtx[2]
+	d0x, d0y := int64(v1.x.V-v0.x.V), int64(v1.y.V-v0.y.V)
+	d1x, d1y := int64(v2.x.V-v0.x.V), int64(v2.y.V-v0.y.V)
+	return d0x*d1y == d1x*d0y
+}
+
+// segmentEnds returns the two endpoints of a segment polygon, ordered by y
+// (and by x for horizontal segments)
+func (poly *Polygon) segmentEnds() (*Vertex, *Vertex) {
+	a, b := poly.vtx[0], poly.vtx[2]
+	if a.y == b.y {
+		for _, v := range poly.vtx {
+			if v.x.V < a.x.V {
+				a = v
+			}
+			if v.x.V > b.x.V {
+				b = v
+			}
+		}
+	}
+	return a, b
+}
+
+// setSegmentSpan sets the interpolators of a segment polygon to the run of
+// pixels of the segment that lies in line y, so that it can be drawn by the
+// polyfiller like the span of any other polygon.
+//
+// For mostly-horizontal segments, each line gets the pixels whose center is
+// closer to it than to the other lines; mostly-vertical segments have a
+// single pixel per line. Attributes are interpolated along the major axis
+// of the segment.
+func (poly *Polygon) setSegmentSpan(y int32) {
+	a, b := poly.segmentEnds()
+	xa, ya := a.x.TruncInt32(), a.y.TruncInt32()
+	xb, yb := b.x.TruncInt32(), b.y.TruncInt32()
+	dx, dy := xb-xa, yb-ya
+
+	x0, x1 := xa, xb
+	if dy != 0 {
+		if y > ya {
+			x0 = xa + divRound(dx*(2*(y-ya)-1), 2*dy)
+		}
+		if y < yb {
+			x1 = xa + divRound(dx*(2*(y-ya)+1), 2*dy)
+			// The pixel at the boundary belongs to the next line
+			if x1 > x0 {
+				x1--
+			} else if x1 < x0 {
+				x1++
+			}
+		}
+	}
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+
+	va, vb := segmentValues(a), segmentValues(b)
+	set := func(l *[NumLerps]lerp, x int32) {
+		num, den := int64(0), int64(1)
+		if dx != 0 && abs32(dx) >= abs32(dy) {
+			num, den = int64(x-xa), int64(dx)
+		} else if dy != 0 {
+			num, den = int64(y-ya), int64(dy)
+		}
+		for i := range l {
+			l[i].cur = va[i] + (vb[i]-va[i])*num/den
+		}
+		l[LerpX].cur = fixed.NewF32(x).V
+	}
+	set(&poly.left, x0)
+	set(&poly.right, x1)
+}
+
+// segmentValues returns the values of the interpolators at the vertex
+func segmentValues(v *Vertex) [NumLerps]int64 {
+	var vals [NumLerps]int64
+	vals[LerpX] = v.x.ToF32().V
+	vals[LerpD] = v.d.V
+	vals[LerpS] = v.s.V
+	vals[LerpT] = v.t.V
+	vals[LerpR] = v.r.ToF32().V
+	vals[LerpG] = v.g.ToF32().V
+	vals[LerpB] = v.b.ToF32().V
+	return vals
+}
+
+// divRound divides two integers, rounding to the nearest (den must be
+// positive)
+func divRound(num, den int32) int32 {
+	if num < 0 {
+		return -((-num + den/2) / den)
+	}
+	return (num + den/2) / den
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
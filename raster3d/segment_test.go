@@ -0,0 +1,112 @@
+package raster3d
+
+import (
+	"fmt"
+	"testing"
+)
+
+// drawnPixels returns the list of pixels that aren't the (black) rear plane
+func (s *testScene) drawnPixels() [][2]int {
+	var pixels [][2]int
+	for y := 0; y < 192; y++ {
+		for x := 0; x < 256; x++ {
+			if s.color(x, y) != 0 {
+				pixels = append(pixels, [2]int{x, y})
+			}
+		}
+	}
+	return pixels
+}
+
+func TestSegmentCoverage(t *testing.T) {
+	c := testRed
+	// pixelRange returns the pixels from (x0,y0) to (x1,y1) included,
+	// stepping by (dx,dy)
+	pixelRange := func(x0, y0, dx, dy, n int) [][2]int {
+		var pixels [][2]int
+		for i := 0; i < n; i++ {
+			pixels = append(pixels, [2]int{x0 + dx*i, y0 + dy*i})
+		}
+		return pixels
+	}
+
+	for _, tc := range []struct {
+		desc string
+		vtx  []testVtx
+		exp  [][2]int
+	}{
+		{"horizontal triangle",
+			[]testVtx{{10, 50, 0, c}, {60, 50, 0, c}, {30, 50, 0, c}},
+			pixelRange(10, 50, 1, 0, 51)},
+		{"horizontal triangle (reversed)",
+			[]testVtx{{60, 50, 0, c}, {30, 50, 0, c}, {10, 50, 0, c}},
+			pixelRange(10, 50, 1, 0, 51)},
+		{"vertical triangle",
+			[]testVtx{{100, 20, 0, c}, {100, 60, 0, c}, {100, 40, 0, c}},
+			pixelRange(100, 20, 0, 1, 41)},
+		{"diagonal triangle",
+			[]testVtx{{20, 100, 0, c}, {40, 120, 0, c}, {60, 140, 0, c}},
+			pixelRange(20, 100, 1, 1, 41)},
+		{"anti-diagonal triangle",
+			[]testVtx{{60, 100, 0, c}, {40, 120, 0, c}, {20, 140, 0, c}},
+			pixelRange(60, 100, -1, 1, 41)},
+		{"horizontal quad",
+			[]testVtx{{10, 50, 0, c}, {30, 50, 0, c}, {60, 50, 0, c}, {40, 50, 0, c}},
+			pixelRange(10, 50, 1, 0, 51)},
+		{"vertical quad",
+			[]testVtx{{100, 20, 0, c}, {100, 30, 0, c}, {100, 60, 0, c}, {100, 50, 0, c}},
+			pixelRange(100, 20, 0, 1, 41)},
+		{"point",
+			[]testVtx{{128, 96, 0, c}, {128, 96, 0, c}, {128, 96, 0, c}},
+			[][2]int{{128, 96}}},
+	} {
+		s := newTestScene()
+		s.poly(opaqueAttr(1), tc.vtx...)
+		s.draw()
+
+		if got, exp := fmt.Sprint(s.drawnPixels()), fmt.Sprint(tc.exp); got != exp {
+			t.Errorf("%s: drawn pixels:\n%s\nwant:\n%s", tc.desc, got, exp)
+		}
+	}
+}
+
+func TestSegmentInterpolation(t *testing.T) {
+	// Horizontal segment from red (near) to blue (far); the third vertex is
+	// in the middle.
+	s := newTestScene()
+	s.poly(opaqueAttr(1),
+		testVtx{0, 50, -0.5, testRed},
+		testVtx{200, 50, 0.5, testBlue},
+		testVtx{100, 50, 0, [3]uint8{15, 0, 15}})
+	s.draw()
+
+	for _, tc := range []struct {
+		x     int
+		color uint32
+	}{
+		{0, 31},
+		{100, 15 | 15<<10},
+		{200, 31 << 10},
+	} {
+		if got := s.color(tc.x, 50); got != tc.color {
+			t.Errorf("x=%d: color = %#x, want %#x", tc.x, got, tc.color)
+		}
+	}
+
+	// Depth must go from the nearest to the farthest endpoint. Like for
+	// other polygons, it is interpolated linearly in 1/z, so the middle
+	// pixel has the harmonic mean of the depths of the endpoints.
+	near := func(z, exp uint32) bool { return z+0x200 >= exp && z <= exp+0x200 }
+	z0, z1 := s.pixel(0, 50).z, s.pixel(200, 50).z
+	if !near(z0, 0x3FFEFF) || !near(z1, 0xBFFF07) {
+		t.Fatalf("wrong depth at endpoints: z(0)=%#x, z(200)=%#x", z0, z1)
+	}
+	for x := 1; x <= 200; x++ {
+		if z, prev := s.pixel(x, 50).z, s.pixel(x-1, 50).z; z < prev {
+			t.Errorf("x=%d: depth decreasing: %#x -> %#x", x, prev, z)
+		}
+	}
+	if zm, exp := s.pixel(100, 50).z, uint32(2*uint64(z0)*uint64(z1)/uint64(z0+z1)); !near(zm, exp) {
+		t.Errorf("depth in the middle = %#x, want about %#x", zm, exp)
+	}
+}
@@ -0,0 +1,168 @@
+package raster3d
+
+import (
+	"math"
+	"testing"
+
+	"ndsemu/emu/fixed"
+)
+
+// testScene builds a frame through the whole geometry and rendering
+// pipeline. The viewport is the whole screen and W is always 1, so that
+// vertices can be specified directly in screen coordinates.
+type testScene struct {
+	e3d *HwEngine3d
+}
+
+// testVtx is a vertex in screen coordinates; z is the clip-space depth
+// (-1 is the nearest, 1 the farthest), c the RGB555 color.
+type testVtx struct {
+	x, y int
+	z    float64
+	c    [3]uint8
+}
+
+func newTestScene() *testScene {
+	e3d := NewHwEngine3d()
+	e3d.ClearDepth.Value = 0x7FFF
+	e3d.CmdViewport(Primitive_SetViewport{0, 0, 256, 192})
+	return &testScene{e3d}
+}
+
+// poly adds a triangle or a quad (depending on the number of vertices),
+// drawn regardless of its facing.
+func (s *testScene) poly(attr uint32, vtx ...testVtx) {
+	var cmd Primitive_Polygon
+	for i, v := range vtx {
+		cmd.Vtx[i] = len(s.e3d.next.Vram)
+		s.e3d.CmdVertex(Primitive_Vertex{
+			X: fixed.F12{V: int32(v.x*32 - 0x1000)},
+			Y: fixed.F12{V: 0x1000 - int32(math.Round(float64(v.y)*0x1000/96))},
+			Z: fixed.F12{V: int32(v.z * 0x1000)},
+			W: fixed.NewF12(1),
+			C: v.c,
+		})
+	}
+	cmd.Attr = attr | uint32(PFRenderBack|PFRenderFront)
+	if len(vtx) == 4 {
+		cmd.Attr |= PFQuad
+	}
+	s.e3d.CmdPolygon(cmd)
+}
+
+// rect adds an axis-aligned quad covering [x0,x1]x[y0,y1], of uniform
+// depth and color.
+func (s *testScene) rect(attr uint32, x0, y0, x1, y1 int, z float64, c [3]uint8) {
+	s.poly(attr,
+		testVtx{x0, y0, z, c}, testVtx{x1, y0, z, c},
+		testVtx{x1, y1, z, c}, testVtx{x0, y1, z, c})
+}
+
+// draw renders the scene synchronously.
+func (s *testScene) draw() {
+	s.e3d.CmdSwapBuffers(Primitive_SwapBuffers{})
+	s.e3d.EndFrame()
+	s.e3d.drawScene()
+}
+
+func (s *testScene) pixel(x, y int) testPixel {
+	return getTestPixel(s.e3d, x, y)
+}
+
+// color returns the RGB555 color of the pixel
+func (s *testScene) color(x, y int) uint32 {
+	return s.pixel(x, y).color & 0x7FFF
+}
+
+// Attributes of opaque polygons and shadow polygons with the specified ID
+func opaqueAttr(id uint32) uint32 { return 31<<16 | polyID(id) }
+func shadowAttr(id uint32) uint32 { return 31<<16 | PCMShadow<<4 | polyID(id) }
+
+var (
+	testRed   = [3]uint8{31, 0, 0}
+	testGreen = [3]uint8{0, 31, 0}
+	testBlue  = [3]uint8{0, 0, 31}
+)
+
+func TestShadowMask(t *testing.T) {
+	// A floor (ID 1) covers x=0-99. The mask (ID 0) is behind it, and
+	// extends over the rear plane up to x=139.
+	s := newTestScene()
+	s.rect(opaqueAttr(1), 0, 0, 100, 40, 0, testRed)
+	s.rect(shadowAttr(0), 20, 0, 140, 40, 0.5, testGreen)
+	s.draw()
+
+	for _, tc := range []struct {
+		desc    string
+		x       int
+		stencil bool
+		color   uint32
+	}{
+		{"floor, outside the mask", 10, false, 31},
+		{"floor, depth test failed", 50, true, 31},
+		{"rear plane, depth test passed", 120, false, 0},
+	} {
+		p := s.pixel(tc.x, 20)
+		if got := p.attr&PAStencil != 0; got != tc.stencil {
+			t.Errorf("%s: stencil = %v, want %v", tc.desc, got, tc.stencil)
+		}
+		if got := s.color(tc.x, 20); got != tc.color {
+			t.Errorf("%s: color = %#x, want %#x (mask drawn?)", tc.desc, got, tc.color)
+		}
+	}
+}
+
+func TestShadowDraw(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		id    uint32 // ID of the shadow polygon
+		x     int
+		color uint32
+	}{
+		{"stencil set", 2, 50, 31 << 10},
+		{"stencil not set (floor)", 2, 10, 31},
+		{"stencil not set (rear plane)", 2, 120, 0},
+		{"same ID as the floor", 1, 50, 31},
+	} {
+		// Same scene as TestShadowMask, plus a shadow in front of
+		// everything, covering the whole area.
+		s := newTestScene()
+		s.rect(opaqueAttr(1), 0, 0, 100, 40, 0, testRed)
+		s.rect(shadowAttr(0), 20, 0, 140, 40, 0.5, testGreen)
+		s.rect(shadowAttr(tc.id), 0, 0, 160, 40, -0.5, testBlue)
+		s.draw()
+
+		if got := s.color(tc.x, 20); got != tc.color {
+			t.Errorf("%s: color = %#x, want %#x", tc.desc, got, tc.color)
+		}
+	}
+}
+
+func TestShadowStencilCleared(t *testing.T) {
+	// Two mask+shadow groups: the first shadow only covers part of the
+	// first mask, so the stencil is still set in x=40-59 when the second
+	// group begins. The second mask must start from a cleared stencil, so
+	// that the second shadow is only drawn where the second mask is.
+	s := newTestScene()
+	s.rect(opaqueAttr(1), 0, 0, 100, 40, 0, testRed)
+	s.rect(shadowAttr(0), 20, 0, 60, 40, 0.5, testGreen)
+	s.rect(shadowAttr(2), 0, 0, 40, 40, -0.5, testBlue)
+	s.rect(shadowAttr(0), 70, 0, 90, 40, 0.5, testGreen)
+	s.rect(shadowAttr(3), 0, 0, 160, 40, -0.75, testGreen)
+	s.draw()
+
+	for _, tc := range []struct {
+		desc  string
+		x     int
+		color uint32
+	}{
+		{"first shadow", 30, 31 << 10},
+		{"first mask, not shadowed", 50, 31},
+		{"second shadow", 80, 31 << 5},
+		{"no mask", 95, 31},
+	} {
+		if got := s.color(tc.x, 20); got != tc.color {
+			t.Errorf("%s: color = %#x, want %#x", tc.desc, got, tc.color)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package raster3d
+
+import (
+	"ndsemu/emu/hwio"
+	"ndsemu/emu/savestate"
+)
+
+// Serialize saves or loads the texture parameters. It is exported because
+// the geometry engine keeps the current texture as part of its own state.
+func (t *Texture) Serialize(st *savestate.Stream) {
+	st.Uint32(&t.VramTexOffset)
+	st.Uint32(&t.VramPalOffset)
+	st.Uint32(&t.Width)
+	st.Uint32(&t.Height)
+	pitch := int(t.PitchShift)
+	st.Int(&pitch)
+	t.PitchShift = uint(pitch)
+	st.Uint32(&t.SClampMask)
+	st.Uint32(&t.TClampMask)
+	st.Uint32(&t.SFlipMask)
+	st.Uint32(&t.TFlipMask)
+	st.Bool(&t.ColorKey)
+	format, flags := int(t.Format), int(t.Flags)
+	st.Int(&format)
+	st.Int(&flags)
+	t.Format, t.Flags = TexFormat(format), TexFlags(flags)
+}
+
+func (v *Vertex) serialize(st *savestate.Stream) {
+	st.Int32(&v.cx.V)
+	st.Int32(&v.cy.V)
+	st.Int32(&v.cz.V)
+	st.Int32(&v.cw.V)
+	st.Int32(&v.x.V)
+	st.Int32(&v.y.V)
+	st.Int64(&v.s.V)
+	st.Int64(&v.t.V)
+	st.Int32(&v.r.V)
+	st.Int32(&v.g.V)
+	st.Int32(&v.b.V)
+	st.Int64(&v.d.V)
+	st.Uint32((*uint32)(&v.flags))
+}
+
+func (l *lerp) serialize(st *savestate.Stream) {
+	st.Int64(&l.cur)
+	st.Int64(&l.delta[0])
+	st.Int64(&l.delta[1])
+	st.Int64(&l.start)
+}
+
+// Maximum number of vertices and polygons found in a savestate (way more
+// than what the hardware can handle per frame); anything bigger means that
+// the savestate is corrupted.
+const cMaxSavedVertices = 1 << 18
+
+func serializeVertices(st *savestate.Stream, vram *[]Vertex) {
+	n := len(*vram)
+	st.Len(&n, cMaxSavedVertices)
+	if st.Err() != nil {
+		return
+	}
+	if st.Loading() {
+		*vram = append((*vram)[:0], make([]Vertex, n)...)
+	}
+	for i := range *vram {
+		(*vram)[i].serialize(st)
+	}
+}
+
+// serialize saves or loads a whole scene buffer.
+//
+// Polygons reference their vertices through pointers, that might point
+// either within Vram or ClipVram (or even a previous backing array of
+// ClipVram, as it grows while clipping). Vertices referenced by polygons are
+// never modified anymore once the polygon has been pushed (except for the
+// one-time processing in polysSetDepth, which is idempotent), so we simply
+// save them by value, and create private copies at load time.
+// Vram is still saved as a whole, because it is indexed by the geometry
+// engine while the scene is being accumulated.
+func (b *buffer3d) serialize(st *savestate.Stream) {
+	serializeVertices(st, &b.Vram)
+
+	n := len(b.Pram)
+	st.Len(&n, cMaxSavedVertices)
+	if st.Err() != nil {
+		return
+	}
+	if st.Loading() {
+		b.Pram = append(b.Pram[:0], make([]Polygon, n)...)
+		b.ClipVram = make([]Vertex, 3*n)
+	}
+	for i := range b.Pram {
+		poly := &b.Pram[i]
+		for j := range poly.vtx {
+			if st.Loading() {
+				poly.vtx[j] = &b.ClipVram[i*3+j]
+			}
+			poly.vtx[j].serialize(st)
+		}
+		st.Uint32((*uint32)(&poly.flags))
+		poly.tex.Serialize(st)
+		st.Int32(&poly.hy)
+		for j := range poly.left {
+			poly.left[j].serialize(st)
+			poly.right[j].serialize(st)
+		}
+	}
+}
+
+// Serialize saves or loads the state of the 3D engine, including the scene
+// currently being displayed, the one being accumulated, and the last
+// rendered frame. It must be called at frame boundary.
+func (e3d *HwEngine3d) Serialize(st *savestate.Stream) {
+	// Wait for the rendering goroutine to finish drawing, as it
+	// accesses most of the state that we need to serialize.
+	e3d.drawing.Wait()
+
+	st.Section("e3d")
+	hwio.SerializeRegs(st, e3d)
+	st.Int(&e3d.viewport.VX0)
+	st.Int(&e3d.viewport.VY0)
+	st.Int(&e3d.viewport.VX1)
+	st.Int(&e3d.viewport.VY1)
+	st.Int(&e3d.framecnt)
+	st.Bytes(e3d.backbuf[:])
+
+	e3d.cur.serialize(st)
+	e3d.next.serialize(st)
+
+	// Check whether there is a scene queued by SwapBuffers that hasn't
+	// been picked up by EndFrame() yet.
+	var pending buffer3d
+	queued := false
+	select {
+	case pending = <-e3d.nextCh:
+		queued = true
+	default:
+	}
+	st.Bool(&queued)
+	if queued {
+		if st.Loading() && pending.Pram == nil {
+			pending = e3d.pool.Get().(buffer3d)
+		}
+		pending.serialize(st)
+		e3d.nextCh <- pending
+	}
+
+	if st.Loading() {
+		// The backbuffer is fully drawn
+		e3d.backY = 191
+	}
+}
@@ -48,6 +48,7 @@ type PolygonFlags uint32
 const (
 	PFRenderBack  PolygonFlags = 1 << 6
 	PFRenderFront              = 1 << 7
+	PFFog                      = 1 << 15
 	PFQuad                     = 1 << 31
 )
 
@@ -62,6 +63,24 @@ const (
 
 func (f PolygonFlags) Alpha() int                  { return int(f>>16) & 0x1F }
 func (f PolygonFlags) ColorMode() PolygonColorMode { return PolygonColorMode(f>>4) & 3 }
+func (f PolygonFlags) ID() int                     { return int(f>>24) & 0x3F }
+
+// Attributes of each pixel, stored in the attribute buffer while the polygons
+// are drawn. Bits match those of PolygonFlags when possible.
+const (
+	PAEdgeLeft   uint32 = 1 << 0 // pixel is on the left edge of an opaque polygon
+	PAEdgeRight  uint32 = 1 << 1 // pixel is on the right edge of an opaque polygon
+	PAEdgeTop    uint32 = 1 << 2 // pixel is on the top edge of an opaque polygon
+	PAEdgeBottom uint32 = 1 << 3 // pixel is on the bottom edge of an opaque polygon
+	PAEdgeMask          = PAEdgeLeft | PAEdgeRight | PAEdgeTop | PAEdgeBottom
+	PAStencil    uint32 = 1 << 4 // stencil bit, set by shadow mask polygons
+
+	PACoverageShift        = 8                       // coverage of left/right edge pixels (0-31),
+	PACoverage      uint32 = 0x1F << PACoverageShift // used for anti-aliasing
+
+	PAFog    uint32 = 1 << 15    // fog must be applied to the pixel
+	PAPolyID uint32 = 0x3F << 24 // ID of the opaque polygon the pixel belongs to
+)
 
 const (
 	LerpX = iota // coordinate on screen (X)
@@ -84,12 +103,19 @@ type Polygon struct {
 	// y coordinate of middle vertex
 	hy int32
 
+	// true if the polygon has no area, and it's drawn as a segment
+	line bool
+
 	// linear interpolators for left and right edge of the polygon
 	left  [NumLerps]lerp
 	right [NumLerps]lerp
 
+	// attributes of the left, inner and right pixels of the span being
+	// drawn, besides those of the polygon (see setSpanAttr)
+	spanAttr [3]uint32
+
 	// polyfiller for this polygon
-	filler func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gfx.Line)
+	filler func(*HwEngine3d, *Polygon, gfx.Line, gfx.Line, gfx.Line, gfx.Line)
 
 	// texture pointer
 	texptr []byte
@@ -154,6 +180,13 @@ func (p *Polygon) UseAlpha() bool {
 	return alpha > 0 && alpha < 31
 }
 
+// IsShadowMask returns true if the polygon is a shadow mask, that is a shadow
+// polygon with ID 0. Masks are not drawn, and only mark the stencil buffer
+// for the shadow polygons that follow.
+func (p *Polygon) IsShadowMask() bool {
+	return p.flags.ColorMode() == PCMShadow && p.flags.ID() == 0
+}
+
 func bool2uint32ff(x bool) uint32 {
 	ret := uint32(0)
 	if !x {
@@ -1,22 +1,17 @@
 package raster3d
 
 import (
-	"fmt"
-	"image"
-	icolor "image/color"
-	"image/png"
 	"ndsemu/emu"
-	"os"
 )
 
 // Cache holding decompressed textures. It is a locked dictionary,
 // indexed by the texture address in VRAM, and containing the texture
 // raw bits.
 // FIXME: possibile improvements:
-//   * As a key, use a fast hash of texture bits (eg: crc64); this would
+//   - As a key, use a fast hash of texture bits (eg: crc64); this would
 //     allow reusing the same texture across different frames. We need to
 //     benchmark whether it's a net win
-//   * Once we switch to the above, we could use a proper LRU cache to
+//   - Once we switch to the above, we could use a proper LRU cache to
 //     also decrease pressure on the GC; as things stand, texture buffers
 //     are allocated / deallocated 60 times per second.
 type texCache struct {
@@ -54,20 +49,8 @@ func (cache *texCache) Update(polys []Polygon, e3d *HwEngine3d) {
 			continue
 		}
 
+		// NOTE: to inspect decompressed textures, see DecodeTexture()
 		out := decompFunc(cache, poly, e3d)
-
-		if false {
-			f, err := os.Create(fmt.Sprintf("tex-%x.png", poly.tex.VramTexOffset))
-			if err == nil {
-				png.Encode(f, &Image555{
-					buf: out,
-					w:   int(poly.tex.Width),
-					h:   int(poly.tex.Height),
-				})
-				f.Close()
-			}
-		}
-
 		cache.Put(poly.tex.VramTexOffset, out)
 	}
 }
@@ -132,32 +115,3 @@ func (cache *texCache) decompTex4x4(poly *Polygon, e3d *HwEngine3d) []byte {
 
 	return out
 }
-
-// Implement image.Image interface for a linear rgb555 buffer
-type Image555 struct {
-	buf  []uint8
-	w, h int
-}
-
-func (i *Image555) Bounds() image.Rectangle {
-	return image.Rect(0, 0, i.w, i.h)
-}
-
-func (i *Image555) ColorModel() icolor.Model {
-	return icolor.RGBAModel
-}
-
-func (i *Image555) At(x, y int) icolor.Color {
-	c0 := emu.Read16LE(i.buf[(y*i.w+x)*2:])
-
-	var c1 icolor.RGBA
-	c1.R = uint8(c0>>0) & 0x1F
-	c1.G = uint8(c0>>5) & 0x1F
-	c1.B = uint8(c0>>10) & 0x1F
-	c1.R = (c1.R << 3) | (c1.R >> 2)
-	c1.G = (c1.G << 3) | (c1.G >> 2)
-	c1.B = (c1.B << 3) | (c1.B >> 2)
-	c1.A = 0xFF
-
-	return c1
-}
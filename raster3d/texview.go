@@ -0,0 +1,169 @@
+package raster3d
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	icolor "image/color"
+	"ndsemu/emu"
+	"sort"
+)
+
+// TextureUse is a texture referenced by the polygons of the current frame
+type TextureUse struct {
+	Texture
+	NumPolygons int // Number of polygons using the texture
+}
+
+func (t *Texture) String() string {
+	s := fmt.Sprintf("%v %dx%d tex=%05x", t.Format, t.Width, t.Height, t.VramTexOffset)
+	if t.Format != TexDirect {
+		s += fmt.Sprintf(" pal=%05x", t.VramPalOffset)
+	}
+	if t.Flags&TexSRepeat != 0 {
+		s += " reps"
+	}
+	if t.Flags&TexTRepeat != 0 {
+		s += " rept"
+	}
+	if t.Flags&TexSFlip != 0 {
+		s += " flips"
+	}
+	if t.Flags&TexTFlip != 0 {
+		s += " flipt"
+	}
+	if t.ColorKey {
+		s += " colorkey"
+	}
+	return s
+}
+
+// Textures returns the list of the textures used by the polygons of the
+// frame being currently displayed, sorted by VRAM offset. The same texture
+// data used with different palettes or parameters is reported multiple times.
+func (e3d *HwEngine3d) Textures() []TextureUse {
+	count := make(map[Texture]int)
+	for idx := range e3d.cur.Pram {
+		tex := e3d.cur.Pram[idx].tex
+		if tex.Format == TexNone {
+			continue
+		}
+		count[tex]++
+	}
+
+	texs := make([]TextureUse, 0, len(count))
+	for tex, n := range count {
+		texs = append(texs, TextureUse{Texture: tex, NumPolygons: n})
+	}
+	sort.Slice(texs, func(i, j int) bool {
+		ti, tj := &texs[i].Texture, &texs[j].Texture
+		if ti.VramTexOffset != tj.VramTexOffset {
+			return ti.VramTexOffset < tj.VramTexOffset
+		}
+		return ti.String() < tj.String()
+	})
+	return texs
+}
+
+func (e3d *HwEngine3d) texel8(off uint32) uint8 {
+	off &= 32*16*1024 - 1
+	return e3d.texVram.Slots[off>>14][off&0x3FFF]
+}
+
+func (e3d *HwEngine3d) texel16(off uint32) uint16 {
+	return uint16(e3d.texel8(off)) | uint16(e3d.texel8(off+1))<<8
+}
+
+// palColor looks up a palette color; differently from VramTexturePalette, it
+// checks the boundaries of the palette VRAM, as the palette offsets might be
+// garbage for a texture that is not really being used.
+func (e3d *HwEngine3d) palColor(paloff uint32, idx uint8) uint16 {
+	off := paloff + uint32(idx)*2
+	slot := off >> 14
+	if int(slot) >= len(e3d.palVram.Slots) || int(off&0x3FFF)+1 >= len(e3d.palVram.Slots[slot]) {
+		return 0
+	}
+	return emu.Read16LE(e3d.palVram.Slots[slot][off&0x3FFF:])
+}
+
+func nrgba555(c uint16, alpha uint8) icolor.NRGBA {
+	r := uint8(c) & 0x1F
+	g := uint8(c>>5) & 0x1F
+	b := uint8(c>>10) & 0x1F
+	return icolor.NRGBA{r<<3 | r>>2, g<<3 | g>>2, b<<3 | b>>2, alpha}
+}
+
+// DecodeTexture decodes a texture from the texture VRAM, using its palette
+// from the texture palette VRAM (as currently mapped). Transparent texels
+// (color key, texture alpha) are reflected in the alpha channel.
+func (e3d *HwEngine3d) DecodeTexture(tex Texture) (*image.NRGBA, error) {
+	w, h := int(tex.Width), int(tex.Height)
+	if w == 0 || h == 0 {
+		return nil, errors.New("invalid texture size")
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	off, pal := tex.VramTexOffset, tex.VramPalOffset
+
+	// Palettized textures with the color key flag use color 0 as transparent
+	keyed := func(px uint8) uint8 {
+		if tex.ColorKey && px == 0 {
+			return 0
+		}
+		return 0xFF
+	}
+
+	switch tex.Format {
+	case Tex4x4:
+		// Compressed textures are decoded by the same code used by the
+		// rasterizer; it only supports textures in slots 0 and 2.
+		if slot := off / (128 * 1024); slot != 0 && slot != 2 {
+			return nil, fmt.Errorf("compressed texture in slot %d", slot)
+		}
+		var cache texCache
+		buf := cache.decompTex4x4(&Polygon{tex: tex}, e3d)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				// Tex4x4 is always color-keyed (see polyfillers)
+				if px := emu.Read16LE(buf[(y*w+x)*2:]); px != 0 {
+					img.SetNRGBA(x, y, nrgba555(px, 0xFF))
+				}
+			}
+		}
+		return img, nil
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			idx := uint32(y*w + x)
+			var c icolor.NRGBA
+			switch tex.Format {
+			case Tex4:
+				px := (e3d.texel8(off+idx/4) >> (2 * (idx & 3))) & 3
+				c = nrgba555(e3d.palColor(pal, px), keyed(px))
+			case Tex16:
+				px := (e3d.texel8(off+idx/2) >> (4 * (idx & 1))) & 0xF
+				c = nrgba555(e3d.palColor(pal, px), keyed(px))
+			case Tex256:
+				px := e3d.texel8(off + idx)
+				c = nrgba555(e3d.palColor(pal, px), keyed(px))
+			case TexA3I5:
+				px := e3d.texel8(off + idx)
+				a := px >> 5
+				a = a<<5 | a<<2 | a>>1
+				c = nrgba555(e3d.palColor(pal, px&0x1F), a)
+			case TexA5I3:
+				px := e3d.texel8(off + idx)
+				a := px >> 3
+				a = a<<3 | a>>2
+				c = nrgba555(e3d.palColor(pal, px&0x7), a)
+			case TexDirect:
+				px := e3d.texel16(off + idx*2)
+				c = nrgba555(px, uint8(px>>15)*0xFF)
+			default:
+				return nil, fmt.Errorf("invalid texture format: %v", tex.Format)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img, nil
+}